@@ -1,12 +1,16 @@
 package attendance
 
 import (
-	"log"
+	"log/slog"
 
 	"github.com/turfaa/apotek-hris/internal/attendance"
 	"github.com/turfaa/apotek-hris/internal/config"
+	"github.com/turfaa/apotek-hris/internal/document"
 	"github.com/turfaa/apotek-hris/internal/hris"
+	"github.com/turfaa/apotek-hris/internal/queue"
+	"github.com/turfaa/apotek-hris/pkg/blobstore"
 	"github.com/turfaa/apotek-hris/pkg/database"
+	"github.com/turfaa/apotek-hris/pkg/logging"
 
 	"github.com/spf13/cobra"
 )
@@ -23,26 +27,32 @@ var increaseQuotaCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		configFiles, err := cmd.Root().Flags().GetStringSlice("config")
 		if err != nil {
-			log.Fatalf("Failed to get config flag: %v", err)
+			logging.Fatal(cmd.Context(), "failed to get config flag", err)
 		}
 
 		cfg, err := config.Load(configFiles...)
 		if err != nil {
-			log.Fatalf("Failed to load config: %v", err)
+			logging.Fatal(cmd.Context(), "failed to load config", err)
 		}
 
 		ctx := cmd.Context()
 
 		db, err := database.NewPostgresConnection(ctx, cfg.Database)
 		if err != nil {
-			log.Fatalf("Failed to connect to database: %v", err)
+			logging.Fatal(cmd.Context(), "failed to connect to database", err)
 		}
 		defer db.Close()
 
-		hrisSvc := hris.NewService(db)
-		employees, err := hrisSvc.GetEmployees(ctx)
+		blobStore, err := blobstore.New(cfg.Storage)
 		if err != nil {
-			log.Fatalf("Failed to get employees: %v", err)
+			logging.Fatal(cmd.Context(), "failed to create blob store", err)
+		}
+
+		documentSvc := document.NewService(db, blobStore)
+		hrisSvc := hris.NewService(db, cfg.HRIS, blobStore, documentSvc, queue.New(db, cfg.Queue, blobStore))
+		employees, err := hrisSvc.GetEmployees(ctx, nil)
+		if err != nil {
+			logging.Fatal(cmd.Context(), "failed to get employees", err)
 		}
 
 		employeeIDs := make([]int64, len(employees))
@@ -50,13 +60,17 @@ var increaseQuotaCmd = &cobra.Command{
 			employeeIDs[i] = e.ID
 		}
 
-		attendanceSvc := attendance.NewService(db)
+		attendanceSvc := attendance.NewService(db, blobStore)
 		affected, err := attendanceSvc.IncrementQuotaForEmployees(ctx, employeeIDs, attendanceTypeID, quotaIncrement)
 		if err != nil {
-			log.Fatalf("Failed to increase quota: %v", err)
+			logging.Fatal(cmd.Context(), "failed to increase quota", err)
 		}
 
-		log.Printf("Successfully increased quota by %d for attendance type %d. Affected %d employees.", quotaIncrement, attendanceTypeID, affected)
+		slog.InfoContext(ctx, "increased attendance quota",
+			slog.Int64("type_id", attendanceTypeID),
+			slog.Int("increment", quotaIncrement),
+			slog.Int64("affected_employees", affected),
+		)
 	},
 }
 
@@ -0,0 +1,53 @@
+package document
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/turfaa/apotek-hris/internal/config"
+	"github.com/turfaa/apotek-hris/internal/document"
+	"github.com/turfaa/apotek-hris/pkg/blobstore"
+	"github.com/turfaa/apotek-hris/pkg/database"
+	"github.com/turfaa/apotek-hris/pkg/logging"
+	"github.com/turfaa/go-date"
+
+	"github.com/spf13/cobra"
+)
+
+var checkExpiryCmd = &cobra.Command{
+	Use:   "check-expiry",
+	Short: "Raise alerts for employee documents nearing expiry",
+	Long:  `Raises an alert for every employee document that is 90, 30 or 7 days from expiring. Each alert is raised once, so the command is meant to run daily from a scheduler such as cron.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		configFiles, err := cmd.Root().Flags().GetStringSlice("config")
+		if err != nil {
+			logging.Fatal(cmd.Context(), "failed to get config flag", err)
+		}
+
+		cfg, err := config.Load(configFiles...)
+		if err != nil {
+			logging.Fatal(cmd.Context(), "failed to load config", err)
+		}
+
+		ctx := cmd.Context()
+
+		db, err := database.NewPostgresConnection(ctx, cfg.Database)
+		if err != nil {
+			logging.Fatal(cmd.Context(), "failed to connect to database", err)
+		}
+		defer db.Close()
+
+		blobStore, err := blobstore.New(cfg.Storage)
+		if err != nil {
+			logging.Fatal(cmd.Context(), "failed to create blob store", err)
+		}
+
+		documentSvc := document.NewService(db, blobStore)
+		alerts, err := documentSvc.CheckExpiry(ctx, date.NewFromTime(time.Now()))
+		if err != nil {
+			logging.Fatal(cmd.Context(), "failed to check document expiry", err)
+		}
+
+		slog.InfoContext(ctx, "checked document expiry", slog.Int("raised_alerts", len(alerts)))
+	},
+}
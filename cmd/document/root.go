@@ -0,0 +1,15 @@
+package document
+
+import "github.com/spf13/cobra"
+
+// Command returns the documents parent command with all subcommands registered.
+func Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "documents",
+		Short: "Employee document management commands",
+	}
+
+	cmd.AddCommand(checkExpiryCmd)
+
+	return cmd
+}
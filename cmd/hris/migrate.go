@@ -2,13 +2,14 @@ package hris
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/spf13/cobra"
 	"github.com/turfaa/apotek-hris/internal/config"
+	"github.com/turfaa/apotek-hris/pkg/logging"
 )
 
 var migrateCmd = &cobra.Command{
@@ -23,18 +24,18 @@ var migrateUpCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		cfg, err := config.Load(configFiles...)
 		if err != nil {
-			log.Fatalf("Failed to load config: %v", err)
+			logging.Fatal(cmd.Context(), "failed to load config", err)
 		}
 
 		m, err := getMigrator(cfg)
 		if err != nil {
-			log.Fatal(err)
+			logging.Fatal(cmd.Context(), "failed to create migrator", err)
 		}
 
 		if err := m.Up(); err != nil && err != migrate.ErrNoChange {
-			log.Fatal(err)
+			logging.Fatal(cmd.Context(), "failed to run migrations", err)
 		}
-		fmt.Println("Migration completed successfully")
+		slog.InfoContext(cmd.Context(), "migration completed")
 	},
 }
 
@@ -44,18 +45,18 @@ var migrateDownCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		cfg, err := config.Load(configFiles...)
 		if err != nil {
-			log.Fatalf("Failed to load config: %v", err)
+			logging.Fatal(cmd.Context(), "failed to load config", err)
 		}
 
 		m, err := getMigrator(cfg)
 		if err != nil {
-			log.Fatal(err)
+			logging.Fatal(cmd.Context(), "failed to create migrator", err)
 		}
 
 		if err := m.Down(); err != nil && err != migrate.ErrNoChange {
-			log.Fatal(err)
+			logging.Fatal(cmd.Context(), "failed to roll back migrations", err)
 		}
-		fmt.Println("Rollback completed successfully")
+		slog.InfoContext(cmd.Context(), "rollback completed")
 	},
 }
 
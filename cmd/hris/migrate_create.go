@@ -2,13 +2,14 @@ package hris
 
 import (
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
+
+	"github.com/turfaa/apotek-hris/pkg/logging"
 )
 
 var migrateCreateCmd = &cobra.Command{
@@ -25,19 +26,19 @@ var migrateCreateCmd = &cobra.Command{
 
 		// Create migrations directory if it doesn't exist
 		if err := os.MkdirAll("migrations", 0755); err != nil {
-			log.Fatalf("Failed to create migrations directory: %v", err)
+			logging.Fatal(cmd.Context(), "failed to create migrations directory", err)
 		}
 
 		// Create up migration
 		upFile := filepath.Join("migrations", fmt.Sprintf("%s_%s.up.sql", timestamp, name))
 		if err := os.WriteFile(upFile, []byte("-- Write your UP migration SQL here\n"), 0644); err != nil {
-			log.Fatalf("Failed to create up migration file: %v", err)
+			logging.Fatal(cmd.Context(), "failed to create up migration file", err)
 		}
 
 		// Create down migration
 		downFile := filepath.Join("migrations", fmt.Sprintf("%s_%s.down.sql", timestamp, name))
 		if err := os.WriteFile(downFile, []byte("-- Write your DOWN migration SQL here\n"), 0644); err != nil {
-			log.Fatalf("Failed to create down migration file: %v", err)
+			logging.Fatal(cmd.Context(), "failed to create down migration file", err)
 		}
 
 		fmt.Printf("Created migration files:\n%s\n%s\n", upFile, downFile)
@@ -5,6 +5,8 @@ import (
 	"os"
 
 	"github.com/turfaa/apotek-hris/cmd/attendance"
+	"github.com/turfaa/apotek-hris/cmd/document"
+	"github.com/turfaa/apotek-hris/cmd/webhook"
 
 	"github.com/spf13/cobra"
 )
@@ -22,6 +24,8 @@ var (
 func init() {
 	rootCmd.PersistentFlags().StringSliceVarP(&configFiles, "config", "c", []string{"config/config.yaml", "config/secret.yaml"}, "config file paths")
 	rootCmd.AddCommand(attendance.Command())
+	rootCmd.AddCommand(document.Command())
+	rootCmd.AddCommand(webhook.Command())
 }
 
 func Execute() error {
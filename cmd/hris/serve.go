@@ -3,17 +3,22 @@ package hris
 import (
 	"context"
 	"errors"
-	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/turfaa/apotek-hris/internal/config"
+	"github.com/turfaa/apotek-hris/internal/notification"
+	"github.com/turfaa/apotek-hris/internal/webhook"
+	"github.com/turfaa/apotek-hris/pkg/blobstore"
 	"github.com/turfaa/apotek-hris/pkg/database"
+	"github.com/turfaa/apotek-hris/pkg/logging"
 	"github.com/turfaa/apotek-hris/pkg/server"
+	"github.com/turfaa/apotek-hris/pkg/tracing"
 
 	"github.com/spf13/cobra"
 )
@@ -24,16 +29,38 @@ var serveCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		cfg, err := config.Load(configFiles...)
 		if err != nil {
-			log.Fatalf("Failed to load config: %v", err)
+			logging.Fatal(cmd.Context(), "failed to load config", err)
+		}
+
+		shutdownTracing, err := tracing.Setup(cmd.Context(), cfg.Tracing)
+		if err != nil {
+			logging.Fatal(cmd.Context(), "failed to set up tracing", err)
 		}
 
 		db, err := database.NewPostgresConnection(cmd.Context(), cfg.Database)
 		if err != nil {
-			log.Fatalf("Failed to connect to database: %v", err)
+			logging.Fatal(cmd.Context(), "failed to connect to database", err)
 		}
 		defer db.Close()
 
-		srv := server.New(cfg.Server, db)
+		blobStore, err := blobstore.New(cfg.Storage)
+		if err != nil {
+			logging.Fatal(cmd.Context(), "failed to create blob store", err)
+		}
+
+		srv, err := server.New(cfg.Server, cfg.HRIS, cfg.Attendance, cfg.Scheduler, cfg.Queue, cfg.Idempotency, db, blobStore, notification.NewSender(cfg.Notification))
+		if err != nil {
+			logging.Fatal(cmd.Context(), "failed to set up server", err)
+		}
+
+		backgroundCtx, stopBackground := context.WithCancel(cmd.Context())
+		defer stopBackground()
+
+		go webhook.NewDispatcher(db, cfg.Webhooks).Run(backgroundCtx)
+
+		var background sync.WaitGroup
+		background.Go(func() { srv.RunScheduler(backgroundCtx) })
+		background.Go(func() { srv.RunQueue(backgroundCtx) })
 
 		// Handle graceful shutdown
 		done := make(chan os.Signal, 1)
@@ -41,23 +68,31 @@ var serveCmd = &cobra.Command{
 
 		go func() {
 			if err := srv.Start(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-				log.Fatalf("Failed to start server: %v", err)
+				logging.Fatal(cmd.Context(), "failed to start server", err)
 			}
 		}()
 
-		log.Printf("Server is running on %s:%d", cfg.Server.Host, cfg.Server.Port)
+		slog.InfoContext(cmd.Context(), "server is running", slog.String("host", cfg.Server.Host), slog.Int("port", cfg.Server.Port))
 
 		<-done
-		log.Print("Server is shutting down...")
+		slog.InfoContext(cmd.Context(), "server is shutting down")
+		stopBackground()
 
 		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 		defer cancel()
 
 		if err := srv.Shutdown(ctx); err != nil {
-			log.Fatalf("Server forced to shutdown: %v", err)
+			logging.Fatal(cmd.Context(), "server forced to shutdown", err)
+		}
+
+		// Let the running jobs finish, or return to the queue, and record their outcome.
+		background.Wait()
+
+		if err := shutdownTracing(ctx); err != nil {
+			slog.ErrorContext(ctx, "failed to flush traces", slog.Any("error", err))
 		}
 
-		fmt.Println("Server exited properly")
+		slog.InfoContext(cmd.Context(), "server exited properly")
 	},
 }
 
@@ -0,0 +1,49 @@
+package webhook
+
+import (
+	"log/slog"
+
+	"github.com/turfaa/apotek-hris/internal/config"
+	"github.com/turfaa/apotek-hris/internal/webhook"
+	"github.com/turfaa/apotek-hris/pkg/database"
+	"github.com/turfaa/apotek-hris/pkg/logging"
+
+	"github.com/spf13/cobra"
+)
+
+var dispatchCmd = &cobra.Command{
+	Use:   "dispatch",
+	Short: "Deliver pending webhook events once",
+	Long:  `Creates the deliveries of new outbox events and attempts every delivery that is due, once. The server already does this continuously; the command is meant for debugging and for deployments that don't run the server.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		configFiles, err := cmd.Root().Flags().GetStringSlice("config")
+		if err != nil {
+			logging.Fatal(cmd.Context(), "failed to get config flag", err)
+		}
+
+		cfg, err := config.Load(configFiles...)
+		if err != nil {
+			logging.Fatal(cmd.Context(), "failed to load config", err)
+		}
+
+		ctx := cmd.Context()
+
+		db, err := database.NewPostgresConnection(ctx, cfg.Database)
+		if err != nil {
+			logging.Fatal(cmd.Context(), "failed to connect to database", err)
+		}
+		defer db.Close()
+
+		result, err := webhook.NewDispatcher(db, cfg.Webhooks).DispatchOnce(ctx)
+		if err != nil {
+			logging.Fatal(cmd.Context(), "failed to dispatch webhooks", err)
+		}
+
+		slog.InfoContext(ctx, "dispatched webhook events",
+			slog.Int("events", result.Events),
+			slog.Int("succeeded", result.Succeeded),
+			slog.Int("retrying", result.Retrying),
+			slog.Int("failed", result.Failed),
+		)
+	},
+}
@@ -0,0 +1,16 @@
+package webhook
+
+import "github.com/spf13/cobra"
+
+// Command returns the webhooks parent command with all subcommands registered.
+func Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "webhooks",
+		Short: "Webhook delivery commands",
+	}
+
+	cmd.AddCommand(dispatchCmd)
+	cmd.AddCommand(stubCmd)
+
+	return cmd
+}
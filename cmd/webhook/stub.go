@@ -0,0 +1,60 @@
+package webhook
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/turfaa/apotek-hris/internal/webhook"
+	"github.com/turfaa/apotek-hris/pkg/logging"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	stubAddr   string
+	stubSecret string
+	stubStatus int
+)
+
+var stubCmd = &cobra.Command{
+	Use:   "stub",
+	Short: "Run a local webhook receiver for testing",
+	Long:  `Runs an HTTP server that logs every delivery it receives and checks its signature. Register http://<addr>/ as a webhook with the same secret, then create some data or ping the webhook. Use --status to answer with an error and watch the retries.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			valid := webhook.VerifySignature(stubSecret, r.Header.Get(webhook.HeaderTimestamp), body, r.Header.Get(webhook.HeaderSignature))
+			slog.InfoContext(r.Context(), "received webhook delivery",
+				slog.String("event", r.Header.Get(webhook.HeaderEvent)),
+				slog.String("delivery", r.Header.Get(webhook.HeaderDelivery)),
+				slog.Bool("signature_valid", valid),
+				slog.String("body", string(body)),
+			)
+
+			if !valid {
+				http.Error(w, "invalid signature", http.StatusUnauthorized)
+				return
+			}
+
+			w.WriteHeader(stubStatus)
+		})
+
+		slog.InfoContext(cmd.Context(), "webhook stub is listening", slog.String("address", stubAddr))
+		if err := http.ListenAndServe(stubAddr, handler); err != nil {
+			logging.Fatal(cmd.Context(), "failed to run webhook stub", err)
+		}
+	},
+}
+
+func init() {
+	stubCmd.Flags().StringVar(&stubAddr, "addr", "127.0.0.1:9090", "address to listen on")
+	stubCmd.Flags().StringVar(&stubSecret, "secret", "", "secret of the webhook")
+	stubCmd.Flags().IntVar(&stubStatus, "status", http.StatusNoContent, "status code to answer valid deliveries with")
+	_ = stubCmd.MarkFlagRequired("secret")
+}
@@ -0,0 +1,12 @@
+package attendance
+
+type Config struct {
+	// QuotaIncreases are added to the remaining quota of every employee by the increase-quota job,
+	// e.g. the yearly leave allowance. The job is not registered when there are none.
+	QuotaIncreases []QuotaIncrease `mapstructure:"quota_increases" validate:"dive"`
+}
+
+type QuotaIncrease struct {
+	TypeID    int64 `mapstructure:"type_id" validate:"required"`
+	Increment int   `mapstructure:"increment" validate:"required"`
+}
@@ -5,14 +5,22 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"slices"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/shopspring/decimal"
+	"github.com/turfaa/apotek-hris/internal/audit"
+	"github.com/turfaa/apotek-hris/internal/webhook"
+	"github.com/turfaa/apotek-hris/pkg/database"
+	"github.com/turfaa/apotek-hris/pkg/etag"
+	"github.com/turfaa/apotek-hris/pkg/pagination"
 	"github.com/turfaa/go-date"
 )
 
 type SelectorContext interface {
 	GetContext(ctx context.Context, dest any, query string, args ...any) error
+	SelectContext(ctx context.Context, dest any, query string, args ...any) error
 	Rebind(query string) string
 }
 
@@ -24,34 +32,47 @@ func newDB(db *sqlx.DB) *DB {
 	return &DB{db: db}
 }
 
-func (d *DB) GetAttendancesBetweenDates(ctx context.Context, from date.Date, to date.Date) ([]Attendance, error) {
+// GetAttendancesBetweenDates returns the attendances in the range, limited to the branch if branchID is not nil.
+func (d *DB) GetAttendancesBetweenDates(ctx context.Context, from date.Date, to date.Date, branchID *int64) ([]Attendance, error) {
 	query := `
 		SELECT
 			a.id,
 			a.employee_id,
+			a.branch_id,
 			a.date,
 			at.id AS "type.id",
 			at.name AS "type.name",
 			at.payable_type AS "type.payable_type",
 			at.has_quota AS "type.has_quota",
+			at.attachment_required_after_days AS "type.attachment_required_after_days",
 			at.created_at AS "type.created_at",
 			at.updated_at AS "type.updated_at",
 			a.overtime_hours,
+			a.notes,
 			a.created_at,
 			a.updated_at
 		FROM attendances a
 		JOIN attendance_types at ON a.type_id = at.id
 		WHERE a.date BETWEEN ? AND ?
 	`
+	args := []any{from, to}
+
+	if branchID != nil {
+		query += `AND a.branch_id = ?`
+		args = append(args, *branchID)
+	}
 
 	query = d.db.Rebind(query)
-	args := []any{from, to}
 
 	var attendances []Attendance
 	if err := d.db.SelectContext(ctx, &attendances, query, args...); err != nil {
 		return nil, fmt.Errorf("d.db.SelectContext: %w", err)
 	}
 
+	if err := d.fillAttachments(ctx, attendances); err != nil {
+		return nil, fmt.Errorf("fill attachments: %w", err)
+	}
+
 	return attendances, nil
 }
 
@@ -60,14 +81,17 @@ func (d *DB) GetEmployeeAttendancesBetweenDates(ctx context.Context, employeeID
 		SELECT
 			a.id,
 			a.employee_id,
+			a.branch_id,
 			a.date,
 			at.id AS "type.id",
 			at.name AS "type.name",
 			at.payable_type AS "type.payable_type",
 			at.has_quota AS "type.has_quota",
+			at.attachment_required_after_days AS "type.attachment_required_after_days",
 			at.created_at AS "type.created_at",
 			at.updated_at AS "type.updated_at",
 			a.overtime_hours,
+			a.notes,
 			a.created_at,
 			a.updated_at
 		FROM attendances a
@@ -85,9 +109,28 @@ func (d *DB) GetEmployeeAttendancesBetweenDates(ctx context.Context, employeeID
 		return nil, fmt.Errorf("d.db.SelectContext: %w", err)
 	}
 
+	if err := d.fillAttachments(ctx, attendances); err != nil {
+		return nil, fmt.Errorf("fill attachments: %w", err)
+	}
+
 	return attendances, nil
 }
 
+// GetEmployeeAttendance returns the attendance of an employee at a date, with its attachments.
+func (d *DB) GetEmployeeAttendance(ctx context.Context, employeeID int64, date date.Date) (Attendance, error) {
+	attendance, err := d.GetEmployeeAttendanceAtDate(ctx, employeeID, date)
+	if err != nil {
+		return Attendance{}, err
+	}
+
+	attendances := []Attendance{attendance}
+	if err := d.fillAttachments(ctx, attendances); err != nil {
+		return Attendance{}, fmt.Errorf("fill attachments: %w", err)
+	}
+
+	return attendances[0], nil
+}
+
 func (d *DB) GetEmployeeAttendanceAtDate(ctx context.Context, employeeID int64, date date.Date) (Attendance, error) {
 	return d.GetEmployeeAttendanceAtDateWithSelector(ctx, d.db, employeeID, date)
 }
@@ -97,14 +140,17 @@ func (d *DB) GetEmployeeAttendanceAtDateWithSelector(ctx context.Context, select
 		SELECT
 			a.id,
 			a.employee_id,
+			a.branch_id,
 			a.date,
 			at.id AS "type.id",
 			at.name AS "type.name",
 			at.payable_type AS "type.payable_type",
 			at.has_quota AS "type.has_quota",
+			at.attachment_required_after_days AS "type.attachment_required_after_days",
 			at.created_at AS "type.created_at",
 			at.updated_at AS "type.updated_at",
 			a.overtime_hours,
+			a.notes,
 			a.created_at,
 			a.updated_at
 		FROM attendances a
@@ -126,7 +172,7 @@ func (d *DB) GetEmployeeAttendanceAtDateWithSelector(ctx context.Context, select
 // getAttendanceTypeWithSelector fetches a single attendance type by ID within a transaction or db.
 func (d *DB) getAttendanceTypeWithSelector(ctx context.Context, selector SelectorContext, typeID int64) (Type, error) {
 	query := selector.Rebind(`
-		SELECT id, name, payable_type, has_quota, created_at, updated_at
+		SELECT id, name, payable_type, has_quota, attachment_required_after_days, created_at, updated_at
 		FROM attendance_types
 		WHERE id = ?
 	`)
@@ -241,6 +287,10 @@ func (d *DB) UpsertAttendance(
 	date date.Date,
 	typeID int64,
 	overtimeHours decimal.Decimal,
+	notes string,
+	attachmentIDs []int64,
+	branchID *int64,
+	precondition etag.Precondition,
 ) (Attendance, error) {
 	tx, err := d.db.BeginTxx(ctx, nil)
 	if err != nil {
@@ -249,17 +299,37 @@ func (d *DB) UpsertAttendance(
 
 	defer tx.Rollback()
 
+	// Lock the existing attendance so the precondition holds until the write is committed.
+	lockQuery := tx.Rebind(`SELECT id FROM attendances WHERE employee_id = ? AND date = ? FOR UPDATE`)
+	var lockedID int64
+	if err := tx.GetContext(ctx, &lockedID, lockQuery, employeeID, date); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return Attendance{}, fmt.Errorf("lock existing attendance: %w", err)
+	}
+
 	// Determine the existing attendance type (if any).
 	existingTypeID := int64(0)
 	existingTypeHasQuota := false
+	existingETag := ""
 
 	existing, err := d.GetEmployeeAttendanceAtDateWithSelector(ctx, tx, employeeID, date)
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		return Attendance{}, fmt.Errorf("get existing attendance: %w", err)
 	}
-	if err == nil {
+	exists := err == nil
+	if exists {
 		existingTypeID = existing.Type.ID
 		existingTypeHasQuota = existing.Type.HasQuota
+		existingETag = etag.FromTime(existing.UpdatedAt)
+
+		attachments, err := d.getAttachmentsByAttendanceIDs(ctx, tx, []int64{existing.ID})
+		if err != nil {
+			return Attendance{}, fmt.Errorf("get existing attachments: %w", err)
+		}
+		existing.Attachments = attachments[existing.ID]
+	}
+
+	if err := precondition.Check(existing, existingETag); err != nil {
+		return Attendance{}, err
 	}
 
 	// Handle quota changes only when the attendance type is changing (or this is a new record).
@@ -285,15 +355,36 @@ func (d *DB) UpsertAttendance(
 		}
 	}
 
+	if branchID != nil {
+		inBranch, err := d.isEmployeeInBranch(ctx, tx, employeeID, *branchID)
+		if err != nil {
+			return Attendance{}, fmt.Errorf("check employee branch: %w", err)
+		}
+		if !inBranch {
+			return Attendance{}, ErrEmployeeNotInBranch
+		}
+	}
+
 	// Upsert the attendance record.
 	upsertQuery := tx.Rebind(`
-		INSERT INTO attendances (employee_id, date, type_id, overtime_hours, created_at, updated_at)
-		VALUES (?, ?, ?, ?, NOW(), NOW())
-		ON CONFLICT (employee_id, date) DO UPDATE SET type_id = ?, overtime_hours = ?, updated_at = NOW()
+		INSERT INTO attendances (employee_id, branch_id, date, type_id, overtime_hours, notes, created_at, updated_at)
+		VALUES (?, COALESCE(?, (SELECT home_branch_id FROM employees WHERE id = ?)), ?, ?, ?, ?, NOW(), NOW())
+		ON CONFLICT (employee_id, date) DO UPDATE
+		SET branch_id = COALESCE(?, attendances.branch_id), type_id = ?, overtime_hours = ?, notes = ?, updated_at = NOW()
+		RETURNING id
 	`)
+	args := []any{
+		employeeID, branchID, employeeID, date, typeID, overtimeHours, notes,
+		branchID, typeID, overtimeHours, notes,
+	}
 
-	if _, err := tx.ExecContext(ctx, upsertQuery, employeeID, date, typeID, overtimeHours, typeID, overtimeHours); err != nil {
-		return Attendance{}, fmt.Errorf("tx.ExecContext: %w", err)
+	var attendanceID int64
+	if err := tx.GetContext(ctx, &attendanceID, upsertQuery, args...); err != nil {
+		return Attendance{}, fmt.Errorf("tx.GetContext: %w", err)
+	}
+
+	if err := d.linkAttachments(ctx, tx, attendanceID, attachmentIDs); err != nil {
+		return Attendance{}, fmt.Errorf("link attachments: %w", err)
 	}
 
 	attendance, err := d.GetEmployeeAttendanceAtDateWithSelector(ctx, tx, employeeID, date)
@@ -301,6 +392,25 @@ func (d *DB) UpsertAttendance(
 		return Attendance{}, fmt.Errorf("d.GetEmployeeAttendanceAtDateWithSelector: %w", err)
 	}
 
+	attachments, err := d.getAttachmentsByAttendanceIDs(ctx, tx, []int64{attendance.ID})
+	if err != nil {
+		return Attendance{}, fmt.Errorf("get attachments: %w", err)
+	}
+	attendance.Attachments = attachments[attendance.ID]
+
+	if err := webhook.Publish(ctx, tx, webhook.EventAttendanceUpserted, attendance.ID, attendance); err != nil {
+		return Attendance{}, fmt.Errorf("publish event: %w", err)
+	}
+
+	if exists {
+		err = audit.Record(ctx, tx, audit.ActionUpdate, auditEntityAttendance, attendance.ID, existing, attendance)
+	} else {
+		err = audit.Record(ctx, tx, audit.ActionCreate, auditEntityAttendance, attendance.ID, nil, attendance)
+	}
+	if err != nil {
+		return Attendance{}, fmt.Errorf("record audit log: %w", err)
+	}
+
 	if err := tx.Commit(); err != nil {
 		return Attendance{}, fmt.Errorf("tx.Commit: %w", err)
 	}
@@ -308,35 +418,78 @@ func (d *DB) UpsertAttendance(
 	return attendance, nil
 }
 
+// isEmployeeInBranch reports whether the branch is the employee's home branch or one they are assigned to.
+func (d *DB) isEmployeeInBranch(ctx context.Context, selector SelectorContext, employeeID int64, branchID int64) (bool, error) {
+	query := selector.Rebind(`
+		SELECT EXISTS (
+			SELECT 1 FROM employees WHERE id = ? AND home_branch_id = ?
+			UNION ALL
+			SELECT 1 FROM employee_branches WHERE employee_id = ? AND branch_id = ?
+		)
+	`)
+
+	var inBranch bool
+	if err := selector.GetContext(ctx, &inBranch, query, employeeID, branchID, employeeID, branchID); err != nil {
+		return false, fmt.Errorf("selector.GetContext: %w", err)
+	}
+
+	return inBranch, nil
+}
+
 // EnableAttendanceTypeQuota sets has_quota = true for an attendance type.
 // Returns ErrAlreadyHasQuota if the type already has quota enabled.
 func (d *DB) EnableAttendanceTypeQuota(ctx context.Context, typeID int64) (Type, error) {
-	query := d.db.Rebind(`
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return Type{}, fmt.Errorf("d.db.BeginTxx: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	before, err := d.lockAttendanceType(ctx, tx, typeID)
+	if err != nil {
+		return Type{}, fmt.Errorf("lock attendance type: %w", err)
+	}
+
+	if before.HasQuota {
+		return Type{}, ErrAlreadyHasQuota
+	}
+
+	query := tx.Rebind(`
 		UPDATE attendance_types
 		SET has_quota = TRUE, updated_at = NOW()
-		WHERE id = ? AND has_quota = FALSE
-		RETURNING id, name, payable_type, has_quota, created_at, updated_at
+		WHERE id = ?
+		RETURNING id, name, payable_type, has_quota, attachment_required_after_days, created_at, updated_at
 	`)
 
 	var t Type
-	err := d.db.GetContext(ctx, &t, query, typeID)
-	if errors.Is(err, sql.ErrNoRows) {
-		// Either the type doesn't exist or it already has quota enabled.
-		// Check which case it is.
-		existing, getErr := d.getAttendanceTypeWithSelector(ctx, d.db, typeID)
-		if errors.Is(getErr, sql.ErrNoRows) {
-			return Type{}, fmt.Errorf("d.db.GetContext: %w", sql.ErrNoRows)
-		}
-		if getErr != nil {
-			return Type{}, fmt.Errorf("get attendance type: %w", getErr)
-		}
-		if existing.HasQuota {
-			return Type{}, ErrAlreadyHasQuota
-		}
-		return Type{}, fmt.Errorf("d.db.GetContext: %w", err)
+	if err := tx.GetContext(ctx, &t, query, typeID); err != nil {
+		return Type{}, fmt.Errorf("tx.GetContext: %w", err)
 	}
-	if err != nil {
-		return Type{}, fmt.Errorf("d.db.GetContext: %w", err)
+
+	if err := audit.Record(ctx, tx, audit.ActionUpdate, auditEntityAttendanceType, t.ID, before, t); err != nil {
+		return Type{}, fmt.Errorf("record audit log: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Type{}, fmt.Errorf("tx.Commit: %w", err)
+	}
+
+	return t, nil
+}
+
+// lockAttendanceType fetches an attendance type by ID and locks it until the transaction ends.
+func (d *DB) lockAttendanceType(ctx context.Context, tx *sqlx.Tx, typeID int64) (Type, error) {
+	query := tx.Rebind(`
+		SELECT id, name, payable_type, has_quota, attachment_required_after_days, created_at, updated_at
+		FROM attendance_types
+		WHERE id = ?
+		FOR UPDATE
+	`)
+
+	var t Type
+	if err := tx.GetContext(ctx, &t, query, typeID); err != nil {
+		return Type{}, fmt.Errorf("tx.GetContext: %w", err)
 	}
 
 	return t, nil
@@ -352,7 +505,7 @@ func (d *DB) GetQuotaEnabledAttendanceTypes(ctx context.Context) ([]Type, error)
 
 func (d *DB) getAttendanceTypes(ctx context.Context, whereClause string) ([]Type, error) {
 	query := fmt.Sprintf(`
-		SELECT id, name, payable_type, has_quota, created_at, updated_at
+		SELECT id, name, payable_type, has_quota, attachment_required_after_days, created_at, updated_at
 		FROM attendance_types
 		%s
 	`, whereClause)
@@ -365,16 +518,73 @@ func (d *DB) getAttendanceTypes(ctx context.Context, whereClause string) ([]Type
 	return types, nil
 }
 
-func (d *DB) CreateAttendanceType(ctx context.Context, name string, payableType PayableType, hasQuota bool) (Type, error) {
-	query := d.db.Rebind(`
-		INSERT INTO attendance_types (name, payable_type, has_quota)
-		VALUES (?, ?, ?)
-		RETURNING id, name, payable_type, has_quota, created_at, updated_at
+func (d *DB) CreateAttendanceType(ctx context.Context, name string, payableType PayableType, hasQuota bool, attachmentRequiredAfterDays *int) (Type, error) {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return Type{}, fmt.Errorf("d.db.BeginTxx: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	query := tx.Rebind(`
+		INSERT INTO attendance_types (name, payable_type, has_quota, attachment_required_after_days)
+		VALUES (?, ?, ?, ?)
+		RETURNING id, name, payable_type, has_quota, attachment_required_after_days, created_at, updated_at
+	`)
+
+	var t Type
+	if err := tx.GetContext(ctx, &t, query, name, payableType, hasQuota, attachmentRequiredAfterDays); err != nil {
+		return Type{}, fmt.Errorf("tx.GetContext: %w", err)
+	}
+
+	if err := audit.Record(ctx, tx, audit.ActionCreate, auditEntityAttendanceType, t.ID, nil, t); err != nil {
+		return Type{}, fmt.Errorf("record audit log: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Type{}, fmt.Errorf("tx.Commit: %w", err)
+	}
+
+	return t, nil
+}
+
+func (d *DB) GetAttendanceType(ctx context.Context, typeID int64) (Type, error) {
+	return d.getAttendanceTypeWithSelector(ctx, d.db, typeID)
+}
+
+// SetAttachmentRequirement sets the number of consecutive days an attendance type is allowed
+// without a supporting attachment. A nil value removes the requirement.
+func (d *DB) SetAttachmentRequirement(ctx context.Context, typeID int64, attachmentRequiredAfterDays *int) (Type, error) {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return Type{}, fmt.Errorf("d.db.BeginTxx: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	before, err := d.lockAttendanceType(ctx, tx, typeID)
+	if err != nil {
+		return Type{}, fmt.Errorf("lock attendance type: %w", err)
+	}
+
+	query := tx.Rebind(`
+		UPDATE attendance_types
+		SET attachment_required_after_days = ?, updated_at = NOW()
+		WHERE id = ?
+		RETURNING id, name, payable_type, has_quota, attachment_required_after_days, created_at, updated_at
 	`)
 
 	var t Type
-	if err := d.db.GetContext(ctx, &t, query, name, payableType, hasQuota); err != nil {
-		return Type{}, fmt.Errorf("d.db.GetContext: %w", err)
+	if err := tx.GetContext(ctx, &t, query, attachmentRequiredAfterDays, typeID); err != nil {
+		return Type{}, fmt.Errorf("tx.GetContext: %w", err)
+	}
+
+	if err := audit.Record(ctx, tx, audit.ActionUpdate, auditEntityAttendanceType, t.ID, before, t); err != nil {
+		return Type{}, fmt.Errorf("record audit log: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Type{}, fmt.Errorf("tx.Commit: %w", err)
 	}
 
 	return t, nil
@@ -383,7 +593,7 @@ func (d *DB) CreateAttendanceType(ctx context.Context, name string, payableType
 // UpsertEmployeeQuota sets the remaining_quota for an employee+attendance type pair.
 // This is an administrative operation to allocate quota to an employee.
 // Also inserts an audit log entry recording the change.
-func (d *DB) UpsertEmployeeQuota(ctx context.Context, employeeID int64, typeID int64, remainingQuota int) (EmployeeAttendanceQuota, error) {
+func (d *DB) UpsertEmployeeQuota(ctx context.Context, employeeID int64, typeID int64, remainingQuota int, precondition etag.Precondition) (EmployeeAttendanceQuota, error) {
 	tx, err := d.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return EmployeeAttendanceQuota{}, fmt.Errorf("d.db.BeginTxx: %w", err)
@@ -391,6 +601,11 @@ func (d *DB) UpsertEmployeeQuota(ctx context.Context, employeeID int64, typeID i
 
 	defer tx.Rollback()
 
+	before, err := d.checkQuotaPrecondition(ctx, tx, employeeID, typeID, precondition)
+	if err != nil {
+		return EmployeeAttendanceQuota{}, err
+	}
+
 	// Get the previous quota value (0 if not yet allocated).
 	previousQuota, err := d.getCurrentQuota(ctx, tx, employeeID, typeID)
 	if err != nil {
@@ -414,15 +629,58 @@ func (d *DB) UpsertEmployeeQuota(ctx context.Context, employeeID int64, typeID i
 		return EmployeeAttendanceQuota{}, fmt.Errorf("insert audit log: %w", err)
 	}
 
+	quota, err := d.getEmployeeQuotaByID(ctx, tx, id)
+	if err != nil {
+		return EmployeeAttendanceQuota{}, fmt.Errorf("get quota: %w", err)
+	}
+
+	if before != nil {
+		err = audit.Record(ctx, tx, audit.ActionUpdate, auditEntityAttendanceQuota, quota.ID, before, quota)
+	} else {
+		err = audit.Record(ctx, tx, audit.ActionCreate, auditEntityAttendanceQuota, quota.ID, nil, quota)
+	}
+	if err != nil {
+		return EmployeeAttendanceQuota{}, fmt.Errorf("record audit log: %w", err)
+	}
+
 	if err := tx.Commit(); err != nil {
 		return EmployeeAttendanceQuota{}, fmt.Errorf("tx.Commit: %w", err)
 	}
 
-	return d.getEmployeeQuotaByID(ctx, id)
+	return quota, nil
 }
 
-func (d *DB) getEmployeeQuotaByID(ctx context.Context, id int64) (EmployeeAttendanceQuota, error) {
-	query := d.db.Rebind(`
+// checkQuotaPrecondition locks the quota of an employee+attendance type pair, if allocated, and returns it,
+// or a StaleError with it if it does not satisfy the precondition. The quota is nil if not allocated.
+func (d *DB) checkQuotaPrecondition(ctx context.Context, tx *sqlx.Tx, employeeID int64, typeID int64, precondition etag.Precondition) (*EmployeeAttendanceQuota, error) {
+	query := tx.Rebind(`
+		SELECT id FROM employee_attendance_quotas
+		WHERE employee_id = ? AND attendance_type_id = ?
+		FOR UPDATE
+	`)
+
+	var id int64
+	if err := tx.GetContext(ctx, &id, query, employeeID, typeID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, precondition.Check(nil, "")
+		}
+		return nil, fmt.Errorf("lock quota: %w", err)
+	}
+
+	current, err := d.getEmployeeQuotaByID(ctx, tx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get current quota: %w", err)
+	}
+
+	if err := precondition.Check(current, etag.FromTime(current.UpdatedAt)); err != nil {
+		return nil, err
+	}
+
+	return &current, nil
+}
+
+func (d *DB) getEmployeeQuotaByID(ctx context.Context, selector SelectorContext, id int64) (EmployeeAttendanceQuota, error) {
+	query := selector.Rebind(`
 		SELECT
 			q.id,
 			q.employee_id,
@@ -430,6 +688,7 @@ func (d *DB) getEmployeeQuotaByID(ctx context.Context, id int64) (EmployeeAttend
 			at.name AS "attendance_type.name",
 			at.payable_type AS "attendance_type.payable_type",
 			at.has_quota AS "attendance_type.has_quota",
+			at.attachment_required_after_days AS "attendance_type.attachment_required_after_days",
 			at.created_at AS "attendance_type.created_at",
 			at.updated_at AS "attendance_type.updated_at",
 			q.remaining_quota,
@@ -441,7 +700,36 @@ func (d *DB) getEmployeeQuotaByID(ctx context.Context, id int64) (EmployeeAttend
 	`)
 
 	var quota EmployeeAttendanceQuota
-	if err := d.db.GetContext(ctx, &quota, query, id); err != nil {
+	if err := selector.GetContext(ctx, &quota, query, id); err != nil {
+		return EmployeeAttendanceQuota{}, fmt.Errorf("selector.GetContext: %w", err)
+	}
+
+	return quota, nil
+}
+
+// GetEmployeeQuota returns the quota allocation of an employee for an attendance type.
+func (d *DB) GetEmployeeQuota(ctx context.Context, employeeID int64, typeID int64) (EmployeeAttendanceQuota, error) {
+	query := d.db.Rebind(`
+		SELECT
+			q.id,
+			q.employee_id,
+			at.id AS "attendance_type.id",
+			at.name AS "attendance_type.name",
+			at.payable_type AS "attendance_type.payable_type",
+			at.has_quota AS "attendance_type.has_quota",
+			at.attachment_required_after_days AS "attendance_type.attachment_required_after_days",
+			at.created_at AS "attendance_type.created_at",
+			at.updated_at AS "attendance_type.updated_at",
+			q.remaining_quota,
+			q.created_at,
+			q.updated_at
+		FROM employee_attendance_quotas q
+		JOIN attendance_types at ON q.attendance_type_id = at.id
+		WHERE q.employee_id = ? AND q.attendance_type_id = ?
+	`)
+
+	var quota EmployeeAttendanceQuota
+	if err := d.db.GetContext(ctx, &quota, query, employeeID, typeID); err != nil {
 		return EmployeeAttendanceQuota{}, fmt.Errorf("d.db.GetContext: %w", err)
 	}
 
@@ -458,6 +746,7 @@ func (d *DB) GetAllQuotas(ctx context.Context) ([]EmployeeAttendanceQuota, error
 			at.name AS "attendance_type.name",
 			at.payable_type AS "attendance_type.payable_type",
 			at.has_quota AS "attendance_type.has_quota",
+			at.attachment_required_after_days AS "attendance_type.attachment_required_after_days",
 			at.created_at AS "attendance_type.created_at",
 			at.updated_at AS "attendance_type.updated_at",
 			q.remaining_quota,
@@ -486,6 +775,7 @@ func (d *DB) GetEmployeeQuotas(ctx context.Context, employeeID int64) ([]Employe
 			at.name AS "attendance_type.name",
 			at.payable_type AS "attendance_type.payable_type",
 			at.has_quota AS "attendance_type.has_quota",
+			at.attachment_required_after_days AS "attendance_type.attachment_required_after_days",
 			at.created_at AS "attendance_type.created_at",
 			at.updated_at AS "attendance_type.updated_at",
 			q.remaining_quota,
@@ -514,7 +804,14 @@ func (d *DB) IncrementQuotaForEmployees(ctx context.Context, employeeIDs []int64
 		return 0, nil
 	}
 
-	query := d.db.Rebind(`
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("d.db.BeginTxx: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	query := tx.Rebind(`
 		WITH current_quotas AS (
 			SELECT eid AS employee_id, COALESCE(q.remaining_quota, 0) AS previous_quota
 			FROM unnest(?::bigint[]) AS eid
@@ -534,9 +831,9 @@ func (d *DB) IncrementQuotaForEmployees(ctx context.Context, employeeIDs []int64
 		JOIN upserted u ON cq.employee_id = u.employee_id
 	`)
 
-	result, err := d.db.ExecContext(ctx, query, employeeIDs, typeID, typeID, increment, increment, typeID)
+	result, err := tx.ExecContext(ctx, query, employeeIDs, typeID, typeID, increment, increment, typeID)
 	if err != nil {
-		return 0, fmt.Errorf("d.db.ExecContext: %w", err)
+		return 0, fmt.Errorf("tx.ExecContext: %w", err)
 	}
 
 	affected, err := result.RowsAffected()
@@ -544,12 +841,90 @@ func (d *DB) IncrementQuotaForEmployees(ctx context.Context, employeeIDs []int64
 		return 0, fmt.Errorf("result.RowsAffected: %w", err)
 	}
 
+	// Per-employee values are kept in the quota audit logs; record the bulk operation itself here.
+	after := map[string]any{
+		"quotaIncrement":    increment,
+		"employeeIDs":       employeeIDs,
+		"affectedEmployees": affected,
+	}
+	if err := audit.Record(ctx, tx, audit.ActionUpdate, auditEntityAttendanceType, typeID, nil, after); err != nil {
+		return 0, fmt.Errorf("record audit log: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("tx.Commit: %w", err)
+	}
+
 	return affected, nil
 }
 
-// GetQuotaAuditLogs returns all audit logs for quota changes, ordered by most recent first.
-func (d *DB) GetQuotaAuditLogs(ctx context.Context) ([]QuotaAuditLog, error) {
-	query := `
+func (d *DB) GetEmployeeIDs(ctx context.Context) ([]int64, error) {
+	query := `SELECT id FROM employees ORDER BY id ASC`
+
+	var employeeIDs []int64
+	if err := d.db.SelectContext(ctx, &employeeIDs, query); err != nil {
+		return nil, fmt.Errorf("d.db.SelectContext: %w", err)
+	}
+
+	return employeeIDs, nil
+}
+
+var quotaAuditLogSorting = pagination.Sorting[QuotaAuditLog]{
+	Fields: map[string]pagination.Field[QuotaAuditLog]{
+		"id":        {Column: "l.id", Type: "bigint", Value: func(l QuotaAuditLog) string { return pagination.FormatInt(l.ID) }},
+		"createdAt": {Column: "l.created_at", Type: "timestamptz", Value: func(l QuotaAuditLog) string { return l.CreatedAt.Format(time.RFC3339Nano) }},
+	},
+	Default:  "-createdAt",
+	IDColumn: "l.id",
+	ID:       func(l QuotaAuditLog) int64 { return l.ID },
+}
+
+// ListQuotaAuditLogs returns a page of the audit logs for quota changes matching the filters of the request.
+func (d *DB) ListQuotaAuditLogs(ctx context.Context, request ListQuotaAuditLogsRequest) (pagination.Page[QuotaAuditLog], error) {
+	page, err := quotaAuditLogSorting.Query(request.Page)
+	if err != nil {
+		return pagination.Page[QuotaAuditLog]{}, err
+	}
+
+	var filters []string
+	var args []any
+
+	if request.EmployeeID != nil {
+		filters = append(filters, "l.employee_id = ?")
+		args = append(args, *request.EmployeeID)
+	}
+
+	if request.AttendanceTypeID != nil {
+		filters = append(filters, "l.attendance_type_id = ?")
+		args = append(args, *request.AttendanceTypeID)
+	}
+
+	if request.Reason != nil {
+		filters = append(filters, "l.reason = ?")
+		args = append(args, *request.Reason)
+	}
+
+	if request.From != nil {
+		filters = append(filters, "l.created_at >= ?")
+		args = append(args, *request.From)
+	}
+
+	if request.To != nil {
+		filters = append(filters, "l.created_at <= ?")
+		args = append(args, *request.To)
+	}
+
+	var total int
+	if err := d.db.GetContext(ctx, &total, d.db.Rebind(`SELECT COUNT(*) FROM attendance_quota_audit_logs l `+database.Where(filters)), args...); err != nil {
+		return pagination.Page[QuotaAuditLog]{}, fmt.Errorf("count quota audit logs in db: %w", err)
+	}
+
+	if condition, cursorArgs := page.Where(); condition != "" {
+		filters = append(filters, condition)
+		args = append(args, cursorArgs...)
+	}
+
+	query := d.db.Rebind(`
 		SELECT
 			l.id,
 			l.employee_id,
@@ -557,6 +932,7 @@ func (d *DB) GetQuotaAuditLogs(ctx context.Context) ([]QuotaAuditLog, error) {
 			at.name AS "attendance_type.name",
 			at.payable_type AS "attendance_type.payable_type",
 			at.has_quota AS "attendance_type.has_quota",
+			at.attachment_required_after_days AS "attendance_type.attachment_required_after_days",
 			at.created_at AS "attendance_type.created_at",
 			at.updated_at AS "attendance_type.updated_at",
 			l.previous_quota,
@@ -565,15 +941,15 @@ func (d *DB) GetQuotaAuditLogs(ctx context.Context) ([]QuotaAuditLog, error) {
 			l.created_at
 		FROM attendance_quota_audit_logs l
 		JOIN attendance_types at ON l.attendance_type_id = at.id
-		ORDER BY l.created_at DESC
-	`
+		` + database.Where(filters) + `
+		` + page.OrderByLimit())
 
 	var logs []QuotaAuditLog
-	if err := d.db.SelectContext(ctx, &logs, query); err != nil {
-		return nil, fmt.Errorf("d.db.SelectContext: %w", err)
+	if err := d.db.SelectContext(ctx, &logs, query, args...); err != nil {
+		return pagination.Page[QuotaAuditLog]{}, fmt.Errorf("d.db.SelectContext: %w", err)
 	}
 
-	return logs, nil
+	return page.Page(logs, total), nil
 }
 
 // GetEmployeeQuotaAuditLogs returns audit logs for a specific employee, ordered by most recent first.
@@ -586,6 +962,7 @@ func (d *DB) GetEmployeeQuotaAuditLogs(ctx context.Context, employeeID int64) ([
 			at.name AS "attendance_type.name",
 			at.payable_type AS "attendance_type.payable_type",
 			at.has_quota AS "attendance_type.has_quota",
+			at.attachment_required_after_days AS "attendance_type.attachment_required_after_days",
 			at.created_at AS "attendance_type.created_at",
 			at.updated_at AS "attendance_type.updated_at",
 			l.previous_quota,
@@ -605,3 +982,122 @@ func (d *DB) GetEmployeeQuotaAuditLogs(ctx context.Context, employeeID int64) ([
 
 	return logs, nil
 }
+
+// fillAttachments loads the attachments of the given attendances in place.
+func (d *DB) fillAttachments(ctx context.Context, attendances []Attendance) error {
+	if len(attendances) == 0 {
+		return nil
+	}
+
+	attendanceIDs := make([]int64, len(attendances))
+	for i, a := range attendances {
+		attendanceIDs[i] = a.ID
+	}
+
+	attachments, err := d.getAttachmentsByAttendanceIDs(ctx, d.db, attendanceIDs)
+	if err != nil {
+		return fmt.Errorf("get attachments by attendance ids: %w", err)
+	}
+
+	for i, a := range attendances {
+		attendances[i].Attachments = attachments[a.ID]
+	}
+
+	return nil
+}
+
+func (d *DB) getAttachmentsByAttendanceIDs(ctx context.Context, selector SelectorContext, attendanceIDs []int64) (map[int64][]Attachment, error) {
+	query := selector.Rebind(`
+		SELECT id, attendance_id, storage_key, file_name, content_type, size_bytes, created_at
+		FROM attendance_attachments
+		WHERE attendance_id = ANY(?)
+		ORDER BY id ASC
+	`)
+
+	var attachments []Attachment
+	if err := selector.SelectContext(ctx, &attachments, query, attendanceIDs); err != nil {
+		return nil, fmt.Errorf("selector.SelectContext: %w", err)
+	}
+
+	result := make(map[int64][]Attachment)
+	for _, attachment := range attachments {
+		result[*attachment.AttendanceID] = append(result[*attachment.AttendanceID], attachment)
+	}
+
+	return result, nil
+}
+
+// linkAttachments links unlinked attachments to an attendance.
+// Returns ErrAttachmentNotFound if any of the attachments does not exist or belongs to another attendance.
+func (d *DB) linkAttachments(ctx context.Context, tx *sqlx.Tx, attendanceID int64, attachmentIDs []int64) error {
+	if len(attachmentIDs) == 0 {
+		return nil
+	}
+
+	query := tx.Rebind(`
+		UPDATE attendance_attachments
+		SET attendance_id = ?
+		WHERE id = ANY(?) AND (attendance_id IS NULL OR attendance_id = ?)
+	`)
+
+	result, err := tx.ExecContext(ctx, query, attendanceID, attachmentIDs, attendanceID)
+	if err != nil {
+		return fmt.Errorf("tx.ExecContext: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("result.RowsAffected: %w", err)
+	}
+
+	if rows != int64(len(slices.Compact(slices.Sorted(slices.Values(attachmentIDs))))) {
+		return ErrAttachmentNotFound
+	}
+
+	return nil
+}
+
+func (d *DB) CreateAttachment(ctx context.Context, storageKey string, fileName string, contentType string, sizeBytes int64) (Attachment, error) {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return Attachment{}, fmt.Errorf("d.db.BeginTxx: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	query := tx.Rebind(`
+		INSERT INTO attendance_attachments (storage_key, file_name, content_type, size_bytes)
+		VALUES (?, ?, ?, ?)
+		RETURNING id, attendance_id, storage_key, file_name, content_type, size_bytes, created_at
+	`)
+
+	var attachment Attachment
+	if err := tx.GetContext(ctx, &attachment, query, storageKey, fileName, contentType, sizeBytes); err != nil {
+		return Attachment{}, fmt.Errorf("tx.GetContext: %w", err)
+	}
+
+	if err := audit.Record(ctx, tx, audit.ActionCreate, auditEntityAttendanceAttachment, attachment.ID, nil, attachment); err != nil {
+		return Attachment{}, fmt.Errorf("record audit log: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Attachment{}, fmt.Errorf("tx.Commit: %w", err)
+	}
+
+	return attachment, nil
+}
+
+func (d *DB) GetAttachment(ctx context.Context, id int64) (Attachment, error) {
+	query := d.db.Rebind(`
+		SELECT id, attendance_id, storage_key, file_name, content_type, size_bytes, created_at
+		FROM attendance_attachments
+		WHERE id = ?
+	`)
+
+	var attachment Attachment
+	if err := d.db.GetContext(ctx, &attachment, query, id); err != nil {
+		return Attachment{}, fmt.Errorf("d.db.GetContext: %w", err)
+	}
+
+	return attachment, nil
+}
@@ -1,20 +1,24 @@
 package attendance
 
 import (
-	"database/sql"
 	"errors"
+	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-json-experiment/json"
 	"github.com/turfaa/apotek-hris/internal/hris"
+	"github.com/turfaa/apotek-hris/pkg/etag"
 	"github.com/turfaa/apotek-hris/pkg/httpx"
 	"github.com/turfaa/apotek-hris/pkg/timex"
-	"github.com/turfaa/apotek-hris/pkg/validatorx"
 	"github.com/turfaa/go-date"
 )
 
+// maxAttachmentSize is the largest accepted attachment upload, in bytes.
+const maxAttachmentSize = 10 << 20
+
 type Handler struct {
 	service     *Service
 	hrisService *hris.Service
@@ -27,13 +31,19 @@ func NewHandler(service *Service, hrisService *hris.Service) *Handler {
 func (h *Handler) GetAttendancesBetweenDates(w http.ResponseWriter, r *http.Request) {
 	from, to, err := timex.GetMonthDateRangeFromQuery(r)
 	if err != nil {
-		httpx.Error(w, err, http.StatusBadRequest)
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	branchID, err := httpx.GetOptionalInt64FromQuery(r, "branchID")
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
 		return
 	}
 
-	attendances, err := h.service.GetAttendancesBetweenDates(r.Context(), from, to)
+	attendances, err := h.service.GetAttendancesBetweenDates(r.Context(), from, to, branchID)
 	if err != nil {
-		httpServiceError(w, err)
+		httpServiceError(w, r, err)
 		return
 	}
 
@@ -46,53 +56,90 @@ func (h *Handler) GetAttendancesBetweenDates(w http.ResponseWriter, r *http.Requ
 	})
 }
 
+func (h *Handler) GetEmployeeAttendance(w http.ResponseWriter, r *http.Request) {
+	employeeIDStr := chi.URLParam(r, "employeeID")
+	if employeeIDStr == "" {
+		httpx.Error(w, r, errors.New("employeeID is required"), http.StatusBadRequest)
+		return
+	}
+
+	employeeID, err := strconv.ParseInt(employeeIDStr, 10, 64)
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	dateStr := chi.URLParam(r, "date")
+	if dateStr == "" {
+		httpx.Error(w, r, errors.New("date is required"), http.StatusBadRequest)
+		return
+	}
+
+	dt, err := date.NewFromString(dateStr)
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	attendance, err := h.service.GetEmployeeAttendance(r.Context(), employeeID, dt)
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag.FromTime(attendance.UpdatedAt))
+	httpx.Ok(w, attendance)
+}
+
 func (h *Handler) UpsertAttendance(w http.ResponseWriter, r *http.Request) {
 	employeeIDStr := chi.URLParam(r, "employeeID")
 	if employeeIDStr == "" {
-		httpx.Error(w, errors.New("employeeID is required"), http.StatusBadRequest)
+		httpx.Error(w, r, errors.New("employeeID is required"), http.StatusBadRequest)
 		return
 	}
 
 	employeeID, err := strconv.ParseInt(employeeIDStr, 10, 64)
 	if err != nil {
-		httpx.Error(w, err, http.StatusBadRequest)
+		httpx.Error(w, r, err, http.StatusBadRequest)
 		return
 	}
 
 	dateStr := chi.URLParam(r, "date")
 	if dateStr == "" {
-		httpx.Error(w, errors.New("date is required"), http.StatusBadRequest)
+		httpx.Error(w, r, errors.New("date is required"), http.StatusBadRequest)
 		return
 	}
 
 	dt, err := date.NewFromString(dateStr)
 	if err != nil {
-		httpx.Error(w, err, http.StatusBadRequest)
+		httpx.Error(w, r, err, http.StatusBadRequest)
 		return
 	}
 
 	var req UpsertAttendanceRequest
 	if err := json.UnmarshalRead(r.Body, &req); err != nil {
-		httpx.Error(w, err, http.StatusBadRequest)
+		httpx.Error(w, r, err, http.StatusBadRequest)
 		return
 	}
 
 	req.EmployeeID = employeeID
 	req.Date = dt
+	req.IfMatch = httpx.GetPrecondition(r)
 
 	attendance, err := h.service.UpsertAttendance(r.Context(), req)
 	if err != nil {
-		httpServiceError(w, err)
+		httpServiceError(w, r, err)
 		return
 	}
 
+	w.Header().Set("ETag", etag.FromTime(attendance.UpdatedAt))
 	httpx.Ok(w, attendance)
 }
 
 func (h *Handler) GetAttendanceTypes(w http.ResponseWriter, r *http.Request) {
 	attendanceTypes, err := h.service.GetAttendanceTypes(r.Context())
 	if err != nil {
-		httpServiceError(w, err)
+		httpServiceError(w, r, err)
 		return
 	}
 
@@ -102,13 +149,13 @@ func (h *Handler) GetAttendanceTypes(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) CreateAttendanceType(w http.ResponseWriter, r *http.Request) {
 	var req CreateAttendanceTypeRequest
 	if err := json.UnmarshalRead(r.Body, &req); err != nil {
-		httpx.Error(w, err, http.StatusBadRequest)
+		httpx.Error(w, r, err, http.StatusBadRequest)
 		return
 	}
 
 	attendanceType, err := h.service.CreateAttendanceType(r.Context(), req)
 	if err != nil {
-		httpServiceError(w, err)
+		httpServiceError(w, r, err)
 		return
 	}
 
@@ -118,41 +165,125 @@ func (h *Handler) CreateAttendanceType(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) EnableAttendanceTypeQuota(w http.ResponseWriter, r *http.Request) {
 	typeIDStr := chi.URLParam(r, "typeID")
 	if typeIDStr == "" {
-		httpx.Error(w, errors.New("typeID is required"), http.StatusBadRequest)
+		httpx.Error(w, r, errors.New("typeID is required"), http.StatusBadRequest)
 		return
 	}
 
 	typeID, err := strconv.ParseInt(typeIDStr, 10, 64)
 	if err != nil {
-		httpx.Error(w, err, http.StatusBadRequest)
+		httpx.Error(w, r, err, http.StatusBadRequest)
 		return
 	}
 
 	attendanceType, err := h.service.EnableAttendanceTypeQuota(r.Context(), typeID)
 	if err != nil {
-		httpServiceError(w, err)
+		httpServiceError(w, r, err)
 		return
 	}
 
 	httpx.Ok(w, attendanceType)
 }
 
+func (h *Handler) SetAttachmentRequirement(w http.ResponseWriter, r *http.Request) {
+	typeIDStr := chi.URLParam(r, "typeID")
+	if typeIDStr == "" {
+		httpx.Error(w, r, errors.New("typeID is required"), http.StatusBadRequest)
+		return
+	}
+
+	typeID, err := strconv.ParseInt(typeIDStr, 10, 64)
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	var req SetAttachmentRequirementRequest
+	if err := json.UnmarshalRead(r.Body, &req); err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	req.TypeID = typeID
+
+	attendanceType, err := h.service.SetAttachmentRequirement(r.Context(), req)
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Ok(w, attendanceType)
+}
+
+func (h *Handler) UploadAttachment(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxAttachmentSize)
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		httpx.Error(w, r, fmt.Errorf("read file from form: %w", err), http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	attachment, err := h.service.UploadAttachment(
+		r.Context(),
+		header.Filename,
+		header.Header.Get("Content-Type"),
+		header.Size,
+		file,
+	)
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Ok(w, attachment)
+}
+
+func (h *Handler) DownloadAttachment(w http.ResponseWriter, r *http.Request) {
+	attachmentIDStr := chi.URLParam(r, "attachmentID")
+	if attachmentIDStr == "" {
+		httpx.Error(w, r, errors.New("attachmentID is required"), http.StatusBadRequest)
+		return
+	}
+
+	attachmentID, err := strconv.ParseInt(attachmentIDStr, 10, 64)
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	attachment, content, err := h.service.GetAttachment(r.Context(), attachmentID)
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+	defer content.Close()
+
+	httpx.File(w, content, attachment.FileName, attachment.ContentType)
+}
+
 func (h *Handler) GetAllQuotas(w http.ResponseWriter, r *http.Request) {
+	branchID, err := httpx.GetOptionalInt64FromQuery(r, "branchID")
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
 	quotas, err := h.service.GetAllQuotas(r.Context())
 	if err != nil {
-		httpServiceError(w, err)
+		httpServiceError(w, r, err)
 		return
 	}
 
 	quotaEnabledTypes, err := h.service.GetQuotaEnabledAttendanceTypes(r.Context())
 	if err != nil {
-		httpServiceError(w, err)
+		httpServiceError(w, r, err)
 		return
 	}
 
-	employees, err := h.hrisService.GetEmployees(r.Context())
+	employees, err := h.hrisService.GetEmployees(r.Context(), branchID)
 	if err != nil {
-		httpServiceError(w, err)
+		httpServiceError(w, r, err)
 		return
 	}
 
@@ -161,6 +292,12 @@ func (h *Handler) GetAllQuotas(w http.ResponseWriter, r *http.Request) {
 		employeeIDs[i] = e.ID
 	}
 
+	if branchID != nil {
+		quotas = slices.DeleteFunc(quotas, func(q EmployeeAttendanceQuota) bool {
+			return !slices.Contains(employeeIDs, q.EmployeeID)
+		})
+	}
+
 	pages := GroupQuotasByAttendanceType(quotas, quotaEnabledTypes, employeeIDs)
 	httpx.Ok(w, pages)
 }
@@ -168,72 +305,141 @@ func (h *Handler) GetAllQuotas(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) GetEmployeeQuotas(w http.ResponseWriter, r *http.Request) {
 	employeeIDStr := chi.URLParam(r, "employeeID")
 	if employeeIDStr == "" {
-		httpx.Error(w, errors.New("employeeID is required"), http.StatusBadRequest)
+		httpx.Error(w, r, errors.New("employeeID is required"), http.StatusBadRequest)
 		return
 	}
 
 	employeeID, err := strconv.ParseInt(employeeIDStr, 10, 64)
 	if err != nil {
-		httpx.Error(w, err, http.StatusBadRequest)
+		httpx.Error(w, r, err, http.StatusBadRequest)
 		return
 	}
 
 	quotas, err := h.service.GetEmployeeQuotas(r.Context(), employeeID)
 	if err != nil {
-		httpServiceError(w, err)
+		httpServiceError(w, r, err)
 		return
 	}
 
 	httpx.Ok(w, quotas)
 }
 
+func (h *Handler) GetEmployeeQuota(w http.ResponseWriter, r *http.Request) {
+	employeeIDStr := chi.URLParam(r, "employeeID")
+	if employeeIDStr == "" {
+		httpx.Error(w, r, errors.New("employeeID is required"), http.StatusBadRequest)
+		return
+	}
+
+	employeeID, err := strconv.ParseInt(employeeIDStr, 10, 64)
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	typeIDStr := chi.URLParam(r, "typeID")
+	if typeIDStr == "" {
+		httpx.Error(w, r, errors.New("typeID is required"), http.StatusBadRequest)
+		return
+	}
+
+	typeID, err := strconv.ParseInt(typeIDStr, 10, 64)
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	quota, err := h.service.GetEmployeeQuota(r.Context(), employeeID, typeID)
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag.FromTime(quota.UpdatedAt))
+	httpx.Ok(w, quota)
+}
+
 func (h *Handler) SetEmployeeQuota(w http.ResponseWriter, r *http.Request) {
 	employeeIDStr := chi.URLParam(r, "employeeID")
 	if employeeIDStr == "" {
-		httpx.Error(w, errors.New("employeeID is required"), http.StatusBadRequest)
+		httpx.Error(w, r, errors.New("employeeID is required"), http.StatusBadRequest)
 		return
 	}
 
 	employeeID, err := strconv.ParseInt(employeeIDStr, 10, 64)
 	if err != nil {
-		httpx.Error(w, err, http.StatusBadRequest)
+		httpx.Error(w, r, err, http.StatusBadRequest)
 		return
 	}
 
 	typeIDStr := chi.URLParam(r, "typeID")
 	if typeIDStr == "" {
-		httpx.Error(w, errors.New("typeID is required"), http.StatusBadRequest)
+		httpx.Error(w, r, errors.New("typeID is required"), http.StatusBadRequest)
 		return
 	}
 
 	typeID, err := strconv.ParseInt(typeIDStr, 10, 64)
 	if err != nil {
-		httpx.Error(w, err, http.StatusBadRequest)
+		httpx.Error(w, r, err, http.StatusBadRequest)
 		return
 	}
 
 	var req SetEmployeeAttendanceQuotaRequest
 	if err := json.UnmarshalRead(r.Body, &req); err != nil {
-		httpx.Error(w, err, http.StatusBadRequest)
+		httpx.Error(w, r, err, http.StatusBadRequest)
 		return
 	}
 
 	req.EmployeeID = employeeID
 	req.AttendanceTypeID = typeID
+	req.IfMatch = httpx.GetPrecondition(r)
 
 	quota, err := h.service.SetEmployeeQuota(r.Context(), req)
 	if err != nil {
-		httpServiceError(w, err)
+		httpServiceError(w, r, err)
 		return
 	}
 
+	w.Header().Set("ETag", etag.FromTime(quota.UpdatedAt))
 	httpx.Ok(w, quota)
 }
 
 func (h *Handler) GetQuotaAuditLogs(w http.ResponseWriter, r *http.Request) {
-	logs, err := h.service.GetQuotaAuditLogs(r.Context())
+	var req ListQuotaAuditLogsRequest
+	var err error
+
+	req.EmployeeID, err = httpx.GetOptionalInt64FromQuery(r, "employeeID")
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	req.AttendanceTypeID, err = httpx.GetOptionalInt64FromQuery(r, "typeID")
 	if err != nil {
-		httpServiceError(w, err)
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	if reasonStr := r.URL.Query().Get("reason"); reasonStr != "" {
+		reason := QuotaAuditReason(reasonStr)
+		req.Reason = &reason
+	}
+
+	req.From, req.To, err = timex.GetOptionalTimeRangeFromQuery(r)
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	req.Page, err = httpx.GetPageFromQuery(r)
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	logs, err := h.service.ListQuotaAuditLogs(r.Context(), req)
+	if err != nil {
+		httpServiceError(w, r, err)
 		return
 	}
 
@@ -243,36 +449,36 @@ func (h *Handler) GetQuotaAuditLogs(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) GetEmployeeQuotaAuditLogs(w http.ResponseWriter, r *http.Request) {
 	employeeIDStr := chi.URLParam(r, "employeeID")
 	if employeeIDStr == "" {
-		httpx.Error(w, errors.New("employeeID is required"), http.StatusBadRequest)
+		httpx.Error(w, r, errors.New("employeeID is required"), http.StatusBadRequest)
 		return
 	}
 
 	employeeID, err := strconv.ParseInt(employeeIDStr, 10, 64)
 	if err != nil {
-		httpx.Error(w, err, http.StatusBadRequest)
+		httpx.Error(w, r, err, http.StatusBadRequest)
 		return
 	}
 
 	logs, err := h.service.GetEmployeeQuotaAuditLogs(r.Context(), employeeID)
 	if err != nil {
-		httpServiceError(w, err)
+		httpServiceError(w, r, err)
 		return
 	}
 
 	httpx.Ok(w, logs)
 }
 
-func httpServiceError(w http.ResponseWriter, err error) {
-	switch {
-	case errors.Is(err, sql.ErrNoRows):
-		httpx.Error(w, err, http.StatusNotFound)
-	case errors.Is(err, ErrQuotaExhausted):
-		httpx.Error(w, err, http.StatusBadRequest)
-	case errors.Is(err, ErrAlreadyHasQuota):
-		httpx.Error(w, err, http.StatusBadRequest)
-	case errors.As(err, &validatorx.ValidationErrors{}):
-		httpx.Error(w, err, http.StatusBadRequest)
-	default:
-		httpx.Error(w, err, http.StatusInternalServerError)
-	}
+// errorCodes classify the errors of the attendance service for the clients.
+var errorCodes = []httpx.ErrorCode{
+	{Err: ErrAttendanceNotFound, Code: httpx.CodeNotFound},
+	{Err: ErrQuotaNotFound, Code: httpx.CodeNotFound},
+	{Err: ErrQuotaExhausted, Code: httpx.CodeQuotaExhausted},
+	{Err: ErrAlreadyHasQuota, Code: httpx.CodeConflict},
+	{Err: ErrAttachmentRequired, Code: httpx.CodeValidation},
+	{Err: ErrAttachmentNotFound, Code: httpx.CodeNotFound},
+	{Err: ErrEmployeeNotInBranch, Code: httpx.CodeValidation},
+}
+
+func httpServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	httpx.ServiceError(w, r, err, errorCodes)
 }
@@ -0,0 +1,49 @@
+package attendance
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/turfaa/apotek-hris/internal/scheduler"
+)
+
+// Jobs returns the scheduled jobs of the attendance package.
+func (s *Service) Jobs(config Config) []scheduler.Job {
+	if len(config.QuotaIncreases) == 0 {
+		return nil
+	}
+
+	return []scheduler.Job{
+		{
+			Name:        "increase-quota",
+			Description: "Add the configured quota increases to every employee",
+			Schedule:    "0 0 1 1 *",
+			Run: func(ctx context.Context) error {
+				return s.increaseQuotas(ctx, config.QuotaIncreases)
+			},
+		},
+	}
+}
+
+func (s *Service) increaseQuotas(ctx context.Context, increases []QuotaIncrease) error {
+	employeeIDs, err := s.db.GetEmployeeIDs(ctx)
+	if err != nil {
+		return fmt.Errorf("get employee ids from db: %w", err)
+	}
+
+	for _, increase := range increases {
+		affected, err := s.IncrementQuotaForEmployees(ctx, employeeIDs, increase.TypeID, increase.Increment)
+		if err != nil {
+			return fmt.Errorf("increase quota of attendance type %d: %w", increase.TypeID, err)
+		}
+
+		slog.InfoContext(ctx, "increased attendance quota",
+			slog.Int64("type_id", increase.TypeID),
+			slog.Int("increment", increase.Increment),
+			slog.Int64("affected_employees", affected),
+		)
+	}
+
+	return nil
+}
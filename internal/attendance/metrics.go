@@ -0,0 +1,30 @@
+package attendance
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/turfaa/apotek-hris/pkg/metrics"
+)
+
+var (
+	attendanceUpserts = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "attendance",
+			Name:      "upserts_total",
+			Help:      "Number of attendances created or updated, by attendance type.",
+		},
+		[]string{"type"},
+	)
+
+	quotaExhausted = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "attendance",
+			Name:      "quota_exhausted_total",
+			Help:      "Number of attendances rejected because the employee had no quota left, by attendance type.",
+		},
+		[]string{"type"},
+	)
+)
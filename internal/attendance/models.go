@@ -5,23 +5,43 @@ import (
 	"time"
 
 	"github.com/shopspring/decimal"
+	"github.com/turfaa/apotek-hris/pkg/etag"
+	"github.com/turfaa/apotek-hris/pkg/pagination"
 	"github.com/turfaa/apotek-hris/pkg/slicex"
 	"github.com/turfaa/go-date"
 )
 
+// ErrAttendanceNotFound is returned when an employee has no attendance at a date.
+var ErrAttendanceNotFound = errors.New("attendance not found")
+
+// ErrQuotaNotFound is returned when an employee has no quota allocation for an attendance type.
+var ErrQuotaNotFound = errors.New("attendance quota not found")
+
 // ErrQuotaExhausted is returned when an employee has no remaining quota for an attendance type.
 var ErrQuotaExhausted = errors.New("attendance quota exhausted")
 
 // ErrAlreadyHasQuota is returned when trying to enable quota on an attendance type that already has quota enabled.
 var ErrAlreadyHasQuota = errors.New("attendance type already has quota enabled")
 
+// ErrAttachmentRequired is returned when an attendance type requires a supporting attachment for the requested day.
+var ErrAttachmentRequired = errors.New("attendance requires a supporting attachment")
+
+// ErrAttachmentNotFound is returned when an attachment does not exist or is already linked to another attendance.
+var ErrAttachmentNotFound = errors.New("attendance attachment not found")
+
+// ErrEmployeeNotInBranch is returned when an attendance is recorded at a branch the employee does not work at.
+var ErrEmployeeNotInBranch = errors.New("employee does not work at the branch")
+
 type Attendance struct {
 	ID int64 `db:"id" json:"id"`
 
 	EmployeeID    int64           `db:"employee_id" json:"employeeID"`
+	BranchID      int64           `db:"branch_id" json:"branchID"`
 	Date          date.Date       `db:"date" json:"date"`
 	Type          Type            `db:"type" json:"type"`
 	OvertimeHours decimal.Decimal `db:"overtime_hours" json:"overtimeHours"`
+	Notes         string          `db:"notes" json:"notes"`
+	Attachments   []Attachment    `db:"-" json:"attachments"`
 
 	CreatedAt time.Time `db:"created_at" json:"createdAt"`
 	UpdatedAt time.Time `db:"updated_at" json:"updatedAt"`
@@ -34,21 +54,57 @@ type Type struct {
 	PayableType PayableType `db:"payable_type" json:"payableType"`
 	HasQuota    bool        `db:"has_quota" json:"hasQuota"`
 
+	// AttachmentRequiredAfterDays is the number of consecutive days of this type allowed
+	// without a supporting attachment. Nil means attachments are never required.
+	AttachmentRequiredAfterDays *int `db:"attachment_required_after_days" json:"attachmentRequiredAfterDays,omitempty"`
+
 	CreatedAt time.Time `db:"created_at" json:"createdAt"`
 	UpdatedAt time.Time `db:"updated_at" json:"updatedAt"`
 }
 
+// Attachment is a supporting file (e.g. surat dokter) for an attendance.
+// AttendanceID is nil until the attachment is linked through an attendance upsert.
+type Attachment struct {
+	ID           int64     `db:"id" json:"id"`
+	AttendanceID *int64    `db:"attendance_id" json:"attendanceID"`
+	StorageKey   string    `db:"storage_key" json:"-"`
+	FileName     string    `db:"file_name" json:"fileName"`
+	ContentType  string    `db:"content_type" json:"contentType"`
+	SizeBytes    int64     `db:"size_bytes" json:"sizeBytes"`
+	CreatedAt    time.Time `db:"created_at" json:"createdAt"`
+}
+
 type UpsertAttendanceRequest struct {
 	EmployeeID    int64           `json:"-" validate:"required,gt=0"`
 	Date          date.Date       `json:"-" validate:"required"`
 	TypeID        int64           `json:"typeID" validate:"required"`
 	OvertimeHours decimal.Decimal `json:"overtimeHours" validate:"dgte=0"`
+	Notes         string          `json:"notes"`
+
+	// AttachmentIDs are previously uploaded attachments to link to the attendance.
+	// Attachments already linked to the attendance stay linked.
+	AttachmentIDs []int64 `json:"attachmentIDs" validate:"dive,gt=0"`
+
+	// BranchID is where the employee worked. If not provided, an existing attendance
+	// keeps its branch and a new one is recorded at the employee's home branch.
+	BranchID *int64 `json:"branchID" validate:"omitnil,gt=0"`
+
+	// IfMatch is the If-Match header: the attendance is only written if it was not changed since it was read.
+	IfMatch etag.Precondition `json:"-"`
 }
 
 type CreateAttendanceTypeRequest struct {
-	Name        string      `json:"name" validate:"required"`
-	PayableType PayableType `json:"payableType" validate:"required"`
-	HasQuota    bool        `json:"hasQuota"`
+	Name                        string      `json:"name" validate:"required"`
+	PayableType                 PayableType `json:"payableType" validate:"required"`
+	HasQuota                    bool        `json:"hasQuota"`
+	AttachmentRequiredAfterDays *int        `json:"attachmentRequiredAfterDays" validate:"omitempty,gte=0"`
+}
+
+type SetAttachmentRequirementRequest struct {
+	TypeID int64 `json:"-" validate:"required,gt=0"`
+
+	// AttachmentRequiredAfterDays set to nil removes the requirement.
+	AttachmentRequiredAfterDays *int `json:"attachmentRequiredAfterDays" validate:"omitempty,gte=0"`
 }
 
 type EmployeeAttendanceQuota struct {
@@ -83,10 +139,26 @@ type QuotaAuditLog struct {
 	CreatedAt      time.Time        `db:"created_at" json:"createdAt"`
 }
 
+// ListQuotaAuditLogsRequest filters and pages the quota audit logs.
+type ListQuotaAuditLogsRequest struct {
+	EmployeeID       *int64
+	AttendanceTypeID *int64
+	Reason           *QuotaAuditReason `validate:"omitnil,oneof=manual_set attendance_deduction attendance_restoration"`
+
+	// From and To limit the logs to the changes made between them.
+	From *time.Time
+	To   *time.Time
+
+	Page pagination.Request
+}
+
 type SetEmployeeAttendanceQuotaRequest struct {
 	EmployeeID       int64 `json:"-" validate:"required,gt=0"`
 	AttendanceTypeID int64 `json:"-" validate:"required,gt=0"`
 	RemainingQuota   int   `json:"remainingQuota" validate:"gte=0"`
+
+	// IfMatch is the If-Match header: the quota is only set if it was not changed since it was read.
+	IfMatch etag.Precondition `json:"-"`
 }
 
 // AttendanceTypeQuotaPage groups employee quotas by their attendance type.
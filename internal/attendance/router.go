@@ -11,10 +11,15 @@ func (h *Handler) registerAttendanceRoutes(r chi.Router) {
 	r.Get("/types", h.GetAttendanceTypes)
 	r.Post("/types", h.CreateAttendanceType)
 	r.Post("/types/{typeID}/enable-quota", h.EnableAttendanceTypeQuota)
+	r.Put("/types/{typeID}/attachment-requirement", h.SetAttachmentRequirement)
+	r.Post("/attachments", h.UploadAttachment)
+	r.Get("/attachments/{attachmentID}", h.DownloadAttachment)
 	r.Get("/quotas", h.GetAllQuotas)
 	r.Get("/quotas/audit-logs", h.GetQuotaAuditLogs)
 	r.Get("/quotas/audit-logs/{employeeID}", h.GetEmployeeQuotaAuditLogs)
 	r.Get("/quotas/{employeeID}", h.GetEmployeeQuotas)
+	r.Get("/quotas/{employeeID}/{typeID}", h.GetEmployeeQuota)
 	r.Put("/quotas/{employeeID}/{typeID}", h.SetEmployeeQuota)
+	r.Get("/{employeeID}/{date}", h.GetEmployeeAttendance)
 	r.Put("/{employeeID}/{date}", h.UpsertAttendance)
 }
@@ -2,25 +2,51 @@ package attendance
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"io"
 	"slices"
 
 	"github.com/jmoiron/sqlx"
 
+	"github.com/turfaa/apotek-hris/pkg/blobstore"
+	"github.com/turfaa/apotek-hris/pkg/pagination"
+	"github.com/turfaa/apotek-hris/pkg/tracing"
 	"github.com/turfaa/apotek-hris/pkg/validatorx"
 	"github.com/turfaa/go-date"
 )
 
+const (
+	attachmentKeyPrefix = "attendance-attachments"
+
+	// attachmentStreakLookbackDays bounds how far before and after the requested day consecutive days
+	// are counted when checking whether an attachment is required.
+	attachmentStreakLookbackDays = 31
+)
+
+const (
+	auditEntityAttendance           = "attendance"
+	auditEntityAttendanceType       = "attendance_type"
+	auditEntityAttendanceQuota      = "employee_attendance_quota"
+	auditEntityAttendanceAttachment = "attendance_attachment"
+)
+
 type Service struct {
-	db *DB
+	db        *DB
+	blobStore blobstore.Store
 }
 
-func NewService(db *sqlx.DB) *Service {
-	return &Service{db: &DB{db: db}}
+func NewService(db *sqlx.DB, blobStore blobstore.Store) *Service {
+	return &Service{db: &DB{db: db}, blobStore: blobStore}
 }
 
-func (s *Service) GetAttendancesBetweenDates(ctx context.Context, from date.Date, to date.Date) ([]Attendance, error) {
-	attendances, err := s.db.GetAttendancesBetweenDates(ctx, from, to)
+// GetAttendancesBetweenDates returns the attendances in the range, limited to the branch if branchID is not nil.
+func (s *Service) GetAttendancesBetweenDates(ctx context.Context, from date.Date, to date.Date, branchID *int64) ([]Attendance, error) {
+	ctx, span := tracing.Start(ctx, "attendance.Service.GetAttendancesBetweenDates")
+	defer span.End()
+
+	attendances, err := s.db.GetAttendancesBetweenDates(ctx, from, to, branchID)
 	if err != nil {
 		return []Attendance{}, fmt.Errorf("get attendances between dates from db: %w", err)
 	}
@@ -29,6 +55,9 @@ func (s *Service) GetAttendancesBetweenDates(ctx context.Context, from date.Date
 }
 
 func (s *Service) GetEmployeeAttendancesBetweenDates(ctx context.Context, employeeID int64, from date.Date, to date.Date) ([]Attendance, error) {
+	ctx, span := tracing.Start(ctx, "attendance.Service.GetEmployeeAttendancesBetweenDates")
+	defer span.End()
+
 	attendances, err := s.db.GetEmployeeAttendancesBetweenDates(ctx, employeeID, from, to)
 	if err != nil {
 		return []Attendance{}, fmt.Errorf("get employee attendances between dates from db: %w", err)
@@ -37,26 +66,156 @@ func (s *Service) GetEmployeeAttendancesBetweenDates(ctx context.Context, employ
 	return attendances, nil
 }
 
+// GetEmployeeAttendance returns the attendance of an employee at a date, with its attachments.
+func (s *Service) GetEmployeeAttendance(ctx context.Context, employeeID int64, dt date.Date) (Attendance, error) {
+	ctx, span := tracing.Start(ctx, "attendance.Service.GetEmployeeAttendance")
+	defer span.End()
+
+	attendance, err := s.db.GetEmployeeAttendance(ctx, employeeID, dt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Attendance{}, ErrAttendanceNotFound
+	}
+	if err != nil {
+		return Attendance{}, fmt.Errorf("get employee attendance from db: %w", err)
+	}
+
+	return attendance, nil
+}
+
 func (s *Service) UpsertAttendance(ctx context.Context, request UpsertAttendanceRequest) (Attendance, error) {
+	ctx, span := tracing.Start(ctx, "attendance.Service.UpsertAttendance")
+	defer span.End()
+
 	if err := validatorx.Validate(request); err != nil {
 		return Attendance{}, fmt.Errorf("invalid request: %w", err)
 	}
 
+	attendanceType, err := s.db.GetAttendanceType(ctx, request.TypeID)
+	if err != nil {
+		return Attendance{}, fmt.Errorf("get attendance type from db: %w", err)
+	}
+
+	if err := s.checkAttachmentRequirement(ctx, request, attendanceType); err != nil {
+		return Attendance{}, fmt.Errorf("check attachment requirement: %w", err)
+	}
+
 	attendance, err := s.db.UpsertAttendance(
 		ctx,
 		request.EmployeeID,
 		request.Date,
 		request.TypeID,
 		request.OvertimeHours,
+		request.Notes,
+		request.AttachmentIDs,
+		request.BranchID,
+		request.IfMatch,
 	)
 	if err != nil {
+		if errors.Is(err, ErrQuotaExhausted) {
+			quotaExhausted.WithLabelValues(attendanceType.Name).Inc()
+		}
 		return Attendance{}, fmt.Errorf("upsert attendance in db: %w", err)
 	}
 
+	attendanceUpserts.WithLabelValues(attendanceType.Name).Inc()
+
 	return attendance, nil
 }
 
+// checkAttachmentRequirement returns ErrAttachmentRequired when the requested attendance extends a run of
+// consecutive days of the same type beyond the type's allowance and no day in that run has an attachment.
+// The run includes the days on both sides of the requested day, so filling a gap between two runs joins them.
+// A single attachment (e.g. one surat dokter) covers the whole run.
+func (s *Service) checkAttachmentRequirement(ctx context.Context, request UpsertAttendanceRequest, attendanceType Type) error {
+	if attendanceType.AttachmentRequiredAfterDays == nil || len(request.AttachmentIDs) > 0 {
+		return nil
+	}
+
+	nearby, err := s.db.GetEmployeeAttendancesBetweenDates(
+		ctx,
+		request.EmployeeID,
+		request.Date.AddDate(0, 0, -attachmentStreakLookbackDays),
+		request.Date.AddDate(0, 0, attachmentStreakLookbackDays),
+	)
+	if err != nil {
+		return fmt.Errorf("get nearby attendances from db: %w", err)
+	}
+
+	byDate := make(map[date.Date]Attendance, len(nearby))
+	for _, a := range nearby {
+		byDate[a.Date] = a
+	}
+
+	// The requested day counts even though it may not be stored yet; its existing attachments stay linked.
+	streak := 1
+	hasAttachment := len(byDate[request.Date].Attachments) > 0
+	for _, step := range []int{-1, 1} {
+		for day := request.Date.AddDate(0, 0, step); ; day = day.AddDate(0, 0, step) {
+			a, ok := byDate[day]
+			if !ok || a.Type.ID != request.TypeID {
+				break
+			}
+
+			streak++
+			hasAttachment = hasAttachment || len(a.Attachments) > 0
+		}
+	}
+
+	if streak > *attendanceType.AttachmentRequiredAfterDays && !hasAttachment {
+		return fmt.Errorf("%w: %s for more than %d consecutive days", ErrAttachmentRequired, attendanceType.Name, *attendanceType.AttachmentRequiredAfterDays)
+	}
+
+	return nil
+}
+
+// UploadAttachment stores an attachment that can later be linked to an attendance through UpsertAttendance.
+func (s *Service) UploadAttachment(ctx context.Context, fileName string, contentType string, sizeBytes int64, r io.Reader) (Attachment, error) {
+	ctx, span := tracing.Start(ctx, "attendance.Service.UploadAttachment")
+	defer span.End()
+
+	key, err := blobstore.NewKey(attachmentKeyPrefix, fileName)
+	if err != nil {
+		return Attachment{}, fmt.Errorf("new blob key: %w", err)
+	}
+
+	if err := s.blobStore.Put(ctx, key, r); err != nil {
+		return Attachment{}, fmt.Errorf("put attachment to blob store: %w", err)
+	}
+
+	attachment, err := s.db.CreateAttachment(ctx, key, fileName, contentType, sizeBytes)
+	if err != nil {
+		_ = s.blobStore.Delete(ctx, key)
+		return Attachment{}, fmt.Errorf("create attachment in db: %w", err)
+	}
+
+	return attachment, nil
+}
+
+// GetAttachment returns the attachment metadata and its content. The caller must close the content.
+func (s *Service) GetAttachment(ctx context.Context, id int64) (Attachment, io.ReadCloser, error) {
+	ctx, span := tracing.Start(ctx, "attendance.Service.GetAttachment")
+	defer span.End()
+
+	attachment, err := s.db.GetAttachment(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Attachment{}, nil, ErrAttachmentNotFound
+	}
+	if err != nil {
+		return Attachment{}, nil, fmt.Errorf("get attachment from db: %w", err)
+	}
+
+	content, err := s.blobStore.Get(ctx, attachment.StorageKey)
+	if err != nil {
+		return Attachment{}, nil, fmt.Errorf("get attachment from blob store: %w", err)
+	}
+
+	return attachment, content, nil
+}
+
 func (s *Service) GetAttendanceTypes(ctx context.Context) ([]Type, error) {
+	ctx, span := tracing.Start(ctx, "attendance.Service.GetAttendanceTypes")
+	defer span.End()
+
 	attendanceTypes, err := s.db.GetAttendanceTypes(ctx)
 	if err != nil {
 		return []Type{}, fmt.Errorf("get attendance types from db: %w", err)
@@ -66,6 +225,9 @@ func (s *Service) GetAttendanceTypes(ctx context.Context) ([]Type, error) {
 }
 
 func (s *Service) CreateAttendanceType(ctx context.Context, request CreateAttendanceTypeRequest) (Type, error) {
+	ctx, span := tracing.Start(ctx, "attendance.Service.CreateAttendanceType")
+	defer span.End()
+
 	if err := validatorx.Validate(request); err != nil {
 		return Type{}, fmt.Errorf("invalid request: %w", err)
 	}
@@ -74,7 +236,7 @@ func (s *Service) CreateAttendanceType(ctx context.Context, request CreateAttend
 		return Type{}, fmt.Errorf("invalid payable type: %s", request.PayableType)
 	}
 
-	attendanceType, err := s.db.CreateAttendanceType(ctx, request.Name, request.PayableType, request.HasQuota)
+	attendanceType, err := s.db.CreateAttendanceType(ctx, request.Name, request.PayableType, request.HasQuota, request.AttachmentRequiredAfterDays)
 	if err != nil {
 		return Type{}, fmt.Errorf("create attendance type in db: %w", err)
 	}
@@ -82,7 +244,26 @@ func (s *Service) CreateAttendanceType(ctx context.Context, request CreateAttend
 	return attendanceType, nil
 }
 
+func (s *Service) SetAttachmentRequirement(ctx context.Context, request SetAttachmentRequirementRequest) (Type, error) {
+	ctx, span := tracing.Start(ctx, "attendance.Service.SetAttachmentRequirement")
+	defer span.End()
+
+	if err := validatorx.Validate(request); err != nil {
+		return Type{}, fmt.Errorf("invalid request: %w", err)
+	}
+
+	t, err := s.db.SetAttachmentRequirement(ctx, request.TypeID, request.AttachmentRequiredAfterDays)
+	if err != nil {
+		return Type{}, fmt.Errorf("set attachment requirement in db: %w", err)
+	}
+
+	return t, nil
+}
+
 func (s *Service) GetQuotaEnabledAttendanceTypes(ctx context.Context) ([]Type, error) {
+	ctx, span := tracing.Start(ctx, "attendance.Service.GetQuotaEnabledAttendanceTypes")
+	defer span.End()
+
 	types, err := s.db.GetQuotaEnabledAttendanceTypes(ctx)
 	if err != nil {
 		return []Type{}, fmt.Errorf("get quota-enabled attendance types from db: %w", err)
@@ -92,6 +273,9 @@ func (s *Service) GetQuotaEnabledAttendanceTypes(ctx context.Context) ([]Type, e
 }
 
 func (s *Service) GetAllQuotas(ctx context.Context) ([]EmployeeAttendanceQuota, error) {
+	ctx, span := tracing.Start(ctx, "attendance.Service.GetAllQuotas")
+	defer span.End()
+
 	quotas, err := s.db.GetAllQuotas(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("get all quotas from db: %w", err)
@@ -101,6 +285,9 @@ func (s *Service) GetAllQuotas(ctx context.Context) ([]EmployeeAttendanceQuota,
 }
 
 func (s *Service) GetEmployeeQuotas(ctx context.Context, employeeID int64) ([]EmployeeAttendanceQuota, error) {
+	ctx, span := tracing.Start(ctx, "attendance.Service.GetEmployeeQuotas")
+	defer span.End()
+
 	quotas, err := s.db.GetEmployeeQuotas(ctx, employeeID)
 	if err != nil {
 		return nil, fmt.Errorf("get employee quotas from db: %w", err)
@@ -109,7 +296,26 @@ func (s *Service) GetEmployeeQuotas(ctx context.Context, employeeID int64) ([]Em
 	return quotas, nil
 }
 
+// GetEmployeeQuota returns the quota allocation of an employee for an attendance type.
+func (s *Service) GetEmployeeQuota(ctx context.Context, employeeID int64, typeID int64) (EmployeeAttendanceQuota, error) {
+	ctx, span := tracing.Start(ctx, "attendance.Service.GetEmployeeQuota")
+	defer span.End()
+
+	quota, err := s.db.GetEmployeeQuota(ctx, employeeID, typeID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return EmployeeAttendanceQuota{}, ErrQuotaNotFound
+	}
+	if err != nil {
+		return EmployeeAttendanceQuota{}, fmt.Errorf("get employee quota from db: %w", err)
+	}
+
+	return quota, nil
+}
+
 func (s *Service) EnableAttendanceTypeQuota(ctx context.Context, typeID int64) (Type, error) {
+	ctx, span := tracing.Start(ctx, "attendance.Service.EnableAttendanceTypeQuota")
+	defer span.End()
+
 	t, err := s.db.EnableAttendanceTypeQuota(ctx, typeID)
 	if err != nil {
 		return Type{}, fmt.Errorf("enable attendance type quota in db: %w", err)
@@ -119,11 +325,14 @@ func (s *Service) EnableAttendanceTypeQuota(ctx context.Context, typeID int64) (
 }
 
 func (s *Service) SetEmployeeQuota(ctx context.Context, request SetEmployeeAttendanceQuotaRequest) (EmployeeAttendanceQuota, error) {
+	ctx, span := tracing.Start(ctx, "attendance.Service.SetEmployeeQuota")
+	defer span.End()
+
 	if err := validatorx.Validate(request); err != nil {
 		return EmployeeAttendanceQuota{}, fmt.Errorf("invalid request: %w", err)
 	}
 
-	quota, err := s.db.UpsertEmployeeQuota(ctx, request.EmployeeID, request.AttendanceTypeID, request.RemainingQuota)
+	quota, err := s.db.UpsertEmployeeQuota(ctx, request.EmployeeID, request.AttendanceTypeID, request.RemainingQuota, request.IfMatch)
 	if err != nil {
 		return EmployeeAttendanceQuota{}, fmt.Errorf("upsert employee quota in db: %w", err)
 	}
@@ -132,6 +341,9 @@ func (s *Service) SetEmployeeQuota(ctx context.Context, request SetEmployeeAtten
 }
 
 func (s *Service) IncrementQuotaForEmployees(ctx context.Context, employeeIDs []int64, typeID int64, increment int) (int64, error) {
+	ctx, span := tracing.Start(ctx, "attendance.Service.IncrementQuotaForEmployees")
+	defer span.End()
+
 	affected, err := s.db.IncrementQuotaForEmployees(ctx, employeeIDs, typeID, increment)
 	if err != nil {
 		return 0, fmt.Errorf("increment quota for employees in db: %w", err)
@@ -140,16 +352,27 @@ func (s *Service) IncrementQuotaForEmployees(ctx context.Context, employeeIDs []
 	return affected, nil
 }
 
-func (s *Service) GetQuotaAuditLogs(ctx context.Context) ([]QuotaAuditLog, error) {
-	logs, err := s.db.GetQuotaAuditLogs(ctx)
+// ListQuotaAuditLogs returns a page of the audit logs for quota changes matching the filters of the request.
+func (s *Service) ListQuotaAuditLogs(ctx context.Context, request ListQuotaAuditLogsRequest) (pagination.Page[QuotaAuditLog], error) {
+	ctx, span := tracing.Start(ctx, "attendance.Service.ListQuotaAuditLogs")
+	defer span.End()
+
+	if err := validatorx.Validate(request); err != nil {
+		return pagination.Page[QuotaAuditLog]{}, fmt.Errorf("invalid request: %w", err)
+	}
+
+	logs, err := s.db.ListQuotaAuditLogs(ctx, request)
 	if err != nil {
-		return nil, fmt.Errorf("get quota audit logs from db: %w", err)
+		return pagination.Page[QuotaAuditLog]{}, fmt.Errorf("list quota audit logs from db: %w", err)
 	}
 
 	return logs, nil
 }
 
 func (s *Service) GetEmployeeQuotaAuditLogs(ctx context.Context, employeeID int64) ([]QuotaAuditLog, error) {
+	ctx, span := tracing.Start(ctx, "attendance.Service.GetEmployeeQuotaAuditLogs")
+	defer span.End()
+
 	logs, err := s.db.GetEmployeeQuotaAuditLogs(ctx, employeeID)
 	if err != nil {
 		return nil, fmt.Errorf("get employee quota audit logs from db: %w", err)
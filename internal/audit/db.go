@@ -0,0 +1,183 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/turfaa/apotek-hris/pkg/database"
+	"github.com/turfaa/apotek-hris/pkg/pagination"
+)
+
+// chainLockKey is the transaction-scoped advisory lock key serializing appends to the hash chain.
+const chainLockKey = 7_270_001
+
+// genesisHash is the previous hash of the first log in the chain.
+var genesisHash = strings.Repeat("0", 64)
+
+type DB struct {
+	db *sqlx.DB
+}
+
+func NewDB(db *sqlx.DB) *DB {
+	return &DB{db: db}
+}
+
+// appendLog appends a log to the hash chain in the transaction of the change it records.
+// PrevHash, Hash and ID of the given log are ignored.
+func appendLog(ctx context.Context, tx Tx, log Log) error {
+	// The lock is held until the transaction ends, so the chain grows in commit order.
+	if _, err := tx.ExecContext(ctx, tx.Rebind(`SELECT pg_advisory_xact_lock(?)`), chainLockKey); err != nil {
+		return fmt.Errorf("acquire chain lock: %w", err)
+	}
+
+	prevHash := genesisHash
+	err := tx.GetContext(ctx, &prevHash, `SELECT hash FROM audit_logs ORDER BY id DESC LIMIT 1`)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("get last hash: %w", err)
+	}
+
+	log.PrevHash = prevHash
+	log.Hash, err = log.ComputeHash()
+	if err != nil {
+		return fmt.Errorf("compute hash: %w", err)
+	}
+
+	query := tx.Rebind(`
+		INSERT INTO audit_logs (actor_employee_id, entity_type, entity_id, action, before_data, after_data, request_id, prev_hash, hash, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	args := []any{
+		log.ActorEmployeeID,
+		log.EntityType,
+		log.EntityID,
+		log.Action,
+		nullableJSON(log.Before),
+		nullableJSON(log.After),
+		log.RequestID,
+		log.PrevHash,
+		log.Hash,
+		log.CreatedAt,
+	}
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("tx.ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+var logSorting = pagination.Sorting[Log]{
+	Fields: map[string]pagination.Field[Log]{
+		"id":        {Column: "id", Type: "bigint", Value: func(l Log) string { return pagination.FormatInt(l.ID) }},
+		"createdAt": {Column: "created_at", Type: "timestamptz", Value: func(l Log) string { return l.CreatedAt.Format(time.RFC3339Nano) }},
+	},
+	Default:  "-id",
+	IDColumn: "id",
+	ID:       func(l Log) int64 { return l.ID },
+}
+
+// ListLogs returns a page of the logs matching the filters of the request.
+func (d *DB) ListLogs(ctx context.Context, request ListLogsRequest) (pagination.Page[Log], error) {
+	page, err := logSorting.Query(request.Page)
+	if err != nil {
+		return pagination.Page[Log]{}, err
+	}
+
+	var filters []string
+	var args []any
+
+	if request.EntityType != "" {
+		filters = append(filters, "entity_type = ?")
+		args = append(args, request.EntityType)
+	}
+
+	if request.EntityID != nil {
+		filters = append(filters, "entity_id = ?")
+		args = append(args, *request.EntityID)
+	}
+
+	if request.ActorEmployeeID != nil {
+		filters = append(filters, "actor_employee_id = ?")
+		args = append(args, *request.ActorEmployeeID)
+	}
+
+	if request.From != nil {
+		filters = append(filters, "created_at >= ?")
+		args = append(args, *request.From)
+	}
+
+	if request.To != nil {
+		filters = append(filters, "created_at <= ?")
+		args = append(args, *request.To)
+	}
+
+	var total int
+	if err := d.db.GetContext(ctx, &total, d.db.Rebind(`SELECT COUNT(*) FROM audit_logs `+database.Where(filters)), args...); err != nil {
+		return pagination.Page[Log]{}, fmt.Errorf("count logs in db: %w", err)
+	}
+
+	if condition, cursorArgs := page.Where(); condition != "" {
+		filters = append(filters, condition)
+		args = append(args, cursorArgs...)
+	}
+
+	query := `
+		SELECT id, actor_employee_id, entity_type, entity_id, action, before_data, after_data, request_id, prev_hash, hash, created_at
+		FROM audit_logs
+		` + database.Where(filters) + `
+		` + page.OrderByLimit()
+
+	query = d.db.Rebind(query)
+
+	var logDBs []LogDB
+	if err := d.db.SelectContext(ctx, &logDBs, query, args...); err != nil {
+		return pagination.Page[Log]{}, fmt.Errorf("d.db.SelectContext: %w", err)
+	}
+
+	logs := make([]Log, len(logDBs))
+	for i, logDB := range logDBs {
+		logs[i] = logDB.ToLog()
+	}
+
+	return page.Page(logs, total), nil
+}
+
+// GetLogsAfter returns up to limit logs with ID greater than afterID, in chain order.
+func (d *DB) GetLogsAfter(ctx context.Context, afterID int64, limit int) ([]Log, error) {
+	query := d.db.Rebind(`
+		SELECT id, actor_employee_id, entity_type, entity_id, action, before_data, after_data, request_id, prev_hash, hash, created_at
+		FROM audit_logs
+		WHERE id > ?
+		ORDER BY id ASC
+		LIMIT ?
+	`)
+
+	var logDBs []LogDB
+	if err := d.db.SelectContext(ctx, &logDBs, query, afterID, limit); err != nil {
+		return nil, fmt.Errorf("d.db.SelectContext: %w", err)
+	}
+
+	logs := make([]Log, len(logDBs))
+	for i, logDB := range logDBs {
+		logs[i] = logDB.ToLog()
+	}
+
+	return logs, nil
+}
+
+// nullableJSON stores empty values as NULL and keeps the exact bytes otherwise,
+// so the stored value hashes the same as when it was appended.
+func nullableJSON(v []byte) *string {
+	if len(v) == 0 {
+		return nil
+	}
+
+	s := string(v)
+	return &s
+}
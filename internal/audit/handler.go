@@ -0,0 +1,77 @@
+package audit
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/turfaa/apotek-hris/pkg/httpx"
+	"github.com/turfaa/apotek-hris/pkg/timex"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) GetLogs(w http.ResponseWriter, r *http.Request) {
+	queries := r.URL.Query()
+
+	req := ListLogsRequest{
+		EntityType: queries.Get("entityType"),
+	}
+
+	if entityIDStr := queries.Get("entityID"); entityIDStr != "" {
+		entityID, err := strconv.ParseInt(entityIDStr, 10, 64)
+		if err != nil {
+			httpx.Error(w, r, fmt.Errorf("parse entityID: %w", err), http.StatusBadRequest)
+			return
+		}
+
+		req.EntityID = &entityID
+	}
+
+	if actorIDStr := queries.Get("actorID"); actorIDStr != "" {
+		actorID, err := strconv.ParseInt(actorIDStr, 10, 64)
+		if err != nil {
+			httpx.Error(w, r, fmt.Errorf("parse actorID: %w", err), http.StatusBadRequest)
+			return
+		}
+
+		req.ActorEmployeeID = &actorID
+	}
+
+	var err error
+	req.From, req.To, err = timex.GetOptionalTimeRangeFromQuery(r)
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	req.Page, err = httpx.GetPageFromQuery(r)
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	logs, err := h.service.ListLogs(r.Context(), req)
+	if err != nil {
+		httpx.ServiceError(w, r, err, nil)
+		return
+	}
+
+	httpx.Ok(w, logs)
+}
+
+func (h *Handler) Verify(w http.ResponseWriter, r *http.Request) {
+	result, err := h.service.Verify(r.Context())
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	httpx.Ok(w, result)
+}
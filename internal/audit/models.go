@@ -0,0 +1,118 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/go-json-experiment/json"
+	"github.com/go-json-experiment/json/jsontext"
+
+	"github.com/turfaa/apotek-hris/pkg/pagination"
+)
+
+type Action string
+
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+)
+
+// Log is one entry of the audit trail. Each entry's Hash covers its content and the
+// previous entry's hash, so modifying or removing an entry breaks the chain after it.
+type Log struct {
+	ID              int64          `json:"id"`
+	ActorEmployeeID *int64         `json:"actorEmployeeID"`
+	EntityType      string         `json:"entityType"`
+	EntityID        int64          `json:"entityID"`
+	Action          Action         `json:"action"`
+	Before          jsontext.Value `json:"before"`
+	After           jsontext.Value `json:"after"`
+	RequestID       string         `json:"requestID"`
+	PrevHash        string         `json:"prevHash"`
+	Hash            string         `json:"hash"`
+	CreatedAt       time.Time      `json:"createdAt"`
+}
+
+type LogDB struct {
+	ID              int64     `db:"id"`
+	ActorEmployeeID *int64    `db:"actor_employee_id"`
+	EntityType      string    `db:"entity_type"`
+	EntityID        int64     `db:"entity_id"`
+	Action          Action    `db:"action"`
+	Before          []byte    `db:"before_data"`
+	After           []byte    `db:"after_data"`
+	RequestID       string    `db:"request_id"`
+	PrevHash        string    `db:"prev_hash"`
+	Hash            string    `db:"hash"`
+	CreatedAt       time.Time `db:"created_at"`
+}
+
+func (l LogDB) ToLog() Log {
+	return Log{
+		ID:              l.ID,
+		ActorEmployeeID: l.ActorEmployeeID,
+		EntityType:      l.EntityType,
+		EntityID:        l.EntityID,
+		Action:          l.Action,
+		Before:          l.Before,
+		After:           l.After,
+		RequestID:       l.RequestID,
+		PrevHash:        l.PrevHash,
+		Hash:            l.Hash,
+		CreatedAt:       l.CreatedAt,
+	}
+}
+
+// ComputeHash returns the chain hash of the log content and its PrevHash.
+func (l Log) ComputeHash() (string, error) {
+	b, err := json.Marshal(struct {
+		PrevHash        string `json:"prevHash"`
+		ActorEmployeeID *int64 `json:"actorEmployeeID"`
+		EntityType      string `json:"entityType"`
+		EntityID        int64  `json:"entityID"`
+		Action          Action `json:"action"`
+		Before          string `json:"before"`
+		After           string `json:"after"`
+		RequestID       string `json:"requestID"`
+		CreatedAt       string `json:"createdAt"`
+	}{
+		PrevHash:        l.PrevHash,
+		ActorEmployeeID: l.ActorEmployeeID,
+		EntityType:      l.EntityType,
+		EntityID:        l.EntityID,
+		Action:          l.Action,
+		Before:          string(l.Before),
+		After:           string(l.After),
+		RequestID:       l.RequestID,
+		CreatedAt:       l.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return "", fmt.Errorf("marshal hash input: %w", err)
+	}
+
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// ListLogsRequest filters and pages the logs.
+type ListLogsRequest struct {
+	EntityType      string
+	EntityID        *int64
+	ActorEmployeeID *int64
+	From            *time.Time
+	To              *time.Time
+
+	Page pagination.Request
+}
+
+// VerifyResult reports whether the hash chain is intact.
+// When it is not, FirstInvalidLogID points to the first entry whose hash does not match.
+type VerifyResult struct {
+	Valid             bool   `json:"valid"`
+	CheckedLogs       int    `json:"checkedLogs"`
+	FirstInvalidLogID *int64 `json:"firstInvalidLogID,omitempty"`
+	Reason            string `json:"reason,omitempty"`
+}
@@ -0,0 +1,60 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-json-experiment/json"
+
+	"github.com/turfaa/apotek-hris/pkg/actor"
+)
+
+// Tx is satisfied by *sqlx.Tx.
+type Tx interface {
+	GetContext(ctx context.Context, dest any, query string, args ...any) error
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	Rebind(query string) string
+}
+
+// Record appends a change to the audit trail. Pass the transaction of the change, so the change is committed
+// if and only if its log is; before should be read in the same transaction.
+//
+// Appending holds the lock of the hash chain until the transaction ends, so record after the other writes of
+// the transaction, right before committing it. The actor and request ID are taken from ctx. before and after
+// are marshaled to JSON; pass nil for a missing side (e.g. before of a create).
+func Record(ctx context.Context, tx Tx, action Action, entityType string, entityID int64, before any, after any) error {
+	log := Log{
+		EntityType: entityType,
+		EntityID:   entityID,
+		Action:     action,
+		RequestID:  middleware.GetReqID(ctx),
+		// Postgres stores microseconds; truncate so the stored time hashes the same.
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+
+	if employeeID, ok := actor.EmployeeIDFromContext(ctx); ok {
+		log.ActorEmployeeID = &employeeID
+	}
+
+	var err error
+	if before != nil {
+		if log.Before, err = json.Marshal(before); err != nil {
+			return fmt.Errorf("marshal %s %d before: %w", entityType, entityID, err)
+		}
+	}
+
+	if after != nil {
+		if log.After, err = json.Marshal(after); err != nil {
+			return fmt.Errorf("marshal %s %d after: %w", entityType, entityID, err)
+		}
+	}
+
+	if err := appendLog(ctx, tx, log); err != nil {
+		return fmt.Errorf("append %s %d log: %w", entityType, entityID, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,12 @@
+package audit
+
+import "github.com/go-chi/chi/v5"
+
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Route("/audit-logs", h.registerAuditLogRoutes)
+}
+
+func (h *Handler) registerAuditLogRoutes(r chi.Router) {
+	r.Get("/", h.GetLogs)
+	r.Get("/verify", h.Verify)
+}
@@ -0,0 +1,83 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/turfaa/apotek-hris/pkg/pagination"
+	"github.com/turfaa/apotek-hris/pkg/tracing"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// verifyBatchSize is the number of logs loaded at a time while verifying the chain.
+const verifyBatchSize = 1000
+
+type Service struct {
+	db *DB
+}
+
+func NewService(db *sqlx.DB) *Service {
+	return &Service{db: NewDB(db)}
+}
+
+// ListLogs returns a page of the logs matching the filters of the request.
+func (s *Service) ListLogs(ctx context.Context, request ListLogsRequest) (pagination.Page[Log], error) {
+	ctx, span := tracing.Start(ctx, "audit.Service.ListLogs")
+	defer span.End()
+
+	logs, err := s.db.ListLogs(ctx, request)
+	if err != nil {
+		return pagination.Page[Log]{}, fmt.Errorf("list logs from db: %w", err)
+	}
+
+	return logs, nil
+}
+
+// Verify walks the whole chain and checks every link and hash.
+func (s *Service) Verify(ctx context.Context) (VerifyResult, error) {
+	ctx, span := tracing.Start(ctx, "audit.Service.Verify")
+	defer span.End()
+
+	result := VerifyResult{Valid: true}
+	prevHash := genesisHash
+	afterID := int64(0)
+
+	for {
+		logs, err := s.db.GetLogsAfter(ctx, afterID, verifyBatchSize)
+		if err != nil {
+			return VerifyResult{}, fmt.Errorf("get logs after %d from db: %w", afterID, err)
+		}
+
+		for _, log := range logs {
+			result.CheckedLogs++
+
+			if log.PrevHash != prevHash {
+				return invalidResult(result, log.ID, "previous hash does not match the preceding log"), nil
+			}
+
+			hash, err := log.ComputeHash()
+			if err != nil {
+				return VerifyResult{}, fmt.Errorf("compute hash of log %d: %w", log.ID, err)
+			}
+
+			if hash != log.Hash {
+				return invalidResult(result, log.ID, "hash does not match the log content"), nil
+			}
+
+			prevHash = log.Hash
+			afterID = log.ID
+		}
+
+		if len(logs) < verifyBatchSize {
+			return result, nil
+		}
+	}
+}
+
+func invalidResult(result VerifyResult, logID int64, reason string) VerifyResult {
+	result.Valid = false
+	result.FirstInvalidLogID = &logID
+	result.Reason = reason
+	return result
+}
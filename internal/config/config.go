@@ -2,19 +2,46 @@ package config
 
 import (
 	"fmt"
+	"time"
 
+	"github.com/turfaa/apotek-hris/internal/attendance"
+	"github.com/turfaa/apotek-hris/internal/hris"
+	"github.com/turfaa/apotek-hris/internal/idempotency"
+	"github.com/turfaa/apotek-hris/internal/notification"
+	"github.com/turfaa/apotek-hris/internal/queue"
+	"github.com/turfaa/apotek-hris/internal/scheduler"
+	"github.com/turfaa/apotek-hris/internal/webhook"
+	"github.com/turfaa/apotek-hris/pkg/blobstore"
 	"github.com/turfaa/apotek-hris/pkg/database"
+	"github.com/turfaa/apotek-hris/pkg/logging"
 	"github.com/turfaa/apotek-hris/pkg/server"
+	"github.com/turfaa/apotek-hris/pkg/tracing"
 	"github.com/turfaa/apotek-hris/pkg/validatorx"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Database database.Config `mapstructure:"database" validate:"required"`
-	Server   server.Config   `mapstructure:"server" validate:"required"`
+	// Timezone is the deployment's IANA time zone name. It defaults to Asia/Jakarta.
+	// Branches without a time zone of their own use it.
+	Timezone string `mapstructure:"timezone" validate:"omitempty,timezone"`
+
+	Logging      logging.Config      `mapstructure:"logging"`
+	Database     database.Config     `mapstructure:"database" validate:"required"`
+	Server       server.Config       `mapstructure:"server" validate:"required"`
+	Storage      blobstore.Config    `mapstructure:"storage" validate:"required"`
+	HRIS         hris.Config         `mapstructure:"hris"`
+	Webhooks     webhook.Config      `mapstructure:"webhooks"`
+	Notification notification.Config `mapstructure:"notification"`
+	Attendance   attendance.Config   `mapstructure:"attendance"`
+	Scheduler    scheduler.Config    `mapstructure:"scheduler"`
+	Queue        queue.Config        `mapstructure:"queue"`
+	Idempotency  idempotency.Config  `mapstructure:"idempotency"`
+	Tracing      tracing.Config      `mapstructure:"tracing"`
 }
 
+// Load reads the config files, sets time.Local to the configured time zone
+// and makes the configured logger the default one.
 func Load(configPaths ...string) (Config, error) {
 	v := viper.New()
 	v.AutomaticEnv()
@@ -35,5 +62,16 @@ func Load(configPaths ...string) (Config, error) {
 		return Config{}, fmt.Errorf("error validating config: %w", err)
 	}
 
+	if cfg.Timezone != "" {
+		loc, err := time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			return Config{}, fmt.Errorf("error loading timezone %s: %w", cfg.Timezone, err)
+		}
+
+		time.Local = loc
+	}
+
+	logging.Setup(cfg.Logging)
+
 	return cfg, nil
 }
@@ -0,0 +1,260 @@
+package document
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/turfaa/go-date"
+
+	"github.com/turfaa/apotek-hris/internal/audit"
+)
+
+type DB struct {
+	db *sqlx.DB
+}
+
+const documentColumns = `
+	d.id, d.employee_id, e.name AS "employee_name", d.document_type, d.number, d.issued_at, d.expires_at,
+	d.storage_key, d.file_name, d.content_type, d.size_bytes, d.created_at, d.deleted_at`
+
+func (d *DB) GetDocuments(ctx context.Context, request GetDocumentsRequest) ([]Document, error) {
+	query := `
+	SELECT` + documentColumns + `
+	FROM employee_documents d
+	JOIN employees e ON d.employee_id = e.id
+	WHERE d.deleted_at IS NULL`
+	args := []any{}
+
+	if request.EmployeeID != nil {
+		query += " AND d.employee_id = ?"
+		args = append(args, *request.EmployeeID)
+	}
+
+	if request.BranchID != nil {
+		query += " AND (e.home_branch_id = ? OR d.employee_id IN (SELECT employee_id FROM employee_branches WHERE branch_id = ?))"
+		args = append(args, *request.BranchID, *request.BranchID)
+	}
+
+	query += " ORDER BY d.employee_id ASC, d.document_type ASC, d.expires_at DESC NULLS FIRST"
+	query = d.db.Rebind(query)
+
+	var documents []Document
+	if err := d.db.SelectContext(ctx, &documents, query, args...); err != nil {
+		return []Document{}, fmt.Errorf("select context from db: %w", err)
+	}
+
+	return documents, nil
+}
+
+func (d *DB) GetDocument(ctx context.Context, id int64) (Document, error) {
+	query := `
+	SELECT` + documentColumns + `
+	FROM employee_documents d
+	JOIN employees e ON d.employee_id = e.id
+	WHERE d.id = ? AND d.deleted_at IS NULL`
+	query = d.db.Rebind(query)
+	args := []any{id}
+
+	var document Document
+	if err := d.db.GetContext(ctx, &document, query, args...); err != nil {
+		return Document{}, fmt.Errorf("get context from db: %w", err)
+	}
+
+	return document, nil
+}
+
+func (d *DB) CreateDocument(ctx context.Context, request CreateDocumentRequest, storageKey string) (Document, error) {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return Document{}, fmt.Errorf("begin tx: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	query := `
+	WITH inserted_document AS (
+		INSERT INTO employee_documents (employee_id, document_type, number, issued_at, expires_at, storage_key, file_name, content_type, size_bytes)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING *
+	)
+	SELECT` + documentColumns + `
+	FROM inserted_document d
+	JOIN employees e ON d.employee_id = e.id`
+	query = tx.Rebind(query)
+	args := []any{
+		request.EmployeeID, request.Type, request.Number, request.IssuedAt, request.ExpiresAt,
+		storageKey, request.FileName, request.ContentType, request.SizeBytes,
+	}
+
+	var document Document
+	if err := tx.GetContext(ctx, &document, query, args...); err != nil {
+		return Document{}, fmt.Errorf("get context from db: %w", err)
+	}
+
+	if err := audit.Record(ctx, tx, audit.ActionCreate, auditEntityDocument, document.ID, nil, document); err != nil {
+		return Document{}, fmt.Errorf("record audit log: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Document{}, fmt.Errorf("commit tx: %w", err)
+	}
+
+	return document, nil
+}
+
+// DeleteDocument soft deletes a document. The file is kept for the audit trail.
+// Returns sql.ErrNoRows if the document does not exist.
+func (d *DB) DeleteDocument(ctx context.Context, id int64) error {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	query := `
+	SELECT` + documentColumns + `
+	FROM employee_documents d
+	JOIN employees e ON d.employee_id = e.id
+	WHERE d.id = ? AND d.deleted_at IS NULL
+	FOR UPDATE OF d`
+	query = tx.Rebind(query)
+
+	var before Document
+	if err := tx.GetContext(ctx, &before, query, id); err != nil {
+		return fmt.Errorf("lock document: %w", err)
+	}
+
+	query = `
+	UPDATE employee_documents
+	SET deleted_at = CURRENT_TIMESTAMP
+	WHERE id = ?`
+	query = tx.Rebind(query)
+
+	if _, err := tx.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("exec context to db: %w", err)
+	}
+
+	if err := audit.Record(ctx, tx, audit.ActionDelete, auditEntityDocument, id, before, nil); err != nil {
+		return fmt.Errorf("record audit log: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+
+	return nil
+}
+
+// GetDocumentsExpiringBefore returns documents expiring on or before the date, including expired ones.
+// Documents renewed by one of the same type expiring later, or never, are left out.
+func (d *DB) GetDocumentsExpiringBefore(ctx context.Context, before date.Date) ([]Document, error) {
+	query := `
+	SELECT` + documentColumns + `
+	FROM employee_documents d
+	JOIN employees e ON d.employee_id = e.id
+	WHERE d.deleted_at IS NULL
+	AND d.expires_at <= ?
+	AND NOT EXISTS (
+		SELECT 1
+		FROM employee_documents renewal
+		WHERE renewal.employee_id = d.employee_id
+		AND renewal.document_type = d.document_type
+		AND renewal.deleted_at IS NULL
+		AND (renewal.expires_at IS NULL OR renewal.expires_at > d.expires_at)
+	)
+	ORDER BY d.expires_at ASC, e.name ASC`
+	query = d.db.Rebind(query)
+	args := []any{before}
+
+	var documents []Document
+	if err := d.db.SelectContext(ctx, &documents, query, args...); err != nil {
+		return []Document{}, fmt.Errorf("select context from db: %w", err)
+	}
+
+	return documents, nil
+}
+
+// GetExpiredLicenseTypes returns the license types the employee has on file
+// without any document of that type valid on the date.
+func (d *DB) GetExpiredLicenseTypes(ctx context.Context, employeeID int64, on date.Date) ([]Type, error) {
+	query := `
+	SELECT document_type
+	FROM employee_documents
+	WHERE employee_id = ?
+	AND deleted_at IS NULL
+	AND document_type = ANY(?)
+	GROUP BY document_type
+	HAVING NOT BOOL_OR(expires_at IS NULL OR expires_at >= ?)
+	ORDER BY document_type ASC`
+	query = d.db.Rebind(query)
+
+	licenseTypes := make([]string, len(LicenseTypes))
+	for i, t := range LicenseTypes {
+		licenseTypes[i] = string(t)
+	}
+
+	args := []any{employeeID, licenseTypes, on}
+
+	var types []Type
+	if err := d.db.SelectContext(ctx, &types, query, args...); err != nil {
+		return nil, fmt.Errorf("select context from db: %w", err)
+	}
+
+	return types, nil
+}
+
+// CreateAlert records an alert unless the same one was already raised.
+// Returns false if the alert already existed.
+func (d *DB) CreateAlert(ctx context.Context, documentID int64, daysBefore int, expiresAt date.Date) (bool, error) {
+	query := `
+	INSERT INTO employee_document_alerts (document_id, days_before, expires_at)
+	VALUES (?, ?, ?)
+	ON CONFLICT (document_id, days_before, expires_at) DO NOTHING`
+	query = d.db.Rebind(query)
+	args := []any{documentID, daysBefore, expiresAt}
+
+	result, err := d.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, fmt.Errorf("exec context to db: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// GetAlerts returns the alerts of documents that are still on file, newest first.
+func (d *DB) GetAlerts(ctx context.Context) ([]Alert, error) {
+	query := `
+	SELECT 
+		a.id, a.days_before, a.expires_at, a.created_at,
+		d.id AS "document.id",
+		d.employee_id AS "document.employee_id",
+		e.name AS "document.employee_name",
+		d.document_type AS "document.document_type",
+		d.number AS "document.number",
+		d.issued_at AS "document.issued_at",
+		d.expires_at AS "document.expires_at",
+		d.file_name AS "document.file_name",
+		d.content_type AS "document.content_type",
+		d.size_bytes AS "document.size_bytes",
+		d.created_at AS "document.created_at"
+	FROM employee_document_alerts a
+	JOIN employee_documents d ON a.document_id = d.id
+	JOIN employees e ON d.employee_id = e.id
+	WHERE d.deleted_at IS NULL
+	ORDER BY a.created_at DESC, a.id DESC`
+	query = d.db.Rebind(query)
+
+	var alerts []Alert
+	if err := d.db.SelectContext(ctx, &alerts, query); err != nil {
+		return []Alert{}, fmt.Errorf("select context from db: %w", err)
+	}
+
+	return alerts, nil
+}
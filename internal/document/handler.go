@@ -0,0 +1,210 @@
+package document
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/turfaa/apotek-hris/pkg/httpx"
+	"github.com/turfaa/go-date"
+)
+
+// maxDocumentSize is the largest accepted document upload, in bytes.
+const maxDocumentSize = 10 << 20
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) GetDocuments(w http.ResponseWriter, r *http.Request) {
+	var req GetDocumentsRequest
+	if employeeIDStr := r.URL.Query().Get("employeeID"); employeeIDStr != "" {
+		employeeID, err := strconv.ParseInt(employeeIDStr, 10, 64)
+		if err != nil {
+			httpx.Error(w, r, err, http.StatusBadRequest)
+			return
+		}
+
+		req.EmployeeID = &employeeID
+	}
+
+	branchID, err := httpx.GetOptionalInt64FromQuery(r, "branchID")
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	req.BranchID = branchID
+
+	documents, err := h.service.GetDocuments(r.Context(), req)
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Ok(w, documents)
+}
+
+func (h *Handler) UploadDocument(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxDocumentSize)
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		httpx.Error(w, r, fmt.Errorf("read file from form: %w", err), http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	employeeID, err := strconv.ParseInt(r.FormValue("employeeID"), 10, 64)
+	if err != nil {
+		httpx.Error(w, r, fmt.Errorf("invalid employeeID: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	issuedAt, err := optionalDateFormValue(r, "issuedAt")
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	expiresAt, err := optionalDateFormValue(r, "expiresAt")
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	document, err := h.service.UploadDocument(r.Context(), CreateDocumentRequest{
+		EmployeeID:  employeeID,
+		Type:        Type(r.FormValue("type")),
+		Number:      r.FormValue("number"),
+		IssuedAt:    issuedAt,
+		ExpiresAt:   expiresAt,
+		FileName:    header.Filename,
+		ContentType: header.Header.Get("Content-Type"),
+		SizeBytes:   header.Size,
+	}, file)
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Ok(w, document)
+}
+
+func (h *Handler) GetDocument(w http.ResponseWriter, r *http.Request) {
+	documentID, err := documentIDFromURL(r)
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	document, err := h.service.GetDocument(r.Context(), documentID)
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Ok(w, document)
+}
+
+func (h *Handler) DownloadDocument(w http.ResponseWriter, r *http.Request) {
+	documentID, err := documentIDFromURL(r)
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	document, content, err := h.service.GetDocumentFile(r.Context(), documentID)
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+	defer content.Close()
+
+	httpx.File(w, content, document.FileName, document.ContentType)
+}
+
+func (h *Handler) DeleteDocument(w http.ResponseWriter, r *http.Request) {
+	documentID, err := documentIDFromURL(r)
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeleteDocument(r.Context(), documentID); err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Ok(w, map[string]string{"message": "successfully deleted the document"})
+}
+
+func (h *Handler) GetExpiringDocuments(w http.ResponseWriter, r *http.Request) {
+	withinDays := defaultExpiryWindowDays
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		days, err := strconv.Atoi(daysStr)
+		if err != nil || days < 0 {
+			httpx.Error(w, r, fmt.Errorf("invalid days: %s", daysStr), http.StatusBadRequest)
+			return
+		}
+
+		withinDays = days
+	}
+
+	documents, err := h.service.GetExpiringDocuments(r.Context(), withinDays)
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Ok(w, documents)
+}
+
+func (h *Handler) GetAlerts(w http.ResponseWriter, r *http.Request) {
+	alerts, err := h.service.GetAlerts(r.Context())
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Ok(w, alerts)
+}
+
+func documentIDFromURL(r *http.Request) (int64, error) {
+	documentIDStr := chi.URLParam(r, "documentID")
+	if documentIDStr == "" {
+		return 0, errors.New("documentID is required")
+	}
+
+	return strconv.ParseInt(documentIDStr, 10, 64)
+}
+
+func optionalDateFormValue(r *http.Request, key string) (*date.Date, error) {
+	value := r.FormValue(key)
+	if value == "" {
+		return nil, nil
+	}
+
+	dt, err := date.NewFromString(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", key, err)
+	}
+
+	return &dt, nil
+}
+
+// errorCodes classify the errors of the document service for the clients.
+var errorCodes = []httpx.ErrorCode{
+	{Err: ErrDocumentNotFound, Code: httpx.CodeNotFound},
+	{Err: ErrInvalidDates, Code: httpx.CodeValidation},
+}
+
+func httpServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	httpx.ServiceError(w, r, err, errorCodes)
+}
@@ -0,0 +1,78 @@
+package document
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/turfaa/go-date"
+)
+
+var (
+	ErrDocumentNotFound = errors.New("document not found")
+	ErrInvalidDates     = errors.New("document cannot expire before it is issued")
+)
+
+type Type string
+
+const (
+	TypeSTRA     Type = "stra"
+	TypeSIPA     Type = "sipa"
+	TypeContract Type = "contract"
+	TypeIDCard   Type = "id_card"
+	TypeOther    Type = "other"
+)
+
+// LicenseTypes are the professional licenses a pharmacist needs to practice.
+var LicenseTypes = []Type{TypeSTRA, TypeSIPA}
+
+func (t Type) IsLicense() bool {
+	return slices.Contains(LicenseTypes, t)
+}
+
+// AlertThresholds are the number of days before expiry at which alerts are raised.
+var AlertThresholds = []int{90, 30, 7}
+
+type Document struct {
+	ID           int64      `db:"id" json:"id"`
+	EmployeeID   int64      `db:"employee_id" json:"employeeID"`
+	EmployeeName string     `db:"employee_name" json:"employeeName"`
+	Type         Type       `db:"document_type" json:"type"`
+	Number       string     `db:"number" json:"number"`
+	IssuedAt     *date.Date `db:"issued_at" json:"issuedAt,omitempty"`
+	ExpiresAt    *date.Date `db:"expires_at" json:"expiresAt,omitempty"`
+	StorageKey   string     `db:"storage_key" json:"-"`
+	FileName     string     `db:"file_name" json:"fileName"`
+	ContentType  string     `db:"content_type" json:"contentType"`
+	SizeBytes    int64      `db:"size_bytes" json:"sizeBytes"`
+	CreatedAt    time.Time  `db:"created_at" json:"createdAt"`
+	DeletedAt    *time.Time `db:"deleted_at" json:"deletedAt,omitempty"`
+}
+
+type CreateDocumentRequest struct {
+	EmployeeID int64  `validate:"required"`
+	Type       Type   `validate:"required,oneof=stra sipa contract id_card other"`
+	Number     string `validate:"max=100"`
+	IssuedAt   *date.Date
+	ExpiresAt  *date.Date
+
+	FileName    string `validate:"required"`
+	ContentType string
+	SizeBytes   int64
+}
+
+type GetDocumentsRequest struct {
+	EmployeeID *int64
+
+	// BranchID limits the documents to employees working at the branch.
+	BranchID *int64
+}
+
+// Alert is raised once per document, threshold and expiry date.
+type Alert struct {
+	ID         int64     `db:"id" json:"id"`
+	DaysBefore int       `db:"days_before" json:"daysBefore"`
+	ExpiresAt  date.Date `db:"expires_at" json:"expiresAt"`
+	CreatedAt  time.Time `db:"created_at" json:"createdAt"`
+	Document   Document  `db:"document" json:"document"`
+}
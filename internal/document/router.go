@@ -0,0 +1,17 @@
+package document
+
+import "github.com/go-chi/chi/v5"
+
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Route("/documents", h.registerDocumentRoutes)
+}
+
+func (h *Handler) registerDocumentRoutes(r chi.Router) {
+	r.Get("/", h.GetDocuments)
+	r.Post("/", h.UploadDocument)
+	r.Get("/expiring", h.GetExpiringDocuments)
+	r.Get("/alerts", h.GetAlerts)
+	r.Get("/{documentID}", h.GetDocument)
+	r.Get("/{documentID}/file", h.DownloadDocument)
+	r.Delete("/{documentID}", h.DeleteDocument)
+}
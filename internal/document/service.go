@@ -0,0 +1,232 @@
+package document
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/turfaa/apotek-hris/pkg/blobstore"
+	"github.com/turfaa/apotek-hris/pkg/tracing"
+	"github.com/turfaa/apotek-hris/pkg/validatorx"
+	"github.com/turfaa/go-date"
+)
+
+const documentKeyPrefix = "employee-documents"
+
+const auditEntityDocument = "employee_document"
+
+// defaultExpiryWindowDays is used when listing expiring documents without an explicit window.
+const defaultExpiryWindowDays = 90
+
+type Service struct {
+	db        *DB
+	blobStore blobstore.Store
+}
+
+func NewService(db *sqlx.DB, blobStore blobstore.Store) *Service {
+	return &Service{db: &DB{db: db}, blobStore: blobStore}
+}
+
+func (s *Service) GetDocuments(ctx context.Context, request GetDocumentsRequest) ([]Document, error) {
+	ctx, span := tracing.Start(ctx, "document.Service.GetDocuments")
+	defer span.End()
+
+	documents, err := s.db.GetDocuments(ctx, request)
+	if err != nil {
+		return []Document{}, fmt.Errorf("get documents from db: %w", err)
+	}
+
+	return documents, nil
+}
+
+func (s *Service) GetDocument(ctx context.Context, id int64) (Document, error) {
+	ctx, span := tracing.Start(ctx, "document.Service.GetDocument")
+	defer span.End()
+
+	document, err := s.db.GetDocument(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Document{}, ErrDocumentNotFound
+	}
+	if err != nil {
+		return Document{}, fmt.Errorf("get document from db: %w", err)
+	}
+
+	return document, nil
+}
+
+// UploadDocument stores the document file and records its metadata.
+func (s *Service) UploadDocument(ctx context.Context, request CreateDocumentRequest, r io.Reader) (Document, error) {
+	ctx, span := tracing.Start(ctx, "document.Service.UploadDocument")
+	defer span.End()
+
+	if err := validatorx.Validate(request); err != nil {
+		return Document{}, fmt.Errorf("invalid request: %w", err)
+	}
+
+	if request.IssuedAt != nil && request.ExpiresAt != nil && request.ExpiresAt.Before(*request.IssuedAt) {
+		return Document{}, ErrInvalidDates
+	}
+
+	key, err := blobstore.NewKey(documentKeyPrefix, request.FileName)
+	if err != nil {
+		return Document{}, fmt.Errorf("new blob key: %w", err)
+	}
+
+	if err := s.blobStore.Put(ctx, key, r); err != nil {
+		return Document{}, fmt.Errorf("put document to blob store: %w", err)
+	}
+
+	document, err := s.db.CreateDocument(ctx, request, key)
+	if err != nil {
+		_ = s.blobStore.Delete(ctx, key)
+		return Document{}, fmt.Errorf("create document in db: %w", err)
+	}
+
+	return document, nil
+}
+
+// GetDocumentFile returns the document metadata and its file. The caller must close the file.
+func (s *Service) GetDocumentFile(ctx context.Context, id int64) (Document, io.ReadCloser, error) {
+	ctx, span := tracing.Start(ctx, "document.Service.GetDocumentFile")
+	defer span.End()
+
+	document, err := s.GetDocument(ctx, id)
+	if err != nil {
+		return Document{}, nil, err
+	}
+
+	content, err := s.blobStore.Get(ctx, document.StorageKey)
+	if err != nil {
+		return Document{}, nil, fmt.Errorf("get document from blob store: %w", err)
+	}
+
+	return document, content, nil
+}
+
+func (s *Service) DeleteDocument(ctx context.Context, id int64) error {
+	ctx, span := tracing.Start(ctx, "document.Service.DeleteDocument")
+	defer span.End()
+
+	if err := s.db.DeleteDocument(ctx, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrDocumentNotFound
+		}
+		return fmt.Errorf("delete document from db: %w", err)
+	}
+
+	return nil
+}
+
+// GetExpiringDocuments returns documents expiring within the given number of days,
+// including the ones that have already expired.
+func (s *Service) GetExpiringDocuments(ctx context.Context, withinDays int) ([]Document, error) {
+	ctx, span := tracing.Start(ctx, "document.Service.GetExpiringDocuments")
+	defer span.End()
+
+	before := date.NewFromTime(time.Now()).AddDate(0, 0, withinDays)
+
+	documents, err := s.db.GetDocumentsExpiringBefore(ctx, before)
+	if err != nil {
+		return []Document{}, fmt.Errorf("get documents expiring before %s from db: %w", before, err)
+	}
+
+	return documents, nil
+}
+
+func (s *Service) GetAlerts(ctx context.Context) ([]Alert, error) {
+	ctx, span := tracing.Start(ctx, "document.Service.GetAlerts")
+	defer span.End()
+
+	alerts, err := s.db.GetAlerts(ctx)
+	if err != nil {
+		return []Alert{}, fmt.Errorf("get alerts from db: %w", err)
+	}
+
+	return alerts, nil
+}
+
+// CheckExpiry raises an alert for every document that crossed one of the AlertThresholds
+// since the last check. Alerts already raised are not raised again, so it is safe to run repeatedly.
+func (s *Service) CheckExpiry(ctx context.Context, today date.Date) ([]Alert, error) {
+	ctx, span := tracing.Start(ctx, "document.Service.CheckExpiry")
+	defer span.End()
+
+	maxThreshold := slices.Max(AlertThresholds)
+
+	documents, err := s.db.GetDocumentsExpiringBefore(ctx, today.AddDate(0, 0, maxThreshold))
+	if err != nil {
+		return nil, fmt.Errorf("get documents expiring before %s from db: %w", today, err)
+	}
+
+	var alerts []Alert
+	for _, document := range documents {
+		if document.ExpiresAt == nil || document.ExpiresAt.Before(today) {
+			continue
+		}
+
+		daysLeft := int(document.ExpiresAt.Sub(today).Hours() / 24)
+		threshold, ok := alertThreshold(daysLeft)
+		if !ok {
+			continue
+		}
+
+		created, err := s.db.CreateAlert(ctx, document.ID, threshold, *document.ExpiresAt)
+		if err != nil {
+			return alerts, fmt.Errorf("create alert for document %d in db: %w", document.ID, err)
+		}
+
+		if !created {
+			continue
+		}
+
+		slog.WarnContext(ctx, "employee document is expiring",
+			slog.Int64("documentID", document.ID),
+			slog.Int64("employeeID", document.EmployeeID),
+			slog.String("employeeName", document.EmployeeName),
+			slog.String("type", string(document.Type)),
+			slog.String("expiresAt", document.ExpiresAt.String()),
+			slog.Int("daysLeft", daysLeft),
+		)
+
+		alerts = append(alerts, Alert{
+			DaysBefore: threshold,
+			ExpiresAt:  *document.ExpiresAt,
+			Document:   document,
+		})
+	}
+
+	return alerts, nil
+}
+
+// alertThreshold returns the smallest threshold the days left has crossed.
+func alertThreshold(daysLeft int) (int, bool) {
+	threshold, ok := 0, false
+	for _, t := range AlertThresholds {
+		if daysLeft <= t && (!ok || t < threshold) {
+			threshold, ok = t, true
+		}
+	}
+
+	return threshold, ok
+}
+
+// ExpiredLicenseTypes returns the license types the employee holds that are all expired on the date.
+// Employees without any license on file are not considered expired.
+func (s *Service) ExpiredLicenseTypes(ctx context.Context, employeeID int64, on date.Date) ([]Type, error) {
+	ctx, span := tracing.Start(ctx, "document.Service.ExpiredLicenseTypes")
+	defer span.End()
+
+	types, err := s.db.GetExpiredLicenseTypes(ctx, employeeID, on)
+	if err != nil {
+		return nil, fmt.Errorf("get expired license types of employee %d from db: %w", employeeID, err)
+	}
+
+	return types, nil
+}
@@ -0,0 +1,176 @@
+package hris
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/turfaa/apotek-hris/pkg/tracing"
+	"github.com/turfaa/apotek-hris/pkg/validatorx"
+)
+
+var (
+	ErrBranchNotFound      = errors.New("branch not found")
+	ErrEmployeeNotInBranch = errors.New("employee does not work at the branch")
+)
+
+func (s *Service) GetBranches(ctx context.Context) ([]Branch, error) {
+	ctx, span := tracing.Start(ctx, "hris.Service.GetBranches")
+	defer span.End()
+
+	branches, err := s.db.GetBranches(ctx)
+	if err != nil {
+		return []Branch{}, fmt.Errorf("get branches from db: %w", err)
+	}
+
+	return branches, nil
+}
+
+func (s *Service) GetBranch(ctx context.Context, branchID int64) (Branch, error) {
+	ctx, span := tracing.Start(ctx, "hris.Service.GetBranch")
+	defer span.End()
+
+	branch, err := s.db.GetBranch(ctx, branchID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Branch{}, ErrBranchNotFound
+		}
+		return Branch{}, fmt.Errorf("get branch from db: %w", err)
+	}
+
+	return branch, nil
+}
+
+func (s *Service) CreateBranch(ctx context.Context, request CreateBranchRequest) (Branch, error) {
+	ctx, span := tracing.Start(ctx, "hris.Service.CreateBranch")
+	defer span.End()
+
+	if err := validatorx.Validate(request); err != nil {
+		return Branch{}, fmt.Errorf("invalid request: %w", err)
+	}
+
+	branch, err := s.db.CreateBranch(ctx, request)
+	if err != nil {
+		return Branch{}, fmt.Errorf("create branch in db: %w", err)
+	}
+
+	return branch, nil
+}
+
+func (s *Service) UpdateBranch(ctx context.Context, branchID int64, request UpdateBranchRequest) (Branch, error) {
+	ctx, span := tracing.Start(ctx, "hris.Service.UpdateBranch")
+	defer span.End()
+
+	if err := validatorx.Validate(request); err != nil {
+		return Branch{}, fmt.Errorf("invalid request: %w", err)
+	}
+
+	branch, err := s.db.UpdateBranch(ctx, branchID, request)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Branch{}, ErrBranchNotFound
+		}
+		return Branch{}, fmt.Errorf("update branch in db: %w", err)
+	}
+
+	return branch, nil
+}
+
+func (s *Service) GetEmployeeBranches(ctx context.Context, employeeID int64) (EmployeeBranches, error) {
+	ctx, span := tracing.Start(ctx, "hris.Service.GetEmployeeBranches")
+	defer span.End()
+
+	employee, err := s.db.GetEmployee(ctx, employeeID)
+	if err != nil {
+		return EmployeeBranches{}, fmt.Errorf("get employee from db: %w", err)
+	}
+
+	assignedBranchIDs, err := s.db.GetAssignedBranchIDs(ctx, employeeID)
+	if err != nil {
+		return EmployeeBranches{}, fmt.Errorf("get assigned branch ids from db: %w", err)
+	}
+
+	return EmployeeBranches{
+		EmployeeID:        employee.ID,
+		HomeBranchID:      employee.HomeBranchID,
+		AssignedBranchIDs: assignedBranchIDs,
+		UpdatedAt:         employee.UpdatedAt,
+	}, nil
+}
+
+// SetEmployeeBranches replaces the home branch and the assigned branches of an employee.
+// Past attendances and work logs keep the branch they were recorded at.
+func (s *Service) SetEmployeeBranches(ctx context.Context, request SetEmployeeBranchesRequest) (EmployeeBranches, error) {
+	ctx, span := tracing.Start(ctx, "hris.Service.SetEmployeeBranches")
+	defer span.End()
+
+	if err := validatorx.Validate(request); err != nil {
+		return EmployeeBranches{}, fmt.Errorf("invalid request: %w", err)
+	}
+
+	if err := s.checkBranchesExist(ctx, append([]int64{request.HomeBranchID}, request.AssignedBranchIDs...)); err != nil {
+		return EmployeeBranches{}, err
+	}
+
+	employeeBranches, err := s.db.SetEmployeeBranches(ctx, request)
+	if err != nil {
+		return EmployeeBranches{}, fmt.Errorf("set employee branches in db: %w", err)
+	}
+
+	return employeeBranches, nil
+}
+
+// BranchLocation returns the time zone of the branch, or the deployment's time zone if branchID is nil.
+func (s *Service) BranchLocation(ctx context.Context, branchID *int64) (*time.Location, error) {
+	ctx, span := tracing.Start(ctx, "hris.Service.BranchLocation")
+	defer span.End()
+
+	if branchID == nil {
+		return time.Local, nil
+	}
+
+	branch, err := s.GetBranch(ctx, *branchID)
+	if err != nil {
+		return nil, err
+	}
+
+	return branch.Location(), nil
+}
+
+// checkBranchesExist returns ErrBranchNotFound if any of the branches does not exist.
+func (s *Service) checkBranchesExist(ctx context.Context, branchIDs []int64) error {
+	branches, err := s.GetBranches(ctx)
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[int64]bool, len(branches))
+	for _, branch := range branches {
+		existing[branch.ID] = true
+	}
+
+	for _, branchID := range branchIDs {
+		if !existing[branchID] {
+			return fmt.Errorf("branch %d: %w", branchID, ErrBranchNotFound)
+		}
+	}
+
+	return nil
+}
+
+// checkEmployeeInBranch returns ErrEmployeeNotInBranch if the branch is neither
+// the employee's home branch nor one they are assigned to.
+func (s *Service) checkEmployeeInBranch(ctx context.Context, employeeID int64, branchID int64) error {
+	inBranch, err := s.db.IsEmployeeInBranch(ctx, employeeID, branchID)
+	if err != nil {
+		return fmt.Errorf("check employee branch in db: %w", err)
+	}
+
+	if !inBranch {
+		return ErrEmployeeNotInBranch
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package hris
+
+import (
+	"time"
+
+	"github.com/turfaa/apotek-hris/pkg/escpos"
+)
+
+// DefaultBackdateWindow is used when Config.BackdateWindow is not set.
+const DefaultBackdateWindow = 48 * time.Hour
+
+type Config struct {
+	// BackdateWindow is how far in the past a work log can be performed
+	// before creating it requires a manager.
+	BackdateWindow time.Duration `mapstructure:"backdate_window" validate:"gte=0"`
+
+	// BlockWorkLogsOnExpiredLicense rejects work logs of employees whose STRA or SIPA
+	// has expired on the day the work was performed.
+	BlockWorkLogsOnExpiredLicense bool `mapstructure:"block_work_logs_on_expired_license"`
+
+	// ReceiptPrinter is the thermal printer patient receipts are sent to.
+	ReceiptPrinter escpos.Config `mapstructure:"receipt_printer"`
+
+	// ReceiptVerification configures the QR code printed on patient receipts.
+	ReceiptVerification ReceiptVerificationConfig `mapstructure:"receipt_verification"`
+}
+
+type ReceiptVerificationConfig struct {
+	// BaseURL is the public URL of this server, which the QR code links to.
+	BaseURL string `mapstructure:"base_url" validate:"omitempty,url"`
+
+	// SigningKey signs the tokens in the QR code.
+	// Receipts are printed without a QR code when it or BaseURL is empty.
+	SigningKey string `mapstructure:"signing_key"`
+}
+
+func (c ReceiptVerificationConfig) enabled() bool {
+	return c.BaseURL != "" && c.SigningKey != ""
+}
+
+func (c Config) backdateWindow() time.Duration {
+	if c.BackdateWindow == 0 {
+		return DefaultBackdateWindow
+	}
+
+	return c.BackdateWindow
+}
@@ -3,11 +3,19 @@ package hris
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/shopspring/decimal"
+	"github.com/turfaa/apotek-hris/internal/audit"
+	"github.com/turfaa/apotek-hris/internal/webhook"
+	"github.com/turfaa/apotek-hris/pkg/database"
+	"github.com/turfaa/apotek-hris/pkg/etag"
+	"github.com/turfaa/apotek-hris/pkg/pagination"
+	"github.com/turfaa/go-date"
 )
 
 type DB struct {
@@ -25,28 +33,100 @@ func New(db *sqlx.DB) *DB {
 	return &DB{db: db}
 }
 
-func (d *DB) GetEmployees(ctx context.Context) ([]Employee, error) {
+// GetEmployees returns the employees, limited to those working at the branch if branchID is not nil.
+func (d *DB) GetEmployees(ctx context.Context, branchID *int64) ([]Employee, error) {
 	query := `
-	SELECT id, name, shift_fee, show_in_attendances, created_at, updated_at 
-	FROM employees
+	SELECT id, name, shift_fee, show_in_attendances, role, home_branch_id, email, payslip_by_email, created_at, updated_at 
+	FROM employees`
+	var args []any
+
+	if branchID != nil {
+		query += `
+	WHERE home_branch_id = ?
+	OR id IN (SELECT employee_id FROM employee_branches WHERE branch_id = ?)`
+		args = append(args, *branchID, *branchID)
+	}
+
+	query += `
 	ORDER BY id ASC`
 	query = d.db.Rebind(query)
 
 	var employees []Employee
-	if err := d.db.SelectContext(ctx, &employees, query); err != nil {
+	if err := d.db.SelectContext(ctx, &employees, query, args...); err != nil {
 		return []Employee{}, fmt.Errorf("select context from db: %w", err)
 	}
 
 	return employees, nil
 }
 
+var employeeSorting = pagination.Sorting[Employee]{
+	Fields: map[string]pagination.Field[Employee]{
+		"id":        {Column: "id", Type: "bigint", Value: func(e Employee) string { return pagination.FormatInt(e.ID) }},
+		"name":      {Column: "name", Type: "text", Value: func(e Employee) string { return e.Name }},
+		"createdAt": {Column: "created_at", Type: "timestamptz", Value: func(e Employee) string { return e.CreatedAt.Format(time.RFC3339Nano) }},
+	},
+	Default:  "id",
+	IDColumn: "id",
+	ID:       func(e Employee) int64 { return e.ID },
+}
+
+// ListEmployees returns a page of the employees matching the filters of the request.
+func (d *DB) ListEmployees(ctx context.Context, request ListEmployeesRequest) (pagination.Page[Employee], error) {
+	page, err := employeeSorting.Query(request.Page)
+	if err != nil {
+		return pagination.Page[Employee]{}, err
+	}
+
+	var filters []string
+	var args []any
+
+	if request.BranchID != nil {
+		filters = append(filters, "(home_branch_id = ? OR id IN (SELECT employee_id FROM employee_branches WHERE branch_id = ?))")
+		args = append(args, *request.BranchID, *request.BranchID)
+	}
+
+	if request.Role != nil {
+		filters = append(filters, "role = ?")
+		args = append(args, *request.Role)
+	}
+
+	if request.Name != "" {
+		filters = append(filters, "name ILIKE '%' || ? || '%'")
+		args = append(args, request.Name)
+	}
+
+	var total int
+	if err := d.db.GetContext(ctx, &total, d.db.Rebind(`SELECT COUNT(*) FROM employees `+database.Where(filters)), args...); err != nil {
+		return pagination.Page[Employee]{}, fmt.Errorf("count employees in db: %w", err)
+	}
+
+	if condition, cursorArgs := page.Where(); condition != "" {
+		filters = append(filters, condition)
+		args = append(args, cursorArgs...)
+	}
+
+	query := `
+	SELECT id, name, shift_fee, show_in_attendances, role, home_branch_id, email, payslip_by_email, created_at, updated_at
+	FROM employees
+	` + database.Where(filters) + `
+	` + page.OrderByLimit()
+	query = d.db.Rebind(query)
+
+	var employees []Employee
+	if err := d.db.SelectContext(ctx, &employees, query, args...); err != nil {
+		return pagination.Page[Employee]{}, fmt.Errorf("select context from db: %w", err)
+	}
+
+	return page.Page(employees, total), nil
+}
+
 func (d *DB) GetEmployeesByIDs(ctx context.Context, ids []int64) ([]Employee, error) {
 	if len(ids) == 0 {
 		return []Employee{}, nil
 	}
 
 	query := `
-	SELECT id, name, shift_fee, show_in_attendances, created_at, updated_at
+	SELECT id, name, shift_fee, show_in_attendances, role, home_branch_id, email, payslip_by_email, created_at, updated_at
 	FROM employees
 	WHERE id IN (?)`
 
@@ -67,7 +147,7 @@ func (d *DB) GetEmployeesByIDs(ctx context.Context, ids []int64) ([]Employee, er
 
 func (d *DB) GetEmployee(ctx context.Context, id int64) (Employee, error) {
 	query := `
-	SELECT id, name, shift_fee, show_in_attendances, created_at, updated_at
+	SELECT id, name, shift_fee, show_in_attendances, role, home_branch_id, email, payslip_by_email, created_at, updated_at
 	FROM employees
 	WHERE id = ?`
 	query = d.db.Rebind(query)
@@ -81,11 +161,23 @@ func (d *DB) GetEmployee(ctx context.Context, id int64) (Employee, error) {
 	return employee, nil
 }
 
-func (d *DB) CreateEmployee(ctx context.Context, request CreateEmployeeRequest) (Employee, error) {
+func (d *DB) CreateEmployee(ctx context.Context, request CreateEmployeeRequest) (employee Employee, returnedErr error) {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return Employee{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		if returnedErr != nil {
+			_ = tx.Rollback()
+		} else if err := tx.Commit(); err != nil {
+			returnedErr = fmt.Errorf("commit tx: %w", err)
+		}
+	}()
+
 	query := `
-	INSERT INTO employees (name, shift_fee, show_in_attendances) 
-	VALUES (?, ?, ?) 
-	RETURNING id, name, shift_fee, show_in_attendances, created_at, updated_at`
+	INSERT INTO employees (name, shift_fee, show_in_attendances, role, home_branch_id) 
+	VALUES (?, ?, ?, ?, COALESCE(?, (SELECT MIN(id) FROM branches))) 
+	RETURNING id, name, shift_fee, show_in_attendances, role, home_branch_id, email, payslip_by_email, created_at, updated_at`
 	query = d.db.Rebind(query)
 
 	showInAttendances := true
@@ -93,13 +185,25 @@ func (d *DB) CreateEmployee(ctx context.Context, request CreateEmployeeRequest)
 		showInAttendances = *request.ShowInAttendances
 	}
 
-	args := []any{request.Name, request.ShiftFee, showInAttendances}
+	role := RoleStaff
+	if request.Role != "" {
+		role = request.Role
+	}
+
+	args := []any{request.Name, request.ShiftFee, showInAttendances, role, request.HomeBranchID}
 
-	var employee Employee
-	if err := d.db.GetContext(ctx, &employee, query, args...); err != nil {
+	if err := tx.GetContext(ctx, &employee, query, args...); err != nil {
 		return Employee{}, fmt.Errorf("get context from db: %w", err)
 	}
 
+	if err := webhook.Publish(ctx, tx, webhook.EventEmployeeCreated, employee.ID, employee); err != nil {
+		return Employee{}, fmt.Errorf("publish event: %w", err)
+	}
+
+	if err := audit.Record(ctx, tx, audit.ActionCreate, auditEntityEmployee, employee.ID, nil, employee); err != nil {
+		return Employee{}, fmt.Errorf("record audit log: %w", err)
+	}
+
 	return employee, nil
 }
 
@@ -108,7 +212,7 @@ func (d *DB) UpdateEmployeeShiftFee(ctx context.Context, id int64, shiftFee deci
 	UPDATE employees 
 	SET shift_fee = ?, updated_at = CURRENT_TIMESTAMP
 	WHERE id = ? 
-	RETURNING id, name, shift_fee, created_at, updated_at`
+	RETURNING id, name, shift_fee, show_in_attendances, role, home_branch_id, email, payslip_by_email, created_at, updated_at`
 	query = d.db.Rebind(query)
 	args := []any{shiftFee, id}
 
@@ -120,6 +224,315 @@ func (d *DB) UpdateEmployeeShiftFee(ctx context.Context, id int64, shiftFee deci
 	return employee, nil
 }
 
+func (d *DB) SetEmployeeRole(ctx context.Context, id int64, role Role, precondition etag.Precondition) (Employee, error) {
+	return d.updateEmployee(ctx, id, precondition, `role = ?`, role)
+}
+
+func (d *DB) SetEmployeeContact(ctx context.Context, request SetEmployeeContactRequest) (Employee, error) {
+	return d.updateEmployee(ctx, request.EmployeeID, request.IfMatch, `email = ?, payslip_by_email = ?`, request.Email, request.PayslipByEmail)
+}
+
+// updateEmployee sets the columns of an employee, e.g. "role = ?", with args, if the employee satisfies the precondition.
+func (d *DB) updateEmployee(ctx context.Context, id int64, precondition etag.Precondition, set string, args ...any) (employee Employee, returnedErr error) {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return Employee{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		if returnedErr != nil {
+			_ = tx.Rollback()
+		} else if err := tx.Commit(); err != nil {
+			returnedErr = fmt.Errorf("commit tx: %w", err)
+		}
+	}()
+
+	before, err := d.lockEmployee(ctx, tx, id)
+	if err != nil {
+		return Employee{}, fmt.Errorf("lock employee: %w", err)
+	}
+
+	if err := precondition.Check(before, etag.FromTime(before.UpdatedAt)); err != nil {
+		return Employee{}, err
+	}
+
+	query := tx.Rebind(`
+	UPDATE employees 
+	SET ` + set + `, updated_at = CURRENT_TIMESTAMP
+	WHERE id = ? 
+	RETURNING id, name, shift_fee, show_in_attendances, role, home_branch_id, email, payslip_by_email, created_at, updated_at`)
+	args = append(args, id)
+
+	if err := tx.GetContext(ctx, &employee, query, args...); err != nil {
+		return Employee{}, fmt.Errorf("get context from db: %w", err)
+	}
+
+	if err := audit.Record(ctx, tx, audit.ActionUpdate, auditEntityEmployee, employee.ID, before, employee); err != nil {
+		return Employee{}, fmt.Errorf("record audit log: %w", err)
+	}
+
+	return employee, nil
+}
+
+// lockEmployee returns the employee, locked until the end of the transaction so conditional writes
+// to the employee and its branches apply to the state they were checked against.
+func (d *DB) lockEmployee(ctx context.Context, tx *sqlx.Tx, id int64) (Employee, error) {
+	query := tx.Rebind(`
+	SELECT id, name, shift_fee, show_in_attendances, role, home_branch_id, email, payslip_by_email, created_at, updated_at
+	FROM employees
+	WHERE id = ?
+	FOR UPDATE`)
+
+	var employee Employee
+	if err := tx.GetContext(ctx, &employee, query, id); err != nil {
+		return Employee{}, fmt.Errorf("get context from db: %w", err)
+	}
+
+	return employee, nil
+}
+
+func (d *DB) GetBranches(ctx context.Context) ([]Branch, error) {
+	query := `
+	SELECT id, name, address, sia_number, timezone, created_at, updated_at
+	FROM branches
+	ORDER BY id ASC`
+	query = d.db.Rebind(query)
+
+	var branches []Branch
+	if err := d.db.SelectContext(ctx, &branches, query); err != nil {
+		return []Branch{}, fmt.Errorf("select context from db: %w", err)
+	}
+
+	return branches, nil
+}
+
+func (d *DB) GetBranch(ctx context.Context, id int64) (Branch, error) {
+	query := `
+	SELECT id, name, address, sia_number, timezone, created_at, updated_at
+	FROM branches
+	WHERE id = ?`
+	query = d.db.Rebind(query)
+	args := []any{id}
+
+	var branch Branch
+	if err := d.db.GetContext(ctx, &branch, query, args...); err != nil {
+		return Branch{}, fmt.Errorf("get context from db: %w", err)
+	}
+
+	return branch, nil
+}
+
+func (d *DB) CreateBranch(ctx context.Context, request CreateBranchRequest) (branch Branch, returnedErr error) {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return Branch{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		if returnedErr != nil {
+			_ = tx.Rollback()
+		} else if err := tx.Commit(); err != nil {
+			returnedErr = fmt.Errorf("commit tx: %w", err)
+		}
+	}()
+
+	query := `
+	INSERT INTO branches (name, address, sia_number, timezone)
+	VALUES (?, ?, ?, ?)
+	RETURNING id, name, address, sia_number, timezone, created_at, updated_at`
+	query = tx.Rebind(query)
+	args := []any{request.Name, request.Address, request.SIANumber, request.Timezone}
+
+	if err := tx.GetContext(ctx, &branch, query, args...); err != nil {
+		return Branch{}, fmt.Errorf("get context from db: %w", err)
+	}
+
+	if err := audit.Record(ctx, tx, audit.ActionCreate, auditEntityBranch, branch.ID, nil, branch); err != nil {
+		return Branch{}, fmt.Errorf("record audit log: %w", err)
+	}
+
+	return branch, nil
+}
+
+// UpdateBranch updates a branch. Returns sql.ErrNoRows if the branch does not exist.
+func (d *DB) UpdateBranch(ctx context.Context, id int64, request UpdateBranchRequest) (branch Branch, returnedErr error) {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return Branch{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		if returnedErr != nil {
+			_ = tx.Rollback()
+		} else if err := tx.Commit(); err != nil {
+			returnedErr = fmt.Errorf("commit tx: %w", err)
+		}
+	}()
+
+	query := `
+	SELECT id, name, address, sia_number, timezone, created_at, updated_at
+	FROM branches
+	WHERE id = ?
+	FOR UPDATE`
+	query = tx.Rebind(query)
+
+	var before Branch
+	if err := tx.GetContext(ctx, &before, query, id); err != nil {
+		return Branch{}, fmt.Errorf("lock branch: %w", err)
+	}
+
+	query = `
+	UPDATE branches
+	SET name = ?, address = ?, sia_number = ?, timezone = ?, updated_at = CURRENT_TIMESTAMP
+	WHERE id = ?
+	RETURNING id, name, address, sia_number, timezone, created_at, updated_at`
+	query = tx.Rebind(query)
+	args := []any{request.Name, request.Address, request.SIANumber, request.Timezone, id}
+
+	if err := tx.GetContext(ctx, &branch, query, args...); err != nil {
+		return Branch{}, fmt.Errorf("get context from db: %w", err)
+	}
+
+	if err := audit.Record(ctx, tx, audit.ActionUpdate, auditEntityBranch, branch.ID, before, branch); err != nil {
+		return Branch{}, fmt.Errorf("record audit log: %w", err)
+	}
+
+	return branch, nil
+}
+
+// GetAssignedBranchIDs returns the branches an employee is assigned to besides their home branch.
+func (d *DB) GetAssignedBranchIDs(ctx context.Context, employeeID int64) ([]int64, error) {
+	return d.getAssignedBranchIDs(ctx, d.db, employeeID)
+}
+
+func (d *DB) getAssignedBranchIDs(ctx context.Context, queryer Queryer, employeeID int64) ([]int64, error) {
+	query := `
+	SELECT branch_id
+	FROM employee_branches
+	WHERE employee_id = ?
+	ORDER BY branch_id ASC`
+	query = queryer.Rebind(query)
+	args := []any{employeeID}
+
+	branchIDs := []int64{}
+	if err := queryer.SelectContext(ctx, &branchIDs, query, args...); err != nil {
+		return []int64{}, fmt.Errorf("select context from db: %w", err)
+	}
+
+	return branchIDs, nil
+}
+
+// SetEmployeeBranches replaces the home branch and the assigned branches of an employee and returns them.
+func (d *DB) SetEmployeeBranches(ctx context.Context, request SetEmployeeBranchesRequest) (employeeBranches EmployeeBranches, returnedErr error) {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return EmployeeBranches{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		if returnedErr != nil {
+			_ = tx.Rollback()
+		} else if err := tx.Commit(); err != nil {
+			returnedErr = fmt.Errorf("commit tx: %w", err)
+		}
+	}()
+
+	employee, err := d.lockEmployee(ctx, tx, request.EmployeeID)
+	if err != nil {
+		return EmployeeBranches{}, fmt.Errorf("lock employee: %w", err)
+	}
+
+	// The assigned branches only change along with the locked employee.
+	assignedBranchIDs, err := d.getAssignedBranchIDs(ctx, tx, request.EmployeeID)
+	if err != nil {
+		return EmployeeBranches{}, fmt.Errorf("get assigned branch ids: %w", err)
+	}
+
+	before := EmployeeBranches{
+		EmployeeID:        employee.ID,
+		HomeBranchID:      employee.HomeBranchID,
+		AssignedBranchIDs: assignedBranchIDs,
+		UpdatedAt:         employee.UpdatedAt,
+	}
+	if err := request.IfMatch.Check(before, etag.FromTime(employee.UpdatedAt)); err != nil {
+		return EmployeeBranches{}, err
+	}
+
+	query := tx.Rebind(`
+	UPDATE employees
+	SET home_branch_id = ?, updated_at = CURRENT_TIMESTAMP
+	WHERE id = ?
+	RETURNING updated_at`)
+	var updatedAt time.Time
+	if err := tx.GetContext(ctx, &updatedAt, query, request.HomeBranchID, request.EmployeeID); err != nil {
+		return EmployeeBranches{}, fmt.Errorf("update home branch: %w", err)
+	}
+
+	query = tx.Rebind(`DELETE FROM employee_branches WHERE employee_id = ?`)
+	if _, err := tx.ExecContext(ctx, query, request.EmployeeID); err != nil {
+		return EmployeeBranches{}, fmt.Errorf("delete assigned branches: %w", err)
+	}
+
+	if len(request.AssignedBranchIDs) > 0 {
+		query = tx.Rebind(`
+		INSERT INTO employee_branches (employee_id, branch_id)
+		SELECT ?, t.branch_id
+		FROM unnest(?::bigint[]) AS t(branch_id)
+		WHERE t.branch_id <> ?
+		ON CONFLICT DO NOTHING`)
+		if _, err := tx.ExecContext(ctx, query, request.EmployeeID, request.AssignedBranchIDs, request.HomeBranchID); err != nil {
+			return EmployeeBranches{}, fmt.Errorf("insert assigned branches: %w", err)
+		}
+	}
+
+	assignedBranchIDs, err = d.getAssignedBranchIDs(ctx, tx, request.EmployeeID)
+	if err != nil {
+		return EmployeeBranches{}, fmt.Errorf("get assigned branch ids: %w", err)
+	}
+
+	employeeBranches = EmployeeBranches{
+		EmployeeID:        request.EmployeeID,
+		HomeBranchID:      request.HomeBranchID,
+		AssignedBranchIDs: assignedBranchIDs,
+		UpdatedAt:         updatedAt,
+	}
+
+	if err := audit.Record(ctx, tx, audit.ActionUpdate, auditEntityEmployeeBranches, request.EmployeeID, before, employeeBranches); err != nil {
+		return EmployeeBranches{}, fmt.Errorf("record audit log: %w", err)
+	}
+
+	return employeeBranches, nil
+}
+
+// HasManager reports whether any employee is a manager.
+func (d *DB) HasManager(ctx context.Context) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM employees WHERE role = ?)`
+	query = d.db.Rebind(query)
+	args := []any{RoleManager}
+
+	var hasManager bool
+	if err := d.db.GetContext(ctx, &hasManager, query, args...); err != nil {
+		return false, fmt.Errorf("get context from db: %w", err)
+	}
+
+	return hasManager, nil
+}
+
+// IsEmployeeInBranch reports whether the branch is the employee's home branch or one they are assigned to.
+func (d *DB) IsEmployeeInBranch(ctx context.Context, employeeID int64, branchID int64) (bool, error) {
+	query := `
+	SELECT EXISTS (
+		SELECT 1 FROM employees WHERE id = ? AND home_branch_id = ?
+		UNION ALL
+		SELECT 1 FROM employee_branches WHERE employee_id = ? AND branch_id = ?
+	)`
+	query = d.db.Rebind(query)
+	args := []any{employeeID, branchID, employeeID, branchID}
+
+	var inBranch bool
+	if err := d.db.GetContext(ctx, &inBranch, query, args...); err != nil {
+		return false, fmt.Errorf("get context from db: %w", err)
+	}
+
+	return inBranch, nil
+}
+
 func (d *DB) CreateLeaveBalanceChange(ctx context.Context, employeeID int64, changeAmount int, description string) error {
 	query := `
 	INSERT INTO leave_balance_changes (employee_id, change_amount, description)
@@ -134,26 +547,35 @@ func (d *DB) CreateLeaveBalanceChange(ctx context.Context, employeeID int64, cha
 	return nil
 }
 
-func (d *DB) GetWorkLogsBetween(ctx context.Context, startDate time.Time, endDate time.Time) ([]WorkLog, error) {
+// GetWorkLogsBetween returns the work logs performed in the range, limited to the branch if branchID is not nil.
+func (d *DB) GetWorkLogsBetween(ctx context.Context, startDate time.Time, endDate time.Time, branchID *int64) ([]WorkLog, error) {
 	if startDate.After(endDate) {
 		startDate, endDate = endDate, startDate
 	}
 
 	query := `
 	SELECT 
-		wl.id, wl.patient_name, wl.created_at, wl.deleted_at, wl.deleted_by,
+		wl.id, wl.branch_id, wl.patient_name, wl.patient_id, wl.performed_at, wl.created_at, wl.deleted_at, wl.deleted_by,
 		e.id AS "employee.id",
 		e.name AS "employee.name",
 		e.shift_fee AS "employee.shift_fee",
+		e.home_branch_id AS "employee.home_branch_id",
 		e.created_at AS "employee.created_at",
 		e.updated_at AS "employee.updated_at"
 	FROM work_logs wl
 	JOIN employees e ON wl.employee_id = e.id
 	WHERE wl.deleted_at IS NULL
-	AND wl.created_at BETWEEN ? AND ?`
-	query = d.db.Rebind(query)
+	AND wl.performed_at BETWEEN ? AND ?`
 	args := []any{startDate, endDate}
 
+	if branchID != nil {
+		query += `
+	AND wl.branch_id = ?`
+		args = append(args, *branchID)
+	}
+
+	query = d.db.Rebind(query)
+
 	var workLogs []WorkLog
 	if err := d.db.SelectContext(ctx, &workLogs, query, args...); err != nil {
 		return []WorkLog{}, fmt.Errorf("select context from db: %w", err)
@@ -181,6 +603,95 @@ func (d *DB) GetWorkLogsBetween(ctx context.Context, startDate time.Time, endDat
 	return workLogs, nil
 }
 
+var workLogSorting = pagination.Sorting[WorkLog]{
+	Fields: map[string]pagination.Field[WorkLog]{
+		"id":          {Column: "wl.id", Type: "bigint", Value: func(w WorkLog) string { return pagination.FormatInt(w.ID) }},
+		"performedAt": {Column: "wl.performed_at", Type: "timestamptz", Value: func(w WorkLog) string { return w.PerformedAt.Format(time.RFC3339Nano) }},
+		"createdAt":   {Column: "wl.created_at", Type: "timestamptz", Value: func(w WorkLog) string { return w.CreatedAt.Format(time.RFC3339Nano) }},
+	},
+	Default:  "-performedAt",
+	IDColumn: "wl.id",
+	ID:       func(w WorkLog) int64 { return w.ID },
+}
+
+// ListWorkLogs returns a page of the work logs performed in the range of the request, matching its filters.
+func (d *DB) ListWorkLogs(ctx context.Context, request ListWorkLogsRequest) (pagination.Page[WorkLog], error) {
+	page, err := workLogSorting.Query(request.Page)
+	if err != nil {
+		return pagination.Page[WorkLog]{}, err
+	}
+
+	from, to := request.From, request.To
+	if from.After(to) {
+		from, to = to, from
+	}
+
+	filters := []string{"wl.deleted_at IS NULL", "wl.performed_at BETWEEN ? AND ?"}
+	args := []any{from, to}
+
+	if request.BranchID != nil {
+		filters = append(filters, "wl.branch_id = ?")
+		args = append(args, *request.BranchID)
+	}
+
+	if request.EmployeeID != nil {
+		filters = append(filters, "wl.employee_id = ?")
+		args = append(args, *request.EmployeeID)
+	}
+
+	if request.WorkTypeID != nil {
+		filters = append(filters, "EXISTS (SELECT 1 FROM work_log_units wlu WHERE wlu.work_log_id = wl.id AND wlu.work_type_id = ? AND wlu.deleted_at IS NULL)")
+		args = append(args, *request.WorkTypeID)
+	}
+
+	var total int
+	if err := d.db.GetContext(ctx, &total, d.db.Rebind(`SELECT COUNT(*) FROM work_logs wl `+database.Where(filters)), args...); err != nil {
+		return pagination.Page[WorkLog]{}, fmt.Errorf("count work logs in db: %w", err)
+	}
+
+	if condition, cursorArgs := page.Where(); condition != "" {
+		filters = append(filters, condition)
+		args = append(args, cursorArgs...)
+	}
+
+	query := `
+	SELECT 
+		wl.id, wl.branch_id, wl.patient_name, wl.patient_id, wl.performed_at, wl.created_at, wl.deleted_at, wl.deleted_by,
+		e.id AS "employee.id",
+		e.name AS "employee.name",
+		e.shift_fee AS "employee.shift_fee",
+		e.home_branch_id AS "employee.home_branch_id",
+		e.created_at AS "employee.created_at",
+		e.updated_at AS "employee.updated_at"
+	FROM work_logs wl
+	JOIN employees e ON wl.employee_id = e.id
+	` + database.Where(filters) + `
+	` + page.OrderByLimit()
+	query = d.db.Rebind(query)
+
+	var workLogs []WorkLog
+	if err := d.db.SelectContext(ctx, &workLogs, query, args...); err != nil {
+		return pagination.Page[WorkLog]{}, fmt.Errorf("select context from db: %w", err)
+	}
+
+	workLogIDs := make([]int64, len(workLogs))
+	for i, workLog := range workLogs {
+		workLogIDs[i] = workLog.ID
+	}
+
+	workLogUnitsByWorkLogID, err := d.GetWorkLogUnitsByWorkLogIDs(ctx, workLogIDs)
+	if err != nil {
+		return pagination.Page[WorkLog]{}, fmt.Errorf("get work log units by work log ids: %w", err)
+	}
+
+	for i, workLog := range workLogs {
+		workLog.Units = workLogUnitsByWorkLogID[workLog.ID]
+		workLogs[i] = workLog
+	}
+
+	return page.Page(workLogs, total), nil
+}
+
 func (d *DB) GetEmployeeWorkLogsBetween(ctx context.Context, employeeID int64, startDate time.Time, endDate time.Time) ([]WorkLog, error) {
 	if startDate.After(endDate) {
 		startDate, endDate = endDate, startDate
@@ -188,17 +699,18 @@ func (d *DB) GetEmployeeWorkLogsBetween(ctx context.Context, employeeID int64, s
 
 	query := `
 	SELECT 
-		wl.id, wl.patient_name, wl.created_at, wl.deleted_at, wl.deleted_by,
+		wl.id, wl.branch_id, wl.patient_name, wl.patient_id, wl.performed_at, wl.created_at, wl.deleted_at, wl.deleted_by,
 		e.id AS "employee.id",
 		e.name AS "employee.name",
 		e.shift_fee AS "employee.shift_fee",
+		e.home_branch_id AS "employee.home_branch_id",
 		e.created_at AS "employee.created_at",
 		e.updated_at AS "employee.updated_at"
 	FROM work_logs wl
 	JOIN employees e ON wl.employee_id = e.id
 	WHERE wl.deleted_at IS NULL
 	AND wl.employee_id = ?
-	AND wl.created_at BETWEEN ? AND ?`
+	AND wl.performed_at BETWEEN ? AND ?`
 	query = d.db.Rebind(query)
 	args := []any{employeeID, startDate, endDate}
 
@@ -229,29 +741,97 @@ func (d *DB) GetEmployeeWorkLogsBetween(ctx context.Context, employeeID int64, s
 	return workLogs, nil
 }
 
-func (d *DB) GetWorkLog(ctx context.Context, id int64) (WorkLog, error) {
+// GetPatientWorkLogs returns the work logs of a patient, oldest first.
+func (d *DB) GetPatientWorkLogs(ctx context.Context, patientID int64) ([]WorkLog, error) {
 	query := `
 	SELECT 
-		wl.id, wl.patient_name, wl.created_at, wl.deleted_at, wl.deleted_by,
+		wl.id, wl.branch_id, wl.patient_name, wl.patient_id, wl.performed_at, wl.created_at, wl.deleted_at, wl.deleted_by,
 		e.id AS "employee.id",
 		e.name AS "employee.name",
 		e.shift_fee AS "employee.shift_fee",
+		e.home_branch_id AS "employee.home_branch_id",
 		e.created_at AS "employee.created_at",
 		e.updated_at AS "employee.updated_at"
 	FROM work_logs wl
 	JOIN employees e ON wl.employee_id = e.id
-	WHERE wl.deleted_at IS NULL AND wl.id = ?`
+	WHERE wl.deleted_at IS NULL
+	AND wl.patient_id = ?
+	ORDER BY wl.performed_at ASC, wl.id ASC`
 	query = d.db.Rebind(query)
-	args := []any{id}
+	args := []any{patientID}
+
+	var workLogs []WorkLog
+	if err := d.db.SelectContext(ctx, &workLogs, query, args...); err != nil {
+		return []WorkLog{}, fmt.Errorf("select context from db: %w", err)
+	}
+
+	if len(workLogs) == 0 {
+		return []WorkLog{}, nil
+	}
+
+	workLogIDs := make([]int64, len(workLogs))
+	for i, workLog := range workLogs {
+		workLogIDs[i] = workLog.ID
+	}
+
+	workLogUnitsByWorkLogID, err := d.GetWorkLogUnitsByWorkLogIDs(ctx, workLogIDs)
+	if err != nil {
+		return []WorkLog{}, fmt.Errorf("get work log units by work log ids: %w", err)
+	}
+
+	for i, workLog := range workLogs {
+		workLog.Units = workLogUnitsByWorkLogID[workLog.ID]
+		workLogs[i] = workLog
+	}
+
+	return workLogs, nil
+}
+
+func (d *DB) GetWorkLog(ctx context.Context, id int64) (WorkLog, error) {
+	return d.getWorkLog(ctx, d.db, id, false)
+}
+
+// GetWorkLogIncludingDeleted returns the work log even if it has been deleted,
+// together with the units that were deleted along with it.
+func (d *DB) GetWorkLogIncludingDeleted(ctx context.Context, id int64) (WorkLog, error) {
+	return d.getWorkLog(ctx, d.db, id, true)
+}
+
+func (d *DB) getWorkLog(ctx context.Context, queryer Queryer, id int64, includeDeleted bool) (WorkLog, error) {
+	query := `
+	SELECT 
+		wl.id, wl.branch_id, wl.patient_name, wl.patient_id, wl.performed_at, wl.created_at, wl.deleted_at, wl.deleted_by,
+		e.id AS "employee.id",
+		e.name AS "employee.name",
+		e.shift_fee AS "employee.shift_fee",
+		e.home_branch_id AS "employee.home_branch_id",
+		e.created_at AS "employee.created_at",
+		e.updated_at AS "employee.updated_at"
+	FROM work_logs wl
+	JOIN employees e ON wl.employee_id = e.id
+	WHERE (wl.deleted_at IS NULL OR ?) AND wl.id = ?`
+	query = queryer.Rebind(query)
+	args := []any{includeDeleted, id}
 
 	var workLog WorkLog
-	if err := d.db.GetContext(ctx, &workLog, query, args...); err != nil {
+	if err := queryer.GetContext(ctx, &workLog, query, args...); err != nil {
 		return WorkLog{}, fmt.Errorf("get context from db: %w", err)
 	}
 
-	workLogUnits, err := d.GetWorkLogUnitsByWorkLogIDs(ctx, []int64{id})
+	if workLog.DeletedAt == nil {
+		workLogUnits, err := d.getWorkLogUnits(ctx, queryer, "wlu.deleted_at IS NULL AND wlu.work_log_id = ?", id)
+		if err != nil {
+			return WorkLog{}, fmt.Errorf("get work log units: %w", err)
+		}
+		workLog.Units = workLogUnits[id]
+
+		return workLog, nil
+	}
+
+	// Deleting a work log deletes its live units in the same transaction, so they share its deletion time.
+	workLogUnits, err := d.getWorkLogUnits(ctx, queryer, "wlu.deleted_at = ? AND wlu.work_log_id = ?", *workLog.DeletedAt, id)
 	if err != nil {
-		return WorkLog{}, fmt.Errorf("get work log units by work log ids: %w", err)
+		return WorkLog{}, fmt.Errorf("get deleted work log units: %w", err)
 	}
 	workLog.Units = workLogUnits[id]
 
@@ -266,8 +846,8 @@ func (d *DB) CreateWorkLog(ctx context.Context, request CreateWorkLogRequest) (w
 	defer func() {
 		if returnedErr != nil {
 			_ = tx.Rollback()
-		} else {
-			_ = tx.Commit()
+		} else if err := tx.Commit(); err != nil {
+			returnedErr = fmt.Errorf("commit tx: %w", err)
 		}
 	}()
 
@@ -279,23 +859,27 @@ func (d *DB) CreateWorkLog(ctx context.Context, request CreateWorkLogRequest) (w
 
 	query := `
 	WITH inserted_work_log AS (
-		INSERT INTO work_logs (employee_id, patient_name)
-		VALUES (?, ?)
-		RETURNING id, employee_id, patient_name, created_at
+		INSERT INTO work_logs (employee_id, branch_id, patient_name, patient_id, performed_at)
+		VALUES (?, COALESCE(?, (SELECT home_branch_id FROM employees WHERE id = ?)), ?, ?, COALESCE(?, CURRENT_TIMESTAMP))
+		RETURNING id, employee_id, branch_id, patient_name, patient_id, performed_at, created_at
 	)
 	SELECT 
 		iwl.id AS "id",
+		iwl.branch_id AS "branch_id",
 		iwl.patient_name AS "patient_name",
+		iwl.patient_id AS "patient_id",
+		iwl.performed_at AS "performed_at",
 		iwl.created_at AS "created_at",
 		e.id AS "employee.id",
 		e.name AS "employee.name",
 		e.shift_fee AS "employee.shift_fee",
+		e.home_branch_id AS "employee.home_branch_id",
 		e.created_at AS "employee.created_at",
 		e.updated_at AS "employee.updated_at"
 	FROM inserted_work_log iwl
 	JOIN employees e ON iwl.employee_id = e.id`
 	query = d.db.Rebind(query)
-	args := []any{request.EmployeeID, request.PatientName}
+	args := []any{request.EmployeeID, request.BranchID, request.EmployeeID, request.PatientName, request.PatientID, request.PerformedAt}
 
 	if err := tx.GetContext(ctx, &workLog, query, args...); err != nil {
 		return WorkLog{}, fmt.Errorf("get context from db: %w", err)
@@ -306,43 +890,72 @@ func (d *DB) CreateWorkLog(ctx context.Context, request CreateWorkLogRequest) (w
 		return WorkLog{}, fmt.Errorf("create work log units: %w", err)
 	}
 
+	if err := webhook.Publish(ctx, tx, webhook.EventWorkLogCreated, workLog.ID, workLog); err != nil {
+		return WorkLog{}, fmt.Errorf("publish event: %w", err)
+	}
+
+	if err := audit.Record(ctx, tx, audit.ActionCreate, auditEntityWorkLog, workLog.ID, nil, workLog); err != nil {
+		return WorkLog{}, fmt.Errorf("record audit log: %w", err)
+	}
+
 	return workLog, nil
 }
 
+// CreateWorkLogUnitsWithQueryer creates work log units using the work type versions that applied
+// when the work log was performed. Returns ErrWorkTypeArchived if any work type is archived.
 func (d *DB) CreateWorkLogUnitsWithQueryer(ctx context.Context, queryer Queryer, workLogID int64, units []CreateWorkLogUnitRequest) ([]WorkLogUnit, error) {
+	workTypeIDs := make([]int64, len(units))
+	workOutcomes := make([]string, len(units))
+	for i, unit := range units {
+		workTypeIDs[i] = unit.WorkTypeID
+		workOutcomes[i] = unit.WorkOutcome
+	}
+
+	if err := d.checkWorkTypesUsable(ctx, queryer, workTypeIDs); err != nil {
+		return []WorkLogUnit{}, err
+	}
+
 	query := `
 	WITH inserted_work_log_units AS (
-		INSERT INTO work_log_units (work_log_id, work_type_id, work_outcome, work_multiplier)
+		INSERT INTO work_log_units (work_log_id, work_type_id, work_type_version_id, work_outcome, work_multiplier)
 		SELECT 
-			?, -- work_log_id
+			wl.id,
 			t.work_type_id,
+			wtv.id,
 			t.work_outcome,
-			wt.multiplier -- Set work_multiplier from work type's multiplier
+			wtv.multiplier -- Set work_multiplier from the work type version's multiplier
 		FROM unnest(?::bigint[], ?::text[]) AS t(work_type_id, work_outcome)
-		JOIN work_types wt ON t.work_type_id = wt.id
-		RETURNING id, work_type_id, work_outcome, work_multiplier
+		JOIN work_logs wl ON wl.id = ?
+		JOIN LATERAL (
+			SELECT v.id, v.multiplier
+			FROM work_type_versions v
+			WHERE v.work_type_id = t.work_type_id
+			AND (v.valid_from IS NULL OR v.valid_from <= wl.performed_at)
+			ORDER BY v.valid_from DESC NULLS LAST
+			LIMIT 1
+		) wtv ON TRUE
+		RETURNING id, work_type_id, work_type_version_id, work_outcome, work_multiplier, created_at
 	)
 	SELECT 
 		iwl.id AS "id", 
 		iwl.work_outcome AS "work_outcome",
 		iwl.work_multiplier AS "work_multiplier",
+		iwl.created_at AS "created_at",
 		wt.id AS "work_type.id",
-		wt.name AS "work_type.name",
-		wt.outcome_unit AS "work_type.outcome_unit",
-		wt.multiplier AS "work_type.multiplier",
-		wt.notes AS "work_type.notes"
+		wtv.name AS "work_type.name",
+		wtv.outcome_unit AS "work_type.outcome_unit",
+		wtv.multiplier AS "work_type.multiplier",
+		wtv.notes AS "work_type.notes",
+		wtv.outcome_schema AS "work_type.outcome_schema",
+		wtv.reference_ranges AS "work_type.reference_ranges",
+		wt.archived_at AS "work_type.archived_at",
+		wt.requires_competency AS "work_type.requires_competency"
 	FROM inserted_work_log_units iwl
-	JOIN work_types wt ON iwl.work_type_id = wt.id`
+	JOIN work_types wt ON iwl.work_type_id = wt.id
+	JOIN work_type_versions wtv ON iwl.work_type_version_id = wtv.id`
 	query = queryer.Rebind(query)
 
-	workTypeIDs := make([]int64, len(units))
-	workOutcomes := make([]string, len(units))
-	for i, unit := range units {
-		workTypeIDs[i] = unit.WorkTypeID
-		workOutcomes[i] = unit.WorkOutcome
-	}
-
-	args := []any{workLogID, workTypeIDs, workOutcomes}
+	args := []any{workTypeIDs, workOutcomes, workLogID}
 
 	var workLogUnits []WorkLogUnit
 	if err := queryer.SelectContext(ctx, &workLogUnits, query, args...); err != nil {
@@ -352,6 +965,38 @@ func (d *DB) CreateWorkLogUnitsWithQueryer(ctx context.Context, queryer Queryer,
 	return workLogUnits, nil
 }
 
+func (d *DB) checkWorkTypesUsable(ctx context.Context, queryer Queryer, workTypeIDs []int64) error {
+	query := `
+	SELECT id, name, archived_at
+	FROM work_types
+	WHERE id = ANY(?)`
+	query = queryer.Rebind(query)
+	args := []any{workTypeIDs}
+
+	var workTypes []WorkType
+	if err := queryer.SelectContext(ctx, &workTypes, query, args...); err != nil {
+		return fmt.Errorf("select context from db: %w", err)
+	}
+
+	workTypesByID := make(map[int64]WorkType, len(workTypes))
+	for _, workType := range workTypes {
+		workTypesByID[workType.ID] = workType
+	}
+
+	for _, workTypeID := range workTypeIDs {
+		workType, ok := workTypesByID[workTypeID]
+		if !ok {
+			return fmt.Errorf("%w: %d", ErrWorkTypeNotFound, workTypeID)
+		}
+
+		if workType.ArchivedAt != nil {
+			return fmt.Errorf("%w: %s", ErrWorkTypeArchived, workType.Name)
+		}
+	}
+
+	return nil
+}
+
 func (d *DB) GetWorkLogUnitsByWorkLogID(ctx context.Context, workLogID int64) ([]WorkLogUnit, error) {
 	workLogUnits, err := d.GetWorkLogUnitsByWorkLogIDs(ctx, []int64{workLogID})
 	if err != nil {
@@ -362,24 +1007,35 @@ func (d *DB) GetWorkLogUnitsByWorkLogID(ctx context.Context, workLogID int64) ([
 }
 
 func (d *DB) GetWorkLogUnitsByWorkLogIDs(ctx context.Context, workLogIDs []int64) (map[int64][]WorkLogUnit, error) {
+	return d.getWorkLogUnits(ctx, d.db, "wlu.deleted_at IS NULL AND wlu.work_log_id = ANY(?)", workLogIDs)
+}
+
+// getWorkLogUnits returns the work log units matching the condition, grouped by work log ID.
+func (d *DB) getWorkLogUnits(ctx context.Context, queryer Queryer, condition string, args ...any) (map[int64][]WorkLogUnit, error) {
 	query := `
 	SELECT 
 		wlu.id AS "id",
 		wlu.work_outcome AS "work_outcome",
 		wlu.work_log_id,
 		wlu.work_multiplier,
+		wlu.created_at,
 		wlu.deleted_at,
 		wlu.deleted_by,
+		wlu.replaces_unit_id,
 		wt.id AS "work_type.id",
-		wt.name AS "work_type.name",
-		wt.outcome_unit AS "work_type.outcome_unit",
-		wt.multiplier AS "work_type.multiplier",
-		wt.notes AS "work_type.notes"
+		wtv.name AS "work_type.name",
+		wtv.outcome_unit AS "work_type.outcome_unit",
+		wtv.multiplier AS "work_type.multiplier",
+		wtv.notes AS "work_type.notes",
+		wtv.outcome_schema AS "work_type.outcome_schema",
+		wtv.reference_ranges AS "work_type.reference_ranges",
+		wt.archived_at AS "work_type.archived_at",
+		wt.requires_competency AS "work_type.requires_competency"
 	FROM work_log_units wlu
 	JOIN work_types wt ON wlu.work_type_id = wt.id
-	WHERE wlu.deleted_at IS NULL AND wlu.work_log_id = ANY(?)`
-	query = d.db.Rebind(query)
-	args := []any{workLogIDs}
+	JOIN work_type_versions wtv ON wlu.work_type_version_id = wtv.id
+	WHERE ` + condition
+	query = queryer.Rebind(query)
 
 	type workLogUnitWithWorkLogID struct {
 		WorkLogUnit
@@ -387,7 +1043,7 @@ func (d *DB) GetWorkLogUnitsByWorkLogIDs(ctx context.Context, workLogIDs []int64
 	}
 
 	var workLogUnits []workLogUnitWithWorkLogID
-	if err := d.db.SelectContext(ctx, &workLogUnits, query, args...); err != nil {
+	if err := queryer.SelectContext(ctx, &workLogUnits, query, args...); err != nil {
 		return nil, fmt.Errorf("select context from db: %w", err)
 	}
 
@@ -399,116 +1055,1115 @@ func (d *DB) GetWorkLogUnitsByWorkLogIDs(ctx context.Context, workLogIDs []int64
 	return result, nil
 }
 
-func (d *DB) GetWorkTypes(ctx context.Context) ([]WorkType, error) {
-	query := `
-	SELECT id, name, outcome_unit, multiplier, notes
-	FROM work_types`
-	query = d.db.Rebind(query)
+// UpdateWorkLog applies an edit to a work log and records it as a revision.
+// Modified units are inserted as new versions and the superseded versions are soft deleted by the editor.
+func (d *DB) UpdateWorkLog(ctx context.Context, id int64, editorID int64, request UpdateWorkLogRequest) (revision WorkLogRevision, returnedErr error) {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return WorkLogRevision{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		if returnedErr != nil {
+			_ = tx.Rollback()
+		} else if err := tx.Commit(); err != nil {
+			returnedErr = fmt.Errorf("commit tx: %w", err)
+		}
+	}()
 
-	var workTypes []WorkType
-	if err := d.db.SelectContext(ctx, &workTypes, query); err != nil {
-		return []WorkType{}, fmt.Errorf("select context from db: %w", err)
+	var patientNameBefore string
+	lockQuery := tx.Rebind(`SELECT patient_name FROM work_logs WHERE id = ? AND deleted_at IS NULL FOR UPDATE`)
+	if err := tx.GetContext(ctx, &patientNameBefore, lockQuery, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return WorkLogRevision{}, ErrWorkLogNotFound
+		}
+		return WorkLogRevision{}, fmt.Errorf("lock work log: %w", err)
 	}
 
-	return workTypes, nil
+	before, err := d.getWorkLog(ctx, tx, id, false)
+	if err != nil {
+		return WorkLogRevision{}, fmt.Errorf("get work log: %w", err)
+	}
+
+	patientNameAfter := patientNameBefore
+	if request.PatientName != nil {
+		patientNameAfter = *request.PatientName
+	}
+
+	patientIDBefore, patientIDAfter := before.PatientID, before.PatientID
+	if request.PatientID != nil {
+		patientIDAfter = request.PatientID
+	}
+
+	revisionQuery := tx.Rebind(`
+	INSERT INTO work_log_revisions (work_log_id, edited_by, patient_name_before, patient_name_after, patient_id_before, patient_id_after)
+	VALUES (?, ?, ?, ?, ?, ?)
+	RETURNING id, work_log_id, edited_by, patient_name_before, patient_name_after, patient_id_before, patient_id_after, created_at`)
+	revisionArgs := []any{id, editorID, patientNameBefore, patientNameAfter, patientIDBefore, patientIDAfter}
+	if err := tx.GetContext(ctx, &revision, revisionQuery, revisionArgs...); err != nil {
+		return WorkLogRevision{}, fmt.Errorf("insert revision: %w", err)
+	}
+
+	if patientNameAfter != patientNameBefore {
+		query := tx.Rebind(`UPDATE work_logs SET patient_name = ? WHERE id = ?`)
+		if _, err := tx.ExecContext(ctx, query, patientNameAfter, id); err != nil {
+			return WorkLogRevision{}, fmt.Errorf("update patient name: %w", err)
+		}
+	}
+
+	if request.PatientID != nil {
+		query := tx.Rebind(`UPDATE work_logs SET patient_id = ? WHERE id = ?`)
+		if _, err := tx.ExecContext(ctx, query, *request.PatientID, id); err != nil {
+			return WorkLogRevision{}, fmt.Errorf("update patient id: %w", err)
+		}
+	}
+
+	supersededUnitIDs := make([]int64, 0, len(request.RemoveUnitIDs)+len(request.UpdateUnits))
+	supersededUnitIDs = append(supersededUnitIDs, request.RemoveUnitIDs...)
+	for _, unit := range request.UpdateUnits {
+		supersededUnitIDs = append(supersededUnitIDs, unit.ID)
+	}
+
+	if err := d.supersedeWorkLogUnits(ctx, tx, id, revision.ID, editorID, supersededUnitIDs); err != nil {
+		return WorkLogRevision{}, fmt.Errorf("supersede work log units: %w", err)
+	}
+
+	if err := d.insertWorkLogUnitVersions(ctx, tx, revision.ID, request.UpdateUnits); err != nil {
+		return WorkLogRevision{}, fmt.Errorf("insert work log unit versions: %w", err)
+	}
+
+	if len(request.AddUnits) > 0 {
+		added, err := d.CreateWorkLogUnitsWithQueryer(ctx, tx, id, request.AddUnits)
+		if err != nil {
+			return WorkLogRevision{}, fmt.Errorf("create work log units: %w", err)
+		}
+
+		addedIDs := make([]int64, len(added))
+		for i, unit := range added {
+			addedIDs[i] = unit.ID
+		}
+
+		query := tx.Rebind(`UPDATE work_log_units SET added_in_revision_id = ? WHERE id = ANY(?)`)
+		if _, err := tx.ExecContext(ctx, query, revision.ID, addedIDs); err != nil {
+			return WorkLogRevision{}, fmt.Errorf("mark added units: %w", err)
+		}
+	}
+
+	var remainingUnits int
+	countQuery := tx.Rebind(`SELECT COUNT(*) FROM work_log_units WHERE work_log_id = ? AND deleted_at IS NULL`)
+	if err := tx.GetContext(ctx, &remainingUnits, countQuery, id); err != nil {
+		return WorkLogRevision{}, fmt.Errorf("count remaining units: %w", err)
+	}
+
+	if remainingUnits == 0 {
+		return WorkLogRevision{}, ErrWorkLogWithoutUnits
+	}
+
+	after, err := d.getWorkLog(ctx, tx, id, false)
+	if err != nil {
+		return WorkLogRevision{}, fmt.Errorf("get updated work log: %w", err)
+	}
+
+	if err := webhook.Publish(ctx, tx, webhook.EventWorkLogUpdated, id, after); err != nil {
+		return WorkLogRevision{}, fmt.Errorf("publish event: %w", err)
+	}
+
+	if err := audit.Record(ctx, tx, audit.ActionUpdate, auditEntityWorkLog, id, before, after); err != nil {
+		return WorkLogRevision{}, fmt.Errorf("record audit log: %w", err)
+	}
+
+	return revision, nil
 }
 
-func (d *DB) GetWorkType(ctx context.Context, id int64) (WorkType, error) {
-	return d.GetWorkTypeQueryer(ctx, d.db, id)
+// supersedeWorkLogUnits soft deletes live units of a work log as part of a revision.
+// Returns ErrWorkLogUnitNotFound if any unit is not a live unit of the work log.
+func (d *DB) supersedeWorkLogUnits(ctx context.Context, tx *sqlx.Tx, workLogID int64, revisionID int64, editorID int64, unitIDs []int64) error {
+	if len(unitIDs) == 0 {
+		return nil
+	}
+
+	query := tx.Rebind(`
+	UPDATE work_log_units
+	SET deleted_at = CURRENT_TIMESTAMP, deleted_by = ?, removed_in_revision_id = ?
+	WHERE work_log_id = ? AND id = ANY(?) AND deleted_at IS NULL`)
+	args := []any{editorID, revisionID, workLogID, unitIDs}
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("exec context to db: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+
+	if rowsAffected != int64(len(unitIDs)) {
+		return ErrWorkLogUnitNotFound
+	}
+
+	return nil
 }
 
-func (d *DB) GetWorkTypeQueryer(ctx context.Context, queryer Queryer, id int64) (WorkType, error) {
-	query := `
-	SELECT id, name, outcome_unit, multiplier
-	FROM work_types
-	WHERE id = ?`
-	query = queryer.Rebind(query)
-	args := []any{id}
+// insertWorkLogUnitVersions inserts new versions of modified units, keeping the work type version and
+// multiplier of the unit they replace so salary attribution does not change.
+func (d *DB) insertWorkLogUnitVersions(ctx context.Context, tx *sqlx.Tx, revisionID int64, units []UpdateWorkLogUnitRequest) error {
+	if len(units) == 0 {
+		return nil
+	}
 
-	var workType WorkType
-	if err := queryer.GetContext(ctx, &workType, query, args...); err != nil {
-		return WorkType{}, fmt.Errorf("get context from db: %w", err)
+	query := tx.Rebind(`
+	INSERT INTO work_log_units (work_log_id, work_type_id, work_type_version_id, work_outcome, work_multiplier, replaces_unit_id, added_in_revision_id)
+	SELECT wlu.work_log_id, wlu.work_type_id, wlu.work_type_version_id, t.work_outcome, wlu.work_multiplier, wlu.id, ?
+	FROM unnest(?::bigint[], ?::text[]) AS t(unit_id, work_outcome)
+	JOIN work_log_units wlu ON wlu.id = t.unit_id`)
+
+	unitIDs := make([]int64, len(units))
+	workOutcomes := make([]string, len(units))
+	for i, unit := range units {
+		unitIDs[i] = unit.ID
+		workOutcomes[i] = unit.WorkOutcome
 	}
 
-	return workType, nil
+	if _, err := tx.ExecContext(ctx, query, revisionID, unitIDs, workOutcomes); err != nil {
+		return fmt.Errorf("exec context to db: %w", err)
+	}
+
+	return nil
 }
 
-func (d *DB) CreateWorkType(ctx context.Context, request CreateWorkTypeRequest) (WorkType, error) {
+// GetWorkLogRevisions returns the revisions of a work log, oldest first, with the units each revision added and removed.
+func (d *DB) GetWorkLogRevisions(ctx context.Context, workLogID int64) ([]WorkLogRevision, error) {
 	query := `
-	INSERT INTO work_types (name, outcome_unit, multiplier, notes)
-	VALUES (?, ?, ?, ?)
-	RETURNING id, name, outcome_unit, multiplier, notes`
+	SELECT id, work_log_id, edited_by, patient_name_before, patient_name_after, patient_id_before, patient_id_after, created_at
+	FROM work_log_revisions
+	WHERE work_log_id = ?
+	ORDER BY id ASC`
 	query = d.db.Rebind(query)
-	args := []any{request.Name, request.OutcomeUnit, request.Multiplier, request.Notes}
+	args := []any{workLogID}
 
-	var workType WorkType
-	if err := d.db.GetContext(ctx, &workType, query, args...); err != nil {
-		return WorkType{}, fmt.Errorf("get context from db: %w", err)
+	var revisions []WorkLogRevision
+	if err := d.db.SelectContext(ctx, &revisions, query, args...); err != nil {
+		return nil, fmt.Errorf("select context from db: %w", err)
 	}
 
-	return workType, nil
+	if len(revisions) == 0 {
+		return []WorkLogRevision{}, nil
+	}
+
+	unitsQuery := `
+	SELECT 
+		wlu.id AS "id",
+		wlu.work_outcome AS "work_outcome",
+		wlu.work_multiplier,
+		wlu.created_at,
+		wlu.deleted_at,
+		wlu.deleted_by,
+		wlu.replaces_unit_id,
+		wlu.added_in_revision_id,
+		wlu.removed_in_revision_id,
+		wt.id AS "work_type.id",
+		wtv.name AS "work_type.name",
+		wtv.outcome_unit AS "work_type.outcome_unit",
+		wtv.multiplier AS "work_type.multiplier",
+		wtv.notes AS "work_type.notes",
+		wtv.outcome_schema AS "work_type.outcome_schema",
+		wtv.reference_ranges AS "work_type.reference_ranges",
+		wt.archived_at AS "work_type.archived_at",
+		wt.requires_competency AS "work_type.requires_competency"
+	FROM work_log_units wlu
+	JOIN work_types wt ON wlu.work_type_id = wt.id
+	JOIN work_type_versions wtv ON wlu.work_type_version_id = wtv.id
+	WHERE wlu.work_log_id = ?
+	AND (wlu.added_in_revision_id IS NOT NULL OR wlu.removed_in_revision_id IS NOT NULL)
+	ORDER BY wlu.id ASC`
+	unitsQuery = d.db.Rebind(unitsQuery)
+
+	type revisionUnit struct {
+		WorkLogUnit
+		AddedInRevisionID   *int64 `db:"added_in_revision_id"`
+		RemovedInRevisionID *int64 `db:"removed_in_revision_id"`
+	}
+
+	var units []revisionUnit
+	if err := d.db.SelectContext(ctx, &units, unitsQuery, args...); err != nil {
+		return nil, fmt.Errorf("select revision units from db: %w", err)
+	}
+
+	revisionIndexByID := make(map[int64]int, len(revisions))
+	for i, revision := range revisions {
+		revisionIndexByID[revision.ID] = i
+	}
+
+	for _, unit := range units {
+		if unit.AddedInRevisionID != nil {
+			i := revisionIndexByID[*unit.AddedInRevisionID]
+			revisions[i].AddedUnits = append(revisions[i].AddedUnits, unit.WorkLogUnit)
+		}
+
+		if unit.RemovedInRevisionID != nil {
+			i := revisionIndexByID[*unit.RemovedInRevisionID]
+			revisions[i].RemovedUnits = append(revisions[i].RemovedUnits, unit.WorkLogUnit)
+		}
+	}
+
+	return revisions, nil
 }
 
-func (d *DB) DeleteWorkLog(ctx context.Context, id int64, employeeID int64) error {
+func (d *DB) CreatePatient(ctx context.Context, request CreatePatientRequest) (patient Patient, returnedErr error) {
 	tx, err := d.db.BeginTxx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("begin tx: %w", err)
+		return Patient{}, fmt.Errorf("begin tx: %w", err)
 	}
 	defer func() {
-		if err != nil {
+		if returnedErr != nil {
 			_ = tx.Rollback()
-		} else {
-			_ = tx.Commit()
+		} else if err := tx.Commit(); err != nil {
+			returnedErr = fmt.Errorf("commit tx: %w", err)
 		}
 	}()
 
-	if err := d.softDeleteWorkLogUnits(ctx, tx, id, employeeID); err != nil {
-		return fmt.Errorf("soft delete work log units: %w", err)
+	query := `
+	INSERT INTO patients (name, phone, birth_year, gender)
+	VALUES (?, ?, ?, ?)
+	RETURNING id, name, phone, birth_year, gender, created_at, updated_at`
+	query = tx.Rebind(query)
+	args := []any{request.Name, request.Phone, request.BirthYear, request.Gender}
+
+	if err := tx.GetContext(ctx, &patient, query, args...); err != nil {
+		return Patient{}, fmt.Errorf("get context from db: %w", err)
 	}
 
-	if err := d.softDeleteWorkLog(ctx, tx, id, employeeID); err != nil {
-		return fmt.Errorf("soft delete work log: %w", err)
+	if err := audit.Record(ctx, tx, audit.ActionCreate, auditEntityPatient, patient.ID, nil, patient); err != nil {
+		return Patient{}, fmt.Errorf("record audit log: %w", err)
 	}
 
-	return nil
+	return patient, nil
 }
 
-func (d *DB) softDeleteWorkLogUnits(ctx context.Context, tx *sqlx.Tx, workLogID int64, employeeID int64) error {
+func (d *DB) GetPatient(ctx context.Context, id int64) (Patient, error) {
 	query := `
-	UPDATE work_log_units 
-	SET deleted_at = CURRENT_TIMESTAMP, deleted_by = ?
-	WHERE work_log_id = ? AND deleted_at IS NULL`
-	query = tx.Rebind(query)
-	args := []any{employeeID, workLogID}
+	SELECT id, name, phone, birth_year, gender, created_at, updated_at
+	FROM patients
+	WHERE id = ?`
+	query = d.db.Rebind(query)
+	args := []any{id}
 
-	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
-		return fmt.Errorf("exec context to db: %w", err)
+	var patient Patient
+	if err := d.db.GetContext(ctx, &patient, query, args...); err != nil {
+		return Patient{}, fmt.Errorf("get context from db: %w", err)
 	}
 
-	return nil
+	return patient, nil
 }
 
-func (d *DB) softDeleteWorkLog(ctx context.Context, tx *sqlx.Tx, id int64, employeeID int64) error {
+// SearchPatients returns patients whose name or phone starts with the query, for typeahead.
+func (d *DB) SearchPatients(ctx context.Context, request SearchPatientsRequest) ([]Patient, error) {
 	query := `
-	UPDATE work_logs 
-	SET deleted_at = CURRENT_TIMESTAMP, deleted_by = ?
-	WHERE id = ? AND deleted_at IS NULL`
-	query = tx.Rebind(query)
-	args := []any{employeeID, id}
+	SELECT id, name, phone, birth_year, gender, created_at, updated_at
+	FROM patients
+	WHERE LOWER(name) LIKE ? OR phone LIKE ?
+	ORDER BY name ASC, id ASC
+	LIMIT ?`
+	query = d.db.Rebind(query)
 
-	result, err := tx.ExecContext(ctx, query, args...)
-	if err != nil {
-		return fmt.Errorf("exec context to db: %w", err)
-	}
+	pattern := escapeLikePattern(strings.ToLower(request.Query)) + "%"
+	args := []any{pattern, pattern, request.Limit}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("rows affected: %w", err)
+	var patients []Patient
+	if err := d.db.SelectContext(ctx, &patients, query, args...); err != nil {
+		return []Patient{}, fmt.Errorf("select context from db: %w", err)
 	}
 
-	if rowsAffected == 0 {
-		return ErrWorkLogNotFound
-	}
+	return patients, nil
+}
 
-	return nil
+func escapeLikePattern(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+func (d *DB) GetWorkTypes(ctx context.Context, includeArchived bool) ([]WorkType, error) {
+	query := `
+	SELECT id, name, outcome_unit, multiplier, notes, outcome_schema, reference_ranges, archived_at, requires_competency
+	FROM work_types
+	WHERE ? OR archived_at IS NULL`
+	query = d.db.Rebind(query)
+	args := []any{includeArchived}
+
+	var workTypes []WorkType
+	if err := d.db.SelectContext(ctx, &workTypes, query, args...); err != nil {
+		return []WorkType{}, fmt.Errorf("select context from db: %w", err)
+	}
+
+	return workTypes, nil
+}
+
+func (d *DB) GetWorkTypesByIDs(ctx context.Context, ids []int64) ([]WorkType, error) {
+	if len(ids) == 0 {
+		return []WorkType{}, nil
+	}
+
+	query := `
+	SELECT id, name, outcome_unit, multiplier, notes, outcome_schema, reference_ranges, archived_at, requires_competency
+	FROM work_types
+	WHERE id IN (?)`
+
+	query, args, err := sqlx.In(query, ids)
+	if err != nil {
+		return nil, fmt.Errorf("sqlx.In: %w", err)
+	}
+
+	query = d.db.Rebind(query)
+
+	var workTypes []WorkType
+	if err := d.db.SelectContext(ctx, &workTypes, query, args...); err != nil {
+		return nil, fmt.Errorf("select context from db: %w", err)
+	}
+
+	return workTypes, nil
+}
+
+func (d *DB) GetWorkType(ctx context.Context, id int64) (WorkType, error) {
+	return d.GetWorkTypeQueryer(ctx, d.db, id)
+}
+
+func (d *DB) GetWorkTypeQueryer(ctx context.Context, queryer Queryer, id int64) (WorkType, error) {
+	query := `
+	SELECT id, name, outcome_unit, multiplier, notes, outcome_schema, reference_ranges, archived_at, requires_competency
+	FROM work_types
+	WHERE id = ?`
+	query = queryer.Rebind(query)
+	args := []any{id}
+
+	var workType WorkType
+	if err := queryer.GetContext(ctx, &workType, query, args...); err != nil {
+		return WorkType{}, fmt.Errorf("get context from db: %w", err)
+	}
+
+	return workType, nil
+}
+
+func (d *DB) CreateWorkType(ctx context.Context, request CreateWorkTypeRequest) (workType WorkType, returnedErr error) {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return WorkType{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		if returnedErr != nil {
+			_ = tx.Rollback()
+		} else if err := tx.Commit(); err != nil {
+			returnedErr = fmt.Errorf("commit tx: %w", err)
+		}
+	}()
+
+	query := `
+	INSERT INTO work_types (name, outcome_unit, multiplier, notes, outcome_schema, reference_ranges, requires_competency)
+	VALUES (?, ?, ?, ?, ?, ?, ?)
+	RETURNING id, name, outcome_unit, multiplier, notes, outcome_schema, reference_ranges, archived_at, requires_competency`
+	query = tx.Rebind(query)
+	args := []any{request.Name, request.OutcomeUnit, request.Multiplier, request.Notes, request.OutcomeSchema, request.ReferenceRanges, request.RequiresCompetency}
+
+	if err := tx.GetContext(ctx, &workType, query, args...); err != nil {
+		return WorkType{}, fmt.Errorf("get context from db: %w", err)
+	}
+
+	// The first version applies to every work log, however far it is backdated.
+	if _, err := d.createWorkTypeVersion(ctx, tx, workType, nil, nil); err != nil {
+		return WorkType{}, fmt.Errorf("create work type version: %w", err)
+	}
+
+	if err := audit.Record(ctx, tx, audit.ActionCreate, auditEntityWorkType, workType.ID, nil, workType); err != nil {
+		return WorkType{}, fmt.Errorf("record audit log: %w", err)
+	}
+
+	return workType, nil
+}
+
+// UpdateWorkType changes a work type. Changes to its definition start a new version of it from now.
+func (d *DB) UpdateWorkType(ctx context.Context, id int64, request UpdateWorkTypeRequest, editorID *int64) (workType WorkType, returnedErr error) {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return WorkType{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		if returnedErr != nil {
+			_ = tx.Rollback()
+		} else if err := tx.Commit(); err != nil {
+			returnedErr = fmt.Errorf("commit tx: %w", err)
+		}
+	}()
+
+	lockQuery := tx.Rebind(`
+	SELECT id, name, outcome_unit, multiplier, notes, outcome_schema, reference_ranges, archived_at, requires_competency
+	FROM work_types
+	WHERE id = ?
+	FOR UPDATE`)
+	if err := tx.GetContext(ctx, &workType, lockQuery, id); err != nil {
+		return WorkType{}, fmt.Errorf("lock work type: %w", err)
+	}
+
+	before := workType
+
+	if request.Name != nil {
+		workType.Name = *request.Name
+	}
+	if request.OutcomeUnit != nil {
+		workType.OutcomeUnit = *request.OutcomeUnit
+	}
+	if request.Multiplier != nil {
+		workType.Multiplier = *request.Multiplier
+	}
+	if request.Notes != nil {
+		workType.Notes = *request.Notes
+	}
+	if request.OutcomeSchema != nil {
+		workType.OutcomeSchema = *request.OutcomeSchema
+	}
+	if request.ReferenceRanges != nil {
+		workType.ReferenceRanges = *request.ReferenceRanges
+	}
+	if request.RequiresCompetency != nil {
+		workType.RequiresCompetency = *request.RequiresCompetency
+	}
+
+	query := `
+	UPDATE work_types
+	SET name = ?, outcome_unit = ?, multiplier = ?, notes = ?, outcome_schema = ?, reference_ranges = ?, requires_competency = ?
+	WHERE id = ?`
+	query = tx.Rebind(query)
+	args := []any{workType.Name, workType.OutcomeUnit, workType.Multiplier, workType.Notes, workType.OutcomeSchema, workType.ReferenceRanges, workType.RequiresCompetency, id}
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return WorkType{}, fmt.Errorf("exec context to db: %w", err)
+	}
+
+	if request.changesDefinition() {
+		now := time.Now()
+		if _, err := d.createWorkTypeVersion(ctx, tx, workType, &now, editorID); err != nil {
+			return WorkType{}, fmt.Errorf("create work type version: %w", err)
+		}
+	}
+
+	if err := audit.Record(ctx, tx, audit.ActionUpdate, auditEntityWorkType, workType.ID, before, workType); err != nil {
+		return WorkType{}, fmt.Errorf("record audit log: %w", err)
+	}
+
+	return workType, nil
+}
+
+func (d *DB) createWorkTypeVersion(ctx context.Context, queryer Queryer, workType WorkType, validFrom *time.Time, createdBy *int64) (WorkTypeVersion, error) {
+	query := `
+	INSERT INTO work_type_versions (work_type_id, name, outcome_unit, multiplier, notes, outcome_schema, reference_ranges, valid_from, created_by)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	RETURNING id, work_type_id, name, outcome_unit, multiplier, notes, outcome_schema, reference_ranges, valid_from, created_by, created_at`
+	query = queryer.Rebind(query)
+	args := []any{workType.ID, workType.Name, workType.OutcomeUnit, workType.Multiplier, workType.Notes, workType.OutcomeSchema, workType.ReferenceRanges, validFrom, createdBy}
+
+	var version WorkTypeVersion
+	if err := queryer.GetContext(ctx, &version, query, args...); err != nil {
+		return WorkTypeVersion{}, fmt.Errorf("get context from db: %w", err)
+	}
+
+	return version, nil
+}
+
+// SetWorkTypeArchived archives or restores a work type. Returns sql.ErrNoRows if the work type does not exist.
+func (d *DB) SetWorkTypeArchived(ctx context.Context, id int64, archived bool) (workType WorkType, returnedErr error) {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return WorkType{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		if returnedErr != nil {
+			_ = tx.Rollback()
+		} else if err := tx.Commit(); err != nil {
+			returnedErr = fmt.Errorf("commit tx: %w", err)
+		}
+	}()
+
+	lockQuery := tx.Rebind(`
+	SELECT id, name, outcome_unit, multiplier, notes, outcome_schema, reference_ranges, archived_at, requires_competency
+	FROM work_types
+	WHERE id = ?
+	FOR UPDATE`)
+	var before WorkType
+	if err := tx.GetContext(ctx, &before, lockQuery, id); err != nil {
+		return WorkType{}, fmt.Errorf("lock work type: %w", err)
+	}
+
+	query := `
+	UPDATE work_types
+	SET archived_at = CASE WHEN ? THEN COALESCE(archived_at, CURRENT_TIMESTAMP) END
+	WHERE id = ?
+	RETURNING id, name, outcome_unit, multiplier, notes, outcome_schema, reference_ranges, archived_at, requires_competency`
+	query = tx.Rebind(query)
+	args := []any{archived, id}
+
+	if err := tx.GetContext(ctx, &workType, query, args...); err != nil {
+		return WorkType{}, fmt.Errorf("get context from db: %w", err)
+	}
+
+	if err := audit.Record(ctx, tx, audit.ActionUpdate, auditEntityWorkType, workType.ID, before, workType); err != nil {
+		return WorkType{}, fmt.Errorf("record audit log: %w", err)
+	}
+
+	return workType, nil
+}
+
+// GetWorkTypeVersions returns the versions of a work type, oldest first.
+func (d *DB) GetWorkTypeVersions(ctx context.Context, workTypeID int64) ([]WorkTypeVersion, error) {
+	query := `
+	SELECT id, work_type_id, name, outcome_unit, multiplier, notes, outcome_schema, reference_ranges, valid_from, created_by, created_at
+	FROM work_type_versions
+	WHERE work_type_id = ?
+	ORDER BY id ASC`
+	query = d.db.Rebind(query)
+	args := []any{workTypeID}
+
+	var versions []WorkTypeVersion
+	if err := d.db.SelectContext(ctx, &versions, query, args...); err != nil {
+		return []WorkTypeVersion{}, fmt.Errorf("select context from db: %w", err)
+	}
+
+	return versions, nil
+}
+
+// GetWorkTypeVersionsAt returns the versions of the work types in effect at the time, keyed by work type ID.
+// Work types that do not exist are left out.
+func (d *DB) GetWorkTypeVersionsAt(ctx context.Context, workTypeIDs []int64, at time.Time) (map[int64]WorkTypeVersion, error) {
+	query := `
+	SELECT wtv.id, wtv.work_type_id, wtv.name, wtv.outcome_unit, wtv.multiplier, wtv.notes, wtv.outcome_schema, wtv.reference_ranges, wtv.valid_from, wtv.created_by, wtv.created_at
+	FROM unnest(?::bigint[]) AS t(work_type_id)
+	JOIN LATERAL (
+		SELECT v.*
+		FROM work_type_versions v
+		WHERE v.work_type_id = t.work_type_id
+		AND (v.valid_from IS NULL OR v.valid_from <= ?)
+		ORDER BY v.valid_from DESC NULLS LAST
+		LIMIT 1
+	) wtv ON TRUE`
+	query = d.db.Rebind(query)
+	args := []any{workTypeIDs, at}
+
+	var versions []WorkTypeVersion
+	if err := d.db.SelectContext(ctx, &versions, query, args...); err != nil {
+		return nil, fmt.Errorf("select context from db: %w", err)
+	}
+
+	result := make(map[int64]WorkTypeVersion, len(versions))
+	for _, version := range versions {
+		result[version.WorkTypeID] = version
+	}
+
+	return result, nil
+}
+
+func (d *DB) GetEmployeeCompetencies(ctx context.Context, employeeID int64) ([]Competency, error) {
+	query := `
+	SELECT 
+		ec.id, ec.employee_id, e.name AS "employee_name", ec.work_type_id, wt.name AS "work_type_name",
+		ec.certified_at, ec.expires_at, ec.created_at, ec.updated_at
+	FROM employee_competencies ec
+	JOIN employees e ON ec.employee_id = e.id
+	JOIN work_types wt ON ec.work_type_id = wt.id
+	WHERE ec.employee_id = ?
+	ORDER BY wt.name ASC`
+	query = d.db.Rebind(query)
+	args := []any{employeeID}
+
+	var competencies []Competency
+	if err := d.db.SelectContext(ctx, &competencies, query, args...); err != nil {
+		return []Competency{}, fmt.Errorf("select context from db: %w", err)
+	}
+
+	return competencies, nil
+}
+
+func (d *DB) GetEmployeeCompetency(ctx context.Context, employeeID int64, workTypeID int64) (Competency, error) {
+	return d.getEmployeeCompetency(ctx, d.db, employeeID, workTypeID, "")
+}
+
+// getEmployeeCompetency returns the competency of the employee for the work type, with the locking clause appended.
+func (d *DB) getEmployeeCompetency(ctx context.Context, queryer Queryer, employeeID int64, workTypeID int64, locking string) (Competency, error) {
+	query := `
+	SELECT 
+		ec.id, ec.employee_id, e.name AS "employee_name", ec.work_type_id, wt.name AS "work_type_name",
+		ec.certified_at, ec.expires_at, ec.created_at, ec.updated_at
+	FROM employee_competencies ec
+	JOIN employees e ON ec.employee_id = e.id
+	JOIN work_types wt ON ec.work_type_id = wt.id
+	WHERE ec.employee_id = ? AND ec.work_type_id = ?
+	` + locking
+	query = queryer.Rebind(query)
+	args := []any{employeeID, workTypeID}
+
+	var competency Competency
+	if err := queryer.GetContext(ctx, &competency, query, args...); err != nil {
+		return Competency{}, fmt.Errorf("get context from db: %w", err)
+	}
+
+	return competency, nil
+}
+
+// GetCompetenciesExpiringBefore returns competencies expiring on or before the date, including expired ones.
+func (d *DB) GetCompetenciesExpiringBefore(ctx context.Context, before date.Date) ([]Competency, error) {
+	query := `
+	SELECT 
+		ec.id, ec.employee_id, e.name AS "employee_name", ec.work_type_id, wt.name AS "work_type_name",
+		ec.certified_at, ec.expires_at, ec.created_at, ec.updated_at
+	FROM employee_competencies ec
+	JOIN employees e ON ec.employee_id = e.id
+	JOIN work_types wt ON ec.work_type_id = wt.id
+	WHERE ec.expires_at <= ?
+	ORDER BY ec.expires_at ASC, e.name ASC`
+	query = d.db.Rebind(query)
+	args := []any{before}
+
+	var competencies []Competency
+	if err := d.db.SelectContext(ctx, &competencies, query, args...); err != nil {
+		return []Competency{}, fmt.Errorf("select context from db: %w", err)
+	}
+
+	return competencies, nil
+}
+
+// UpsertEmployeeCompetency creates or replaces the competency of the employee for the work type and returns it.
+func (d *DB) UpsertEmployeeCompetency(ctx context.Context, request SetCompetencyRequest) (competency Competency, returnedErr error) {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return Competency{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		if returnedErr != nil {
+			_ = tx.Rollback()
+		} else if err := tx.Commit(); err != nil {
+			returnedErr = fmt.Errorf("commit tx: %w", err)
+		}
+	}()
+
+	before, err := d.getEmployeeCompetency(ctx, tx, request.EmployeeID, request.WorkTypeID, "FOR UPDATE OF ec")
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return Competency{}, fmt.Errorf("lock employee competency: %w", err)
+	}
+	exists := err == nil
+
+	query := `
+	INSERT INTO employee_competencies (employee_id, work_type_id, certified_at, expires_at)
+	VALUES (?, ?, ?, ?)
+	ON CONFLICT (employee_id, work_type_id) DO UPDATE
+	SET certified_at = EXCLUDED.certified_at, expires_at = EXCLUDED.expires_at, updated_at = CURRENT_TIMESTAMP`
+	query = tx.Rebind(query)
+	args := []any{request.EmployeeID, request.WorkTypeID, request.CertifiedAt, request.ExpiresAt}
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return Competency{}, fmt.Errorf("exec context to db: %w", err)
+	}
+
+	competency, err = d.getEmployeeCompetency(ctx, tx, request.EmployeeID, request.WorkTypeID, "")
+	if err != nil {
+		return Competency{}, fmt.Errorf("get employee competency: %w", err)
+	}
+
+	if exists {
+		err = audit.Record(ctx, tx, audit.ActionUpdate, auditEntityCompetency, competency.ID, before, competency)
+	} else {
+		err = audit.Record(ctx, tx, audit.ActionCreate, auditEntityCompetency, competency.ID, nil, competency)
+	}
+	if err != nil {
+		return Competency{}, fmt.Errorf("record audit log: %w", err)
+	}
+
+	return competency, nil
+}
+
+// DeleteEmployeeCompetency deletes the competency of the employee for the work type.
+// Returns sql.ErrNoRows if the employee has no competency for it.
+func (d *DB) DeleteEmployeeCompetency(ctx context.Context, employeeID int64, workTypeID int64) (returnedErr error) {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		if returnedErr != nil {
+			_ = tx.Rollback()
+		} else if err := tx.Commit(); err != nil {
+			returnedErr = fmt.Errorf("commit tx: %w", err)
+		}
+	}()
+
+	competency, err := d.getEmployeeCompetency(ctx, tx, employeeID, workTypeID, "FOR UPDATE OF ec")
+	if err != nil {
+		return fmt.Errorf("lock employee competency: %w", err)
+	}
+
+	query := `
+	DELETE FROM employee_competencies
+	WHERE id = ?`
+	query = tx.Rebind(query)
+	args := []any{competency.ID}
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("exec context to db: %w", err)
+	}
+
+	if err := audit.Record(ctx, tx, audit.ActionDelete, auditEntityCompetency, competency.ID, competency, nil); err != nil {
+		return fmt.Errorf("record audit log: %w", err)
+	}
+
+	return nil
+}
+
+// GetWorkTypesWithoutCompetency returns the work types among workTypeIDs that require a competency
+// the employee does not hold on the given date.
+func (d *DB) GetWorkTypesWithoutCompetency(ctx context.Context, employeeID int64, workTypeIDs []int64, on date.Date) ([]WorkType, error) {
+	query := `
+	SELECT wt.id, wt.name
+	FROM work_types wt
+	WHERE wt.id = ANY(?)
+	AND wt.requires_competency
+	AND NOT EXISTS (
+		SELECT 1
+		FROM employee_competencies ec
+		WHERE ec.employee_id = ?
+		AND ec.work_type_id = wt.id
+		AND ec.certified_at <= ?
+		AND (ec.expires_at IS NULL OR ec.expires_at >= ?)
+	)
+	ORDER BY wt.name ASC`
+	query = d.db.Rebind(query)
+	args := []any{workTypeIDs, employeeID, on, on}
+
+	var workTypes []WorkType
+	if err := d.db.SelectContext(ctx, &workTypes, query, args...); err != nil {
+		return []WorkType{}, fmt.Errorf("select context from db: %w", err)
+	}
+
+	return workTypes, nil
+}
+
+func (d *DB) DeleteWorkLog(ctx context.Context, id int64, employeeID int64) (returnedErr error) {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		if returnedErr != nil {
+			_ = tx.Rollback()
+		} else if err := tx.Commit(); err != nil {
+			returnedErr = fmt.Errorf("commit tx: %w", err)
+		}
+	}()
+
+	lockQuery := tx.Rebind(`SELECT id FROM work_logs WHERE id = ? AND deleted_at IS NULL FOR UPDATE`)
+	var lockedID int64
+	if err := tx.GetContext(ctx, &lockedID, lockQuery, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrWorkLogNotFound
+		}
+		return fmt.Errorf("lock work log: %w", err)
+	}
+
+	before, err := d.getWorkLog(ctx, tx, id, false)
+	if err != nil {
+		return fmt.Errorf("get work log: %w", err)
+	}
+
+	if err := d.softDeleteWorkLogUnits(ctx, tx, id, employeeID); err != nil {
+		return fmt.Errorf("soft delete work log units: %w", err)
+	}
+
+	if err := d.softDeleteWorkLog(ctx, tx, id, employeeID); err != nil {
+		return fmt.Errorf("soft delete work log: %w", err)
+	}
+
+	payload := WorkLogDeletedEvent{ID: id, DeletedBy: employeeID}
+	if err := webhook.Publish(ctx, tx, webhook.EventWorkLogDeleted, id, payload); err != nil {
+		return fmt.Errorf("publish event: %w", err)
+	}
+
+	if err := audit.Record(ctx, tx, audit.ActionDelete, auditEntityWorkLog, id, before, nil); err != nil {
+		return fmt.Errorf("record audit log: %w", err)
+	}
+
+	return nil
+}
+
+func (d *DB) softDeleteWorkLogUnits(ctx context.Context, tx *sqlx.Tx, workLogID int64, employeeID int64) error {
+	query := `
+	UPDATE work_log_units 
+	SET deleted_at = CURRENT_TIMESTAMP, deleted_by = ?
+	WHERE work_log_id = ? AND deleted_at IS NULL`
+	query = tx.Rebind(query)
+	args := []any{employeeID, workLogID}
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("exec context to db: %w", err)
+	}
+
+	return nil
+}
+
+func (d *DB) softDeleteWorkLog(ctx context.Context, tx *sqlx.Tx, id int64, employeeID int64) error {
+	query := `
+	UPDATE work_logs 
+	SET deleted_at = CURRENT_TIMESTAMP, deleted_by = ?
+	WHERE id = ? AND deleted_at IS NULL`
+	query = tx.Rebind(query)
+	args := []any{employeeID, id}
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("exec context to db: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrWorkLogNotFound
+	}
+
+	return nil
+}
+
+func (d *DB) GetReceiptBranding(ctx context.Context) (ReceiptBranding, error) {
+	query := `
+	SELECT store_name, address, sia_number, footer_disclaimer, logo_storage_key IS NOT NULL AS has_logo, logo_storage_key, logo_content_type, updated_at, updated_by
+	FROM receipt_branding
+	WHERE id = 1`
+
+	var branding ReceiptBranding
+	if err := d.db.GetContext(ctx, &branding, query); err != nil {
+		return ReceiptBranding{}, fmt.Errorf("get context from db: %w", err)
+	}
+
+	return branding, nil
+}
+
+func (d *DB) UpdateReceiptBranding(ctx context.Context, request UpdateReceiptBrandingRequest, editorID *int64) (branding ReceiptBranding, returnedErr error) {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return ReceiptBranding{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		if returnedErr != nil {
+			_ = tx.Rollback()
+		} else if err := tx.Commit(); err != nil {
+			returnedErr = fmt.Errorf("commit tx: %w", err)
+		}
+	}()
+
+	before, err := d.lockReceiptBranding(ctx, tx)
+	if err != nil {
+		return ReceiptBranding{}, fmt.Errorf("lock receipt branding: %w", err)
+	}
+
+	query := `
+	UPDATE receipt_branding
+	SET store_name = ?, address = ?, sia_number = ?, footer_disclaimer = ?, updated_at = CURRENT_TIMESTAMP, updated_by = ?
+	WHERE id = 1
+	RETURNING store_name, address, sia_number, footer_disclaimer, logo_storage_key IS NOT NULL AS has_logo, logo_storage_key, logo_content_type, updated_at, updated_by`
+	query = tx.Rebind(query)
+	args := []any{request.StoreName, request.Address, request.SIANumber, request.FooterDisclaimer, editorID}
+
+	if err := tx.GetContext(ctx, &branding, query, args...); err != nil {
+		return ReceiptBranding{}, fmt.Errorf("get context from db: %w", err)
+	}
+
+	if err := audit.Record(ctx, tx, audit.ActionUpdate, auditEntityReceiptBranding, receiptBrandingID, before, branding); err != nil {
+		return ReceiptBranding{}, fmt.Errorf("record audit log: %w", err)
+	}
+
+	return branding, nil
+}
+
+// SetReceiptBrandingLogo points the branding to a new logo, or removes it if storageKey is nil.
+// It returns the branding before and after the change, so the caller can delete the previous logo.
+func (d *DB) SetReceiptBrandingLogo(ctx context.Context, storageKey *string, contentType *string, editorID *int64) (before ReceiptBranding, branding ReceiptBranding, returnedErr error) {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return ReceiptBranding{}, ReceiptBranding{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		if returnedErr != nil {
+			_ = tx.Rollback()
+		} else if err := tx.Commit(); err != nil {
+			returnedErr = fmt.Errorf("commit tx: %w", err)
+		}
+	}()
+
+	before, err = d.lockReceiptBranding(ctx, tx)
+	if err != nil {
+		return ReceiptBranding{}, ReceiptBranding{}, fmt.Errorf("lock receipt branding: %w", err)
+	}
+
+	query := `
+	UPDATE receipt_branding
+	SET logo_storage_key = ?, logo_content_type = ?, updated_at = CURRENT_TIMESTAMP, updated_by = ?
+	WHERE id = 1
+	RETURNING store_name, address, sia_number, footer_disclaimer, logo_storage_key IS NOT NULL AS has_logo, logo_storage_key, logo_content_type, updated_at, updated_by`
+	query = tx.Rebind(query)
+	args := []any{storageKey, contentType, editorID}
+
+	if err := tx.GetContext(ctx, &branding, query, args...); err != nil {
+		return ReceiptBranding{}, ReceiptBranding{}, fmt.Errorf("get context from db: %w", err)
+	}
+
+	if err := audit.Record(ctx, tx, audit.ActionUpdate, auditEntityReceiptBranding, receiptBrandingID, before, branding); err != nil {
+		return ReceiptBranding{}, ReceiptBranding{}, fmt.Errorf("record audit log: %w", err)
+	}
+
+	return before, branding, nil
+}
+
+func (d *DB) lockReceiptBranding(ctx context.Context, tx *sqlx.Tx) (ReceiptBranding, error) {
+	query := `
+	SELECT store_name, address, sia_number, footer_disclaimer, logo_storage_key IS NOT NULL AS has_logo, logo_storage_key, logo_content_type, updated_at, updated_by
+	FROM receipt_branding
+	WHERE id = 1
+	FOR UPDATE`
+
+	var branding ReceiptBranding
+	if err := tx.GetContext(ctx, &branding, query); err != nil {
+		return ReceiptBranding{}, fmt.Errorf("get context from db: %w", err)
+	}
+
+	return branding, nil
+}
+
+func (d *DB) GetReceiptTemplates(ctx context.Context) ([]ReceiptTemplate, error) {
+	query := `
+	SELECT rt.id, rt.work_type_id, wt.name AS "work_type_name", rt.content, rt.created_at, rt.created_by
+	FROM receipt_templates rt
+	JOIN work_types wt ON rt.work_type_id = wt.id
+	WHERE rt.deleted_at IS NULL
+	ORDER BY wt.name ASC`
+
+	var receiptTemplates []ReceiptTemplate
+	if err := d.db.SelectContext(ctx, &receiptTemplates, query); err != nil {
+		return []ReceiptTemplate{}, fmt.Errorf("select context from db: %w", err)
+	}
+
+	return receiptTemplates, nil
+}
+
+// GetReceiptTemplatesByWorkTypeIDs returns the current templates of the work types, keyed by work type ID.
+func (d *DB) GetReceiptTemplatesByWorkTypeIDs(ctx context.Context, workTypeIDs []int64) (map[int64]ReceiptTemplate, error) {
+	query := `
+	SELECT rt.id, rt.work_type_id, wt.name AS "work_type_name", rt.content, rt.created_at, rt.created_by
+	FROM receipt_templates rt
+	JOIN work_types wt ON rt.work_type_id = wt.id
+	WHERE rt.deleted_at IS NULL AND rt.work_type_id = ANY(?)`
+	query = d.db.Rebind(query)
+	args := []any{workTypeIDs}
+
+	var receiptTemplates []ReceiptTemplate
+	if err := d.db.SelectContext(ctx, &receiptTemplates, query, args...); err != nil {
+		return nil, fmt.Errorf("select context from db: %w", err)
+	}
+
+	result := make(map[int64]ReceiptTemplate, len(receiptTemplates))
+	for _, receiptTemplate := range receiptTemplates {
+		result[receiptTemplate.WorkTypeID] = receiptTemplate
+	}
+
+	return result, nil
+}
+
+// SetReceiptTemplate replaces the current template of the work type, keeping the previous one soft deleted.
+func (d *DB) SetReceiptTemplate(ctx context.Context, workTypeID int64, content string, editorID *int64) (receiptTemplate ReceiptTemplate, returnedErr error) {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return ReceiptTemplate{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		if returnedErr != nil {
+			_ = tx.Rollback()
+		} else if err := tx.Commit(); err != nil {
+			returnedErr = fmt.Errorf("commit tx: %w", err)
+		}
+	}()
+
+	// previous is left zero if the work type had no template.
+	previous, err := d.softDeleteReceiptTemplate(ctx, tx, workTypeID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return ReceiptTemplate{}, fmt.Errorf("delete previous template: %w", err)
+	}
+
+	insertQuery := tx.Rebind(`
+	WITH inserted_template AS (
+		INSERT INTO receipt_templates (work_type_id, content, created_by)
+		VALUES (?, ?, ?)
+		RETURNING *
+	)
+	SELECT rt.id, rt.work_type_id, wt.name AS "work_type_name", rt.content, rt.created_at, rt.created_by
+	FROM inserted_template rt
+	JOIN work_types wt ON rt.work_type_id = wt.id`)
+	if err := tx.GetContext(ctx, &receiptTemplate, insertQuery, workTypeID, content, editorID); err != nil {
+		return ReceiptTemplate{}, fmt.Errorf("insert template: %w", err)
+	}
+
+	if previous.ID != 0 {
+		if err := audit.Record(ctx, tx, audit.ActionDelete, auditEntityReceiptTemplate, previous.ID, previous, nil); err != nil {
+			return ReceiptTemplate{}, fmt.Errorf("record audit log: %w", err)
+		}
+	}
+
+	if err := audit.Record(ctx, tx, audit.ActionCreate, auditEntityReceiptTemplate, receiptTemplate.ID, nil, receiptTemplate); err != nil {
+		return ReceiptTemplate{}, fmt.Errorf("record audit log: %w", err)
+	}
+
+	return receiptTemplate, nil
+}
+
+// DeleteReceiptTemplate soft deletes the current template of the work type.
+// Returns sql.ErrNoRows if the work type has no template.
+func (d *DB) DeleteReceiptTemplate(ctx context.Context, workTypeID int64) (returnedErr error) {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		if returnedErr != nil {
+			_ = tx.Rollback()
+		} else if err := tx.Commit(); err != nil {
+			returnedErr = fmt.Errorf("commit tx: %w", err)
+		}
+	}()
+
+	receiptTemplate, err := d.softDeleteReceiptTemplate(ctx, tx, workTypeID)
+	if err != nil {
+		return fmt.Errorf("soft delete template: %w", err)
+	}
+
+	if err := audit.Record(ctx, tx, audit.ActionDelete, auditEntityReceiptTemplate, receiptTemplate.ID, receiptTemplate, nil); err != nil {
+		return fmt.Errorf("record audit log: %w", err)
+	}
+
+	return nil
+}
+
+// softDeleteReceiptTemplate soft deletes the current template of the work type and returns it.
+// Returns sql.ErrNoRows if the work type has no template.
+func (d *DB) softDeleteReceiptTemplate(ctx context.Context, tx *sqlx.Tx, workTypeID int64) (ReceiptTemplate, error) {
+	query := `
+	WITH deleted_template AS (
+		UPDATE receipt_templates
+		SET deleted_at = CURRENT_TIMESTAMP
+		WHERE work_type_id = ? AND deleted_at IS NULL
+		RETURNING *
+	)
+	SELECT rt.id, rt.work_type_id, wt.name AS "work_type_name", rt.content, rt.created_at, rt.created_by
+	FROM deleted_template rt
+	JOIN work_types wt ON rt.work_type_id = wt.id`
+	query = tx.Rebind(query)
+	args := []any{workTypeID}
+
+	var receiptTemplate ReceiptTemplate
+	if err := tx.GetContext(ctx, &receiptTemplate, query, args...); err != nil {
+		return ReceiptTemplate{}, fmt.Errorf("get context from db: %w", err)
+	}
+
+	return receiptTemplate, nil
 }
@@ -0,0 +1,121 @@
+package hris
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-json-experiment/json"
+
+	"github.com/turfaa/apotek-hris/internal/queue"
+	"github.com/turfaa/apotek-hris/pkg/blobstore"
+	"github.com/turfaa/apotek-hris/pkg/tracing"
+)
+
+// TaskExportWorkLogs is the kind of the background jobs exporting work logs as CSV.
+const TaskExportWorkLogs = "hris.export_work_logs"
+
+const exportKeyPrefix = "exports/work-logs"
+
+// Tasks returns the background job tasks of the hris package.
+func (s *Service) Tasks() []queue.Task {
+	return []queue.Task{
+		{Kind: TaskExportWorkLogs, Run: s.runExportWorkLogs},
+	}
+}
+
+// EnqueueExportWorkLogs queues a background job exporting the work logs performed in the range as CSV.
+func (s *Service) EnqueueExportWorkLogs(ctx context.Context, request ExportWorkLogsRequest) (queue.Job, error) {
+	ctx, span := tracing.Start(ctx, "hris.Service.EnqueueExportWorkLogs")
+	defer span.End()
+
+	if request.BranchID != nil {
+		if _, err := s.GetBranch(ctx, *request.BranchID); err != nil {
+			return queue.Job{}, err
+		}
+	}
+
+	job, err := s.queue.Enqueue(ctx, TaskExportWorkLogs, request)
+	if err != nil {
+		return queue.Job{}, fmt.Errorf("enqueue job: %w", err)
+	}
+
+	return job, nil
+}
+
+func (s *Service) runExportWorkLogs(ctx context.Context, job queue.Job, progress *queue.Progress) (queue.Result, error) {
+	var request ExportWorkLogsRequest
+	if err := json.Unmarshal(job.Payload, &request); err != nil {
+		return queue.Result{}, fmt.Errorf("unmarshal payload: %w", err)
+	}
+
+	workLogs, err := s.GetWorkLogsBetween(ctx, request.From, request.To, request.BranchID)
+	if err != nil {
+		return queue.Result{}, err
+	}
+
+	branches, err := s.GetBranches(ctx)
+	if err != nil {
+		return queue.Result{}, err
+	}
+
+	branchesByID := make(map[int64]Branch, len(branches))
+	for _, branch := range branches {
+		branchesByID[branch.ID] = branch
+	}
+
+	progress.SetTotal(len(workLogs))
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	_ = w.Write([]string{"Work Log ID", "Performed At", "Employee", "Branch", "Patient", "Work Type", "Outcome", "Multiplier"})
+
+	for _, workLog := range workLogs {
+		if ctx.Err() != nil {
+			return queue.Result{}, ctx.Err()
+		}
+
+		// Times are written in the time zone of the branch the work was performed at.
+		branch := branchesByID[workLog.BranchID]
+		performedAt := workLog.PerformedAt.In(branch.Location()).Format(time.DateTime)
+
+		for _, unit := range workLog.Units {
+			_ = w.Write([]string{
+				strconv.FormatInt(workLog.ID, 10),
+				performedAt,
+				workLog.Employee.Name,
+				branch.Name,
+				workLog.PatientName,
+				unit.WorkType.Name,
+				unit.WorkOutcome,
+				unit.WorkMultiplier.String(),
+			})
+		}
+
+		progress.Add(1)
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return queue.Result{}, fmt.Errorf("write csv: %w", err)
+	}
+
+	fileName := fmt.Sprintf("work-logs-%s-%s.csv", request.From.Format(time.DateOnly), request.To.Format(time.DateOnly))
+
+	key, err := blobstore.NewKey(exportKeyPrefix, fileName)
+	if err != nil {
+		return queue.Result{}, fmt.Errorf("new blob key: %w", err)
+	}
+
+	if err := s.blobStore.Put(ctx, key, &buf); err != nil {
+		return queue.Result{}, fmt.Errorf("put export to blob store: %w", err)
+	}
+
+	return queue.Result{
+		Summary: map[string]int{"workLogs": len(workLogs)},
+		File:    &queue.ResultFile{Key: key, Name: fileName, ContentType: "text/csv"},
+	}, nil
+}
@@ -1,15 +1,19 @@
 package hris
 
 import (
-	"database/sql"
+	"bytes"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 
 	"github.com/turfaa/apotek-hris/internal/hris/templates"
+	"github.com/turfaa/apotek-hris/internal/queue"
+	"github.com/turfaa/apotek-hris/pkg/escpos"
+	"github.com/turfaa/apotek-hris/pkg/etag"
 	"github.com/turfaa/apotek-hris/pkg/httpx"
 	"github.com/turfaa/apotek-hris/pkg/timex"
-	"github.com/turfaa/apotek-hris/pkg/validatorx"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-json-experiment/json"
@@ -19,6 +23,14 @@ const (
 	headerEmployeeID = "X-Employee-ID"
 )
 
+const (
+	receiptFormatHTML   = "html"
+	receiptFormatESCPOS = "escpos"
+)
+
+// maxReceiptUploadSize is the largest accepted receipt logo or template upload, in bytes.
+const maxReceiptUploadSize = 1 << 20
+
 var (
 	ErrMissingEmployeeID = errors.New("missing employee ID in header")
 	ErrInvalidEmployeeID = errors.New("invalid employee ID in header")
@@ -33,9 +45,32 @@ func NewHandler(service *Service) *Handler {
 }
 
 func (h *Handler) GetEmployees(w http.ResponseWriter, r *http.Request) {
-	employees, err := h.service.GetEmployees(r.Context())
+	branchID, err := httpx.GetOptionalInt64FromQuery(r, "branchID")
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	page, err := httpx.GetPageFromQuery(r)
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	req := ListEmployeesRequest{
+		BranchID: branchID,
+		Name:     r.URL.Query().Get("name"),
+		Page:     page,
+	}
+
+	if roleStr := r.URL.Query().Get("role"); roleStr != "" {
+		role := Role(roleStr)
+		req.Role = &role
+	}
+
+	employees, err := h.service.ListEmployees(r.Context(), req)
 	if err != nil {
-		httpServiceError(w, err)
+		httpServiceError(w, r, err)
 		return
 	}
 
@@ -45,23 +80,328 @@ func (h *Handler) GetEmployees(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) CreateEmployee(w http.ResponseWriter, r *http.Request) {
 	var req CreateEmployeeRequest
 	if err := json.UnmarshalRead(r.Body, &req); err != nil {
-		httpx.Error(w, err, http.StatusBadRequest)
+		httpx.Error(w, r, err, http.StatusBadRequest)
 		return
 	}
 
 	employee, err := h.service.CreateEmployee(r.Context(), req)
 	if err != nil {
-		httpServiceError(w, err)
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Ok(w, employee)
+}
+
+func (h *Handler) GetEmployee(w http.ResponseWriter, r *http.Request) {
+	employeeIDStr := chi.URLParam(r, "employeeID")
+	if employeeIDStr == "" {
+		httpx.Error(w, r, errors.New("employeeID is required"), http.StatusBadRequest)
+		return
+	}
+
+	employeeID, err := strconv.ParseInt(employeeIDStr, 10, 64)
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	employee, err := h.service.GetEmployee(r.Context(), employeeID)
+	if err != nil {
+		httpServiceError(w, r, err)
 		return
 	}
 
+	w.Header().Set("ETag", etag.FromTime(employee.UpdatedAt))
 	httpx.Ok(w, employee)
 }
 
+func (h *Handler) SetEmployeeRole(w http.ResponseWriter, r *http.Request) {
+	employeeIDStr := chi.URLParam(r, "employeeID")
+	if employeeIDStr == "" {
+		httpx.Error(w, r, errors.New("employeeID is required"), http.StatusBadRequest)
+		return
+	}
+
+	employeeID, err := strconv.ParseInt(employeeIDStr, 10, 64)
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	var req SetEmployeeRoleRequest
+	if err := json.UnmarshalRead(r.Body, &req); err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	req.EmployeeID = employeeID
+	req.IfMatch = httpx.GetPrecondition(r)
+
+	employee, err := h.service.SetEmployeeRole(r.Context(), req)
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag.FromTime(employee.UpdatedAt))
+	httpx.Ok(w, employee)
+}
+
+func (h *Handler) SetEmployeeContact(w http.ResponseWriter, r *http.Request) {
+	employeeIDStr := chi.URLParam(r, "employeeID")
+	if employeeIDStr == "" {
+		httpx.Error(w, r, errors.New("employeeID is required"), http.StatusBadRequest)
+		return
+	}
+
+	employeeID, err := strconv.ParseInt(employeeIDStr, 10, 64)
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	var req SetEmployeeContactRequest
+	if err := json.UnmarshalRead(r.Body, &req); err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	req.EmployeeID = employeeID
+	req.IfMatch = httpx.GetPrecondition(r)
+
+	employee, err := h.service.SetEmployeeContact(r.Context(), req)
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag.FromTime(employee.UpdatedAt))
+	httpx.Ok(w, employee)
+}
+
+func (h *Handler) GetEmployeeBranches(w http.ResponseWriter, r *http.Request) {
+	employeeIDStr := chi.URLParam(r, "employeeID")
+	if employeeIDStr == "" {
+		httpx.Error(w, r, errors.New("employeeID is required"), http.StatusBadRequest)
+		return
+	}
+
+	employeeID, err := strconv.ParseInt(employeeIDStr, 10, 64)
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	employeeBranches, err := h.service.GetEmployeeBranches(r.Context(), employeeID)
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag.FromTime(employeeBranches.UpdatedAt))
+	httpx.Ok(w, employeeBranches)
+}
+
+func (h *Handler) SetEmployeeBranches(w http.ResponseWriter, r *http.Request) {
+	employeeIDStr := chi.URLParam(r, "employeeID")
+	if employeeIDStr == "" {
+		httpx.Error(w, r, errors.New("employeeID is required"), http.StatusBadRequest)
+		return
+	}
+
+	employeeID, err := strconv.ParseInt(employeeIDStr, 10, 64)
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	var req SetEmployeeBranchesRequest
+	if err := json.UnmarshalRead(r.Body, &req); err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	req.EmployeeID = employeeID
+	req.IfMatch = httpx.GetPrecondition(r)
+
+	employeeBranches, err := h.service.SetEmployeeBranches(r.Context(), req)
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag.FromTime(employeeBranches.UpdatedAt))
+	httpx.Ok(w, employeeBranches)
+}
+
+func (h *Handler) GetBranches(w http.ResponseWriter, r *http.Request) {
+	branches, err := h.service.GetBranches(r.Context())
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Ok(w, branches)
+}
+
+func (h *Handler) CreateBranch(w http.ResponseWriter, r *http.Request) {
+	var req CreateBranchRequest
+	if err := json.UnmarshalRead(r.Body, &req); err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	branch, err := h.service.CreateBranch(r.Context(), req)
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Ok(w, branch)
+}
+
+func (h *Handler) UpdateBranch(w http.ResponseWriter, r *http.Request) {
+	branchIDStr := chi.URLParam(r, "branchID")
+	if branchIDStr == "" {
+		httpx.Error(w, r, errors.New("branchID is required"), http.StatusBadRequest)
+		return
+	}
+
+	branchID, err := strconv.ParseInt(branchIDStr, 10, 64)
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	var req UpdateBranchRequest
+	if err := json.UnmarshalRead(r.Body, &req); err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	branch, err := h.service.UpdateBranch(r.Context(), branchID, req)
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Ok(w, branch)
+}
+
+func (h *Handler) GetEmployeeCompetencies(w http.ResponseWriter, r *http.Request) {
+	employeeIDStr := chi.URLParam(r, "employeeID")
+	if employeeIDStr == "" {
+		httpx.Error(w, r, errors.New("employeeID is required"), http.StatusBadRequest)
+		return
+	}
+
+	employeeID, err := strconv.ParseInt(employeeIDStr, 10, 64)
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	competencies, err := h.service.GetEmployeeCompetencies(r.Context(), employeeID)
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Ok(w, competencies)
+}
+
+func (h *Handler) SetEmployeeCompetency(w http.ResponseWriter, r *http.Request) {
+	employeeIDStr := chi.URLParam(r, "employeeID")
+	if employeeIDStr == "" {
+		httpx.Error(w, r, errors.New("employeeID is required"), http.StatusBadRequest)
+		return
+	}
+
+	employeeID, err := strconv.ParseInt(employeeIDStr, 10, 64)
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	workTypeID, err := workTypeIDFromURL(r)
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	var req SetCompetencyRequest
+	if err := json.UnmarshalRead(r.Body, &req); err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	req.EmployeeID = employeeID
+	req.WorkTypeID = workTypeID
+
+	competency, err := h.service.SetEmployeeCompetency(r.Context(), req)
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Ok(w, competency)
+}
+
+func (h *Handler) DeleteEmployeeCompetency(w http.ResponseWriter, r *http.Request) {
+	employeeIDStr := chi.URLParam(r, "employeeID")
+	if employeeIDStr == "" {
+		httpx.Error(w, r, errors.New("employeeID is required"), http.StatusBadRequest)
+		return
+	}
+
+	employeeID, err := strconv.ParseInt(employeeIDStr, 10, 64)
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	workTypeID, err := workTypeIDFromURL(r)
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeleteEmployeeCompetency(r.Context(), employeeID, workTypeID); err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Ok(w, map[string]string{"message": "successfully deleted the competency"})
+}
+
+func (h *Handler) GetExpiringCompetencies(w http.ResponseWriter, r *http.Request) {
+	withinDays := defaultCompetencyExpiryWindowDays
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		days, err := strconv.Atoi(daysStr)
+		if err != nil || days < 0 {
+			httpx.Error(w, r, fmt.Errorf("invalid days: %s", daysStr), http.StatusBadRequest)
+			return
+		}
+
+		withinDays = days
+	}
+
+	competencies, err := h.service.GetExpiringCompetencies(r.Context(), withinDays)
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Ok(w, competencies)
+}
+
 func (h *Handler) GetWorkTypes(w http.ResponseWriter, r *http.Request) {
-	workTypes, err := h.service.GetWorkTypes(r.Context())
+	includeArchived := r.URL.Query().Get("includeArchived") == "true"
+
+	workTypes, err := h.service.GetWorkTypes(r.Context(), includeArchived)
 	if err != nil {
-		httpServiceError(w, err)
+		httpServiceError(w, r, err)
 		return
 	}
 
@@ -71,45 +411,191 @@ func (h *Handler) GetWorkTypes(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) CreateWorkType(w http.ResponseWriter, r *http.Request) {
 	var req CreateWorkTypeRequest
 	if err := json.UnmarshalRead(r.Body, &req); err != nil {
-		httpx.Error(w, err, http.StatusBadRequest)
+		httpx.Error(w, r, err, http.StatusBadRequest)
 		return
 	}
 
 	workType, err := h.service.CreateWorkType(r.Context(), req)
 	if err != nil {
-		httpServiceError(w, err)
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Ok(w, workType)
+}
+
+func (h *Handler) UpdateWorkType(w http.ResponseWriter, r *http.Request) {
+	workTypeID, err := workTypeIDFromURL(r)
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	var req UpdateWorkTypeRequest
+	if err := json.UnmarshalRead(r.Body, &req); err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	workType, err := h.service.UpdateWorkType(r.Context(), workTypeID, req)
+	if err != nil {
+		httpServiceError(w, r, err)
 		return
 	}
 
 	httpx.Ok(w, workType)
 }
 
+func (h *Handler) ArchiveWorkType(w http.ResponseWriter, r *http.Request) {
+	h.setWorkTypeArchived(w, r, true)
+}
+
+func (h *Handler) UnarchiveWorkType(w http.ResponseWriter, r *http.Request) {
+	h.setWorkTypeArchived(w, r, false)
+}
+
+func (h *Handler) setWorkTypeArchived(w http.ResponseWriter, r *http.Request, archived bool) {
+	workTypeID, err := workTypeIDFromURL(r)
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	workType, err := h.service.SetWorkTypeArchived(r.Context(), workTypeID, archived)
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Ok(w, workType)
+}
+
+func (h *Handler) GetWorkTypeVersions(w http.ResponseWriter, r *http.Request) {
+	workTypeID, err := workTypeIDFromURL(r)
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	versions, err := h.service.GetWorkTypeVersions(r.Context(), workTypeID)
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Ok(w, versions)
+}
+
+func workTypeIDFromURL(r *http.Request) (int64, error) {
+	workTypeIDStr := chi.URLParam(r, "workTypeID")
+	if workTypeIDStr == "" {
+		return 0, errors.New("workTypeID is required")
+	}
+
+	return strconv.ParseInt(workTypeIDStr, 10, 64)
+}
+
+func workLogIDFromURL(r *http.Request) (int64, error) {
+	workLogIDStr := chi.URLParam(r, "workLogID")
+	if workLogIDStr == "" {
+		return 0, errors.New("workLogID is required")
+	}
+
+	return strconv.ParseInt(workLogIDStr, 10, 64)
+}
+
 func (h *Handler) GetWorkLogs(w http.ResponseWriter, r *http.Request) {
-	from, to, err := timex.GetTimeRangeFromQuery(r)
+	branchID, err := httpx.GetOptionalInt64FromQuery(r, "branchID")
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	// Days are the branch's days when filtering by branch.
+	loc, err := h.service.BranchLocation(r.Context(), branchID)
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	from, to, err := timex.GetTimeRangeFromQueryIn(r, loc)
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	employeeID, err := httpx.GetOptionalInt64FromQuery(r, "employeeID")
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	workTypeID, err := httpx.GetOptionalInt64FromQuery(r, "workTypeID")
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	page, err := httpx.GetPageFromQuery(r)
 	if err != nil {
-		httpx.Error(w, err, http.StatusBadRequest)
+		httpx.Error(w, r, err, http.StatusBadRequest)
 		return
 	}
 
-	workLogs, err := h.service.GetWorkLogsBetween(r.Context(), from, to)
+	workLogs, err := h.service.ListWorkLogs(r.Context(), ListWorkLogsRequest{
+		From:       from,
+		To:         to,
+		BranchID:   branchID,
+		EmployeeID: employeeID,
+		WorkTypeID: workTypeID,
+		Page:       page,
+	})
 	if err != nil {
-		httpServiceError(w, err)
+		httpServiceError(w, r, err)
 		return
 	}
 
 	httpx.Ok(w, workLogs)
 }
 
+func (h *Handler) ExportWorkLogs(w http.ResponseWriter, r *http.Request) {
+	branchID, err := httpx.GetOptionalInt64FromQuery(r, "branchID")
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	loc, err := h.service.BranchLocation(r.Context(), branchID)
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	from, to, err := timex.GetTimeRangeFromQueryIn(r, loc)
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	job, err := h.service.EnqueueExportWorkLogs(r.Context(), ExportWorkLogsRequest{From: from, To: to, BranchID: branchID})
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	queue.EnqueuedStatus(w, job)
+}
+
 func (h *Handler) CreateWorkLog(w http.ResponseWriter, r *http.Request) {
 	var req CreateWorkLogRequest
 	if err := json.UnmarshalRead(r.Body, &req); err != nil {
-		httpx.Error(w, err, http.StatusBadRequest)
+		httpx.Error(w, r, err, http.StatusBadRequest)
 		return
 	}
 
 	workLog, err := h.service.CreateWorkLog(r.Context(), req)
 	if err != nil {
-		httpServiceError(w, err)
+		httpServiceError(w, r, err)
 		return
 	}
 
@@ -117,86 +603,416 @@ func (h *Handler) CreateWorkLog(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) PrintWorkLogForPatient(w http.ResponseWriter, r *http.Request) {
+	workLogID, err := workLogIDFromURL(r)
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	switch format := r.URL.Query().Get("format"); format {
+	case "", receiptFormatHTML:
+		receipt, tmpl, err := h.service.GetWorkLogReceipt(r.Context(), workLogID)
+		if err != nil {
+			httpServiceError(w, r, err)
+			return
+		}
+
+		httpx.Template(w, tmpl, receipt)
+
+	case receiptFormatESCPOS:
+		data, err := h.service.GetWorkLogReceiptESCPOS(r.Context(), workLogID)
+		if err != nil {
+			httpServiceError(w, r, err)
+			return
+		}
+
+		httpx.File(w, bytes.NewReader(data), fmt.Sprintf("work-log-%d.bin", workLogID), "application/octet-stream")
+
+	default:
+		httpx.Error(w, r, fmt.Errorf("unknown format: %s", format), http.StatusBadRequest)
+	}
+}
+
+// VerifyWorkLog is the public page linked from the QR code on patient receipts.
+func (h *Handler) VerifyWorkLog(w http.ResponseWriter, r *http.Request) {
+	verification, err := h.service.VerifyWorkLogReceipt(r.Context(), chi.URLParam(r, "token"))
+	switch {
+	case errors.Is(err, ErrInvalidReceiptToken), errors.Is(err, ErrWorkLogNotFound):
+		httpx.TemplateStatus(w, templates.VerifyWorkLog, templates.Verification{Valid: false}, http.StatusNotFound)
+	case err != nil:
+		httpServiceError(w, r, err)
+	default:
+		httpx.Template(w, templates.VerifyWorkLog, verification)
+	}
+}
+
+func (h *Handler) GetReceiptBranding(w http.ResponseWriter, r *http.Request) {
+	branding, err := h.service.GetReceiptBranding(r.Context())
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Ok(w, branding)
+}
+
+func (h *Handler) UpdateReceiptBranding(w http.ResponseWriter, r *http.Request) {
+	var req UpdateReceiptBrandingRequest
+	if err := json.UnmarshalRead(r.Body, &req); err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	branding, err := h.service.UpdateReceiptBranding(r.Context(), req)
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Ok(w, branding)
+}
+
+func (h *Handler) UploadReceiptLogo(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxReceiptUploadSize)
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		httpx.Error(w, r, fmt.Errorf("read file from form: %w", err), http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	branding, err := h.service.UploadReceiptLogo(r.Context(), header.Filename, header.Header.Get("Content-Type"), file)
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Ok(w, branding)
+}
+
+func (h *Handler) DownloadReceiptLogo(w http.ResponseWriter, r *http.Request) {
+	contentType, content, err := h.service.GetReceiptLogo(r.Context())
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+	defer content.Close()
+
+	httpx.File(w, content, "logo", contentType)
+}
+
+func (h *Handler) DeleteReceiptLogo(w http.ResponseWriter, r *http.Request) {
+	branding, err := h.service.DeleteReceiptLogo(r.Context())
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Ok(w, branding)
+}
+
+func (h *Handler) GetReceiptTemplates(w http.ResponseWriter, r *http.Request) {
+	receiptTemplates, err := h.service.GetReceiptTemplates(r.Context())
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Ok(w, receiptTemplates)
+}
+
+func (h *Handler) GetReceiptTemplate(w http.ResponseWriter, r *http.Request) {
+	workTypeID, err := workTypeIDFromURL(r)
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	receiptTemplate, err := h.service.GetReceiptTemplate(r.Context(), workTypeID)
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Ok(w, receiptTemplate)
+}
+
+func (h *Handler) UploadReceiptTemplate(w http.ResponseWriter, r *http.Request) {
+	workTypeID, err := workTypeIDFromURL(r)
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxReceiptUploadSize)
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		httpx.Error(w, r, fmt.Errorf("read file from form: %w", err), http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		httpx.Error(w, r, fmt.Errorf("read file: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	receiptTemplate, err := h.service.SetReceiptTemplate(r.Context(), workTypeID, string(content))
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Ok(w, receiptTemplate)
+}
+
+func (h *Handler) DeleteReceiptTemplate(w http.ResponseWriter, r *http.Request) {
+	workTypeID, err := workTypeIDFromURL(r)
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeleteReceiptTemplate(r.Context(), workTypeID); err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Ok(w, map[string]string{"message": "successfully deleted the receipt template"})
+}
+
+func (h *Handler) SendWorkLogForPatientToPrinter(w http.ResponseWriter, r *http.Request) {
+	workLogID, err := workLogIDFromURL(r)
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.PrintWorkLogReceipt(r.Context(), workLogID); err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Ok(w, map[string]string{"message": "successfully sent the receipt to the printer"})
+}
+
+func (h *Handler) UpdateWorkLog(w http.ResponseWriter, r *http.Request) {
 	workLogIDStr := chi.URLParam(r, "workLogID")
 	if workLogIDStr == "" {
-		httpx.Error(w, errors.New("workLogID is required"), http.StatusBadRequest)
+		httpx.Error(w, r, errors.New("workLogID is required"), http.StatusBadRequest)
 		return
 	}
 
 	workLogID, err := strconv.ParseInt(workLogIDStr, 10, 64)
 	if err != nil {
-		httpx.Error(w, err, http.StatusBadRequest)
+		httpx.Error(w, r, err, http.StatusBadRequest)
 		return
 	}
 
-	workLog, err := h.service.GetWorkLog(r.Context(), workLogID)
-	if err != nil {
-		httpServiceError(w, err)
+	if _, err := employeeIDFromHeader(r); err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
 		return
 	}
 
-	units := make([]templates.WorkLogUnitForPatientData, len(workLog.Units))
-	for i, unit := range workLog.Units {
-		units[i] = templates.WorkLogUnitForPatientData{
-			WorkType:    unit.WorkType.Name,
-			WorkOutcome: unit.WorkOutcome,
-			OutcomeUnit: unit.WorkType.OutcomeUnit,
-			Notes:       unit.WorkType.Notes,
-		}
+	var req UpdateWorkLogRequest
+	if err := json.UnmarshalRead(r.Body, &req); err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
 	}
 
-	httpx.Template(w, templates.WorkLogForPatient, templates.WorkLogForPatientData{
-		PatientName:  workLog.PatientName,
-		Place:        "Apotek Aulia Farma",
-		EmployeeName: workLog.Employee.Name,
-		Units:        units,
-		Notes:        "Untuk hasil yang lebih akurat, silakan lakukan tes kembali di laboratorium terdekat.",
-		Date:         timex.FormatDate(workLog.CreatedAt),
-	})
+	workLog, err := h.service.UpdateWorkLog(r.Context(), workLogID, req)
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Ok(w, workLog)
 }
 
-func (h *Handler) DeleteWorkLog(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetWorkLogRevisions(w http.ResponseWriter, r *http.Request) {
 	workLogIDStr := chi.URLParam(r, "workLogID")
 	if workLogIDStr == "" {
-		httpx.Error(w, errors.New("workLogID is required"), http.StatusBadRequest)
+		httpx.Error(w, r, errors.New("workLogID is required"), http.StatusBadRequest)
 		return
 	}
 
 	workLogID, err := strconv.ParseInt(workLogIDStr, 10, 64)
 	if err != nil {
-		httpx.Error(w, err, http.StatusBadRequest)
+		httpx.Error(w, r, err, http.StatusBadRequest)
 		return
 	}
 
-	employeeIDStr := r.Header.Get(headerEmployeeID)
-	if employeeIDStr == "" {
-		httpx.Error(w, ErrMissingEmployeeID, http.StatusBadRequest)
+	revisions, err := h.service.GetWorkLogRevisions(r.Context(), workLogID)
+	if err != nil {
+		httpServiceError(w, r, err)
 		return
 	}
 
-	employeeID, err := strconv.ParseInt(employeeIDStr, 10, 64)
+	httpx.Ok(w, revisions)
+}
+
+func (h *Handler) DeleteWorkLog(w http.ResponseWriter, r *http.Request) {
+	workLogIDStr := chi.URLParam(r, "workLogID")
+	if workLogIDStr == "" {
+		httpx.Error(w, r, errors.New("workLogID is required"), http.StatusBadRequest)
+		return
+	}
+
+	workLogID, err := strconv.ParseInt(workLogIDStr, 10, 64)
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	employeeID, err := employeeIDFromHeader(r)
 	if err != nil {
-		httpx.Error(w, ErrInvalidEmployeeID, http.StatusBadRequest)
+		httpx.Error(w, r, err, http.StatusBadRequest)
 		return
 	}
 
 	if err := h.service.DeleteWorkLog(r.Context(), workLogID, employeeID); err != nil {
-		httpServiceError(w, err)
+		httpServiceError(w, r, err)
 		return
 	}
 
 	httpx.Ok(w, map[string]string{"message": "successfully deleted the work log"})
 }
 
-func httpServiceError(w http.ResponseWriter, err error) {
-	switch {
-	case errors.Is(err, sql.ErrNoRows):
-		httpx.Error(w, err, http.StatusNotFound)
-	case errors.Is(err, ErrWorkLogNotFound):
-		httpx.Error(w, err, http.StatusNotFound)
-	case errors.As(err, &validatorx.ValidationErrors{}):
-		httpx.Error(w, err, http.StatusBadRequest)
-	default:
-		httpx.Error(w, err, http.StatusInternalServerError)
+func (h *Handler) SearchPatients(w http.ResponseWriter, r *http.Request) {
+	queries := r.URL.Query()
+
+	req := SearchPatientsRequest{
+		Query: queries.Get("q"),
+	}
+
+	if limitStr := queries.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil {
+			httpx.Error(w, r, fmt.Errorf("invalid limit: %w", err), http.StatusBadRequest)
+			return
+		}
+
+		req.Limit = limit
+	}
+
+	patients, err := h.service.SearchPatients(r.Context(), req)
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Ok(w, patients)
+}
+
+func (h *Handler) CreatePatient(w http.ResponseWriter, r *http.Request) {
+	var req CreatePatientRequest
+	if err := json.UnmarshalRead(r.Body, &req); err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	patient, err := h.service.CreatePatient(r.Context(), req)
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Ok(w, patient)
+}
+
+func (h *Handler) GetPatient(w http.ResponseWriter, r *http.Request) {
+	patientIDStr := chi.URLParam(r, "patientID")
+	if patientIDStr == "" {
+		httpx.Error(w, r, errors.New("patientID is required"), http.StatusBadRequest)
+		return
+	}
+
+	patientID, err := strconv.ParseInt(patientIDStr, 10, 64)
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	patient, err := h.service.GetPatient(r.Context(), patientID)
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Ok(w, patient)
+}
+
+func (h *Handler) GetPatientHistory(w http.ResponseWriter, r *http.Request) {
+	patientIDStr := chi.URLParam(r, "patientID")
+	if patientIDStr == "" {
+		httpx.Error(w, r, errors.New("patientID is required"), http.StatusBadRequest)
+		return
+	}
+
+	patientID, err := strconv.ParseInt(patientIDStr, 10, 64)
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	history, err := h.service.GetPatientHistory(r.Context(), patientID)
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
 	}
+
+	httpx.Ok(w, history)
+}
+
+func employeeIDFromHeader(r *http.Request) (int64, error) {
+	employeeIDStr := r.Header.Get(headerEmployeeID)
+	if employeeIDStr == "" {
+		return 0, ErrMissingEmployeeID
+	}
+
+	employeeID, err := strconv.ParseInt(employeeIDStr, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidEmployeeID
+	}
+
+	return employeeID, nil
+}
+
+// errorCodes classify the errors of the HRIS service for the clients.
+var errorCodes = []httpx.ErrorCode{
+	{Err: ErrWorkLogNotFound, Code: httpx.CodeNotFound},
+	{Err: ErrWorkLogUnitNotFound, Code: httpx.CodeNotFound},
+	{Err: ErrPatientNotFound, Code: httpx.CodeNotFound},
+	{Err: ErrWorkTypeNotFound, Code: httpx.CodeNotFound},
+	{Err: ErrCompetencyNotFound, Code: httpx.CodeNotFound},
+	{Err: ErrBranchNotFound, Code: httpx.CodeNotFound},
+	{Err: ErrReceiptLogoNotFound, Code: httpx.CodeNotFound},
+	{Err: ErrReceiptTemplateNotFound, Code: httpx.CodeNotFound},
+	{Err: ErrMissingCompetency, Code: httpx.CodeForbidden},
+	{Err: ErrLicenseExpired, Code: httpx.CodeForbidden},
+	{Err: ErrBackdateNeedsManager, Code: httpx.CodeForbidden},
+	{Err: ErrForbidden, Code: httpx.CodeForbidden},
+	{Err: ErrEmployeeNotInBranch, Code: httpx.CodeValidation},
+	{Err: ErrUnknownActor, Code: httpx.CodeValidation},
+	{Err: ErrInvalidEmployeeID, Code: httpx.CodeValidation},
+	{Err: ErrInvalidCompetency, Code: httpx.CodeValidation},
+	{Err: ErrWorkTypeArchived, Code: httpx.CodeValidation},
+	{Err: ErrEmptyWorkTypeUpdate, Code: httpx.CodeValidation},
+	{Err: ErrWorkLogWithoutUnits, Code: httpx.CodeValidation},
+	{Err: ErrEmptyWorkLogUpdate, Code: httpx.CodeValidation},
+	{Err: ErrDuplicateUnitChange, Code: httpx.CodeValidation},
+	{Err: ErrInvalidWorkOutcome, Code: httpx.CodeValidation},
+	{Err: ErrInvalidOutcomeSchema, Code: httpx.CodeValidation},
+	{Err: ErrPerformedInFuture, Code: httpx.CodeValidation},
+	{Err: ErrInvalidLogo, Code: httpx.CodeValidation},
+	{Err: templates.ErrInvalidTemplate, Code: httpx.CodeValidation},
+	{Err: escpos.ErrPrinterNotConfigured, Code: httpx.CodeUnavailable},
+	{Err: escpos.ErrPrinterUnavailable, Code: httpx.CodeUnavailable, Status: http.StatusBadGateway},
+}
+
+func httpServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	httpx.ServiceError(w, r, err, errorCodes)
 }
@@ -0,0 +1,35 @@
+package hris
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/turfaa/go-date"
+
+	"github.com/turfaa/apotek-hris/internal/scheduler"
+)
+
+// Jobs returns the scheduled jobs of the hris package.
+func (s *Service) Jobs() []scheduler.Job {
+	return []scheduler.Job{
+		{
+			Name:        "check-license-expiry",
+			Description: "Raise alerts for employee licenses and documents nearing expiry",
+			Schedule:    "0 6 * * *",
+			Run:         s.checkLicenseExpiry,
+		},
+	}
+}
+
+func (s *Service) checkLicenseExpiry(ctx context.Context) error {
+	alerts, err := s.documentService.CheckExpiry(ctx, date.NewFromTime(time.Now()))
+	if err != nil {
+		return fmt.Errorf("check document expiry: %w", err)
+	}
+
+	slog.InfoContext(ctx, "checked license expiry", slog.Int("raised_alerts", len(alerts)))
+
+	return nil
+}
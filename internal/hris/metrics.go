@@ -0,0 +1,30 @@
+package hris
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/turfaa/apotek-hris/pkg/metrics"
+)
+
+var workLogsCreated = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Name:      "work_logs_created_total",
+		Help:      "Number of work logs created, by the work types of their units.",
+	},
+	[]string{"work_type"},
+)
+
+// recordWorkLogCreated counts a new work log once for every work type it has units of.
+func recordWorkLogCreated(workLog WorkLog) {
+	counted := make(map[int64]bool, len(workLog.Units))
+	for _, unit := range workLog.Units {
+		if counted[unit.WorkType.ID] {
+			continue
+		}
+
+		counted[unit.WorkType.ID] = true
+		workLogsCreated.WithLabelValues(unit.WorkType.Name).Inc()
+	}
+}
@@ -4,6 +4,10 @@ import (
 	"time"
 
 	"github.com/shopspring/decimal"
+	"github.com/turfaa/go-date"
+
+	"github.com/turfaa/apotek-hris/pkg/etag"
+	"github.com/turfaa/apotek-hris/pkg/pagination"
 )
 
 type Employee struct {
@@ -11,6 +15,12 @@ type Employee struct {
 	Name              string          `db:"name" json:"name"`
 	ShiftFee          decimal.Decimal `db:"shift_fee" json:"shiftFee"`
 	ShowInAttendances bool            `db:"show_in_attendances" json:"showInAttendances"`
+	Role              Role            `db:"role" json:"role"`
+	HomeBranchID      int64           `db:"home_branch_id" json:"homeBranchID"`
+
+	// Email is where notifications are sent. Payslips are only emailed if PayslipByEmail is set.
+	Email          string `db:"email" json:"email"`
+	PayslipByEmail bool   `db:"payslip_by_email" json:"payslipByEmail"`
 
 	CreatedAt time.Time `db:"created_at" json:"createdAt"`
 	UpdatedAt time.Time `db:"updated_at" json:"updatedAt"`
@@ -22,6 +32,113 @@ type CreateEmployeeRequest struct {
 
 	// ShowInAttendances will be true if not provided.
 	ShowInAttendances *bool `json:"showInAttendances"`
+
+	// Role will be RoleStaff if not provided.
+	Role Role `json:"role" validate:"omitempty,oneof=staff manager"`
+
+	// HomeBranchID will be the first branch if not provided.
+	HomeBranchID *int64 `json:"homeBranchID" validate:"omitnil,gt=0"`
+}
+
+type Role string
+
+const (
+	RoleStaff   Role = "staff"
+	RoleManager Role = "manager"
+)
+
+// ListEmployeesRequest filters and pages the employees.
+type ListEmployeesRequest struct {
+	// BranchID limits the employees to those working at the branch.
+	BranchID *int64
+	Role     *Role `validate:"omitnil,oneof=staff manager"`
+
+	// Name matches the employees whose name contains it, ignoring case.
+	Name string
+
+	Page pagination.Request
+}
+
+type SetEmployeeRoleRequest struct {
+	EmployeeID int64 `json:"-" validate:"required"`
+	Role       Role  `json:"role" validate:"required,oneof=staff manager"`
+
+	// IfMatch is the If-Match header: the employee is only updated if it was not changed since it was read.
+	IfMatch etag.Precondition `json:"-"`
+}
+
+type SetEmployeeContactRequest struct {
+	EmployeeID     int64  `json:"-" validate:"required"`
+	Email          string `json:"email" validate:"required_if=PayslipByEmail true,omitempty,email,max=255"`
+	PayslipByEmail bool   `json:"payslipByEmail"`
+
+	// IfMatch is the If-Match header: the employee is only updated if it was not changed since it was read.
+	IfMatch etag.Precondition `json:"-"`
+}
+
+// Branch is an outlet of the store. Employees belong to a home branch and can be assigned to others.
+type Branch struct {
+	ID        int64     `db:"id" json:"id"`
+	Name      string    `db:"name" json:"name"`
+	Address   string    `db:"address" json:"address"`
+	SIANumber string    `db:"sia_number" json:"siaNumber"`
+	Timezone  string    `db:"timezone" json:"timezone"`
+	CreatedAt time.Time `db:"created_at" json:"createdAt"`
+	UpdatedAt time.Time `db:"updated_at" json:"updatedAt"`
+}
+
+// Location returns the branch's time zone, or the deployment's time zone if it has none.
+func (b Branch) Location() *time.Location {
+	if b.Timezone == "" {
+		return time.Local
+	}
+
+	loc, err := time.LoadLocation(b.Timezone)
+	if err != nil {
+		return time.Local
+	}
+
+	return loc
+}
+
+type CreateBranchRequest struct {
+	Name      string `json:"name" validate:"required,max=100"`
+	Address   string `json:"address" validate:"max=255"`
+	SIANumber string `json:"siaNumber" validate:"max=100"`
+
+	// Timezone is an IANA time zone name, e.g. Asia/Makassar.
+	// Empty means the deployment's time zone.
+	Timezone string `json:"timezone" validate:"omitempty,timezone"`
+}
+
+type UpdateBranchRequest struct {
+	Name      string `json:"name" validate:"required,max=100"`
+	Address   string `json:"address" validate:"max=255"`
+	SIANumber string `json:"siaNumber" validate:"max=100"`
+
+	// Timezone is an IANA time zone name, e.g. Asia/Makassar.
+	// Empty means the deployment's time zone.
+	Timezone string `json:"timezone" validate:"omitempty,timezone"`
+}
+
+// EmployeeBranches are the branches an employee works at.
+// AssignedBranchIDs never contains the home branch.
+type EmployeeBranches struct {
+	EmployeeID        int64   `json:"employeeID"`
+	HomeBranchID      int64   `json:"homeBranchID"`
+	AssignedBranchIDs []int64 `json:"assignedBranchIDs"`
+
+	// UpdatedAt is when the employee was last updated, branches included.
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type SetEmployeeBranchesRequest struct {
+	EmployeeID        int64   `json:"-" validate:"required"`
+	HomeBranchID      int64   `json:"homeBranchID" validate:"required,gt=0"`
+	AssignedBranchIDs []int64 `json:"assignedBranchIDs" validate:"dive,gt=0"`
+
+	// IfMatch is the If-Match header: the branches are only set if the employee was not changed since it was read.
+	IfMatch etag.Precondition `json:"-"`
 }
 
 type WorkType struct {
@@ -30,6 +147,16 @@ type WorkType struct {
 	OutcomeUnit string          `db:"outcome_unit" json:"outcomeUnit"`
 	Multiplier  decimal.Decimal `db:"multiplier" json:"multiplier"`
 	Notes       string          `db:"notes" json:"notes"`
+
+	OutcomeSchema   OutcomeSchema   `db:"outcome_schema" json:"outcomeSchema"`
+	ReferenceRanges ReferenceRanges `db:"reference_ranges" json:"referenceRanges"`
+
+	// ArchivedAt is set when the work type is no longer offered.
+	// Archived work types can't be used in new work log units.
+	ArchivedAt *time.Time `db:"archived_at" json:"archivedAt,omitempty"`
+
+	// RequiresCompetency restricts the work type to employees with a valid competency for it.
+	RequiresCompetency bool `db:"requires_competency" json:"requiresCompetency"`
 }
 
 type CreateWorkTypeRequest struct {
@@ -37,13 +164,61 @@ type CreateWorkTypeRequest struct {
 	OutcomeUnit string          `json:"outcomeUnit"`
 	Multiplier  decimal.Decimal `json:"multiplier" validate:"dgte=0"`
 	Notes       string          `json:"notes"`
+
+	// OutcomeSchema will accept any text if not provided.
+	OutcomeSchema   OutcomeSchema   `json:"outcomeSchema"`
+	ReferenceRanges ReferenceRanges `json:"referenceRanges" validate:"dive"`
+
+	RequiresCompetency bool `json:"requiresCompetency"`
+}
+
+// UpdateWorkTypeRequest changes a work type. Only the provided fields are changed.
+// Work logs performed before the update keep the previous version.
+type UpdateWorkTypeRequest struct {
+	Name            *string          `json:"name" validate:"omitnil,min=1,max=100"`
+	OutcomeUnit     *string          `json:"outcomeUnit" validate:"omitnil,max=20"`
+	Multiplier      *decimal.Decimal `json:"multiplier" validate:"omitnil,dgte=0"`
+	Notes           *string          `json:"notes"`
+	OutcomeSchema   *OutcomeSchema   `json:"outcomeSchema"`
+	ReferenceRanges *ReferenceRanges `json:"referenceRanges" validate:"omitnil,dive"`
+
+	// RequiresCompetency is not versioned: it applies to new work logs only.
+	RequiresCompetency *bool `json:"requiresCompetency"`
+}
+
+func (r UpdateWorkTypeRequest) HasChanges() bool {
+	return r.changesDefinition() || r.RequiresCompetency != nil
+}
+
+// changesDefinition reports whether the update changes what work logs show, which needs a new version.
+func (r UpdateWorkTypeRequest) changesDefinition() bool {
+	return r.Name != nil || r.OutcomeUnit != nil || r.Multiplier != nil || r.Notes != nil || r.OutcomeSchema != nil || r.ReferenceRanges != nil
+}
+
+// WorkTypeVersion is the definition of a work type for work logs performed from ValidFrom
+// until the next version starts. The first version has no ValidFrom.
+type WorkTypeVersion struct {
+	ID              int64           `db:"id" json:"id"`
+	WorkTypeID      int64           `db:"work_type_id" json:"workTypeID"`
+	Name            string          `db:"name" json:"name"`
+	OutcomeUnit     string          `db:"outcome_unit" json:"outcomeUnit"`
+	Multiplier      decimal.Decimal `db:"multiplier" json:"multiplier"`
+	Notes           string          `db:"notes" json:"notes"`
+	OutcomeSchema   OutcomeSchema   `db:"outcome_schema" json:"outcomeSchema"`
+	ReferenceRanges ReferenceRanges `db:"reference_ranges" json:"referenceRanges"`
+	ValidFrom       *time.Time      `db:"valid_from" json:"validFrom,omitempty"`
+	CreatedBy       *int64          `db:"created_by" json:"createdBy,omitempty"`
+	CreatedAt       time.Time       `db:"created_at" json:"createdAt"`
 }
 
 type WorkLog struct {
 	ID          int64         `db:"id" json:"id"`
 	Employee    Employee      `db:"employee" json:"employee"`
+	BranchID    int64         `db:"branch_id" json:"branchID"`
 	PatientName string        `db:"patient_name" json:"patientName"`
+	PatientID   *int64        `db:"patient_id" json:"patientID,omitempty"`
 	Units       []WorkLogUnit `db:"-" json:"units"`
+	PerformedAt time.Time     `db:"performed_at" json:"performedAt"`
 	CreatedAt   time.Time     `db:"created_at" json:"createdAt"`
 	DeletedAt   *time.Time    `db:"deleted_at" json:"deletedAt,omitempty"`
 	DeletedBy   *int64        `db:"deleted_by" json:"deletedBy,omitempty"`
@@ -54,17 +229,212 @@ type WorkLogUnit struct {
 	WorkType       WorkType        `json:"workType" db:"work_type"`
 	WorkOutcome    string          `json:"workOutcome" db:"work_outcome"`
 	WorkMultiplier decimal.Decimal `json:"workMultiplier" db:"work_multiplier"`
+	CreatedAt      time.Time       `json:"createdAt" db:"created_at"`
 	DeletedAt      *time.Time      `db:"deleted_at" json:"deletedAt,omitempty"`
 	DeletedBy      *int64          `db:"deleted_by" json:"deletedBy,omitempty"`
+
+	// ReplacesUnitID is the superseded unit this unit is a new version of.
+	ReplacesUnitID *int64 `json:"replacesUnitID,omitempty" db:"replaces_unit_id"`
+}
+
+// ExportWorkLogsRequest is the payload of the background job exporting work logs.
+type ExportWorkLogsRequest struct {
+	From     time.Time `json:"from"`
+	To       time.Time `json:"to"`
+	BranchID *int64    `json:"branchID"`
+}
+
+// ListWorkLogsRequest filters and pages the work logs performed in a range.
+type ListWorkLogsRequest struct {
+	From       time.Time
+	To         time.Time
+	BranchID   *int64
+	EmployeeID *int64
+
+	// WorkTypeID limits the work logs to those with a unit of the work type.
+	WorkTypeID *int64
+
+	Page pagination.Request
 }
 
 type CreateWorkLogRequest struct {
 	EmployeeID  int64                      `json:"employeeID" validate:"required"`
-	PatientName string                     `json:"patientName" validate:"required"`
+	PatientName string                     `json:"patientName" validate:"required_without=PatientID,max=100"`
 	Units       []CreateWorkLogUnitRequest `json:"units" validate:"min=1,dive"`
+
+	// PatientID links the work log to a registered patient.
+	// PatientName will be the patient's name if not provided.
+	PatientID *int64 `json:"patientID"`
+
+	// PerformedAt is when the work was done, used for reporting and salary.
+	// It will be the time of entry if not provided.
+	PerformedAt *time.Time `json:"performedAt"`
+
+	// BranchID is where the work was done. It will be the employee's home branch if not provided.
+	BranchID *int64 `json:"branchID" validate:"omitnil,gt=0"`
 }
 
 type CreateWorkLogUnitRequest struct {
 	WorkTypeID  int64  `json:"workTypeID" validate:"required"`
 	WorkOutcome string `json:"workOutcome" validate:"required"`
 }
+
+// UpdateWorkLogRequest edits a work log in place. Modified units are stored as new versions
+// and the superseded versions are soft deleted, so the receipt keeps the same work log ID.
+type UpdateWorkLogRequest struct {
+	PatientName   *string                    `json:"patientName" validate:"omitnil,min=1,max=100"`
+	AddUnits      []CreateWorkLogUnitRequest `json:"addUnits" validate:"dive"`
+	UpdateUnits   []UpdateWorkLogUnitRequest `json:"updateUnits" validate:"dive"`
+	RemoveUnitIDs []int64                    `json:"removeUnitIDs" validate:"dive,gt=0"`
+
+	// PatientID links the work log to a registered patient.
+	// The patient name will be the patient's name if not provided.
+	PatientID *int64 `json:"patientID" validate:"omitnil,gt=0"`
+}
+
+func (r UpdateWorkLogRequest) HasChanges() bool {
+	return r.PatientName != nil || r.PatientID != nil || len(r.AddUnits) > 0 || len(r.UpdateUnits) > 0 || len(r.RemoveUnitIDs) > 0
+}
+
+type UpdateWorkLogUnitRequest struct {
+	ID          int64  `json:"id" validate:"required"`
+	WorkOutcome string `json:"workOutcome" validate:"required"`
+}
+
+// WorkLogDeletedEvent is the data of the work_log.deleted webhook event.
+type WorkLogDeletedEvent struct {
+	ID        int64 `json:"id"`
+	DeletedBy int64 `json:"deletedBy"`
+}
+
+// WorkLogRevision is one edit of a work log.
+// A modified unit shows up as a removed unit and an added unit that replaces it.
+type WorkLogRevision struct {
+	ID                int64         `db:"id" json:"id"`
+	WorkLogID         int64         `db:"work_log_id" json:"workLogID"`
+	EditedBy          int64         `db:"edited_by" json:"editedBy"`
+	PatientNameBefore string        `db:"patient_name_before" json:"patientNameBefore"`
+	PatientNameAfter  string        `db:"patient_name_after" json:"patientNameAfter"`
+	PatientIDBefore   *int64        `db:"patient_id_before" json:"patientIDBefore,omitempty"`
+	PatientIDAfter    *int64        `db:"patient_id_after" json:"patientIDAfter,omitempty"`
+	AddedUnits        []WorkLogUnit `db:"-" json:"addedUnits"`
+	RemovedUnits      []WorkLogUnit `db:"-" json:"removedUnits"`
+	CreatedAt         time.Time     `db:"created_at" json:"createdAt"`
+}
+
+type Gender string
+
+const (
+	GenderMale   Gender = "male"
+	GenderFemale Gender = "female"
+)
+
+type Patient struct {
+	ID        int64     `db:"id" json:"id"`
+	Name      string    `db:"name" json:"name"`
+	Phone     *string   `db:"phone" json:"phone,omitempty"`
+	BirthYear *int      `db:"birth_year" json:"birthYear,omitempty"`
+	Gender    *Gender   `db:"gender" json:"gender,omitempty"`
+	CreatedAt time.Time `db:"created_at" json:"createdAt"`
+	UpdatedAt time.Time `db:"updated_at" json:"updatedAt"`
+}
+
+// AgeAt returns the patient's age in years at t, if the birth year is known.
+// Without a birth date it can be off by one before the patient's birthday.
+func (p Patient) AgeAt(t time.Time) *int {
+	if p.BirthYear == nil {
+		return nil
+	}
+
+	age := t.Year() - *p.BirthYear
+	return &age
+}
+
+type CreatePatientRequest struct {
+	Name      string  `json:"name" validate:"required,max=100"`
+	Phone     *string `json:"phone" validate:"omitnil,min=1,max=20"`
+	BirthYear *int    `json:"birthYear" validate:"omitnil,gte=1900,lte=2100"`
+	Gender    *Gender `json:"gender" validate:"omitnil,oneof=male female"`
+}
+
+type SearchPatientsRequest struct {
+	// Query matches the start of the patient's name or phone number.
+	Query string `validate:"required"`
+	Limit int    `validate:"gte=1,lte=50"`
+}
+
+// PatientHistory is every visit of a patient with the results grouped by work type.
+type PatientHistory struct {
+	Patient   Patient                  `json:"patient"`
+	Visits    []WorkLog                `json:"visits"`
+	WorkTypes []PatientWorkTypeHistory `json:"workTypes"`
+}
+
+type PatientWorkTypeHistory struct {
+	WorkType WorkType                 `json:"workType"`
+	Results  []PatientResult          `json:"results"`
+	Series   []PatientResultDataPoint `json:"series"`
+}
+
+type PatientResult struct {
+	WorkLogID   int64     `json:"workLogID"`
+	UnitID      int64     `json:"unitID"`
+	WorkOutcome string    `json:"workOutcome"`
+	PerformedAt time.Time `json:"performedAt"`
+}
+
+// PatientResultDataPoint is a numeric result, oldest first, for plotting trends.
+type PatientResultDataPoint struct {
+	PerformedAt time.Time       `json:"performedAt"`
+	Value       decimal.Decimal `json:"value"`
+}
+
+// Competency certifies an employee to perform a work type from CertifiedAt until ExpiresAt, inclusive.
+type Competency struct {
+	ID           int64      `db:"id" json:"id"`
+	EmployeeID   int64      `db:"employee_id" json:"employeeID"`
+	EmployeeName string     `db:"employee_name" json:"employeeName"`
+	WorkTypeID   int64      `db:"work_type_id" json:"workTypeID"`
+	WorkTypeName string     `db:"work_type_name" json:"workTypeName"`
+	CertifiedAt  date.Date  `db:"certified_at" json:"certifiedAt"`
+	ExpiresAt    *date.Date `db:"expires_at" json:"expiresAt,omitempty"`
+	CreatedAt    time.Time  `db:"created_at" json:"createdAt"`
+	UpdatedAt    time.Time  `db:"updated_at" json:"updatedAt"`
+}
+
+type SetCompetencyRequest struct {
+	EmployeeID  int64      `json:"-" validate:"required"`
+	WorkTypeID  int64      `json:"-" validate:"required"`
+	CertifiedAt date.Date  `json:"certifiedAt" validate:"required"`
+	ExpiresAt   *date.Date `json:"expiresAt"`
+}
+
+// ReceiptBranding identifies the store on every patient receipt.
+type ReceiptBranding struct {
+	StoreName        string    `db:"store_name" json:"storeName"`
+	Address          string    `db:"address" json:"address"`
+	SIANumber        string    `db:"sia_number" json:"siaNumber"`
+	FooterDisclaimer string    `db:"footer_disclaimer" json:"footerDisclaimer"`
+	HasLogo          bool      `db:"has_logo" json:"hasLogo"`
+	LogoStorageKey   *string   `db:"logo_storage_key" json:"-"`
+	LogoContentType  *string   `db:"logo_content_type" json:"-"`
+	UpdatedAt        time.Time `db:"updated_at" json:"updatedAt"`
+	UpdatedBy        *int64    `db:"updated_by" json:"updatedBy,omitempty"`
+}
+
+type UpdateReceiptBrandingRequest struct {
+	StoreName        string `json:"storeName" validate:"required,max=100"`
+	Address          string `json:"address" validate:"max=255"`
+	SIANumber        string `json:"siaNumber" validate:"max=100"`
+	FooterDisclaimer string `json:"footerDisclaimer"`
+}
+
+// ReceiptTemplate replaces the embedded patient receipt template for work logs of a work type.
+type ReceiptTemplate struct {
+	ID           int64     `db:"id" json:"id"`
+	WorkTypeID   int64     `db:"work_type_id" json:"workTypeID"`
+	WorkTypeName string    `db:"work_type_name" json:"workTypeName"`
+	Content      string    `db:"content" json:"content"`
+	CreatedAt    time.Time `db:"created_at" json:"createdAt"`
+	CreatedBy    *int64    `db:"created_by" json:"createdBy,omitempty"`
+}
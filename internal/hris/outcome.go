@@ -0,0 +1,272 @@
+package hris
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/go-json-experiment/json"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrInvalidWorkOutcome   = errors.New("invalid work outcome")
+	ErrInvalidOutcomeSchema = errors.New("invalid outcome schema")
+)
+
+type OutcomeType string
+
+const (
+	OutcomeTypeText    OutcomeType = "text"
+	OutcomeTypeNumeric OutcomeType = "numeric"
+	OutcomeTypeEnum    OutcomeType = "enum"
+)
+
+// OutcomeSchema declares which work outcomes a work type accepts.
+type OutcomeSchema struct {
+	Type OutcomeType `json:"type" validate:"omitempty,oneof=text numeric enum"`
+
+	// Min and Max bound numeric outcomes, inclusive.
+	Min *decimal.Decimal `json:"min,omitempty"`
+	Max *decimal.Decimal `json:"max,omitempty"`
+
+	// Options are the accepted enum outcomes.
+	Options []string `json:"options,omitempty" validate:"required_if=Type enum,dive,required"`
+}
+
+// Validate returns ErrInvalidWorkOutcome if the outcome does not match the schema.
+func (s OutcomeSchema) Validate(outcome string) error {
+	switch s.Type {
+	case OutcomeTypeNumeric:
+		value, err := parseNumericOutcome(outcome)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidWorkOutcome, err)
+		}
+
+		if s.Min != nil && value.LessThan(*s.Min) {
+			return fmt.Errorf("%w: %s is less than the minimum %s", ErrInvalidWorkOutcome, value, s.Min)
+		}
+
+		if s.Max != nil && value.GreaterThan(*s.Max) {
+			return fmt.Errorf("%w: %s is greater than the maximum %s", ErrInvalidWorkOutcome, value, s.Max)
+		}
+
+	case OutcomeTypeEnum:
+		if !slices.Contains(s.Options, strings.TrimSpace(outcome)) {
+			return fmt.Errorf("%w: %q is not one of %s", ErrInvalidWorkOutcome, outcome, strings.Join(s.Options, ", "))
+		}
+	}
+
+	return nil
+}
+
+// checkOutcomeDefinition checks the schema and reference ranges are consistent with each other.
+func checkOutcomeDefinition(schema OutcomeSchema, referenceRanges ReferenceRanges) error {
+	if schema.Min != nil && schema.Max != nil && schema.Min.GreaterThan(*schema.Max) {
+		return fmt.Errorf("%w: min is greater than max", ErrInvalidOutcomeSchema)
+	}
+
+	if schema.Type != OutcomeTypeNumeric && (schema.Min != nil || schema.Max != nil) {
+		return fmt.Errorf("%w: min and max are only allowed for numeric outcomes", ErrInvalidOutcomeSchema)
+	}
+
+	if schema.Type != OutcomeTypeEnum && len(schema.Options) > 0 {
+		return fmt.Errorf("%w: options are only allowed for enum outcomes", ErrInvalidOutcomeSchema)
+	}
+
+	if schema.Type != OutcomeTypeNumeric && len(referenceRanges) > 0 {
+		return fmt.Errorf("%w: reference ranges are only allowed for numeric outcomes", ErrInvalidOutcomeSchema)
+	}
+
+	for i, referenceRange := range referenceRanges {
+		if referenceRange.Low == nil && referenceRange.High == nil {
+			return fmt.Errorf("%w: reference range %d needs a low or high bound", ErrInvalidOutcomeSchema, i)
+		}
+
+		if referenceRange.Low != nil && referenceRange.High != nil && referenceRange.Low.GreaterThan(*referenceRange.High) {
+			return fmt.Errorf("%w: reference range %d has low greater than high", ErrInvalidOutcomeSchema, i)
+		}
+
+		if referenceRange.MinAge != nil && referenceRange.MaxAge != nil && *referenceRange.MinAge > *referenceRange.MaxAge {
+			return fmt.Errorf("%w: reference range %d has minAge greater than maxAge", ErrInvalidOutcomeSchema, i)
+		}
+	}
+
+	return nil
+}
+
+// Value implements the driver.Valuer interface.
+func (s OutcomeSchema) Value() (driver.Value, error) {
+	if s.Type == "" {
+		s.Type = OutcomeTypeText
+	}
+
+	b, err := json.Marshal(s)
+	if err != nil {
+		return nil, fmt.Errorf("marshal outcome schema: %w", err)
+	}
+
+	return string(b), nil
+}
+
+// Scan implements the sql.Scanner interface.
+func (s *OutcomeSchema) Scan(value any) error {
+	return scanJSON(value, s)
+}
+
+// ReferenceRange is the normal range of a numeric outcome.
+// Gender and age bounds narrow which patients the range applies to.
+type ReferenceRange struct {
+	Gender *Gender          `json:"gender,omitempty" validate:"omitnil,oneof=male female"`
+	MinAge *int             `json:"minAge,omitempty" validate:"omitnil,gte=0"`
+	MaxAge *int             `json:"maxAge,omitempty" validate:"omitnil,gte=0"`
+	Low    *decimal.Decimal `json:"low,omitempty"`
+	High   *decimal.Decimal `json:"high,omitempty"`
+}
+
+func (r ReferenceRange) matches(gender *Gender, age *int) bool {
+	if r.Gender != nil && (gender == nil || *gender != *r.Gender) {
+		return false
+	}
+
+	if r.MinAge != nil && (age == nil || *age < *r.MinAge) {
+		return false
+	}
+
+	if r.MaxAge != nil && (age == nil || *age > *r.MaxAge) {
+		return false
+	}
+
+	return true
+}
+
+func (r ReferenceRange) specificity() int {
+	specificity := 0
+	if r.Gender != nil {
+		specificity++
+	}
+	if r.MinAge != nil || r.MaxAge != nil {
+		specificity++
+	}
+	return specificity
+}
+
+// String formats the range for patient receipts, e.g. "70 - 100", "< 200" or "> 40".
+func (r ReferenceRange) String() string {
+	switch {
+	case r.Low != nil && r.High != nil:
+		return fmt.Sprintf("%s - %s", r.Low, r.High)
+	case r.High != nil:
+		return fmt.Sprintf("< %s", r.High)
+	case r.Low != nil:
+		return fmt.Sprintf("> %s", r.Low)
+	default:
+		return ""
+	}
+}
+
+type ResultFlag string
+
+const (
+	ResultFlagNone ResultFlag = ""
+	ResultFlagLow  ResultFlag = "low"
+	ResultFlagHigh ResultFlag = "high"
+)
+
+// Flag returns whether the outcome is below or above the range.
+// Non-numeric outcomes are never flagged.
+func (r ReferenceRange) Flag(outcome string) ResultFlag {
+	value, err := parseNumericOutcome(outcome)
+	if err != nil {
+		return ResultFlagNone
+	}
+
+	if r.Low != nil && value.LessThan(*r.Low) {
+		return ResultFlagLow
+	}
+
+	if r.High != nil && value.GreaterThan(*r.High) {
+		return ResultFlagHigh
+	}
+
+	return ResultFlagNone
+}
+
+type ReferenceRanges []ReferenceRange
+
+// Find returns the most specific range that applies to a patient.
+// A nil gender or age only matches ranges that do not depend on it.
+func (r ReferenceRanges) Find(gender *Gender, age *int) (ReferenceRange, bool) {
+	var (
+		found ReferenceRange
+		ok    bool
+	)
+
+	for _, referenceRange := range r {
+		if !referenceRange.matches(gender, age) {
+			continue
+		}
+
+		if !ok || referenceRange.specificity() > found.specificity() {
+			found, ok = referenceRange, true
+		}
+	}
+
+	return found, ok
+}
+
+// Value implements the driver.Valuer interface.
+func (r ReferenceRanges) Value() (driver.Value, error) {
+	if r == nil {
+		r = ReferenceRanges{}
+	}
+
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, fmt.Errorf("marshal reference ranges: %w", err)
+	}
+
+	return string(b), nil
+}
+
+// Scan implements the sql.Scanner interface.
+func (r *ReferenceRanges) Scan(value any) error {
+	return scanJSON(value, r)
+}
+
+func scanJSON(value any, dest any) error {
+	var b []byte
+	switch v := value.(type) {
+	case string:
+		b = []byte(v)
+	case []byte:
+		b = v
+	default:
+		return fmt.Errorf("cannot scan type %T into %T", value, dest)
+	}
+
+	return json.Unmarshal(b, dest)
+}
+
+// parseNumericOutcome parses outcomes such as "120", "5,6" or "5.6", taking either separator as the decimal one.
+// Thousands separators are not supported, and a separator followed by exactly three digits, such as "1.000",
+// is rejected: Indonesian writes thousands that way, so it could mean 1 as well as 1000.
+func parseNumericOutcome(outcome string) (decimal.Decimal, error) {
+	s := strings.TrimSpace(outcome)
+
+	if i := strings.IndexAny(s, ".,"); i >= 0 {
+		whole, fraction := s[:i], s[i+1:]
+		if len(fraction) == 3 && strings.TrimLeft(whole, "+-0") != "" {
+			return decimal.Decimal{}, fmt.Errorf("%q is ambiguous, write thousands without a separator", outcome)
+		}
+	}
+
+	value, err := decimal.NewFromString(strings.Replace(s, ",", ".", 1))
+	if err != nil {
+		return decimal.Decimal{}, fmt.Errorf("%q is not a number", outcome)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,226 @@
+package hris
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html/template"
+	"strings"
+
+	"github.com/turfaa/apotek-hris/internal/hris/templates"
+	"github.com/turfaa/apotek-hris/pkg/timex"
+	"github.com/turfaa/apotek-hris/pkg/tracing"
+)
+
+// GetWorkLogReceipt lays out the patient receipt of a work log, flagging results outside the patient's reference range.
+// It also returns the HTML template to render it with: the uploaded template of the first unit's work type
+// that has one, or the embedded template.
+func (s *Service) GetWorkLogReceipt(ctx context.Context, workLogID int64) (templates.Receipt, *template.Template, error) {
+	ctx, span := tracing.Start(ctx, "hris.Service.GetWorkLogReceipt")
+	defer span.End()
+
+	workLog, err := s.GetWorkLog(ctx, workLogID)
+	if err != nil {
+		return templates.Receipt{}, nil, err
+	}
+
+	receipt, err := s.workLogReceipt(ctx, workLog)
+	if err != nil {
+		return templates.Receipt{}, nil, err
+	}
+
+	tmpl, err := s.receiptTemplateFor(ctx, workLog)
+	if err != nil {
+		return templates.Receipt{}, nil, err
+	}
+
+	return receipt, tmpl, nil
+}
+
+func (s *Service) workLogReceipt(ctx context.Context, workLog WorkLog) (templates.Receipt, error) {
+	data, err := s.workLogForPatientData(ctx, workLog)
+	if err != nil {
+		return templates.Receipt{}, err
+	}
+
+	if verification := s.config.ReceiptVerification; verification.enabled() {
+		token := signReceiptToken(verification.SigningKey, workLog.ID)
+		data.VerificationURL = strings.TrimSuffix(verification.BaseURL, "/") + "/verify/" + token
+	}
+
+	return data.Receipt(), nil
+}
+
+// VerifyWorkLogReceipt checks the token from a receipt's QR code and returns what the receipt showed.
+// Deleted work logs are still returned so the page can tell they have been cancelled.
+func (s *Service) VerifyWorkLogReceipt(ctx context.Context, token string) (templates.Verification, error) {
+	ctx, span := tracing.Start(ctx, "hris.Service.VerifyWorkLogReceipt")
+	defer span.End()
+
+	workLogID, err := parseReceiptToken(s.config.ReceiptVerification.SigningKey, token)
+	if err != nil {
+		return templates.Verification{}, err
+	}
+
+	workLog, err := s.db.GetWorkLogIncludingDeleted(ctx, workLogID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return templates.Verification{}, ErrWorkLogNotFound
+		}
+		return templates.Verification{}, fmt.Errorf("get work log from db: %w", err)
+	}
+
+	data, err := s.workLogForPatientData(ctx, workLog)
+	if err != nil {
+		return templates.Verification{}, err
+	}
+
+	// The disclaimer is meant for the printed receipt only.
+	data.Notes = ""
+
+	verification := templates.Verification{Valid: true, Receipt: data.Receipt()}
+	if workLog.DeletedAt != nil {
+		loc, err := s.BranchLocation(ctx, &workLog.BranchID)
+		if err != nil {
+			return templates.Verification{}, err
+		}
+
+		verification.DeletedAt = timex.FormatDateTime(workLog.DeletedAt.In(loc))
+	}
+
+	return verification, nil
+}
+
+func (s *Service) workLogForPatientData(ctx context.Context, workLog WorkLog) (templates.WorkLogForPatientData, error) {
+	var (
+		gender *Gender
+		age    *int
+	)
+	if workLog.PatientID != nil {
+		patient, err := s.GetPatient(ctx, *workLog.PatientID)
+		if err != nil {
+			return templates.WorkLogForPatientData{}, err
+		}
+
+		gender = patient.Gender
+		age = patient.AgeAt(workLog.PerformedAt)
+	}
+
+	units := make([]templates.WorkLogUnitForPatientData, len(workLog.Units))
+	for i, unit := range workLog.Units {
+		units[i] = templates.WorkLogUnitForPatientData{
+			WorkType:    unit.WorkType.Name,
+			WorkOutcome: unit.WorkOutcome,
+			OutcomeUnit: unit.WorkType.OutcomeUnit,
+			Notes:       unit.WorkType.Notes,
+		}
+
+		if referenceRange, ok := unit.WorkType.ReferenceRanges.Find(gender, age); ok {
+			flag := referenceRange.Flag(unit.WorkOutcome)
+			units[i].ReferenceRange = referenceRange.String()
+			units[i].IsHigh = flag == ResultFlagHigh
+			units[i].IsLow = flag == ResultFlagLow
+		}
+	}
+
+	branding, err := s.GetReceiptBranding(ctx)
+	if err != nil {
+		return templates.WorkLogForPatientData{}, err
+	}
+
+	branch, err := s.GetBranch(ctx, workLog.BranchID)
+	if err != nil {
+		return templates.WorkLogForPatientData{}, err
+	}
+
+	// Each outlet has its own address and SIA, the branding ones are kept for branches without them.
+	data := templates.WorkLogForPatientData{
+		StoreName:    branding.StoreName,
+		StoreAddress: cmp.Or(branch.Address, branding.Address),
+		SIANumber:    cmp.Or(branch.SIANumber, branding.SIANumber),
+		BranchName:   branch.Name,
+		PatientName:  workLog.PatientName,
+		EmployeeName: workLog.Employee.Name,
+		Units:        units,
+		Notes:        branding.FooterDisclaimer,
+		Date:         timex.FormatDate(workLog.PerformedAt.In(branch.Location())),
+	}
+
+	if branding.HasLogo {
+		logo, err := s.getReceiptLogo(ctx, branding)
+		if err != nil {
+			return templates.WorkLogForPatientData{}, err
+		}
+
+		data.Logo = templates.ImageDataURL(*branding.LogoContentType, logo)
+	}
+
+	return data, nil
+}
+
+// receiptTemplateFor returns the uploaded template of the first unit's work type that has one,
+// falling back to the embedded template.
+func (s *Service) receiptTemplateFor(ctx context.Context, workLog WorkLog) (*template.Template, error) {
+	workTypeIDs := make([]int64, len(workLog.Units))
+	for i, unit := range workLog.Units {
+		workTypeIDs[i] = unit.WorkType.ID
+	}
+
+	receiptTemplates, err := s.db.GetReceiptTemplatesByWorkTypeIDs(ctx, workTypeIDs)
+	if err != nil {
+		return nil, fmt.Errorf("get receipt templates from db: %w", err)
+	}
+
+	for _, workTypeID := range workTypeIDs {
+		receiptTemplate, ok := receiptTemplates[workTypeID]
+		if !ok {
+			continue
+		}
+
+		tmpl, err := templates.ParseReceiptTemplate(receiptTemplateName(receiptTemplate.WorkTypeID), receiptTemplate.Content)
+		if err != nil {
+			return nil, fmt.Errorf("parse receipt template of work type %d: %w", workTypeID, err)
+		}
+
+		return tmpl, nil
+	}
+
+	return templates.WorkLogForPatient, nil
+}
+
+// GetWorkLogReceiptESCPOS renders the patient receipt of a work log for the configured receipt printer's paper width.
+func (s *Service) GetWorkLogReceiptESCPOS(ctx context.Context, workLogID int64) ([]byte, error) {
+	ctx, span := tracing.Start(ctx, "hris.Service.GetWorkLogReceiptESCPOS")
+	defer span.End()
+
+	workLog, err := s.GetWorkLog(ctx, workLogID)
+	if err != nil {
+		return nil, err
+	}
+
+	receipt, err := s.workLogReceipt(ctx, workLog)
+	if err != nil {
+		return nil, err
+	}
+
+	return receipt.ESCPOS(s.receiptPrinter.NewBuilder()), nil
+}
+
+// PrintWorkLogReceipt sends the patient receipt of a work log to the configured receipt printer.
+func (s *Service) PrintWorkLogReceipt(ctx context.Context, workLogID int64) error {
+	ctx, span := tracing.Start(ctx, "hris.Service.PrintWorkLogReceipt")
+	defer span.End()
+
+	data, err := s.GetWorkLogReceiptESCPOS(ctx, workLogID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.receiptPrinter.Print(ctx, data); err != nil {
+		return fmt.Errorf("print receipt: %w", err)
+	}
+
+	return nil
+}
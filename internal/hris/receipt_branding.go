@@ -0,0 +1,242 @@
+package hris
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/turfaa/apotek-hris/internal/hris/templates"
+	"github.com/turfaa/apotek-hris/pkg/blobstore"
+	"github.com/turfaa/apotek-hris/pkg/tracing"
+	"github.com/turfaa/apotek-hris/pkg/validatorx"
+)
+
+var (
+	ErrInvalidLogo             = errors.New("logo must be an image")
+	ErrReceiptLogoNotFound     = errors.New("receipt logo not found")
+	ErrReceiptTemplateNotFound = errors.New("receipt template not found")
+)
+
+const receiptLogoKeyPrefix = "receipt-logos"
+
+// receiptBrandingID is the ID of the only receipt branding row, used for its audit logs.
+const receiptBrandingID = 1
+
+func (s *Service) GetReceiptBranding(ctx context.Context) (ReceiptBranding, error) {
+	ctx, span := tracing.Start(ctx, "hris.Service.GetReceiptBranding")
+	defer span.End()
+
+	branding, err := s.db.GetReceiptBranding(ctx)
+	if err != nil {
+		return ReceiptBranding{}, fmt.Errorf("get receipt branding from db: %w", err)
+	}
+
+	return branding, nil
+}
+
+func (s *Service) UpdateReceiptBranding(ctx context.Context, request UpdateReceiptBrandingRequest) (ReceiptBranding, error) {
+	ctx, span := tracing.Start(ctx, "hris.Service.UpdateReceiptBranding")
+	defer span.End()
+
+	if err := validatorx.Validate(request); err != nil {
+		return ReceiptBranding{}, fmt.Errorf("invalid request: %w", err)
+	}
+
+	actorID, err := s.actorID(ctx)
+	if err != nil {
+		return ReceiptBranding{}, err
+	}
+
+	branding, err := s.db.UpdateReceiptBranding(ctx, request, actorID)
+	if err != nil {
+		return ReceiptBranding{}, fmt.Errorf("update receipt branding in db: %w", err)
+	}
+
+	return branding, nil
+}
+
+// UploadReceiptLogo replaces the logo printed at the top of HTML receipts.
+func (s *Service) UploadReceiptLogo(ctx context.Context, fileName string, contentType string, r io.Reader) (ReceiptBranding, error) {
+	ctx, span := tracing.Start(ctx, "hris.Service.UploadReceiptLogo")
+	defer span.End()
+
+	if !strings.HasPrefix(contentType, "image/") {
+		return ReceiptBranding{}, ErrInvalidLogo
+	}
+
+	actorID, err := s.actorID(ctx)
+	if err != nil {
+		return ReceiptBranding{}, err
+	}
+
+	key, err := blobstore.NewKey(receiptLogoKeyPrefix, fileName)
+	if err != nil {
+		return ReceiptBranding{}, fmt.Errorf("new blob key: %w", err)
+	}
+
+	if err := s.blobStore.Put(ctx, key, r); err != nil {
+		return ReceiptBranding{}, fmt.Errorf("put logo to blob store: %w", err)
+	}
+
+	before, branding, err := s.db.SetReceiptBrandingLogo(ctx, &key, &contentType, actorID)
+	if err != nil {
+		_ = s.blobStore.Delete(ctx, key)
+		return ReceiptBranding{}, fmt.Errorf("set receipt logo in db: %w", err)
+	}
+
+	if before.HasLogo {
+		_ = s.blobStore.Delete(ctx, *before.LogoStorageKey)
+	}
+
+	return branding, nil
+}
+
+func (s *Service) DeleteReceiptLogo(ctx context.Context) (ReceiptBranding, error) {
+	ctx, span := tracing.Start(ctx, "hris.Service.DeleteReceiptLogo")
+	defer span.End()
+
+	current, err := s.GetReceiptBranding(ctx)
+	if err != nil {
+		return ReceiptBranding{}, err
+	}
+
+	if !current.HasLogo {
+		return ReceiptBranding{}, ErrReceiptLogoNotFound
+	}
+
+	actorID, err := s.actorID(ctx)
+	if err != nil {
+		return ReceiptBranding{}, err
+	}
+
+	before, branding, err := s.db.SetReceiptBrandingLogo(ctx, nil, nil, actorID)
+	if err != nil {
+		return ReceiptBranding{}, fmt.Errorf("remove receipt logo in db: %w", err)
+	}
+
+	if before.HasLogo {
+		_ = s.blobStore.Delete(ctx, *before.LogoStorageKey)
+	}
+
+	return branding, nil
+}
+
+// GetReceiptLogo returns the logo content type and content. The caller must close the content.
+func (s *Service) GetReceiptLogo(ctx context.Context) (string, io.ReadCloser, error) {
+	ctx, span := tracing.Start(ctx, "hris.Service.GetReceiptLogo")
+	defer span.End()
+
+	branding, err := s.GetReceiptBranding(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if !branding.HasLogo {
+		return "", nil, ErrReceiptLogoNotFound
+	}
+
+	content, err := s.blobStore.Get(ctx, *branding.LogoStorageKey)
+	if err != nil {
+		return "", nil, fmt.Errorf("get logo from blob store: %w", err)
+	}
+
+	return *branding.LogoContentType, content, nil
+}
+
+func (s *Service) getReceiptLogo(ctx context.Context, branding ReceiptBranding) ([]byte, error) {
+	content, err := s.blobStore.Get(ctx, *branding.LogoStorageKey)
+	if err != nil {
+		return nil, fmt.Errorf("get logo from blob store: %w", err)
+	}
+	defer content.Close()
+
+	logo, err := io.ReadAll(content)
+	if err != nil {
+		return nil, fmt.Errorf("read logo: %w", err)
+	}
+
+	return logo, nil
+}
+
+func (s *Service) GetReceiptTemplates(ctx context.Context) ([]ReceiptTemplate, error) {
+	ctx, span := tracing.Start(ctx, "hris.Service.GetReceiptTemplates")
+	defer span.End()
+
+	receiptTemplates, err := s.db.GetReceiptTemplates(ctx)
+	if err != nil {
+		return []ReceiptTemplate{}, fmt.Errorf("get receipt templates from db: %w", err)
+	}
+
+	return receiptTemplates, nil
+}
+
+func (s *Service) GetReceiptTemplate(ctx context.Context, workTypeID int64) (ReceiptTemplate, error) {
+	ctx, span := tracing.Start(ctx, "hris.Service.GetReceiptTemplate")
+	defer span.End()
+
+	receiptTemplates, err := s.db.GetReceiptTemplatesByWorkTypeIDs(ctx, []int64{workTypeID})
+	if err != nil {
+		return ReceiptTemplate{}, fmt.Errorf("get receipt templates from db: %w", err)
+	}
+
+	receiptTemplate, ok := receiptTemplates[workTypeID]
+	if !ok {
+		return ReceiptTemplate{}, ErrReceiptTemplateNotFound
+	}
+
+	return receiptTemplate, nil
+}
+
+// SetReceiptTemplate replaces the HTML template used for patient receipts of the work type.
+// The template is executed with a templates.Receipt and rejected if it does not parse or render.
+func (s *Service) SetReceiptTemplate(ctx context.Context, workTypeID int64, content string) (ReceiptTemplate, error) {
+	ctx, span := tracing.Start(ctx, "hris.Service.SetReceiptTemplate")
+	defer span.End()
+
+	if _, err := templates.ParseReceiptTemplate(receiptTemplateName(workTypeID), content); err != nil {
+		return ReceiptTemplate{}, err
+	}
+
+	workTypes, err := s.db.GetWorkTypesByIDs(ctx, []int64{workTypeID})
+	if err != nil {
+		return ReceiptTemplate{}, fmt.Errorf("get work type from db: %w", err)
+	}
+	if len(workTypes) == 0 {
+		return ReceiptTemplate{}, ErrWorkTypeNotFound
+	}
+
+	actorID, err := s.actorID(ctx)
+	if err != nil {
+		return ReceiptTemplate{}, err
+	}
+
+	receiptTemplate, err := s.db.SetReceiptTemplate(ctx, workTypeID, content, actorID)
+	if err != nil {
+		return ReceiptTemplate{}, fmt.Errorf("set receipt template in db: %w", err)
+	}
+
+	return receiptTemplate, nil
+}
+
+// DeleteReceiptTemplate makes the work type fall back to the embedded receipt template.
+func (s *Service) DeleteReceiptTemplate(ctx context.Context, workTypeID int64) error {
+	ctx, span := tracing.Start(ctx, "hris.Service.DeleteReceiptTemplate")
+	defer span.End()
+
+	if err := s.db.DeleteReceiptTemplate(ctx, workTypeID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrReceiptTemplateNotFound
+		}
+		return fmt.Errorf("delete receipt template from db: %w", err)
+	}
+
+	return nil
+}
+
+func receiptTemplateName(workTypeID int64) string {
+	return "work_type_" + strconv.FormatInt(workTypeID, 10) + ".html"
+}
@@ -0,0 +1,53 @@
+package hris
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidReceiptToken = errors.New("invalid receipt verification token")
+
+// receiptTokenMACSize is how many bytes of the HMAC are kept, short enough for a small QR code.
+const receiptTokenMACSize = 16
+
+// signReceiptToken returns a token of the form "<work log ID in base 36>.<HMAC>"
+// proving the receipt was issued by us.
+func signReceiptToken(key string, workLogID int64) string {
+	payload := strconv.FormatInt(workLogID, 36)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(receiptTokenMAC(key, payload))
+}
+
+// parseReceiptToken returns the work log ID of a token signed by signReceiptToken.
+func parseReceiptToken(key string, token string) (int64, error) {
+	if key == "" {
+		return 0, fmt.Errorf("%w: verification is not configured", ErrInvalidReceiptToken)
+	}
+
+	payload, encodedMAC, ok := strings.Cut(token, ".")
+	if !ok {
+		return 0, ErrInvalidReceiptToken
+	}
+
+	mac, err := base64.RawURLEncoding.DecodeString(encodedMAC)
+	if err != nil || !hmac.Equal(mac, receiptTokenMAC(key, payload)) {
+		return 0, ErrInvalidReceiptToken
+	}
+
+	workLogID, err := strconv.ParseInt(payload, 36, 64)
+	if err != nil {
+		return 0, ErrInvalidReceiptToken
+	}
+
+	return workLogID, nil
+}
+
+func receiptTokenMAC(key string, payload string) []byte {
+	h := hmac.New(sha256.New, []byte(key))
+	h.Write([]byte("work-log-receipt:" + payload))
+	return h.Sum(nil)[:receiptTokenMACSize]
+}
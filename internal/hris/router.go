@@ -3,24 +3,74 @@ package hris
 import "github.com/go-chi/chi/v5"
 
 func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Route("/branches", h.registerBranchRoutes)
 	r.Route("/employees", h.registerEmployeeRoutes)
 	r.Route("/work-types", h.registerWorkTypeRoutes)
 	r.Route("/work-logs", h.registerWorkLogRoutes)
+	r.Route("/patients", h.registerPatientRoutes)
+	r.Route("/receipts", h.registerReceiptRoutes)
+	r.Get("/competencies/expiring", h.GetExpiringCompetencies)
+}
+
+// RegisterPublicRoutes registers the pages patients can open without going through the API.
+func (h *Handler) RegisterPublicRoutes(r chi.Router) {
+	r.Get("/verify/{token}", h.VerifyWorkLog)
+}
+
+func (h *Handler) registerBranchRoutes(r chi.Router) {
+	r.Get("/", h.GetBranches)
+	r.Post("/", h.CreateBranch)
+	r.Put("/{branchID}", h.UpdateBranch)
 }
 
 func (h *Handler) registerEmployeeRoutes(r chi.Router) {
 	r.Get("/", h.GetEmployees)
 	r.Post("/", h.CreateEmployee)
+	r.Get("/{employeeID}", h.GetEmployee)
+	r.Put("/{employeeID}/role", h.SetEmployeeRole)
+	r.Put("/{employeeID}/contact", h.SetEmployeeContact)
+	r.Get("/{employeeID}/branches", h.GetEmployeeBranches)
+	r.Put("/{employeeID}/branches", h.SetEmployeeBranches)
+	r.Get("/{employeeID}/competencies", h.GetEmployeeCompetencies)
+	r.Put("/{employeeID}/competencies/{workTypeID}", h.SetEmployeeCompetency)
+	r.Delete("/{employeeID}/competencies/{workTypeID}", h.DeleteEmployeeCompetency)
 }
 
 func (h *Handler) registerWorkTypeRoutes(r chi.Router) {
 	r.Get("/", h.GetWorkTypes)
 	r.Post("/", h.CreateWorkType)
+	r.Patch("/{workTypeID}", h.UpdateWorkType)
+	r.Post("/{workTypeID}/archive", h.ArchiveWorkType)
+	r.Post("/{workTypeID}/unarchive", h.UnarchiveWorkType)
+	r.Get("/{workTypeID}/versions", h.GetWorkTypeVersions)
 }
 
 func (h *Handler) registerWorkLogRoutes(r chi.Router) {
 	r.Get("/", h.GetWorkLogs)
 	r.Post("/", h.CreateWorkLog)
+	r.Post("/export", h.ExportWorkLogs)
 	r.Get("/{workLogID}/for-patient", h.PrintWorkLogForPatient)
+	r.Post("/{workLogID}/for-patient/print", h.SendWorkLogForPatientToPrinter)
+	r.Patch("/{workLogID}", h.UpdateWorkLog)
+	r.Get("/{workLogID}/revisions", h.GetWorkLogRevisions)
 	r.Delete("/{workLogID}", h.DeleteWorkLog)
 }
+
+func (h *Handler) registerPatientRoutes(r chi.Router) {
+	r.Get("/", h.SearchPatients)
+	r.Post("/", h.CreatePatient)
+	r.Get("/{patientID}", h.GetPatient)
+	r.Get("/{patientID}/history", h.GetPatientHistory)
+}
+
+func (h *Handler) registerReceiptRoutes(r chi.Router) {
+	r.Get("/branding", h.GetReceiptBranding)
+	r.Put("/branding", h.UpdateReceiptBranding)
+	r.Get("/branding/logo", h.DownloadReceiptLogo)
+	r.Put("/branding/logo", h.UploadReceiptLogo)
+	r.Delete("/branding/logo", h.DeleteReceiptLogo)
+	r.Get("/templates", h.GetReceiptTemplates)
+	r.Get("/templates/{workTypeID}", h.GetReceiptTemplate)
+	r.Put("/templates/{workTypeID}", h.UploadReceiptTemplate)
+	r.Delete("/templates/{workTypeID}", h.DeleteReceiptTemplate)
+}
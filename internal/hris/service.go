@@ -2,26 +2,102 @@ package hris
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/turfaa/apotek-hris/internal/document"
+	"github.com/turfaa/apotek-hris/internal/queue"
+	"github.com/turfaa/apotek-hris/pkg/actor"
+	"github.com/turfaa/apotek-hris/pkg/blobstore"
+	"github.com/turfaa/apotek-hris/pkg/escpos"
+	"github.com/turfaa/apotek-hris/pkg/pagination"
+	"github.com/turfaa/apotek-hris/pkg/tracing"
 	"github.com/turfaa/apotek-hris/pkg/validatorx"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/turfaa/go-date"
 )
 
-var ErrWorkLogNotFound = errors.New("work log not found")
+var (
+	ErrWorkLogNotFound     = errors.New("work log not found")
+	ErrWorkLogUnitNotFound = errors.New("work log unit not found")
+	ErrWorkLogWithoutUnits = errors.New("work log must keep at least one unit")
+	ErrEmptyWorkLogUpdate  = errors.New("work log update has no changes")
+	ErrDuplicateUnitChange = errors.New("each work log unit can only be updated or removed once per request")
+
+	ErrPerformedInFuture    = errors.New("work log cannot be performed in the future")
+	ErrBackdateNeedsManager = errors.New("backdating a work log beyond the allowed window requires a manager")
+
+	ErrForbidden    = errors.New("only managers can change employee roles")
+	ErrUnknownActor = errors.New("acting employee does not exist")
+
+	ErrPatientNotFound = errors.New("patient not found")
+
+	ErrWorkTypeNotFound    = errors.New("work type not found")
+	ErrWorkTypeArchived    = errors.New("work type is archived")
+	ErrEmptyWorkTypeUpdate = errors.New("work type update has no changes")
+
+	ErrMissingCompetency  = errors.New("employee is not certified for the work type")
+	ErrLicenseExpired     = errors.New("employee license has expired")
+	ErrInvalidCompetency  = errors.New("competency cannot expire before it is certified")
+	ErrCompetencyNotFound = errors.New("competency not found")
+)
+
+// performedAtClockSkew tolerates clients whose clocks run slightly ahead of the server.
+const performedAtClockSkew = 5 * time.Minute
+
+const (
+	auditEntityEmployee = "employee"
+	auditEntityWorkType = "work_type"
+	auditEntityWorkLog  = "work_log"
+	auditEntityPatient  = "patient"
+
+	auditEntityCompetency = "employee_competency"
+
+	auditEntityBranch           = "branch"
+	auditEntityEmployeeBranches = "employee_branches"
+
+	auditEntityReceiptBranding = "receipt_branding"
+	auditEntityReceiptTemplate = "receipt_template"
+)
+
+const defaultPatientSearchLimit = 10
+
+const defaultCompetencyExpiryWindowDays = 30
 
 type Service struct {
-	db *DB
+	db              *DB
+	config          Config
+	receiptPrinter  *escpos.Printer
+	blobStore       blobstore.Store
+	documentService *document.Service
+	queue           *queue.Queue
 }
 
-func NewService(db *sqlx.DB) *Service {
-	return &Service{db: &DB{db: db}}
+func NewService(
+	db *sqlx.DB,
+	config Config,
+	blobStore blobstore.Store,
+	documentService *document.Service,
+	queue *queue.Queue,
+) *Service {
+	return &Service{
+		db:              &DB{db: db},
+		config:          config,
+		receiptPrinter:  escpos.NewPrinter(config.ReceiptPrinter),
+		blobStore:       blobStore,
+		documentService: documentService,
+		queue:           queue,
+	}
 }
 
 func (s *Service) GetEmployee(ctx context.Context, employeeID int64) (Employee, error) {
+	ctx, span := tracing.Start(ctx, "hris.Service.GetEmployee")
+	defer span.End()
+
 	employee, err := s.db.GetEmployee(ctx, employeeID)
 	if err != nil {
 		return Employee{}, fmt.Errorf("get employee from db: %w", err)
@@ -30,8 +106,12 @@ func (s *Service) GetEmployee(ctx context.Context, employeeID int64) (Employee,
 	return employee, nil
 }
 
-func (s *Service) GetEmployees(ctx context.Context) ([]Employee, error) {
-	employees, err := s.db.GetEmployees(ctx)
+// GetEmployees returns the employees, limited to those working at the branch if branchID is not nil.
+func (s *Service) GetEmployees(ctx context.Context, branchID *int64) ([]Employee, error) {
+	ctx, span := tracing.Start(ctx, "hris.Service.GetEmployees")
+	defer span.End()
+
+	employees, err := s.db.GetEmployees(ctx, branchID)
 	if err != nil {
 		return []Employee{}, fmt.Errorf("get employees from db: %w", err)
 	}
@@ -39,7 +119,27 @@ func (s *Service) GetEmployees(ctx context.Context) ([]Employee, error) {
 	return employees, nil
 }
 
+// ListEmployees returns a page of the employees matching the filters of the request.
+func (s *Service) ListEmployees(ctx context.Context, request ListEmployeesRequest) (pagination.Page[Employee], error) {
+	ctx, span := tracing.Start(ctx, "hris.Service.ListEmployees")
+	defer span.End()
+
+	if err := validatorx.Validate(request); err != nil {
+		return pagination.Page[Employee]{}, fmt.Errorf("invalid request: %w", err)
+	}
+
+	employees, err := s.db.ListEmployees(ctx, request)
+	if err != nil {
+		return pagination.Page[Employee]{}, fmt.Errorf("list employees from db: %w", err)
+	}
+
+	return employees, nil
+}
+
 func (s *Service) GetEmployeesByIDs(ctx context.Context, ids []int64) ([]Employee, error) {
+	ctx, span := tracing.Start(ctx, "hris.Service.GetEmployeesByIDs")
+	defer span.End()
+
 	employees, err := s.db.GetEmployeesByIDs(ctx, ids)
 	if err != nil {
 		return []Employee{}, fmt.Errorf("get employees by ids from db: %w", err)
@@ -49,10 +149,19 @@ func (s *Service) GetEmployeesByIDs(ctx context.Context, ids []int64) ([]Employe
 }
 
 func (s *Service) CreateEmployee(ctx context.Context, request CreateEmployeeRequest) (Employee, error) {
+	ctx, span := tracing.Start(ctx, "hris.Service.CreateEmployee")
+	defer span.End()
+
 	if err := validatorx.Validate(request); err != nil {
 		return Employee{}, fmt.Errorf("invalid request: %w", err)
 	}
 
+	if request.HomeBranchID != nil {
+		if err := s.checkBranchesExist(ctx, []int64{*request.HomeBranchID}); err != nil {
+			return Employee{}, err
+		}
+	}
+
 	employee, err := s.db.CreateEmployee(ctx, request)
 	if err != nil {
 		return Employee{}, fmt.Errorf("create employee in db: %w", err)
@@ -61,8 +170,48 @@ func (s *Service) CreateEmployee(ctx context.Context, request CreateEmployeeRequ
 	return employee, nil
 }
 
-func (s *Service) GetWorkTypes(ctx context.Context) ([]WorkType, error) {
-	workTypes, err := s.db.GetWorkTypes(ctx)
+func (s *Service) SetEmployeeRole(ctx context.Context, request SetEmployeeRoleRequest) (Employee, error) {
+	ctx, span := tracing.Start(ctx, "hris.Service.SetEmployeeRole")
+	defer span.End()
+
+	if err := validatorx.Validate(request); err != nil {
+		return Employee{}, fmt.Errorf("invalid request: %w", err)
+	}
+
+	if err := s.checkCanSetRoles(ctx); err != nil {
+		return Employee{}, err
+	}
+
+	employee, err := s.db.SetEmployeeRole(ctx, request.EmployeeID, request.Role, request.IfMatch)
+	if err != nil {
+		return Employee{}, fmt.Errorf("set employee role in db: %w", err)
+	}
+
+	return employee, nil
+}
+
+// SetEmployeeContact sets where and whether notifications are sent to the employee.
+func (s *Service) SetEmployeeContact(ctx context.Context, request SetEmployeeContactRequest) (Employee, error) {
+	ctx, span := tracing.Start(ctx, "hris.Service.SetEmployeeContact")
+	defer span.End()
+
+	if err := validatorx.Validate(request); err != nil {
+		return Employee{}, fmt.Errorf("invalid request: %w", err)
+	}
+
+	employee, err := s.db.SetEmployeeContact(ctx, request)
+	if err != nil {
+		return Employee{}, fmt.Errorf("set employee contact in db: %w", err)
+	}
+
+	return employee, nil
+}
+
+func (s *Service) GetWorkTypes(ctx context.Context, includeArchived bool) ([]WorkType, error) {
+	ctx, span := tracing.Start(ctx, "hris.Service.GetWorkTypes")
+	defer span.End()
+
+	workTypes, err := s.db.GetWorkTypes(ctx, includeArchived)
 	if err != nil {
 		return []WorkType{}, fmt.Errorf("get work types from db: %w", err)
 	}
@@ -71,10 +220,21 @@ func (s *Service) GetWorkTypes(ctx context.Context) ([]WorkType, error) {
 }
 
 func (s *Service) CreateWorkType(ctx context.Context, request CreateWorkTypeRequest) (WorkType, error) {
+	ctx, span := tracing.Start(ctx, "hris.Service.CreateWorkType")
+	defer span.End()
+
 	if err := validatorx.Validate(request); err != nil {
 		return WorkType{}, fmt.Errorf("invalid request: %w", err)
 	}
 
+	if request.OutcomeSchema.Type == "" {
+		request.OutcomeSchema.Type = OutcomeTypeText
+	}
+
+	if err := checkOutcomeDefinition(request.OutcomeSchema, request.ReferenceRanges); err != nil {
+		return WorkType{}, err
+	}
+
 	workType, err := s.db.CreateWorkType(ctx, request)
 	if err != nil {
 		return WorkType{}, fmt.Errorf("create work type in db: %w", err)
@@ -83,8 +243,102 @@ func (s *Service) CreateWorkType(ctx context.Context, request CreateWorkTypeRequ
 	return workType, nil
 }
 
-func (s *Service) GetWorkLogsBetween(ctx context.Context, startDate time.Time, endDate time.Time) ([]WorkLog, error) {
-	workLogs, err := s.db.GetWorkLogsBetween(ctx, startDate, endDate)
+func (s *Service) UpdateWorkType(ctx context.Context, workTypeID int64, request UpdateWorkTypeRequest) (WorkType, error) {
+	ctx, span := tracing.Start(ctx, "hris.Service.UpdateWorkType")
+	defer span.End()
+
+	if err := validatorx.Validate(request); err != nil {
+		return WorkType{}, fmt.Errorf("invalid request: %w", err)
+	}
+
+	if !request.HasChanges() {
+		return WorkType{}, ErrEmptyWorkTypeUpdate
+	}
+
+	current, err := s.getWorkType(ctx, workTypeID)
+	if err != nil {
+		return WorkType{}, err
+	}
+
+	outcomeSchema, referenceRanges := current.OutcomeSchema, current.ReferenceRanges
+	if request.OutcomeSchema != nil {
+		if request.OutcomeSchema.Type == "" {
+			request.OutcomeSchema.Type = OutcomeTypeText
+		}
+		outcomeSchema = *request.OutcomeSchema
+	}
+	if request.ReferenceRanges != nil {
+		referenceRanges = *request.ReferenceRanges
+	}
+
+	if err := checkOutcomeDefinition(outcomeSchema, referenceRanges); err != nil {
+		return WorkType{}, err
+	}
+
+	actorID, err := s.actorID(ctx)
+	if err != nil {
+		return WorkType{}, err
+	}
+
+	workType, err := s.db.UpdateWorkType(ctx, workTypeID, request, actorID)
+	if err != nil {
+		return WorkType{}, fmt.Errorf("update work type in db: %w", err)
+	}
+
+	return workType, nil
+}
+
+// SetWorkTypeArchived archives or restores a work type.
+// Existing work logs keep showing archived work types.
+func (s *Service) SetWorkTypeArchived(ctx context.Context, workTypeID int64, archived bool) (WorkType, error) {
+	ctx, span := tracing.Start(ctx, "hris.Service.SetWorkTypeArchived")
+	defer span.End()
+
+	workType, err := s.db.SetWorkTypeArchived(ctx, workTypeID, archived)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return WorkType{}, ErrWorkTypeNotFound
+		}
+		return WorkType{}, fmt.Errorf("set work type archived in db: %w", err)
+	}
+
+	return workType, nil
+}
+
+func (s *Service) GetWorkTypeVersions(ctx context.Context, workTypeID int64) ([]WorkTypeVersion, error) {
+	ctx, span := tracing.Start(ctx, "hris.Service.GetWorkTypeVersions")
+	defer span.End()
+
+	if _, err := s.getWorkType(ctx, workTypeID); err != nil {
+		return []WorkTypeVersion{}, err
+	}
+
+	versions, err := s.db.GetWorkTypeVersions(ctx, workTypeID)
+	if err != nil {
+		return []WorkTypeVersion{}, fmt.Errorf("get work type versions from db: %w", err)
+	}
+
+	return versions, nil
+}
+
+func (s *Service) getWorkType(ctx context.Context, workTypeID int64) (WorkType, error) {
+	workType, err := s.db.GetWorkType(ctx, workTypeID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return WorkType{}, ErrWorkTypeNotFound
+		}
+		return WorkType{}, fmt.Errorf("get work type from db: %w", err)
+	}
+
+	return workType, nil
+}
+
+// GetWorkLogsBetween returns the work logs performed in the range, limited to the branch if branchID is not nil.
+func (s *Service) GetWorkLogsBetween(ctx context.Context, startDate time.Time, endDate time.Time, branchID *int64) ([]WorkLog, error) {
+	ctx, span := tracing.Start(ctx, "hris.Service.GetWorkLogsBetween")
+	defer span.End()
+
+	workLogs, err := s.db.GetWorkLogsBetween(ctx, startDate, endDate, branchID)
 	if err != nil {
 		return []WorkLog{}, fmt.Errorf("get work logs from db: %w", err)
 	}
@@ -92,7 +346,23 @@ func (s *Service) GetWorkLogsBetween(ctx context.Context, startDate time.Time, e
 	return workLogs, nil
 }
 
+// ListWorkLogs returns a page of the work logs performed in the range of the request, matching its filters.
+func (s *Service) ListWorkLogs(ctx context.Context, request ListWorkLogsRequest) (pagination.Page[WorkLog], error) {
+	ctx, span := tracing.Start(ctx, "hris.Service.ListWorkLogs")
+	defer span.End()
+
+	workLogs, err := s.db.ListWorkLogs(ctx, request)
+	if err != nil {
+		return pagination.Page[WorkLog]{}, fmt.Errorf("list work logs from db: %w", err)
+	}
+
+	return workLogs, nil
+}
+
 func (s *Service) GetEmployeeWorkLogsBetween(ctx context.Context, employeeID int64, startDate time.Time, endDate time.Time) ([]WorkLog, error) {
+	ctx, span := tracing.Start(ctx, "hris.Service.GetEmployeeWorkLogsBetween")
+	defer span.End()
+
 	workLogs, err := s.db.GetEmployeeWorkLogsBetween(ctx, employeeID, startDate, endDate)
 	if err != nil {
 		return []WorkLog{}, fmt.Errorf("get employee work logs from db: %w", err)
@@ -102,6 +372,9 @@ func (s *Service) GetEmployeeWorkLogsBetween(ctx context.Context, employeeID int
 }
 
 func (s *Service) GetWorkLog(ctx context.Context, workLogID int64) (WorkLog, error) {
+	ctx, span := tracing.Start(ctx, "hris.Service.GetWorkLog")
+	defer span.End()
+
 	workLog, err := s.db.GetWorkLog(ctx, workLogID)
 	if err != nil {
 		return WorkLog{}, fmt.Errorf("get work log from db: %w", err)
@@ -111,19 +384,352 @@ func (s *Service) GetWorkLog(ctx context.Context, workLogID int64) (WorkLog, err
 }
 
 func (s *Service) CreateWorkLog(ctx context.Context, request CreateWorkLogRequest) (WorkLog, error) {
+	ctx, span := tracing.Start(ctx, "hris.Service.CreateWorkLog")
+	defer span.End()
+
 	if err := validatorx.Validate(request); err != nil {
 		return WorkLog{}, fmt.Errorf("invalid request: %w", err)
 	}
 
+	if request.PerformedAt != nil {
+		if err := s.checkPerformedAt(ctx, *request.PerformedAt); err != nil {
+			return WorkLog{}, err
+		}
+	}
+
+	performedAt := time.Now()
+	if request.PerformedAt != nil {
+		performedAt = *request.PerformedAt
+	}
+
+	if err := s.validateWorkOutcomes(ctx, performedAt, request.Units); err != nil {
+		return WorkLog{}, err
+	}
+
+	if request.BranchID != nil {
+		if err := s.checkEmployeeInBranch(ctx, request.EmployeeID, *request.BranchID); err != nil {
+			return WorkLog{}, err
+		}
+	} else {
+		employee, err := s.GetEmployee(ctx, request.EmployeeID)
+		if err != nil {
+			return WorkLog{}, err
+		}
+
+		request.BranchID = &employee.HomeBranchID
+	}
+
+	loc, err := s.BranchLocation(ctx, request.BranchID)
+	if err != nil {
+		return WorkLog{}, err
+	}
+
+	// Licenses and competencies are valid per calendar day at the branch the work is done at.
+	performedOn := date.NewFromTime(performedAt.In(loc))
+
+	if err := s.checkCompetencies(ctx, request.EmployeeID, performedOn, request.Units); err != nil {
+		return WorkLog{}, err
+	}
+
+	if err := s.checkLicenses(ctx, request.EmployeeID, performedOn); err != nil {
+		return WorkLog{}, err
+	}
+
+	if request.PatientID != nil {
+		patient, err := s.db.GetPatient(ctx, *request.PatientID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return WorkLog{}, ErrPatientNotFound
+			}
+			return WorkLog{}, fmt.Errorf("get patient from db: %w", err)
+		}
+
+		if request.PatientName == "" {
+			request.PatientName = patient.Name
+		}
+	}
+
 	workLog, err := s.db.CreateWorkLog(ctx, request)
 	if err != nil {
 		return WorkLog{}, fmt.Errorf("create work log in db: %w", err)
 	}
 
+	recordWorkLogCreated(workLog)
+
 	return workLog, nil
 }
 
+// checkLicenses returns ErrLicenseExpired if blocking is enabled and
+// the employee's STRA or SIPA has expired on the day the work was performed.
+func (s *Service) checkLicenses(ctx context.Context, employeeID int64, performedOn date.Date) error {
+	if !s.config.BlockWorkLogsOnExpiredLicense {
+		return nil
+	}
+
+	expired, err := s.documentService.ExpiredLicenseTypes(ctx, employeeID, performedOn)
+	if err != nil {
+		return fmt.Errorf("get expired licenses: %w", err)
+	}
+
+	if len(expired) == 0 {
+		return nil
+	}
+
+	names := make([]string, len(expired))
+	for i, t := range expired {
+		names[i] = strings.ToUpper(string(t))
+	}
+
+	return fmt.Errorf("%w: %s", ErrLicenseExpired, strings.Join(names, ", "))
+}
+
+// validateWorkOutcomes checks each unit's outcome against the outcome schema of its work type
+// as it was when the work was performed, the version the unit is stored with.
+func (s *Service) validateWorkOutcomes(ctx context.Context, performedAt time.Time, units []CreateWorkLogUnitRequest) error {
+	if len(units) == 0 {
+		return nil
+	}
+
+	workTypeIDs := make([]int64, len(units))
+	for i, unit := range units {
+		workTypeIDs[i] = unit.WorkTypeID
+	}
+
+	versions, err := s.db.GetWorkTypeVersionsAt(ctx, workTypeIDs, performedAt)
+	if err != nil {
+		return fmt.Errorf("get work type versions from db: %w", err)
+	}
+
+	for _, unit := range units {
+		version, ok := versions[unit.WorkTypeID]
+		if !ok {
+			return fmt.Errorf("%w: %d", ErrWorkTypeNotFound, unit.WorkTypeID)
+		}
+
+		if err := version.OutcomeSchema.Validate(unit.WorkOutcome); err != nil {
+			return fmt.Errorf("%s: %w", version.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// checkCompetencies rejects units whose work type requires a competency
+// the employee did not hold when the work was performed.
+func (s *Service) checkCompetencies(ctx context.Context, employeeID int64, performedOn date.Date, units []CreateWorkLogUnitRequest) error {
+	if len(units) == 0 {
+		return nil
+	}
+
+	workTypeIDs := make([]int64, len(units))
+	for i, unit := range units {
+		workTypeIDs[i] = unit.WorkTypeID
+	}
+
+	workTypes, err := s.db.GetWorkTypesWithoutCompetency(ctx, employeeID, workTypeIDs, performedOn)
+	if err != nil {
+		return fmt.Errorf("get work types without competency from db: %w", err)
+	}
+
+	if len(workTypes) > 0 {
+		names := make([]string, len(workTypes))
+		for i, workType := range workTypes {
+			names[i] = workType.Name
+		}
+
+		return fmt.Errorf("%w: %s", ErrMissingCompetency, strings.Join(names, ", "))
+	}
+
+	return nil
+}
+
+// checkPerformedAt rejects future work logs and only lets managers backdate
+// a work log beyond the configured window.
+func (s *Service) checkPerformedAt(ctx context.Context, performedAt time.Time) error {
+	now := time.Now()
+	if performedAt.After(now.Add(performedAtClockSkew)) {
+		return ErrPerformedInFuture
+	}
+
+	if !performedAt.Before(now.Add(-s.config.backdateWindow())) {
+		return nil
+	}
+
+	isManager, err := s.isActorManager(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !isManager {
+		return ErrBackdateNeedsManager
+	}
+
+	return nil
+}
+
+// checkCanSetRoles only lets managers change roles. While there is no manager yet,
+// anyone can, so the first one can be set up.
+func (s *Service) checkCanSetRoles(ctx context.Context) error {
+	isManager, err := s.isActorManager(ctx)
+	if err != nil {
+		return err
+	}
+
+	if isManager {
+		return nil
+	}
+
+	hasManager, err := s.db.HasManager(ctx)
+	if err != nil {
+		return fmt.Errorf("check for managers in db: %w", err)
+	}
+
+	if hasManager {
+		return ErrForbidden
+	}
+
+	return nil
+}
+
+// isActorManager reports whether the acting employee is a manager. Requests without
+// an actor, or with an unknown one, are not.
+func (s *Service) isActorManager(ctx context.Context) (bool, error) {
+	actorID, ok := actor.EmployeeIDFromContext(ctx)
+	if !ok {
+		return false, nil
+	}
+
+	employee, err := s.db.GetEmployee(ctx, actorID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("get acting employee from db: %w", err)
+	}
+
+	return employee.Role == RoleManager, nil
+}
+
+// UpdateWorkLog edits a work log as the acting employee, who is recorded as the editor of the revision.
+func (s *Service) UpdateWorkLog(ctx context.Context, workLogID int64, request UpdateWorkLogRequest) (WorkLog, error) {
+	ctx, span := tracing.Start(ctx, "hris.Service.UpdateWorkLog")
+	defer span.End()
+
+	if err := validatorx.Validate(request); err != nil {
+		return WorkLog{}, fmt.Errorf("invalid request: %w", err)
+	}
+
+	editorID, err := s.actorID(ctx)
+	if err != nil {
+		return WorkLog{}, err
+	}
+	if editorID == nil {
+		return WorkLog{}, ErrInvalidEmployeeID
+	}
+
+	if !request.HasChanges() {
+		return WorkLog{}, ErrEmptyWorkLogUpdate
+	}
+
+	changedUnitIDs := make(map[int64]struct{}, len(request.UpdateUnits)+len(request.RemoveUnitIDs))
+	for _, unit := range request.UpdateUnits {
+		if _, ok := changedUnitIDs[unit.ID]; ok {
+			return WorkLog{}, ErrDuplicateUnitChange
+		}
+		changedUnitIDs[unit.ID] = struct{}{}
+	}
+	for _, unitID := range request.RemoveUnitIDs {
+		if _, ok := changedUnitIDs[unitID]; ok {
+			return WorkLog{}, ErrDuplicateUnitChange
+		}
+		changedUnitIDs[unitID] = struct{}{}
+	}
+
+	before, err := s.db.GetWorkLog(ctx, workLogID)
+	if err != nil {
+		return WorkLog{}, fmt.Errorf("get work log: %w", err)
+	}
+
+	if request.PatientID != nil {
+		patient, err := s.db.GetPatient(ctx, *request.PatientID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return WorkLog{}, ErrPatientNotFound
+			}
+			return WorkLog{}, fmt.Errorf("get patient from db: %w", err)
+		}
+
+		if request.PatientName == nil {
+			request.PatientName = &patient.Name
+		}
+	}
+
+	if err := s.validateWorkOutcomes(ctx, before.PerformedAt, request.AddUnits); err != nil {
+		return WorkLog{}, err
+	}
+
+	loc, err := s.BranchLocation(ctx, &before.BranchID)
+	if err != nil {
+		return WorkLog{}, err
+	}
+
+	performedOn := date.NewFromTime(before.PerformedAt.In(loc))
+
+	if err := s.checkCompetencies(ctx, before.Employee.ID, performedOn, request.AddUnits); err != nil {
+		return WorkLog{}, err
+	}
+
+	// Adding units logs new work, which an expired license blocks as much as a new work log.
+	if len(request.AddUnits) > 0 {
+		if err := s.checkLicenses(ctx, before.Employee.ID, performedOn); err != nil {
+			return WorkLog{}, err
+		}
+	}
+
+	unitsByID := make(map[int64]WorkLogUnit, len(before.Units))
+	for _, unit := range before.Units {
+		unitsByID[unit.ID] = unit
+	}
+
+	for _, update := range request.UpdateUnits {
+		unit, ok := unitsByID[update.ID]
+		if !ok {
+			return WorkLog{}, ErrWorkLogUnitNotFound
+		}
+
+		if err := unit.WorkType.OutcomeSchema.Validate(update.WorkOutcome); err != nil {
+			return WorkLog{}, fmt.Errorf("unit %d: %w", update.ID, err)
+		}
+	}
+
+	if _, err := s.db.UpdateWorkLog(ctx, workLogID, *editorID, request); err != nil {
+		return WorkLog{}, fmt.Errorf("update work log in db: %w", err)
+	}
+
+	after, err := s.db.GetWorkLog(ctx, workLogID)
+	if err != nil {
+		return WorkLog{}, fmt.Errorf("get updated work log: %w", err)
+	}
+
+	return after, nil
+}
+
+func (s *Service) GetWorkLogRevisions(ctx context.Context, workLogID int64) ([]WorkLogRevision, error) {
+	ctx, span := tracing.Start(ctx, "hris.Service.GetWorkLogRevisions")
+	defer span.End()
+
+	revisions, err := s.db.GetWorkLogRevisions(ctx, workLogID)
+	if err != nil {
+		return nil, fmt.Errorf("get work log revisions from db: %w", err)
+	}
+
+	return revisions, nil
+}
+
 func (s *Service) DeleteWorkLog(ctx context.Context, workLogID, employeeID int64) error {
+	ctx, span := tracing.Start(ctx, "hris.Service.DeleteWorkLog")
+	defer span.End()
+
 	// Verify that the work log exists and is not deleted
 	_, err := s.db.GetWorkLog(ctx, workLogID)
 	if err != nil {
@@ -136,3 +742,193 @@ func (s *Service) DeleteWorkLog(ctx context.Context, workLogID, employeeID int64
 
 	return nil
 }
+
+func (s *Service) CreatePatient(ctx context.Context, request CreatePatientRequest) (Patient, error) {
+	ctx, span := tracing.Start(ctx, "hris.Service.CreatePatient")
+	defer span.End()
+
+	if err := validatorx.Validate(request); err != nil {
+		return Patient{}, fmt.Errorf("invalid request: %w", err)
+	}
+
+	patient, err := s.db.CreatePatient(ctx, request)
+	if err != nil {
+		return Patient{}, fmt.Errorf("create patient in db: %w", err)
+	}
+
+	return patient, nil
+}
+
+func (s *Service) GetPatient(ctx context.Context, patientID int64) (Patient, error) {
+	ctx, span := tracing.Start(ctx, "hris.Service.GetPatient")
+	defer span.End()
+
+	patient, err := s.db.GetPatient(ctx, patientID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Patient{}, ErrPatientNotFound
+		}
+		return Patient{}, fmt.Errorf("get patient from db: %w", err)
+	}
+
+	return patient, nil
+}
+
+func (s *Service) SearchPatients(ctx context.Context, request SearchPatientsRequest) ([]Patient, error) {
+	ctx, span := tracing.Start(ctx, "hris.Service.SearchPatients")
+	defer span.End()
+
+	if request.Limit == 0 {
+		request.Limit = defaultPatientSearchLimit
+	}
+
+	if err := validatorx.Validate(request); err != nil {
+		return []Patient{}, fmt.Errorf("invalid request: %w", err)
+	}
+
+	patients, err := s.db.SearchPatients(ctx, request)
+	if err != nil {
+		return []Patient{}, fmt.Errorf("search patients in db: %w", err)
+	}
+
+	return patients, nil
+}
+
+// GetPatientHistory returns every visit of a patient, with the results of each work type
+// and the numeric ones as a time series.
+func (s *Service) GetPatientHistory(ctx context.Context, patientID int64) (PatientHistory, error) {
+	ctx, span := tracing.Start(ctx, "hris.Service.GetPatientHistory")
+	defer span.End()
+
+	patient, err := s.GetPatient(ctx, patientID)
+	if err != nil {
+		return PatientHistory{}, err
+	}
+
+	workLogs, err := s.db.GetPatientWorkLogs(ctx, patientID)
+	if err != nil {
+		return PatientHistory{}, fmt.Errorf("get patient work logs from db: %w", err)
+	}
+
+	workTypes := make([]PatientWorkTypeHistory, 0)
+	workTypeIndexByID := make(map[int64]int)
+	for _, workLog := range workLogs {
+		for _, unit := range workLog.Units {
+			i, ok := workTypeIndexByID[unit.WorkType.ID]
+			if !ok {
+				i = len(workTypes)
+				workTypeIndexByID[unit.WorkType.ID] = i
+				workTypes = append(workTypes, PatientWorkTypeHistory{
+					WorkType: unit.WorkType,
+					Results:  []PatientResult{},
+					Series:   []PatientResultDataPoint{},
+				})
+			}
+
+			workTypes[i].Results = append(workTypes[i].Results, PatientResult{
+				WorkLogID:   workLog.ID,
+				UnitID:      unit.ID,
+				WorkOutcome: unit.WorkOutcome,
+				PerformedAt: workLog.PerformedAt,
+			})
+
+			if value, err := parseNumericOutcome(unit.WorkOutcome); err == nil {
+				workTypes[i].Series = append(workTypes[i].Series, PatientResultDataPoint{
+					PerformedAt: workLog.PerformedAt,
+					Value:       value,
+				})
+			}
+		}
+	}
+
+	return PatientHistory{
+		Patient:   patient,
+		Visits:    workLogs,
+		WorkTypes: workTypes,
+	}, nil
+}
+
+func (s *Service) GetEmployeeCompetencies(ctx context.Context, employeeID int64) ([]Competency, error) {
+	ctx, span := tracing.Start(ctx, "hris.Service.GetEmployeeCompetencies")
+	defer span.End()
+
+	competencies, err := s.db.GetEmployeeCompetencies(ctx, employeeID)
+	if err != nil {
+		return []Competency{}, fmt.Errorf("get employee competencies from db: %w", err)
+	}
+
+	return competencies, nil
+}
+
+// GetExpiringCompetencies returns competencies expiring within the given number of days,
+// including the ones that have already expired.
+func (s *Service) GetExpiringCompetencies(ctx context.Context, withinDays int) ([]Competency, error) {
+	ctx, span := tracing.Start(ctx, "hris.Service.GetExpiringCompetencies")
+	defer span.End()
+
+	before := date.NewFromTime(time.Now()).AddDate(0, 0, withinDays)
+
+	competencies, err := s.db.GetCompetenciesExpiringBefore(ctx, before)
+	if err != nil {
+		return []Competency{}, fmt.Errorf("get competencies expiring before %s from db: %w", before, err)
+	}
+
+	return competencies, nil
+}
+
+func (s *Service) SetEmployeeCompetency(ctx context.Context, request SetCompetencyRequest) (Competency, error) {
+	ctx, span := tracing.Start(ctx, "hris.Service.SetEmployeeCompetency")
+	defer span.End()
+
+	if err := validatorx.Validate(request); err != nil {
+		return Competency{}, fmt.Errorf("invalid request: %w", err)
+	}
+
+	if request.ExpiresAt != nil && request.ExpiresAt.Before(request.CertifiedAt) {
+		return Competency{}, ErrInvalidCompetency
+	}
+
+	if _, err := s.getWorkType(ctx, request.WorkTypeID); err != nil {
+		return Competency{}, err
+	}
+
+	competency, err := s.db.UpsertEmployeeCompetency(ctx, request)
+	if err != nil {
+		return Competency{}, fmt.Errorf("upsert employee competency in db: %w", err)
+	}
+
+	return competency, nil
+}
+
+func (s *Service) DeleteEmployeeCompetency(ctx context.Context, employeeID int64, workTypeID int64) error {
+	ctx, span := tracing.Start(ctx, "hris.Service.DeleteEmployeeCompetency")
+	defer span.End()
+
+	if err := s.db.DeleteEmployeeCompetency(ctx, employeeID, workTypeID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrCompetencyNotFound
+		}
+		return fmt.Errorf("delete employee competency in db: %w", err)
+	}
+
+	return nil
+}
+
+// actorID returns the acting employee to record a change by, or nil if the request has none.
+// The actor comes from an unverified header, so one that is not an employee is rejected
+// instead of failing the write on the foreign key.
+func (s *Service) actorID(ctx context.Context) (*int64, error) {
+	actorID, ok := actor.EmployeeIDFromContext(ctx)
+	if !ok {
+		return nil, nil
+	}
+
+	if _, err := s.db.GetEmployee(ctx, actorID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %d", ErrUnknownActor, actorID)
+		}
+		return nil, fmt.Errorf("get acting employee from db: %w", err)
+	}
+
+	return &actorID, nil
+}
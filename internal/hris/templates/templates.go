@@ -2,21 +2,58 @@ package templates
 
 import (
 	_ "embed"
+	"encoding/base64"
+	"errors"
+	"fmt"
 	"html/template"
+	"io"
+	"strings"
+
+	"github.com/skip2/go-qrcode"
+	"github.com/turfaa/apotek-hris/pkg/escpos"
+)
+
+// qrCodeSize is the width and height of QR code images, in pixels.
+const qrCodeSize = 160
+
+var (
+	//go:embed work_log_for_patient.html
+	workLogForPatientTemplate string
+
+	//go:embed verify_work_log.html
+	verifyWorkLogTemplate string
 )
 
-//go:embed work_log_for_patient.html
-var workLogForPatientTemplate string
+var ErrInvalidTemplate = errors.New("invalid receipt template")
+
+var funcs = template.FuncMap{
+	"qrCode": qrCodeDataURL,
+}
+
+// WorkLogForPatient renders a Receipt as HTML sized for 76mm paper.
+var WorkLogForPatient = template.Must(template.New("work_log_for_patient.html").Funcs(funcs).Parse(workLogForPatientTemplate))
 
-var WorkLogForPatient = template.Must(template.New("work_log_for_patient.html").Parse(workLogForPatientTemplate))
+// VerifyWorkLog renders a Verification as the public page the receipt QR code links to.
+var VerifyWorkLog = template.Must(template.New("verify_work_log.html").Funcs(funcs).Parse(verifyWorkLogTemplate))
 
 type WorkLogForPatientData struct {
+	StoreName    string
+	StoreAddress string
+	SIANumber    string
+	Logo         template.URL
+
+	// BranchName is the outlet where the work was done.
+	// It is left out when empty or the same as the store name.
+	BranchName string
+
 	PatientName  string
 	Date         string
-	Place        string
 	EmployeeName string
 	Units        []WorkLogUnitForPatientData
 	Notes        string
+
+	// VerificationURL is encoded in the QR code, empty to print without one.
+	VerificationURL string
 }
 
 type WorkLogUnitForPatientData struct {
@@ -24,4 +61,221 @@ type WorkLogUnitForPatientData struct {
 	WorkOutcome string
 	OutcomeUnit string
 	Notes       string
+
+	// ReferenceRange is the normal range, empty if the work type has none for the patient.
+	ReferenceRange string
+	IsHigh         bool
+	IsLow          bool
+}
+
+// Receipt is the layout shared by the HTML and ESC/POS renderers,
+// so both outputs print the same content in the same order.
+// It is also the data uploaded receipt templates are executed with.
+type Receipt struct {
+	// Header identifies the store. Logo is a data URL, only shown in HTML.
+	Header []string
+	Logo   template.URL
+
+	Title  string
+	Fields []ReceiptRow
+	Items  []ReceiptItem
+
+	FooterTitle string
+	FooterText  string
+
+	// VerificationURL is printed as a QR code at the bottom, if not empty.
+	VerificationURL string
+}
+
+// Verification is the result of verifying a receipt's QR code.
+type Verification struct {
+	Valid bool
+
+	// DeletedAt is set if the work log has been deleted since the receipt was printed.
+	DeletedAt string
+
+	Receipt Receipt
+}
+
+type ReceiptItem struct {
+	Title string
+	Rows  []ReceiptRow
+	Notes string
+}
+
+type ReceiptRow struct {
+	Label string
+	Value string
+
+	// Emphasized rows are printed in bold.
+	Emphasized bool
+}
+
+// Receipt lays out the work log for the patient.
+func (d WorkLogForPatientData) Receipt() Receipt {
+	items := make([]ReceiptItem, len(d.Units))
+	for i, unit := range d.Units {
+		result := joinNonEmpty(unit.WorkOutcome, unit.OutcomeUnit)
+		switch {
+		case unit.IsHigh:
+			result += " (TINGGI)"
+		case unit.IsLow:
+			result += " (RENDAH)"
+		}
+
+		rows := []ReceiptRow{{Label: "Hasil:", Value: result, Emphasized: true}}
+		if unit.ReferenceRange != "" {
+			rows = append(rows, ReceiptRow{Label: "Normal:", Value: joinNonEmpty(unit.ReferenceRange, unit.OutcomeUnit)})
+		}
+
+		items[i] = ReceiptItem{Title: unit.WorkType, Rows: rows, Notes: unit.Notes}
+	}
+
+	var header []string
+	if d.StoreName != "" {
+		header = append(header, d.StoreName)
+	}
+	if d.StoreAddress != "" {
+		header = append(header, d.StoreAddress)
+	}
+	if d.SIANumber != "" {
+		header = append(header, "SIA: "+d.SIANumber)
+	}
+
+	receipt := Receipt{
+		Header: header,
+		Logo:   d.Logo,
+		Title:  "Catatan Hasil Tes",
+		Fields: []ReceiptRow{
+			{Label: "Nama:", Value: d.PatientName},
+			{Label: "Tanggal:", Value: d.Date},
+			{Label: "Petugas:", Value: d.EmployeeName},
+		},
+		Items:           items,
+		VerificationURL: d.VerificationURL,
+	}
+
+	if d.BranchName != "" && d.BranchName != d.StoreName {
+		receipt.Fields = append(receipt.Fields, ReceiptRow{Label: "Cabang:", Value: d.BranchName})
+	}
+
+	if d.Notes != "" {
+		receipt.FooterTitle = "Catatan:"
+		receipt.FooterText = d.Notes
+	}
+
+	return receipt
+}
+
+// ESCPOS renders the receipt as an ESC/POS stream ending with a paper cut.
+func (r Receipt) ESCPOS(b *escpos.Builder) []byte {
+	b.Align(escpos.AlignCenter)
+	for i, line := range r.Header {
+		b.Bold(i == 0).Line(line)
+	}
+	if len(r.Header) > 0 {
+		b.Bold(false).Divider('-')
+	}
+
+	b.Bold(true).Line(r.Title).Bold(false).Align(escpos.AlignLeft)
+	b.Divider('=')
+
+	for _, field := range r.Fields {
+		writeESCPOSRow(b, field)
+	}
+	b.Divider('=')
+
+	for i, item := range r.Items {
+		if i > 0 {
+			b.Divider('-')
+		}
+
+		b.Bold(true).Line(item.Title).Bold(false)
+		for _, row := range item.Rows {
+			writeESCPOSRow(b, row)
+		}
+
+		if item.Notes != "" {
+			b.Line(item.Notes)
+		}
+	}
+
+	if r.FooterText != "" {
+		b.Divider('=')
+		b.Bold(true).Line(r.FooterTitle).Bold(false)
+		b.Line(r.FooterText)
+	}
+
+	if r.VerificationURL != "" {
+		b.Divider('=')
+		b.QRCode(r.VerificationURL)
+		b.Align(escpos.AlignCenter).Line("Pindai untuk verifikasi").Align(escpos.AlignLeft)
+	}
+
+	return b.Feed(4).Cut().Bytes()
+}
+
+func writeESCPOSRow(b *escpos.Builder, row ReceiptRow) {
+	if row.Emphasized {
+		b.Bold(true).Row(row.Label, row.Value).Bold(false)
+		return
+	}
+
+	b.Row(row.Label, row.Value)
+}
+
+func joinNonEmpty(parts ...string) string {
+	nonEmpty := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part != "" {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+
+	return strings.Join(nonEmpty, " ")
+}
+
+// ParseReceiptTemplate parses an uploaded receipt template and checks that it renders a sample Receipt,
+// so mistakes such as unknown fields are caught at upload time instead of when printing.
+func ParseReceiptTemplate(name string, content string) (*template.Template, error) {
+	tmpl, err := template.New(name).Funcs(funcs).Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidTemplate, err)
+	}
+
+	if err := tmpl.Execute(io.Discard, sampleReceipt); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidTemplate, err)
+	}
+
+	return tmpl, nil
+}
+
+var sampleReceipt = WorkLogForPatientData{
+	StoreName:    "Apotek",
+	StoreAddress: "Jl. Contoh No. 1",
+	SIANumber:    "123/SIA/2026",
+	BranchName:   "Pusat",
+	PatientName:  "Pasien",
+	Date:         "01 Januari 2026",
+	EmployeeName: "Petugas",
+	Units: []WorkLogUnitForPatientData{
+		{WorkType: "Gula Darah", WorkOutcome: "120", OutcomeUnit: "mg/dL", ReferenceRange: "70 - 100", IsHigh: true},
+	},
+	Notes:           "Catatan",
+	VerificationURL: "https://example.com/verify/sample",
+}.Receipt()
+
+// ImageDataURL embeds an image so templates can show it without another request.
+func ImageDataURL(contentType string, data []byte) template.URL {
+	return template.URL("data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(data))
+}
+
+// qrCodeDataURL encodes content as a PNG QR code that can be used as an image source.
+func qrCodeDataURL(content string) (template.URL, error) {
+	png, err := qrcode.Encode(content, qrcode.Medium, qrCodeSize)
+	if err != nil {
+		return "", fmt.Errorf("encode qr code: %w", err)
+	}
+
+	return ImageDataURL("image/png", png), nil
 }
@@ -0,0 +1,36 @@
+package idempotency
+
+import "time"
+
+const (
+	// DefaultRetention is used when Config.Retention is not set.
+	DefaultRetention = 24 * time.Hour
+
+	// DefaultMaxBodySize is used when Config.MaxBodySize is not set.
+	DefaultMaxBodySize = 1 << 20
+)
+
+type Config struct {
+	// Retention is how long the response to a key is replayed. After that the key can be used again.
+	Retention time.Duration `mapstructure:"retention" validate:"gte=0"`
+
+	// MaxBodySize is the largest body, in bytes, of a request with a key. Its body is read whole to be hashed,
+	// so larger ones are rejected.
+	MaxBodySize int64 `mapstructure:"max_body_size" validate:"gte=0"`
+}
+
+func (c Config) retention() time.Duration {
+	if c.Retention == 0 {
+		return DefaultRetention
+	}
+
+	return c.Retention
+}
+
+func (c Config) maxBodySize() int64 {
+	if c.MaxBodySize == 0 {
+		return DefaultMaxBodySize
+	}
+
+	return c.MaxBodySize
+}
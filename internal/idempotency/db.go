@@ -0,0 +1,108 @@
+package idempotency
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type DB struct {
+	db *sqlx.DB
+}
+
+func NewDB(db *sqlx.DB) *DB {
+	return &DB{db: db}
+}
+
+// Claim records that a request with the key is being processed. A key stays taken until its retention
+// ends, or until its request is abandoned while processing. It returns sql.ErrNoRows if the key is taken.
+func (d *DB) Claim(ctx context.Context, key string, requestHash string, retention time.Duration, abandonAfter time.Duration) error {
+	query := `
+	INSERT INTO idempotency_keys (key, request_hash)
+	VALUES (?, ?)
+	ON CONFLICT (key) DO UPDATE
+	SET request_hash = EXCLUDED.request_hash, status = 'processing', response_status = NULL,
+		response_headers = NULL, response_body = NULL, created_at = CURRENT_TIMESTAMP, completed_at = NULL
+	WHERE idempotency_keys.created_at < CURRENT_TIMESTAMP - make_interval(secs => ?)
+		OR (idempotency_keys.status = 'processing' AND idempotency_keys.created_at < CURRENT_TIMESTAMP - make_interval(secs => ?))
+	RETURNING key`
+	query = d.db.Rebind(query)
+	args := []any{key, requestHash, retention.Seconds(), abandonAfter.Seconds()}
+
+	var claimed string
+	if err := d.db.GetContext(ctx, &claimed, query, args...); err != nil {
+		return fmt.Errorf("get context from db: %w", err)
+	}
+
+	return nil
+}
+
+func (d *DB) GetKey(ctx context.Context, key string) (Key, error) {
+	query := `
+	SELECT key, request_hash, status, response_status, response_headers, response_body, created_at, completed_at
+	FROM idempotency_keys
+	WHERE key = ?`
+	query = d.db.Rebind(query)
+	args := []any{key}
+
+	var k Key
+	if err := d.db.GetContext(ctx, &k, query, args...); err != nil {
+		return Key{}, fmt.Errorf("get context from db: %w", err)
+	}
+
+	return k, nil
+}
+
+// Complete stores the response to the request of the key, to be replayed on retries.
+func (d *DB) Complete(ctx context.Context, key string, status int, headers []byte, body []byte) error {
+	query := `
+	UPDATE idempotency_keys
+	SET status = 'completed', response_status = ?, response_headers = ?, response_body = ?, completed_at = CURRENT_TIMESTAMP
+	WHERE key = ? AND status = 'processing'`
+	query = d.db.Rebind(query)
+	args := []any{status, string(headers), body, key}
+
+	if _, err := d.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("exec context from db: %w", err)
+	}
+
+	return nil
+}
+
+// Release frees a key whose request did not complete, so it can be retried.
+func (d *DB) Release(ctx context.Context, key string) error {
+	query := `
+	DELETE FROM idempotency_keys
+	WHERE key = ? AND status = 'processing'`
+	query = d.db.Rebind(query)
+	args := []any{key}
+
+	if _, err := d.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("exec context from db: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpired deletes the keys older than the retention and returns how many were deleted.
+func (d *DB) DeleteExpired(ctx context.Context, retention time.Duration) (int64, error) {
+	query := `
+	DELETE FROM idempotency_keys
+	WHERE created_at < CURRENT_TIMESTAMP - make_interval(secs => ?)`
+	query = d.db.Rebind(query)
+	args := []any{retention.Seconds()}
+
+	result, err := d.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("exec context from db: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("get rows affected: %w", err)
+	}
+
+	return deleted, nil
+}
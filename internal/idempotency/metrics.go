@@ -0,0 +1,15 @@
+package idempotency
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/turfaa/apotek-hris/pkg/metrics"
+)
+
+var replays = promauto.NewCounter(prometheus.CounterOpts{
+	Namespace: metrics.Namespace,
+	Subsystem: "idempotency",
+	Name:      "replays_total",
+	Help:      "Number of retried requests answered with the stored response of their idempotency key.",
+})
@@ -0,0 +1,42 @@
+package idempotency
+
+import (
+	"errors"
+	"time"
+
+	"github.com/go-json-experiment/json/jsontext"
+)
+
+var (
+	ErrInvalidKey   = errors.New("idempotency key must be 1 to 255 characters")
+	ErrKeyReused    = errors.New("idempotency key was already used for a different request")
+	ErrInProgress   = errors.New("a request with this idempotency key is still being processed")
+	ErrBodyTooLarge = errors.New("request body is too large for an idempotency key")
+)
+
+type Status string
+
+const (
+	StatusProcessing Status = "processing"
+	StatusCompleted  Status = "completed"
+)
+
+// Key is a request made with an idempotency key and, once completed, its response.
+type Key struct {
+	Key string `db:"key"`
+
+	// RequestHash covers the method, URL, acting employee and body of the request,
+	// so the key cannot be replayed for another request.
+	RequestHash string `db:"request_hash"`
+
+	Status          Status         `db:"status"`
+	ResponseStatus  *int           `db:"response_status"`
+	ResponseHeaders jsontext.Value `db:"response_headers"`
+	ResponseBody    []byte         `db:"response_body"`
+	CreatedAt       time.Time      `db:"created_at"`
+	CompletedAt     *time.Time     `db:"completed_at"`
+}
+
+// replayedHeaders are the response headers stored with the response. The others are set again
+// by the middleware of the server on replay.
+var replayedHeaders = []string{"Content-Type", "Location", "ETag", "Last-Modified"}
@@ -0,0 +1,264 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-json-experiment/json"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/turfaa/apotek-hris/internal/scheduler"
+	"github.com/turfaa/apotek-hris/pkg/actor"
+	"github.com/turfaa/apotek-hris/pkg/httpx"
+)
+
+const (
+	// Header is the request header carrying the idempotency key, e.g. a UUID generated by the client per operation.
+	Header = "Idempotency-Key"
+
+	// ReplayedHeader is set on responses replayed from a previous request with the same key.
+	ReplayedHeader = "Idempotency-Replayed"
+
+	maxKeyLength = 255
+
+	// abandonAfter is how long a request can be processing before its key is freed, in case its instance
+	// stopped in the middle of it. It is longer than the request timeout of the server.
+	abandonAfter = 2 * time.Minute
+)
+
+var errorCodes = []httpx.ErrorCode{
+	{Err: ErrInvalidKey, Code: httpx.CodeValidation},
+	{Err: ErrBodyTooLarge, Code: httpx.CodeValidation, Status: http.StatusRequestEntityTooLarge},
+	{Err: ErrKeyReused, Code: httpx.CodeConflict, Status: http.StatusUnprocessableEntity},
+	{Err: ErrInProgress, Code: httpx.CodeConflict},
+}
+
+type Service struct {
+	db     *DB
+	config Config
+}
+
+func NewService(db *sqlx.DB, config Config) *Service {
+	return &Service{
+		db:     NewDB(db),
+		config: config,
+	}
+}
+
+// Middleware makes POST requests with an Idempotency-Key header safe to retry. The first request with a key
+// runs and its response is stored; retries with the same key get the stored response without running again.
+// Server errors and file responses are not stored, so a retry after one runs again. Multipart requests, i.e.
+// uploads, are passed through without a key, as their bodies are too large to be held for hashing.
+func (s *Service) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(Header)
+		if r.Method != http.MethodPost || key == "" || isMultipart(r.Header) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if len(key) > maxKeyLength {
+			httpx.ServiceError(w, r, ErrInvalidKey, errorCodes)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, s.config.maxBodySize()))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				httpx.ServiceError(w, r, fmt.Errorf("%w: over %d bytes", ErrBodyTooLarge, maxBytesErr.Limit), errorCodes)
+				return
+			}
+
+			httpx.Error(w, r, fmt.Errorf("read body: %w", err), http.StatusBadRequest)
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		requestHash := hashRequest(r, body)
+
+		err = s.db.Claim(r.Context(), key, requestHash, s.config.retention(), abandonAfter)
+		if errors.Is(err, sql.ErrNoRows) {
+			s.replay(w, r, key, requestHash)
+			return
+		}
+
+		if err != nil {
+			httpx.ServiceError(w, r, fmt.Errorf("claim idempotency key in db: %w", err), errorCodes)
+			return
+		}
+
+		s.record(w, r, next, key)
+	})
+}
+
+// record runs the request and stores its response for the key.
+func (s *Service) record(w http.ResponseWriter, r *http.Request, next http.Handler, key string) {
+	ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+	var body bytes.Buffer
+	ww.Tee(&body)
+
+	// Storing the outcome must not be cut short by the end of the request.
+	ctx := context.WithoutCancel(r.Context())
+
+	completed := false
+	defer func() {
+		if completed {
+			return
+		}
+
+		if err := s.db.Release(ctx, key); err != nil {
+			slog.ErrorContext(ctx, "failed to release idempotency key", slog.Any("error", err))
+		}
+	}()
+
+	next.ServeHTTP(ww, r)
+
+	status := ww.Status()
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	if status >= http.StatusInternalServerError || isFile(ww.Header()) {
+		return
+	}
+
+	headers := make(map[string]string)
+	for _, name := range replayedHeaders {
+		if value := ww.Header().Get(name); value != "" {
+			headers[name] = value
+		}
+	}
+
+	b, err := json.Marshal(headers)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to marshal response headers for idempotency key", slog.Any("error", err))
+		return
+	}
+
+	if err := s.db.Complete(ctx, key, status, b, body.Bytes()); err != nil {
+		slog.ErrorContext(ctx, "failed to store response for idempotency key", slog.Any("error", err))
+		return
+	}
+
+	completed = true
+}
+
+// replay answers a retry with the stored response of its key.
+func (s *Service) replay(w http.ResponseWriter, r *http.Request, key string, requestHash string) {
+	k, err := s.db.GetKey(r.Context(), key)
+	if err != nil {
+		// The first request released the key between the claim and now; it did not complete.
+		if errors.Is(err, sql.ErrNoRows) {
+			httpx.ServiceError(w, r, ErrInProgress, errorCodes)
+			return
+		}
+
+		httpx.ServiceError(w, r, fmt.Errorf("get idempotency key from db: %w", err), errorCodes)
+		return
+	}
+
+	if k.RequestHash != requestHash {
+		httpx.ServiceError(w, r, ErrKeyReused, errorCodes)
+		return
+	}
+
+	if k.Status != StatusCompleted || k.ResponseStatus == nil {
+		httpx.ServiceError(w, r, ErrInProgress, errorCodes)
+		return
+	}
+
+	var headers map[string]string
+	if err := json.Unmarshal(k.ResponseHeaders, &headers); err != nil {
+		httpx.ServiceError(w, r, fmt.Errorf("unmarshal stored response headers: %w", err), errorCodes)
+		return
+	}
+
+	for name, value := range headers {
+		w.Header().Set(name, value)
+	}
+
+	w.Header().Set(ReplayedHeader, "true")
+	w.WriteHeader(*k.ResponseStatus)
+
+	if _, err := w.Write(k.ResponseBody); err != nil {
+		slog.ErrorContext(r.Context(), "failed to write replayed response", slog.Any("error", err))
+	}
+
+	replays.Inc()
+}
+
+// isMultipart reports whether the body of a request is multipart, e.g. a file upload.
+func isMultipart(header http.Header) bool {
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	return strings.HasPrefix(mediaType, "multipart/")
+}
+
+// isFile reports whether a response is a file rather than an API resource: a download, or any body
+// other than JSON. Files are not stored, so they are not kept in the database for the retention.
+func isFile(header http.Header) bool {
+	if header.Get("Content-Disposition") != "" {
+		return true
+	}
+
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		return false
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType != "application/json" && mediaType != "application/problem+json"
+}
+
+// hashRequest covers everything that makes two requests the same operation: the method, the URL
+// with its query, the acting employee and the body.
+func hashRequest(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+
+	if employeeID, ok := actor.EmployeeIDFromContext(r.Context()); ok {
+		h.Write([]byte(strconv.FormatInt(employeeID, 10)))
+	}
+
+	h.Write([]byte("\n"))
+	h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Jobs returns the scheduled jobs of the idempotency keys.
+func (s *Service) Jobs() []scheduler.Job {
+	return []scheduler.Job{
+		{
+			Name:        "purge-idempotency-keys",
+			Description: "Delete the idempotency keys past their retention",
+			Schedule:    "30 3 * * *",
+			Run:         s.purge,
+		},
+	}
+}
+
+func (s *Service) purge(ctx context.Context) error {
+	deleted, err := s.db.DeleteExpired(ctx, s.config.retention())
+	if err != nil {
+		return fmt.Errorf("delete expired idempotency keys from db: %w", err)
+	}
+
+	slog.InfoContext(ctx, "purged idempotency keys", slog.Int64("deleted", deleted))
+
+	return nil
+}
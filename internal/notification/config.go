@@ -0,0 +1,34 @@
+package notification
+
+import "time"
+
+// DefaultSMTPTimeout is used when SMTPConfig.Timeout is not set.
+const DefaultSMTPTimeout = 30 * time.Second
+
+type Config struct {
+	// SMTP is the mail server emails are sent through. Emails are not sent when its host is empty.
+	SMTP SMTPConfig `mapstructure:"smtp"`
+}
+
+type SMTPConfig struct {
+	Host string `mapstructure:"host"`
+	Port int    `mapstructure:"port" validate:"omitempty,gt=0,lte=65535"`
+
+	// Username and Password authenticate to the server. Leave them empty for servers
+	// without authentication, such as a local SMTP sink.
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+
+	// From is the sender address, e.g. "Apotek <hr@example.com>".
+	From string `mapstructure:"from"`
+
+	Timeout time.Duration `mapstructure:"timeout" validate:"gte=0"`
+}
+
+func (c SMTPConfig) timeout() time.Duration {
+	if c.Timeout == 0 {
+		return DefaultSMTPTimeout
+	}
+
+	return c.Timeout
+}
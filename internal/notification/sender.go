@@ -0,0 +1,37 @@
+package notification
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrSenderNotConfigured = errors.New("notification sender is not configured")
+
+// Message is an email. At least one of TextBody and HTMLBody must be set.
+type Message struct {
+	To       string
+	Subject  string
+	TextBody string
+	HTMLBody string
+}
+
+// Sender delivers messages to their recipients.
+type Sender interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// NewSender returns the sender configured in config, or one that fails with
+// ErrSenderNotConfigured if none is.
+func NewSender(config Config) Sender {
+	if config.SMTP.Host == "" {
+		return disabledSender{}
+	}
+
+	return NewSMTPSender(config.SMTP)
+}
+
+type disabledSender struct{}
+
+func (disabledSender) Send(context.Context, Message) error {
+	return ErrSenderNotConfigured
+}
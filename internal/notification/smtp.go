@@ -0,0 +1,187 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultSMTPPort is the submission port, used when SMTPConfig.Port is not set.
+const defaultSMTPPort = 587
+
+// SMTPSender sends messages through an SMTP server, upgrading the connection with
+// STARTTLS when the server supports it.
+type SMTPSender struct {
+	config SMTPConfig
+}
+
+func NewSMTPSender(config SMTPConfig) *SMTPSender {
+	return &SMTPSender{config: config}
+}
+
+func (s *SMTPSender) Send(ctx context.Context, message Message) error {
+	from, err := mail.ParseAddress(s.config.From)
+	if err != nil {
+		return fmt.Errorf("parse from address: %w", err)
+	}
+
+	to, err := mail.ParseAddress(message.To)
+	if err != nil {
+		return fmt.Errorf("parse to address: %w", err)
+	}
+
+	body, err := buildMessage(from, to, message)
+	if err != nil {
+		return fmt.Errorf("build message: %w", err)
+	}
+
+	port := s.config.Port
+	if port == 0 {
+		port = defaultSMTPPort
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.config.timeout())
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(s.config.Host, strconv.Itoa(port)))
+	if err != nil {
+		return fmt.Errorf("dial smtp server: %w", err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return fmt.Errorf("set deadline: %w", err)
+		}
+	}
+
+	client, err := smtp.NewClient(conn, s.config.Host)
+	if err != nil {
+		return fmt.Errorf("create smtp client: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: s.config.Host}); err != nil {
+			return fmt.Errorf("starttls: %w", err)
+		}
+	}
+
+	if s.config.Username != "" {
+		auth := smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("authenticate: %w", err)
+		}
+	}
+
+	if err := client.Mail(from.Address); err != nil {
+		return fmt.Errorf("mail from: %w", err)
+	}
+
+	if err := client.Rcpt(to.Address); err != nil {
+		return fmt.Errorf("rcpt to: %w", err)
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("data: %w", err)
+	}
+
+	if _, err := w.Write(body); err != nil {
+		return fmt.Errorf("write message: %w", err)
+	}
+
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("close message: %w", err)
+	}
+
+	if err := client.Quit(); err != nil {
+		return fmt.Errorf("quit: %w", err)
+	}
+
+	return nil
+}
+
+// buildMessage encodes the message as a MIME email with a text and an HTML alternative.
+func buildMessage(from *mail.Address, to *mail.Address, message Message) ([]byte, error) {
+	if message.TextBody == "" && message.HTMLBody == "" {
+		return nil, errors.New("message has no body")
+	}
+
+	messageID, err := newMessageID(from.Address)
+	if err != nil {
+		return nil, fmt.Errorf("new message id: %w", err)
+	}
+
+	var buf bytes.Buffer
+	parts := multipart.NewWriter(&buf)
+
+	fmt.Fprintf(&buf, "From: %s\r\n", from.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", to.String())
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: %s\r\n", messageID)
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", parts.Boundary())
+
+	for _, part := range []struct {
+		contentType string
+		body        string
+	}{
+		{"text/plain; charset=utf-8", message.TextBody},
+		{"text/html; charset=utf-8", message.HTMLBody},
+	} {
+		if part.body == "" {
+			continue
+		}
+
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("create part: %w", err)
+		}
+
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.body)); err != nil {
+			return nil, fmt.Errorf("write part: %w", err)
+		}
+
+		if err := qp.Close(); err != nil {
+			return nil, fmt.Errorf("close part: %w", err)
+		}
+	}
+
+	if err := parts.Close(); err != nil {
+		return nil, fmt.Errorf("close parts: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func newMessageID(fromAddress string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	domain := fromAddress[strings.LastIndex(fromAddress, "@")+1:]
+
+	return fmt.Sprintf("<%s@%s>", hex.EncodeToString(b), domain), nil
+}
@@ -0,0 +1,50 @@
+package queue
+
+import "time"
+
+const (
+	// DefaultWorkers is used when Config.Workers is not set.
+	DefaultWorkers = 2
+
+	// DefaultPollInterval is used when Config.PollInterval is not set.
+	DefaultPollInterval = time.Second
+
+	// DefaultMaxAttempts is used when Config.MaxAttempts is not set.
+	DefaultMaxAttempts = 3
+)
+
+type Config struct {
+	// Workers is the number of jobs run at the same time by this instance.
+	Workers int `mapstructure:"workers" validate:"gte=0"`
+
+	// PollInterval is how often an idle worker looks for queued jobs.
+	PollInterval time.Duration `mapstructure:"poll_interval" validate:"gte=0"`
+
+	// MaxAttempts is how many times a job is started before it is failed, when its workers keep stopping
+	// in the middle of it. A job that returns an error is failed right away.
+	MaxAttempts int `mapstructure:"max_attempts" validate:"gte=0"`
+}
+
+func (c Config) workers() int {
+	if c.Workers == 0 {
+		return DefaultWorkers
+	}
+
+	return c.Workers
+}
+
+func (c Config) pollInterval() time.Duration {
+	if c.PollInterval == 0 {
+		return DefaultPollInterval
+	}
+
+	return c.PollInterval
+}
+
+func (c Config) maxAttempts() int {
+	if c.MaxAttempts == 0 {
+		return DefaultMaxAttempts
+	}
+
+	return c.MaxAttempts
+}
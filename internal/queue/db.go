@@ -0,0 +1,152 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const jobColumns = `id, kind, payload, status, progress_done, progress_total, result, result_location,
+	result_file_key, result_file_name, result_content_type, error, attempts, enqueued_by, created_at, started_at, finished_at`
+
+type DB struct {
+	db *sqlx.DB
+}
+
+func NewDB(db *sqlx.DB) *DB {
+	return &DB{db: db}
+}
+
+func (d *DB) Enqueue(ctx context.Context, kind string, payload []byte, enqueuedBy *int64) (Job, error) {
+	query := `
+	INSERT INTO background_jobs (kind, payload, enqueued_by)
+	VALUES (?, ?, ?)
+	RETURNING ` + jobColumns
+	query = d.db.Rebind(query)
+	args := []any{kind, string(payload), enqueuedBy}
+
+	var job Job
+	if err := d.db.GetContext(ctx, &job, query, args...); err != nil {
+		return Job{}, fmt.Errorf("get context from db: %w", err)
+	}
+
+	return job, nil
+}
+
+func (d *DB) GetJob(ctx context.Context, id int64) (Job, error) {
+	query := `
+	SELECT ` + jobColumns + `
+	FROM background_jobs
+	WHERE id = ?`
+	query = d.db.Rebind(query)
+	args := []any{id}
+
+	var job Job
+	if err := d.db.GetContext(ctx, &job, query, args...); err != nil {
+		return Job{}, fmt.Errorf("get context from db: %w", err)
+	}
+
+	return job, nil
+}
+
+// FailAbandonedJobs fails the running jobs whose lease expired after their last attempt.
+func (d *DB) FailAbandonedJobs(ctx context.Context, maxAttempts int) error {
+	query := `
+	UPDATE background_jobs
+	SET status = 'failed', error = 'worker stopped', lease_until = NULL, finished_at = CURRENT_TIMESTAMP
+	WHERE status = 'running' AND lease_until < CURRENT_TIMESTAMP AND attempts >= ?`
+	query = d.db.Rebind(query)
+	args := []any{maxAttempts}
+
+	if _, err := d.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("exec context from db: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimJob starts the oldest queued or abandoned job of the given kinds, leasing it to the caller.
+// Workers skip each other's jobs. It returns sql.ErrNoRows if there is none.
+func (d *DB) ClaimJob(ctx context.Context, kinds []string, lease time.Duration) (Job, error) {
+	query := `
+	UPDATE background_jobs
+	SET status = 'running', attempts = attempts + 1, error = '',
+		lease_until = CURRENT_TIMESTAMP + make_interval(secs => ?),
+		started_at = COALESCE(started_at, CURRENT_TIMESTAMP)
+	WHERE id = (
+		SELECT id
+		FROM background_jobs
+		WHERE kind = ANY(?::text[])
+			AND (status = 'queued' OR (status = 'running' AND lease_until < CURRENT_TIMESTAMP))
+		ORDER BY id ASC
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING ` + jobColumns
+	query = d.db.Rebind(query)
+	args := []any{lease.Seconds(), kinds}
+
+	var job Job
+	if err := d.db.GetContext(ctx, &job, query, args...); err != nil {
+		return Job{}, fmt.Errorf("get context from db: %w", err)
+	}
+
+	return job, nil
+}
+
+// Heartbeat saves the progress of a running job and extends its lease.
+func (d *DB) Heartbeat(ctx context.Context, id int64, done int, total int, lease time.Duration) error {
+	query := `
+	UPDATE background_jobs
+	SET progress_done = ?, progress_total = ?, lease_until = CURRENT_TIMESTAMP + make_interval(secs => ?)
+	WHERE id = ? AND status = 'running'`
+	query = d.db.Rebind(query)
+	args := []any{done, total, lease.Seconds(), id}
+
+	if _, err := d.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("exec context from db: %w", err)
+	}
+
+	return nil
+}
+
+func (d *DB) Finish(ctx context.Context, id int64, status Status, done int, total int, summary []byte, result Result, jobErr string) error {
+	var fileKey *string
+	var fileName, contentType string
+	if result.File != nil {
+		fileKey, fileName, contentType = &result.File.Key, result.File.Name, result.File.ContentType
+	}
+
+	query := `
+	UPDATE background_jobs
+	SET status = ?, progress_done = ?, progress_total = ?, result = ?, result_location = ?,
+		result_file_key = ?, result_file_name = ?, result_content_type = ?, error = ?,
+		lease_until = NULL, finished_at = CURRENT_TIMESTAMP
+	WHERE id = ?`
+	query = d.db.Rebind(query)
+	args := []any{status, done, total, string(summary), result.Location, fileKey, fileName, contentType, jobErr, id}
+
+	if _, err := d.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("exec context from db: %w", err)
+	}
+
+	return nil
+}
+
+// Release puts a job interrupted by a shutdown back in the queue.
+func (d *DB) Release(ctx context.Context, id int64) error {
+	query := `
+	UPDATE background_jobs
+	SET status = 'queued', lease_until = NULL
+	WHERE id = ? AND status = 'running'`
+	query = d.db.Rebind(query)
+	args := []any{id}
+
+	if _, err := d.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("exec context from db: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package queue
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/turfaa/apotek-hris/pkg/httpx"
+)
+
+type Handler struct {
+	queue *Queue
+}
+
+func NewHandler(queue *Queue) *Handler {
+	return &Handler{queue: queue}
+}
+
+func (h *Handler) GetJob(w http.ResponseWriter, r *http.Request) {
+	jobID, err := int64FromURL(r, "jobID")
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	job, err := h.queue.GetJob(r.Context(), jobID)
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Ok(w, job)
+}
+
+func (h *Handler) GetJobResult(w http.ResponseWriter, r *http.Request) {
+	jobID, err := int64FromURL(r, "jobID")
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	job, file, err := h.queue.OpenResult(r.Context(), jobID)
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+	defer file.Close()
+
+	httpx.File(w, file, job.ResultFileName, job.ResultContentType)
+}
+
+// EnqueuedStatus writes the response of an endpoint that enqueued a job.
+func EnqueuedStatus(w http.ResponseWriter, job Job) {
+	w.Header().Set("Location", "/api/v1/jobs/"+strconv.FormatInt(job.ID, 10))
+	httpx.Status(w, job, http.StatusAccepted)
+}
+
+// errorCodes classify the errors of the queue for the clients.
+var errorCodes = []httpx.ErrorCode{
+	{Err: ErrJobNotFound, Code: httpx.CodeNotFound},
+	{Err: ErrResultNotFound, Code: httpx.CodeNotFound},
+}
+
+func httpServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	httpx.ServiceError(w, r, err, errorCodes)
+}
+
+func int64FromURL(r *http.Request, name string) (int64, error) {
+	valueStr := chi.URLParam(r, name)
+	if valueStr == "" {
+		return 0, fmt.Errorf("%s is required", name)
+	}
+
+	return strconv.ParseInt(valueStr, 10, 64)
+}
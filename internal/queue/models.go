@@ -0,0 +1,109 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-json-experiment/json/jsontext"
+)
+
+var (
+	ErrJobNotFound    = errors.New("job not found")
+	ErrResultNotFound = errors.New("job has no result file")
+	ErrUnknownKind    = errors.New("unknown job kind")
+)
+
+type Status string
+
+const (
+	StatusQueued    Status = "queued"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+)
+
+type Job struct {
+	ID      int64          `db:"id" json:"id"`
+	Kind    string         `db:"kind" json:"kind"`
+	Payload jsontext.Value `db:"payload" json:"payload"`
+	Status  Status         `db:"status" json:"status"`
+
+	ProgressDone  int `db:"progress_done" json:"progressDone"`
+	ProgressTotal int `db:"progress_total" json:"progressTotal"`
+
+	// Result summarizes what a finished job did.
+	Result jsontext.Value `db:"result" json:"result"`
+
+	// ResultLocation is where the output of a succeeded job can be fetched, if it has any.
+	ResultLocation string `db:"result_location" json:"resultLocation"`
+
+	ResultFileKey     *string `db:"result_file_key" json:"-"`
+	ResultFileName    string  `db:"result_file_name" json:"-"`
+	ResultContentType string  `db:"result_content_type" json:"-"`
+
+	Error      string     `db:"error" json:"error"`
+	Attempts   int        `db:"attempts" json:"attempts"`
+	EnqueuedBy *int64     `db:"enqueued_by" json:"enqueuedBy"`
+	CreatedAt  time.Time  `db:"created_at" json:"createdAt"`
+	StartedAt  *time.Time `db:"started_at" json:"startedAt"`
+	FinishedAt *time.Time `db:"finished_at" json:"finishedAt"`
+}
+
+// Task does the work of a kind of job.
+type Task struct {
+	// Kind names the work, e.g. "salary.create_snapshots".
+	Kind string
+
+	// Run does the work described by the payload of the job, reporting its progress along the way.
+	// The context carries the employee who enqueued the job, for the audit trail.
+	Run func(ctx context.Context, job Job, progress *Progress) (Result, error)
+}
+
+// Result is the outcome of a job.
+type Result struct {
+	// Summary is stored as JSON and returned with the job.
+	Summary any
+
+	// Location is an API path where the output of the job can be fetched.
+	Location string
+
+	// File is a file produced by the job. Its location is set to the result endpoint of the job.
+	File *ResultFile
+}
+
+// ResultFile is a file the job stored in the blob store.
+type ResultFile struct {
+	Key         string
+	Name        string
+	ContentType string
+}
+
+// Progress is how far a job is. It is saved periodically while the job runs and when it finishes.
+type Progress struct {
+	done  atomic.Int64
+	total atomic.Int64
+}
+
+// SetTotal sets the amount of work the job has to do.
+func (p *Progress) SetTotal(total int) {
+	if p == nil {
+		return
+	}
+
+	p.total.Store(int64(total))
+}
+
+// Add marks n more units of work as done.
+func (p *Progress) Add(n int) {
+	if p == nil {
+		return
+	}
+
+	p.done.Add(int64(n))
+}
+
+func (p *Progress) get() (done int, total int) {
+	return int(p.done.Load()), int(p.total.Load())
+}
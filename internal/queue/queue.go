@@ -0,0 +1,291 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-json-experiment/json"
+	"github.com/jmoiron/sqlx"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/turfaa/apotek-hris/pkg/actor"
+	"github.com/turfaa/apotek-hris/pkg/blobstore"
+	"github.com/turfaa/apotek-hris/pkg/tracing"
+)
+
+const (
+	// lease is how long a worker owns a job without a heartbeat.
+	lease = time.Minute
+
+	// heartbeatInterval is how often a worker saves the progress of its job and extends its lease.
+	heartbeatInterval = 10 * time.Second
+)
+
+// Queue runs jobs in the background, on any instance sharing the database.
+// Jobs outlive the request that enqueued them, so they are not bound by the request timeouts.
+type Queue struct {
+	db        *DB
+	config    Config
+	blobStore blobstore.Store
+
+	mu    sync.RWMutex
+	tasks map[string]Task
+}
+
+func New(db *sqlx.DB, config Config, blobStore blobstore.Store) *Queue {
+	return &Queue{
+		db:        NewDB(db),
+		config:    config,
+		blobStore: blobStore,
+		tasks:     make(map[string]Task),
+	}
+}
+
+// Register adds the tasks that do the work of the jobs of their kinds.
+func (q *Queue) Register(tasks ...Task) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, task := range tasks {
+		if _, ok := q.tasks[task.Kind]; ok {
+			return fmt.Errorf("task %s is already registered", task.Kind)
+		}
+
+		q.tasks[task.Kind] = task
+	}
+
+	return nil
+}
+
+// Enqueue adds a job to the queue, recording the acting employee as the one who enqueued it.
+func (q *Queue) Enqueue(ctx context.Context, kind string, payload any) (Job, error) {
+	q.mu.RLock()
+	_, ok := q.tasks[kind]
+	q.mu.RUnlock()
+
+	if !ok {
+		return Job{}, fmt.Errorf("%w: %s", ErrUnknownKind, kind)
+	}
+
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return Job{}, fmt.Errorf("marshal payload: %w", err)
+	}
+
+	var enqueuedBy *int64
+	if employeeID, ok := actor.EmployeeIDFromContext(ctx); ok {
+		enqueuedBy = &employeeID
+	}
+
+	job, err := q.db.Enqueue(ctx, kind, b, enqueuedBy)
+	if err != nil {
+		return Job{}, fmt.Errorf("enqueue job in db: %w", err)
+	}
+
+	return job, nil
+}
+
+func (q *Queue) GetJob(ctx context.Context, id int64) (Job, error) {
+	job, err := q.db.GetJob(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Job{}, ErrJobNotFound
+		}
+		return Job{}, fmt.Errorf("get job from db: %w", err)
+	}
+
+	return job, nil
+}
+
+// OpenResult opens the file produced by a job. The caller must close it.
+func (q *Queue) OpenResult(ctx context.Context, id int64) (Job, io.ReadCloser, error) {
+	job, err := q.GetJob(ctx, id)
+	if err != nil {
+		return Job{}, nil, err
+	}
+
+	if job.Status != StatusSucceeded || job.ResultFileKey == nil {
+		return Job{}, nil, ErrResultNotFound
+	}
+
+	file, err := q.blobStore.Get(ctx, *job.ResultFileKey)
+	if err != nil {
+		if errors.Is(err, blobstore.ErrNotFound) {
+			return Job{}, nil, ErrResultNotFound
+		}
+		return Job{}, nil, fmt.Errorf("get result file from blob store: %w", err)
+	}
+
+	return job, file, nil
+}
+
+// ResultLocation is the API path of the result file of a job.
+func ResultLocation(id int64) string {
+	return "/api/v1/jobs/" + strconv.FormatInt(id, 10) + "/result"
+}
+
+// Run works on the queued jobs until ctx is cancelled. Jobs interrupted by the cancellation go back to the queue.
+func (q *Queue) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for range q.config.workers() {
+		wg.Go(func() {
+			q.work(ctx)
+		})
+	}
+
+	wg.Wait()
+}
+
+func (q *Queue) work(ctx context.Context) {
+	ticker := time.NewTicker(q.config.pollInterval())
+	defer ticker.Stop()
+
+	for {
+		// Keep going while there are jobs, only waiting when the queue is empty.
+		for ctx.Err() == nil {
+			claimed, err := q.claimAndProcess(ctx)
+			if err != nil && ctx.Err() == nil {
+				slog.ErrorContext(ctx, "failed to process background job", slog.Any("error", err))
+			}
+
+			if !claimed || err != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (q *Queue) claimAndProcess(ctx context.Context) (bool, error) {
+	q.mu.RLock()
+	kinds := slices.Sorted(maps.Keys(q.tasks))
+	q.mu.RUnlock()
+
+	if len(kinds) == 0 {
+		return false, nil
+	}
+
+	if err := q.db.FailAbandonedJobs(ctx, q.config.maxAttempts()); err != nil {
+		return false, fmt.Errorf("fail abandoned jobs in db: %w", err)
+	}
+
+	job, err := q.db.ClaimJob(ctx, kinds, lease)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("claim job in db: %w", err)
+	}
+
+	q.mu.RLock()
+	task := q.tasks[job.Kind]
+	q.mu.RUnlock()
+
+	return true, q.process(ctx, task, job)
+}
+
+// process runs a claimed job, saving its progress on every heartbeat and its outcome at the end.
+func (q *Queue) process(ctx context.Context, task Task, job Job) error {
+	ctx, span := tracing.Start(ctx, "background job "+job.Kind, trace.WithNewRoot())
+	defer span.End()
+
+	logger := slog.With(slog.Int64("job_id", job.ID), slog.String("kind", job.Kind), slog.Int("attempt", job.Attempts))
+	logger.InfoContext(ctx, "background job started")
+
+	taskCtx := ctx
+	if job.EnqueuedBy != nil {
+		taskCtx = actor.NewContext(taskCtx, *job.EnqueuedBy)
+	}
+
+	var progress Progress
+	heartbeatCtx, stopHeartbeat := context.WithCancel(ctx)
+	heartbeatDone := make(chan struct{})
+	go func() {
+		defer close(heartbeatDone)
+		q.heartbeat(heartbeatCtx, job.ID, &progress)
+	}()
+
+	result, err := runTask(taskCtx, task, job, &progress)
+
+	stopHeartbeat()
+	<-heartbeatDone
+
+	// Saving the outcome must not be cut short by a shutdown.
+	saveCtx := context.WithoutCancel(ctx)
+
+	if err != nil && ctx.Err() != nil {
+		logger.InfoContext(ctx, "background job interrupted, returning it to the queue")
+		if err := q.db.Release(saveCtx, job.ID); err != nil {
+			return fmt.Errorf("release job %d in db: %w", job.ID, err)
+		}
+		return nil
+	}
+
+	status, jobErr := StatusSucceeded, ""
+	if err != nil {
+		status, jobErr = StatusFailed, err.Error()
+		span.SetStatus(codes.Error, jobErr)
+		logger.ErrorContext(ctx, "background job failed", slog.Any("error", err))
+	} else {
+		logger.InfoContext(ctx, "background job succeeded")
+	}
+
+	if result.File != nil {
+		result.Location = ResultLocation(job.ID)
+	}
+
+	summary, err := json.Marshal(result.Summary)
+	if err != nil {
+		return fmt.Errorf("marshal summary of job %d: %w", job.ID, err)
+	}
+
+	done, total := progress.get()
+	if err := q.db.Finish(saveCtx, job.ID, status, done, total, summary, result, jobErr); err != nil {
+		return fmt.Errorf("finish job %d in db: %w", job.ID, err)
+	}
+
+	return nil
+}
+
+func (q *Queue) heartbeat(ctx context.Context, id int64, progress *Progress) {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		done, total := progress.get()
+		if err := q.db.Heartbeat(ctx, id, done, total, lease); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "failed to save background job progress", slog.Int64("job_id", id), slog.Any("error", err))
+		}
+	}
+}
+
+func runTask(ctx context.Context, task Task, job Job, progress *Progress) (result Result, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return task.Run(ctx, job, progress)
+}
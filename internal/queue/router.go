@@ -0,0 +1,9 @@
+package queue
+
+import "github.com/go-chi/chi/v5"
+
+// RegisterRoutes registers the job routes. They share /jobs with the scheduled jobs, which are addressed by name.
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Get(`/jobs/{jobID:^\d+}`, h.GetJob)
+	r.Get(`/jobs/{jobID:^\d+}/result`, h.GetJobResult)
+}
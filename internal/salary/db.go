@@ -3,9 +3,16 @@ package salary
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/turfaa/apotek-hris/internal/audit"
+	"github.com/turfaa/apotek-hris/internal/webhook"
+	"github.com/turfaa/apotek-hris/pkg/database"
+	"github.com/turfaa/apotek-hris/pkg/etag"
+	"github.com/turfaa/apotek-hris/pkg/pagination"
 	"github.com/turfaa/apotek-hris/pkg/timex"
 )
 
@@ -36,40 +43,119 @@ func (d *DB) GetEmployeeStaticComponents(ctx context.Context, employeeID int64)
 	return staticComponents, nil
 }
 
+// CreateStaticComponent adds a static component to an employee if their static components satisfy the precondition.
+// It returns the created component and the entity tag of the static components after the change.
 func (d *DB) CreateStaticComponent(
 	ctx context.Context,
 	employeeID int64,
 	component Component,
-) (StaticComponent, error) {
-	query := `
+	precondition etag.Precondition,
+) (StaticComponent, string, error) {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return StaticComponent{}, "", fmt.Errorf("d.db.BeginTxx: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	staticComponents, err := d.lockStaticComponents(ctx, tx, employeeID)
+	if err != nil {
+		return StaticComponent{}, "", fmt.Errorf("lock static components: %w", err)
+	}
+
+	if err := precondition.Check(staticComponents, etag.FromContent(staticComponents)); err != nil {
+		return StaticComponent{}, "", err
+	}
+
+	query := tx.Rebind(`
 		INSERT INTO salary_static_components (employee_id, description, amount, multiplier, created_at)
 		VALUES (?, ?, ?, ?, NOW())
 		RETURNING id, employee_id, description, amount, multiplier, created_at
-	`
-
-	query = d.db.Rebind(query)
+	`)
 	args := []any{employeeID, component.Description, component.Amount, component.Multiplier}
 
 	var staticComponent StaticComponent
-	if err := d.db.GetContext(ctx, &staticComponent, query, args...); err != nil {
-		return StaticComponent{}, fmt.Errorf("d.db.GetContext: %w", err)
+	if err := tx.GetContext(ctx, &staticComponent, query, args...); err != nil {
+		return StaticComponent{}, "", fmt.Errorf("tx.GetContext: %w", err)
 	}
 
-	return staticComponent, nil
+	if err := audit.Record(ctx, tx, audit.ActionCreate, auditEntityStaticComponent, staticComponent.ID, nil, staticComponent); err != nil {
+		return StaticComponent{}, "", fmt.Errorf("record audit log: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return StaticComponent{}, "", fmt.Errorf("tx.Commit: %w", err)
+	}
+
+	// Components are listed by ID, so the new one is the last.
+	return staticComponent, etag.FromContent(append(staticComponents, staticComponent)), nil
 }
 
-func (d *DB) DeleteStaticComponent(ctx context.Context, employeeID int64, id int64) error {
-	query := `
-		DELETE FROM salary_static_components WHERE id = ? AND employee_id = ?
-	`
-	query = d.db.Rebind(query)
-	args := []any{id, employeeID}
+// DeleteStaticComponent deletes a static component if the static components of the employee satisfy the precondition.
+// It returns the deleted component, nil if it does not exist, and the entity tag of the static components after the change.
+func (d *DB) DeleteStaticComponent(ctx context.Context, employeeID int64, id int64, precondition etag.Precondition) (*StaticComponent, string, error) {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("d.db.BeginTxx: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	staticComponents, err := d.lockStaticComponents(ctx, tx, employeeID)
+	if err != nil {
+		return nil, "", fmt.Errorf("lock static components: %w", err)
+	}
 
-	if _, err := d.db.ExecContext(ctx, query, args...); err != nil {
-		return fmt.Errorf("exec context to db: %w", err)
+	if err := precondition.Check(staticComponents, etag.FromContent(staticComponents)); err != nil {
+		return nil, "", err
 	}
 
-	return nil
+	index := slices.IndexFunc(staticComponents, func(c StaticComponent) bool { return c.ID == id })
+	if index == -1 {
+		return nil, etag.FromContent(staticComponents), nil
+	}
+
+	query := tx.Rebind(`DELETE FROM salary_static_components WHERE id = ? AND employee_id = ?`)
+	if _, err := tx.ExecContext(ctx, query, id, employeeID); err != nil {
+		return nil, "", fmt.Errorf("tx.ExecContext: %w", err)
+	}
+
+	deleted := staticComponents[index]
+	if err := audit.Record(ctx, tx, audit.ActionDelete, auditEntityStaticComponent, deleted.ID, deleted, nil); err != nil {
+		return nil, "", fmt.Errorf("record audit log: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, "", fmt.Errorf("tx.Commit: %w", err)
+	}
+
+	return &deleted, etag.FromContent(slices.Delete(staticComponents, index, index+1)), nil
+}
+
+// lockStaticComponents returns the static components of an employee, locking the employee until the end of
+// the transaction so the components are not changed by others in between. Returns sql.ErrNoRows if the
+// employee does not exist.
+func (d *DB) lockStaticComponents(ctx context.Context, tx *sqlx.Tx, employeeID int64) ([]StaticComponent, error) {
+	lockQuery := tx.Rebind(`SELECT id FROM employees WHERE id = ? FOR UPDATE`)
+
+	var lockedID int64
+	if err := tx.GetContext(ctx, &lockedID, lockQuery, employeeID); err != nil {
+		return nil, fmt.Errorf("lock employee: %w", err)
+	}
+
+	query := tx.Rebind(`
+		SELECT id, employee_id, description, amount, multiplier, created_at
+		FROM salary_static_components
+		WHERE employee_id = ?
+		ORDER BY id ASC
+	`)
+
+	var staticComponents []StaticComponent
+	if err := tx.SelectContext(ctx, &staticComponents, query, employeeID); err != nil {
+		return nil, fmt.Errorf("tx.SelectContext: %w", err)
+	}
+
+	return staticComponents, nil
 }
 
 func (d *DB) GetEmployeeAdditionalComponents(ctx context.Context, employeeID int64, month timex.Month) ([]AdditionalComponent, error) {
@@ -97,18 +183,33 @@ func (d *DB) CreateAdditionalComponent(
 	month timex.Month,
 	component Component,
 ) (AdditionalComponent, error) {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return AdditionalComponent{}, fmt.Errorf("d.db.BeginTxx: %w", err)
+	}
+
+	defer tx.Rollback()
+
 	query := `
 		INSERT INTO salary_additional_components (employee_id, month, description, amount, multiplier, created_at)
 		VALUES (?, ?, ?, ?, ?, NOW())
 		RETURNING id, employee_id, month, description, amount, multiplier, created_at
 	`
 
-	query = d.db.Rebind(query)
+	query = tx.Rebind(query)
 	args := []any{employeeID, month, component.Description, component.Amount, component.Multiplier}
 
 	var additionalComponent AdditionalComponent
-	if err := d.db.GetContext(ctx, &additionalComponent, query, args...); err != nil {
-		return AdditionalComponent{}, fmt.Errorf("d.db.GetContext: %w", err)
+	if err := tx.GetContext(ctx, &additionalComponent, query, args...); err != nil {
+		return AdditionalComponent{}, fmt.Errorf("tx.GetContext: %w", err)
+	}
+
+	if err := audit.Record(ctx, tx, audit.ActionCreate, auditEntityAdditionalComponent, additionalComponent.ID, nil, additionalComponent); err != nil {
+		return AdditionalComponent{}, fmt.Errorf("record audit log: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return AdditionalComponent{}, fmt.Errorf("tx.Commit: %w", err)
 	}
 
 	return additionalComponent, nil
@@ -141,31 +242,66 @@ func (d *DB) BulkCreateAdditionalComponents(
 
 	query += " RETURNING id, employee_id, month, description, amount, multiplier, created_at"
 
-	query = d.db.Rebind(query)
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("d.db.BeginTxx: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	query = tx.Rebind(query)
 
 	var additionalComponents []AdditionalComponent
-	if err := d.db.SelectContext(ctx, &additionalComponents, query, args...); err != nil {
-		return nil, fmt.Errorf("d.db.SelectContext: %w", err)
+	if err := tx.SelectContext(ctx, &additionalComponents, query, args...); err != nil {
+		return nil, fmt.Errorf("tx.SelectContext: %w", err)
+	}
+
+	for _, c := range additionalComponents {
+		if err := audit.Record(ctx, tx, audit.ActionCreate, auditEntityAdditionalComponent, c.ID, nil, c); err != nil {
+			return nil, fmt.Errorf("record audit log: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("tx.Commit: %w", err)
 	}
 
 	return additionalComponents, nil
 }
 
-// DeleteAdditionalComponent deletes an additional component by id.
+// DeleteAdditionalComponent deletes an additional component by id and returns it.
 // The employeeID and month are used to verify that the component belongs to the employee and month.
-func (d *DB) DeleteAdditionalComponent(ctx context.Context, employeeID int64, month timex.Month, id int64) error {
+// Returns sql.ErrNoRows if the component does not exist.
+func (d *DB) DeleteAdditionalComponent(ctx context.Context, employeeID int64, month timex.Month, id int64) (AdditionalComponent, error) {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return AdditionalComponent{}, fmt.Errorf("d.db.BeginTxx: %w", err)
+	}
+
+	defer tx.Rollback()
+
 	query := `
 		DELETE FROM salary_additional_components WHERE id = ? AND employee_id = ? AND month = ?
+		RETURNING id, employee_id, month, description, amount, multiplier, created_at
 	`
 
-	query = d.db.Rebind(query)
+	query = tx.Rebind(query)
 	args := []any{id, employeeID, month}
 
-	if _, err := d.db.ExecContext(ctx, query, args...); err != nil {
-		return fmt.Errorf("d.db.ExecContext: %w", err)
+	var additionalComponent AdditionalComponent
+	if err := tx.GetContext(ctx, &additionalComponent, query, args...); err != nil {
+		return AdditionalComponent{}, fmt.Errorf("tx.GetContext: %w", err)
+	}
+
+	if err := audit.Record(ctx, tx, audit.ActionDelete, auditEntityAdditionalComponent, additionalComponent.ID, additionalComponent, nil); err != nil {
+		return AdditionalComponent{}, fmt.Errorf("record audit log: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return AdditionalComponent{}, fmt.Errorf("tx.Commit: %w", err)
 	}
 
-	return nil
+	return additionalComponent, nil
 }
 
 func (d *DB) GetEmployeeExtraInfos(ctx context.Context, employeeID int64, month timex.Month) ([]ExtraInfo, error) {
@@ -188,36 +324,70 @@ func (d *DB) GetEmployeeExtraInfos(ctx context.Context, employeeID int64, month
 }
 
 func (d *DB) CreateExtraInfo(ctx context.Context, employeeID int64, month timex.Month, title string, description string) (ExtraInfo, error) {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return ExtraInfo{}, fmt.Errorf("d.db.BeginTxx: %w", err)
+	}
+
+	defer tx.Rollback()
+
 	query := `
 		INSERT INTO salary_extra_infos (employee_id, month, title, description, created_at)
 		VALUES (?, ?, ?, ?, NOW())
 		RETURNING id, employee_id, month, title, description, created_at
 	`
 
-	query = d.db.Rebind(query)
+	query = tx.Rebind(query)
 	args := []any{employeeID, month, title, description}
 
 	var extraInfo ExtraInfo
-	if err := d.db.GetContext(ctx, &extraInfo, query, args...); err != nil {
-		return ExtraInfo{}, fmt.Errorf("d.db.GetContext: %w", err)
+	if err := tx.GetContext(ctx, &extraInfo, query, args...); err != nil {
+		return ExtraInfo{}, fmt.Errorf("tx.GetContext: %w", err)
+	}
+
+	if err := audit.Record(ctx, tx, audit.ActionCreate, auditEntityExtraInfo, extraInfo.ID, nil, extraInfo); err != nil {
+		return ExtraInfo{}, fmt.Errorf("record audit log: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ExtraInfo{}, fmt.Errorf("tx.Commit: %w", err)
 	}
 
 	return extraInfo, nil
 }
 
-func (d *DB) DeleteExtraInfo(ctx context.Context, employeeID int64, month timex.Month, id int64) error {
+// DeleteExtraInfo deletes an extra info and returns it.
+// Returns sql.ErrNoRows if the extra info does not exist.
+func (d *DB) DeleteExtraInfo(ctx context.Context, employeeID int64, month timex.Month, id int64) (ExtraInfo, error) {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return ExtraInfo{}, fmt.Errorf("d.db.BeginTxx: %w", err)
+	}
+
+	defer tx.Rollback()
+
 	query := `
 		DELETE FROM salary_extra_infos WHERE id = ? AND employee_id = ? AND month = ?
+		RETURNING id, employee_id, month, title, description, created_at
 	`
 
-	query = d.db.Rebind(query)
+	query = tx.Rebind(query)
 	args := []any{id, employeeID, month}
 
-	if _, err := d.db.ExecContext(ctx, query, args...); err != nil {
-		return fmt.Errorf("d.db.ExecContext: %w", err)
+	var extraInfo ExtraInfo
+	if err := tx.GetContext(ctx, &extraInfo, query, args...); err != nil {
+		return ExtraInfo{}, fmt.Errorf("tx.GetContext: %w", err)
+	}
+
+	if err := audit.Record(ctx, tx, audit.ActionDelete, auditEntityExtraInfo, extraInfo.ID, extraInfo, nil); err != nil {
+		return ExtraInfo{}, fmt.Errorf("record audit log: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ExtraInfo{}, fmt.Errorf("tx.Commit: %w", err)
 	}
 
-	return nil
+	return extraInfo, nil
 }
 
 func (d *DB) GetSnapshots(ctx context.Context, request GetSnapshotsRequest) ([]Snapshot, error) {
@@ -234,6 +404,15 @@ func (d *DB) GetSnapshots(ctx context.Context, request GetSnapshotsRequest) ([]S
 		args = append(args, *request.Month)
 	}
 
+	if request.BranchID != nil {
+		filters = append(filters, `employee_id IN (
+			SELECT id FROM employees WHERE home_branch_id = ?
+			UNION
+			SELECT employee_id FROM employee_branches WHERE branch_id = ?
+		)`)
+		args = append(args, *request.BranchID, *request.BranchID)
+	}
+
 	filters = append(filters, "deleted_at IS NULL")
 
 	filter := "WHERE " + strings.Join(filters, " AND ")
@@ -265,6 +444,88 @@ func (d *DB) GetSnapshots(ctx context.Context, request GetSnapshotsRequest) ([]S
 	return snapshots, nil
 }
 
+var snapshotSorting = pagination.Sorting[Snapshot]{
+	Fields: map[string]pagination.Field[Snapshot]{
+		"id":        {Column: "id", Type: "bigint", Value: func(s Snapshot) string { return pagination.FormatInt(s.ID) }},
+		"month":     {Column: "month", Type: "text", Value: func(s Snapshot) string { return s.Month.String() }},
+		"createdAt": {Column: "created_at", Type: "timestamptz", Value: func(s Snapshot) string { return s.CreatedAt.Format(time.RFC3339Nano) }},
+	},
+	Default:  "-id",
+	IDColumn: "id",
+	ID:       func(s Snapshot) int64 { return s.ID },
+}
+
+// ListSnapshots returns a page of the snapshots matching the filters of the request.
+func (d *DB) ListSnapshots(ctx context.Context, request ListSnapshotsRequest) (pagination.Page[Snapshot], error) {
+	page, err := snapshotSorting.Query(request.Page)
+	if err != nil {
+		return pagination.Page[Snapshot]{}, err
+	}
+
+	filters := []string{"deleted_at IS NULL"}
+	var args []any
+
+	if request.EmployeeID != nil {
+		filters = append(filters, "employee_id = ?")
+		args = append(args, *request.EmployeeID)
+	}
+
+	if request.BranchID != nil {
+		filters = append(filters, `employee_id IN (
+			SELECT id FROM employees WHERE home_branch_id = ?
+			UNION
+			SELECT employee_id FROM employee_branches WHERE branch_id = ?
+		)`)
+		args = append(args, *request.BranchID, *request.BranchID)
+	}
+
+	// Months are stored as YYYY-MM, which sorts chronologically.
+	if request.FromMonth != nil {
+		filters = append(filters, "month >= ?")
+		args = append(args, *request.FromMonth)
+	}
+
+	if request.ToMonth != nil {
+		filters = append(filters, "month <= ?")
+		args = append(args, *request.ToMonth)
+	}
+
+	var total int
+	if err := d.db.GetContext(ctx, &total, d.db.Rebind(`SELECT COUNT(*) FROM salary_snapshots `+database.Where(filters)), args...); err != nil {
+		return pagination.Page[Snapshot]{}, fmt.Errorf("count snapshots in db: %w", err)
+	}
+
+	if condition, cursorArgs := page.Where(); condition != "" {
+		filters = append(filters, condition)
+		args = append(args, cursorArgs...)
+	}
+
+	query := `
+		SELECT id, employee_id, month, salary, created_at, deleted_at
+		FROM salary_snapshots
+		` + database.Where(filters) + `
+		` + page.OrderByLimit()
+
+	query = d.db.Rebind(query)
+
+	var snapshotDBs []SnapshotDB
+	if err := d.db.SelectContext(ctx, &snapshotDBs, query, args...); err != nil {
+		return pagination.Page[Snapshot]{}, fmt.Errorf("d.db.SelectContext: %w", err)
+	}
+
+	snapshots := make([]Snapshot, len(snapshotDBs))
+	for i, snapshotDB := range snapshotDBs {
+		snapshot, err := snapshotDB.ToSnapshot()
+		if err != nil {
+			return pagination.Page[Snapshot]{}, fmt.Errorf("to snapshot: %w", err)
+		}
+
+		snapshots[i] = snapshot
+	}
+
+	return page.Page(snapshots, total), nil
+}
+
 func (d *DB) GetSnapshot(ctx context.Context, id int64) (Snapshot, error) {
 	query := `
 		SELECT id, employee_id, month, salary, created_at
@@ -289,34 +550,136 @@ func (d *DB) GetSnapshot(ctx context.Context, id int64) (Snapshot, error) {
 }
 
 func (d *DB) CreateSnapshot(ctx context.Context, employeeID int64, month timex.Month, salary Salary) (Snapshot, error) {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("d.db.BeginTxx: %w", err)
+	}
+
+	defer tx.Rollback()
+
 	query := `
 		INSERT INTO salary_snapshots (employee_id, month, salary, created_at)
 		VALUES (?, ?, ?, NOW())
 		RETURNING id, employee_id, month, salary, created_at
 	`
 
-	query = d.db.Rebind(query)
+	query = tx.Rebind(query)
 	args := []any{employeeID, month, salary}
 
 	var snapshotDB SnapshotDB
-	if err := d.db.GetContext(ctx, &snapshotDB, query, args...); err != nil {
-		return Snapshot{}, fmt.Errorf("d.db.GetContext: %w", err)
+	if err := tx.GetContext(ctx, &snapshotDB, query, args...); err != nil {
+		return Snapshot{}, fmt.Errorf("tx.GetContext: %w", err)
 	}
 
-	return snapshotDB.ToSnapshot()
+	snapshot, err := snapshotDB.ToSnapshot()
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	if err := webhook.Publish(ctx, tx, webhook.EventSalarySnapshotCreated, snapshot.ID, snapshot); err != nil {
+		return Snapshot{}, fmt.Errorf("publish event: %w", err)
+	}
+
+	if err := audit.Record(ctx, tx, audit.ActionCreate, auditEntitySnapshot, snapshot.ID, nil, snapshot); err != nil {
+		return Snapshot{}, fmt.Errorf("record audit log: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Snapshot{}, fmt.Errorf("tx.Commit: %w", err)
+	}
+
+	return snapshot, nil
 }
 
-func (d *DB) DeleteSnapshot(ctx context.Context, id int64) error {
+// DeleteSnapshot soft deletes a snapshot and returns it.
+// Returns sql.ErrNoRows if the snapshot does not exist or is already deleted.
+func (d *DB) DeleteSnapshot(ctx context.Context, id int64) (Snapshot, error) {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("d.db.BeginTxx: %w", err)
+	}
+
+	defer tx.Rollback()
+
 	query := `
-		UPDATE salary_snapshots SET deleted_at = CURRENT_TIMESTAMP WHERE id = ?
+		UPDATE salary_snapshots SET deleted_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL
+		RETURNING id, employee_id, month, salary, created_at
 	`
 
-	query = d.db.Rebind(query)
+	query = tx.Rebind(query)
 	args := []any{id}
 
-	if _, err := d.db.ExecContext(ctx, query, args...); err != nil {
-		return fmt.Errorf("d.db.ExecContext: %w", err)
+	var snapshotDB SnapshotDB
+	if err := tx.GetContext(ctx, &snapshotDB, query, args...); err != nil {
+		return Snapshot{}, fmt.Errorf("tx.GetContext: %w", err)
+	}
+
+	snapshot, err := snapshotDB.ToSnapshot()
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	if err := webhook.Publish(ctx, tx, webhook.EventSalarySnapshotDeleted, snapshot.ID, snapshot); err != nil {
+		return Snapshot{}, fmt.Errorf("publish event: %w", err)
+	}
+
+	if err := audit.Record(ctx, tx, audit.ActionDelete, auditEntitySnapshot, snapshot.ID, snapshot, nil); err != nil {
+		return Snapshot{}, fmt.Errorf("record audit log: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Snapshot{}, fmt.Errorf("tx.Commit: %w", err)
+	}
+
+	return snapshot, nil
+}
+
+func (d *DB) GetPayslipDeliveries(ctx context.Context, month timex.Month) ([]PayslipDelivery, error) {
+	query := `
+		SELECT pd.id, pd.employee_id, e.name AS employee_name, pd.month, pd.snapshot_id, pd.email, pd.status,
+			pd.error, pd.attempts, pd.sent_at, pd.created_at, pd.updated_at
+		FROM payslip_deliveries pd
+		JOIN employees e ON e.id = pd.employee_id
+		WHERE pd.month = ?
+		ORDER BY e.name ASC
+	`
+
+	query = d.db.Rebind(query)
+	args := []any{month}
+
+	var deliveries []PayslipDelivery
+	if err := d.db.SelectContext(ctx, &deliveries, query, args...); err != nil {
+		return nil, fmt.Errorf("d.db.SelectContext: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// UpsertPayslipDelivery records an attempt at emailing a payslip, replacing the previous one of the employee and month.
+func (d *DB) UpsertPayslipDelivery(ctx context.Context, snapshot Snapshot, email string, status PayslipStatus, deliveryErr string) (PayslipDelivery, error) {
+	query := `
+		WITH upserted AS (
+			INSERT INTO payslip_deliveries (employee_id, month, snapshot_id, email, status, error, attempts, sent_at)
+			VALUES (?, ?, ?, ?, ?, ?, 1, CASE WHEN ? = 'sent' THEN CURRENT_TIMESTAMP END)
+			ON CONFLICT (employee_id, month) DO UPDATE
+			SET snapshot_id = EXCLUDED.snapshot_id, email = EXCLUDED.email, status = EXCLUDED.status, error = EXCLUDED.error,
+				attempts = payslip_deliveries.attempts + 1, sent_at = COALESCE(EXCLUDED.sent_at, payslip_deliveries.sent_at),
+				updated_at = CURRENT_TIMESTAMP
+			RETURNING id, employee_id, month, snapshot_id, email, status, error, attempts, sent_at, created_at, updated_at
+		)
+		SELECT u.id, u.employee_id, e.name AS employee_name, u.month, u.snapshot_id, u.email, u.status,
+			u.error, u.attempts, u.sent_at, u.created_at, u.updated_at
+		FROM upserted u
+		JOIN employees e ON e.id = u.employee_id
+	`
+
+	query = d.db.Rebind(query)
+	args := []any{snapshot.EmployeeID, snapshot.Month, snapshot.ID, email, status, deliveryErr, status}
+
+	var delivery PayslipDelivery
+	if err := d.db.GetContext(ctx, &delivery, query, args...); err != nil {
+		return PayslipDelivery{}, fmt.Errorf("d.db.GetContext: %w", err)
 	}
 
-	return nil
+	return delivery, nil
 }
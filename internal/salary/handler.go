@@ -1,7 +1,6 @@
 package salary
 
 import (
-	"database/sql"
 	"errors"
 	"fmt"
 	"net/http"
@@ -9,9 +8,11 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-json-experiment/json"
+	"github.com/turfaa/apotek-hris/internal/queue"
+	"github.com/turfaa/apotek-hris/internal/salary/templates"
+	"github.com/turfaa/apotek-hris/pkg/etag"
 	"github.com/turfaa/apotek-hris/pkg/httpx"
 	"github.com/turfaa/apotek-hris/pkg/timex"
-	"github.com/turfaa/apotek-hris/pkg/validatorx"
 )
 
 type Handler struct {
@@ -25,13 +26,13 @@ func NewHandler(service *Service) *Handler {
 func (h *Handler) GetSalary(w http.ResponseWriter, r *http.Request) {
 	employeeID, month, err := h.parseEmployeeIDAndMonth(r)
 	if err != nil {
-		httpx.Error(w, err, http.StatusBadRequest)
+		httpx.Error(w, r, err, http.StatusBadRequest)
 		return
 	}
 
 	salary, err := h.service.GetSalary(r.Context(), employeeID, month)
 	if err != nil {
-		httpServiceError(w, err)
+		httpServiceError(w, r, err)
 		return
 	}
 
@@ -41,72 +42,76 @@ func (h *Handler) GetSalary(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) GetEmployeeStaticComponents(w http.ResponseWriter, r *http.Request) {
 	employeeID, err := strconv.ParseInt(chi.URLParam(r, "employeeID"), 10, 64)
 	if err != nil {
-		httpx.Error(w, err, http.StatusBadRequest)
+		httpx.Error(w, r, err, http.StatusBadRequest)
 		return
 	}
 
 	staticComponents, err := h.service.GetEmployeeStaticComponents(r.Context(), employeeID)
 	if err != nil {
-		httpServiceError(w, err)
+		httpServiceError(w, r, err)
 		return
 	}
 
+	w.Header().Set("ETag", etag.FromContent(staticComponents))
 	httpx.Ok(w, staticComponents)
 }
 
 func (h *Handler) CreateStaticComponent(w http.ResponseWriter, r *http.Request) {
 	employeeID, err := strconv.ParseInt(chi.URLParam(r, "employeeID"), 10, 64)
 	if err != nil {
-		httpx.Error(w, err, http.StatusBadRequest)
+		httpx.Error(w, r, err, http.StatusBadRequest)
 		return
 	}
 
 	var req Component
 	if err := json.UnmarshalRead(r.Body, &req); err != nil {
-		httpx.Error(w, err, http.StatusBadRequest)
+		httpx.Error(w, r, err, http.StatusBadRequest)
 		return
 	}
 
-	createdComponent, err := h.service.CreateStaticComponent(r.Context(), employeeID, req)
+	createdComponent, staticComponentsETag, err := h.service.CreateStaticComponent(r.Context(), employeeID, req, httpx.GetPrecondition(r))
 	if err != nil {
-		httpServiceError(w, err)
+		httpServiceError(w, r, err)
 		return
 	}
 
+	w.Header().Set("ETag", staticComponentsETag)
 	httpx.Ok(w, createdComponent)
 }
 
 func (h *Handler) DeleteStaticComponent(w http.ResponseWriter, r *http.Request) {
 	employeeID, err := strconv.ParseInt(chi.URLParam(r, "employeeID"), 10, 64)
 	if err != nil {
-		httpx.Error(w, err, http.StatusBadRequest)
+		httpx.Error(w, r, err, http.StatusBadRequest)
 		return
 	}
 
 	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
-		httpx.Error(w, err, http.StatusBadRequest)
+		httpx.Error(w, r, err, http.StatusBadRequest)
 		return
 	}
 
-	if err := h.service.DeleteStaticComponent(r.Context(), employeeID, id); err != nil {
-		httpServiceError(w, err)
+	staticComponentsETag, err := h.service.DeleteStaticComponent(r.Context(), employeeID, id, httpx.GetPrecondition(r))
+	if err != nil {
+		httpServiceError(w, r, err)
 		return
 	}
 
+	w.Header().Set("ETag", staticComponentsETag)
 	httpx.Ok(w, map[string]string{"message": "successfully deleted the static component"})
 }
 
 func (h *Handler) GetEmployeeAdditionalComponents(w http.ResponseWriter, r *http.Request) {
 	employeeID, month, err := h.parseEmployeeIDAndMonth(r)
 	if err != nil {
-		httpx.Error(w, err, http.StatusBadRequest)
+		httpx.Error(w, r, err, http.StatusBadRequest)
 		return
 	}
 
 	additionalComponents, err := h.service.GetEmployeeAdditionalComponents(r.Context(), employeeID, month)
 	if err != nil {
-		httpServiceError(w, err)
+		httpServiceError(w, r, err)
 		return
 	}
 
@@ -117,19 +122,19 @@ func (h *Handler) GetEmployeeAdditionalComponents(w http.ResponseWriter, r *http
 func (h *Handler) CreateAdditionalComponent(w http.ResponseWriter, r *http.Request) {
 	employeeID, month, err := h.parseEmployeeIDAndMonth(r)
 	if err != nil {
-		httpx.Error(w, err, http.StatusBadRequest)
+		httpx.Error(w, r, err, http.StatusBadRequest)
 		return
 	}
 
 	var component Component
 	if err := json.UnmarshalRead(r.Body, &component); err != nil {
-		httpx.Error(w, err, http.StatusBadRequest)
+		httpx.Error(w, r, err, http.StatusBadRequest)
 		return
 	}
 
 	createdComponent, err := h.service.CreateAdditionalComponent(r.Context(), employeeID, month, component)
 	if err != nil {
-		httpServiceError(w, err)
+		httpServiceError(w, r, err)
 		return
 	}
 
@@ -139,19 +144,19 @@ func (h *Handler) CreateAdditionalComponent(w http.ResponseWriter, r *http.Reque
 func (h *Handler) BulkCreateAdditionalComponents(w http.ResponseWriter, r *http.Request) {
 	monthStr := chi.URLParam(r, "month")
 	if monthStr == "" {
-		httpx.Error(w, errors.New("month is required"), http.StatusBadRequest)
+		httpx.Error(w, r, errors.New("month is required"), http.StatusBadRequest)
 		return
 	}
 
 	month, err := timex.NewMonthFromString(monthStr)
 	if err != nil {
-		httpx.Error(w, fmt.Errorf("parse month: %w", err), http.StatusBadRequest)
+		httpx.Error(w, r, fmt.Errorf("parse month: %w", err), http.StatusBadRequest)
 		return
 	}
 
 	var req BulkCreateAdditionalComponentRequest
 	if err := json.UnmarshalRead(r.Body, &req); err != nil {
-		httpx.Error(w, err, http.StatusBadRequest)
+		httpx.Error(w, r, err, http.StatusBadRequest)
 		return
 	}
 
@@ -159,7 +164,7 @@ func (h *Handler) BulkCreateAdditionalComponents(w http.ResponseWriter, r *http.
 
 	created, err := h.service.BulkCreateAdditionalComponents(r.Context(), req)
 	if err != nil {
-		httpServiceError(w, err)
+		httpServiceError(w, r, err)
 		return
 	}
 
@@ -169,18 +174,18 @@ func (h *Handler) BulkCreateAdditionalComponents(w http.ResponseWriter, r *http.
 func (h *Handler) DeleteAdditionalComponent(w http.ResponseWriter, r *http.Request) {
 	employeeID, month, err := h.parseEmployeeIDAndMonth(r)
 	if err != nil {
-		httpx.Error(w, err, http.StatusBadRequest)
+		httpx.Error(w, r, err, http.StatusBadRequest)
 		return
 	}
 
 	idToDelete, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
-		httpx.Error(w, err, http.StatusBadRequest)
+		httpx.Error(w, r, err, http.StatusBadRequest)
 		return
 	}
 
 	if err := h.service.DeleteAdditionalComponent(r.Context(), employeeID, month, idToDelete); err != nil {
-		httpServiceError(w, err)
+		httpServiceError(w, r, err)
 		return
 	}
 
@@ -190,13 +195,13 @@ func (h *Handler) DeleteAdditionalComponent(w http.ResponseWriter, r *http.Reque
 func (h *Handler) GetEmployeeExtraInfos(w http.ResponseWriter, r *http.Request) {
 	employeeID, month, err := h.parseEmployeeIDAndMonth(r)
 	if err != nil {
-		httpx.Error(w, err, http.StatusBadRequest)
+		httpx.Error(w, r, err, http.StatusBadRequest)
 		return
 	}
 
 	extraInfos, err := h.service.GetEmployeeExtraInfos(r.Context(), employeeID, month)
 	if err != nil {
-		httpServiceError(w, err)
+		httpServiceError(w, r, err)
 		return
 	}
 	httpx.Ok(w, extraInfos)
@@ -206,19 +211,19 @@ func (h *Handler) GetEmployeeExtraInfos(w http.ResponseWriter, r *http.Request)
 func (h *Handler) CreateExtraInfo(w http.ResponseWriter, r *http.Request) {
 	employeeID, month, err := h.parseEmployeeIDAndMonth(r)
 	if err != nil {
-		httpx.Error(w, err, http.StatusBadRequest)
+		httpx.Error(w, r, err, http.StatusBadRequest)
 		return
 	}
 
 	var req CreateExtraInfoRequest
 	if err := json.UnmarshalRead(r.Body, &req); err != nil {
-		httpx.Error(w, err, http.StatusBadRequest)
+		httpx.Error(w, r, err, http.StatusBadRequest)
 		return
 	}
 
 	extraInfo, err := h.service.CreateExtraInfo(r.Context(), employeeID, month, req)
 	if err != nil {
-		httpServiceError(w, err)
+		httpServiceError(w, r, err)
 		return
 	}
 
@@ -228,32 +233,54 @@ func (h *Handler) CreateExtraInfo(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) DeleteExtraInfo(w http.ResponseWriter, r *http.Request) {
 	employeeID, month, err := h.parseEmployeeIDAndMonth(r)
 	if err != nil {
-		httpx.Error(w, err, http.StatusBadRequest)
+		httpx.Error(w, r, err, http.StatusBadRequest)
 		return
 	}
 
 	idToDelete, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
-		httpx.Error(w, err, http.StatusBadRequest)
+		httpx.Error(w, r, err, http.StatusBadRequest)
 		return
 	}
 
 	if err := h.service.DeleteExtraInfo(r.Context(), employeeID, month, idToDelete); err != nil {
-		httpServiceError(w, err)
+		httpServiceError(w, r, err)
 		return
 	}
 
 	httpx.Ok(w, map[string]string{"message": "successfully deleted the extra info"})
 }
 
+func (h *Handler) GetBranchPayrolls(w http.ResponseWriter, r *http.Request) {
+	month, err := timex.NewMonthFromString(chi.URLParam(r, "month"))
+	if err != nil {
+		httpx.Error(w, r, fmt.Errorf("parse month: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	branchID, err := httpx.GetOptionalInt64FromQuery(r, "branchID")
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	payrolls, err := h.service.GetBranchPayrolls(r.Context(), month, branchID)
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Ok(w, payrolls)
+}
+
 func (h *Handler) GetSnapshots(w http.ResponseWriter, r *http.Request) {
 	queries := r.URL.Query()
 
-	var req GetSnapshotsRequest
+	var req ListSnapshotsRequest
 	if employeeIDStr := queries.Get("employeeID"); employeeIDStr != "" {
 		employeeID, err := strconv.ParseInt(employeeIDStr, 10, 64)
 		if err != nil {
-			httpx.Error(w, err, http.StatusBadRequest)
+			httpx.Error(w, r, err, http.StatusBadRequest)
 			return
 		}
 
@@ -263,16 +290,50 @@ func (h *Handler) GetSnapshots(w http.ResponseWriter, r *http.Request) {
 	if monthStr := queries.Get("month"); monthStr != "" {
 		month, err := timex.NewMonthFromString(monthStr)
 		if err != nil {
-			httpx.Error(w, err, http.StatusBadRequest)
+			httpx.Error(w, r, err, http.StatusBadRequest)
+			return
+		}
+
+		req.FromMonth, req.ToMonth = &month, &month
+	}
+
+	if fromMonthStr := queries.Get("fromMonth"); fromMonthStr != "" {
+		fromMonth, err := timex.NewMonthFromString(fromMonthStr)
+		if err != nil {
+			httpx.Error(w, r, fmt.Errorf("parse fromMonth: %w", err), http.StatusBadRequest)
+			return
+		}
+
+		req.FromMonth = &fromMonth
+	}
+
+	if toMonthStr := queries.Get("toMonth"); toMonthStr != "" {
+		toMonth, err := timex.NewMonthFromString(toMonthStr)
+		if err != nil {
+			httpx.Error(w, r, fmt.Errorf("parse toMonth: %w", err), http.StatusBadRequest)
 			return
 		}
 
-		req.Month = &month
+		req.ToMonth = &toMonth
+	}
+
+	branchID, err := httpx.GetOptionalInt64FromQuery(r, "branchID")
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
 	}
 
-	snapshots, err := h.service.GetSnapshots(r.Context(), req)
+	req.BranchID = branchID
+
+	req.Page, err = httpx.GetPageFromQuery(r)
 	if err != nil {
-		httpServiceError(w, err)
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	snapshots, err := h.service.ListSnapshots(r.Context(), req)
+	if err != nil {
+		httpServiceError(w, r, err)
 		return
 	}
 
@@ -282,13 +343,13 @@ func (h *Handler) GetSnapshots(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) GetSnapshot(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
-		httpx.Error(w, err, http.StatusBadRequest)
+		httpx.Error(w, r, err, http.StatusBadRequest)
 		return
 	}
 
 	snapshot, err := h.service.GetSnapshot(r.Context(), id)
 	if err != nil {
-		httpServiceError(w, err)
+		httpServiceError(w, r, err)
 		return
 	}
 
@@ -298,34 +359,114 @@ func (h *Handler) GetSnapshot(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) CreateSnapshot(w http.ResponseWriter, r *http.Request) {
 	var req CreateSnapshotRequest
 	if err := json.UnmarshalRead(r.Body, &req); err != nil {
-		httpx.Error(w, err, http.StatusBadRequest)
+		httpx.Error(w, r, err, http.StatusBadRequest)
 		return
 	}
 
 	snapshot, err := h.service.CreateSnapshot(r.Context(), req)
 	if err != nil {
-		httpServiceError(w, err)
+		httpServiceError(w, r, err)
 		return
 	}
 
 	httpx.Ok(w, snapshot)
 }
 
+func (h *Handler) BulkCreateSnapshots(w http.ResponseWriter, r *http.Request) {
+	var req BulkCreateSnapshotsRequest
+	if err := json.UnmarshalRead(r.Body, &req); err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	job, err := h.service.EnqueueBulkCreateSnapshots(r.Context(), req)
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	queue.EnqueuedStatus(w, job)
+}
+
 func (h *Handler) DeleteSnapshot(w http.ResponseWriter, r *http.Request) {
 	idToDelete, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
-		httpx.Error(w, err, http.StatusBadRequest)
+		httpx.Error(w, r, err, http.StatusBadRequest)
 		return
 	}
 
 	if err := h.service.DeleteSnapshot(r.Context(), idToDelete); err != nil {
-		httpServiceError(w, err)
+		httpServiceError(w, r, err)
 		return
 	}
 
 	httpx.Ok(w, map[string]string{"message": "successfully deleted the snapshot"})
 }
 
+func (h *Handler) GetPayslipDeliveries(w http.ResponseWriter, r *http.Request) {
+	month, err := timex.NewMonthFromString(chi.URLParam(r, "month"))
+	if err != nil {
+		httpx.Error(w, r, fmt.Errorf("parse month: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	deliveries, err := h.service.GetPayslipDeliveries(r.Context(), month)
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Ok(w, deliveries)
+}
+
+func (h *Handler) SendPayslips(w http.ResponseWriter, r *http.Request) {
+	month, err := timex.NewMonthFromString(chi.URLParam(r, "month"))
+	if err != nil {
+		httpx.Error(w, r, fmt.Errorf("parse month: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	deliveries, err := h.service.SendPayslips(r.Context(), month)
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Ok(w, deliveries)
+}
+
+func (h *Handler) ResendPayslip(w http.ResponseWriter, r *http.Request) {
+	employeeID, month, err := h.parseEmployeeIDAndMonth(r)
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	delivery, err := h.service.ResendPayslip(r.Context(), employeeID, month)
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Ok(w, delivery)
+}
+
+func (h *Handler) GetSnapshotPayslip(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	payslip, err := h.service.RenderPayslip(r.Context(), id)
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Template(w, templates.Payslip, payslip)
+}
+
 func (h *Handler) parseEmployeeIDAndMonth(r *http.Request) (int64, timex.Month, error) {
 	monthStr := chi.URLParam(r, "month")
 	if monthStr == "" {
@@ -345,13 +486,11 @@ func (h *Handler) parseEmployeeIDAndMonth(r *http.Request) (int64, timex.Month,
 	return employeeID, month, nil
 }
 
-func httpServiceError(w http.ResponseWriter, err error) {
-	switch {
-	case errors.Is(err, sql.ErrNoRows):
-		httpx.Error(w, err, http.StatusNotFound)
-	case errors.As(err, &validatorx.ValidationErrors{}):
-		httpx.Error(w, err, http.StatusBadRequest)
-	default:
-		httpx.Error(w, err, http.StatusInternalServerError)
-	}
+// errorCodes classify the errors of the salary service for the clients.
+var errorCodes = []httpx.ErrorCode{
+	{Err: ErrNoSnapshot, Code: httpx.CodeNotFound},
+}
+
+func httpServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	httpx.ServiceError(w, r, err, errorCodes)
 }
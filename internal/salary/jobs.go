@@ -0,0 +1,109 @@
+package salary
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/go-json-experiment/json"
+
+	"github.com/turfaa/apotek-hris/internal/queue"
+	"github.com/turfaa/apotek-hris/internal/scheduler"
+	"github.com/turfaa/apotek-hris/pkg/timex"
+)
+
+// TaskCreateSnapshots is the kind of the background jobs creating the snapshots of a month.
+const TaskCreateSnapshots = "salary.create_snapshots"
+
+// Jobs returns the scheduled jobs of the salary package.
+func (s *Service) Jobs() []scheduler.Job {
+	return []scheduler.Job{
+		{
+			Name:        "month-end-snapshots",
+			Description: "Snapshot the salaries of the previous month of every employee without a snapshot",
+			Schedule:    "0 1 1 * *",
+			Run:         s.createMonthEndSnapshots,
+		},
+	}
+}
+
+// Tasks returns the background job tasks of the salary package.
+func (s *Service) Tasks() []queue.Task {
+	return []queue.Task{
+		{Kind: TaskCreateSnapshots, Run: s.runBulkCreateSnapshots},
+	}
+}
+
+func (s *Service) createMonthEndSnapshots(ctx context.Context) error {
+	now := time.Now()
+	previous := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).AddDate(0, -1, 0)
+	month := timex.NewMonth(previous.Year(), int(previous.Month()))
+
+	result, err := s.createMissingSnapshots(ctx, month, nil, nil)
+
+	slog.InfoContext(ctx, "created month-end salary snapshots", slog.String("month", month.String()), slog.Int("created", result.Created))
+
+	return err
+}
+
+func (s *Service) runBulkCreateSnapshots(ctx context.Context, job queue.Job, progress *queue.Progress) (queue.Result, error) {
+	var request BulkCreateSnapshotsRequest
+	if err := json.Unmarshal(job.Payload, &request); err != nil {
+		return queue.Result{}, fmt.Errorf("unmarshal payload: %w", err)
+	}
+
+	query := url.Values{"month": {request.Month.String()}}
+	if request.BranchID != nil {
+		query.Set("branchID", strconv.FormatInt(*request.BranchID, 10))
+	}
+
+	result, err := s.createMissingSnapshots(ctx, request.Month, request.BranchID, progress)
+
+	return queue.Result{Summary: result, Location: "/api/v1/salary/snapshots?" + query.Encode()}, err
+}
+
+// createMissingSnapshots snapshots the salary of the month of every employee without a snapshot of it,
+// only those working at the branch if one is given. One employee's failure doesn't stop the others.
+func (s *Service) createMissingSnapshots(ctx context.Context, month timex.Month, branchID *int64, progress *queue.Progress) (BulkCreateSnapshotsResult, error) {
+	employees, err := s.hrisService.GetEmployees(ctx, branchID)
+	if err != nil {
+		return BulkCreateSnapshotsResult{}, fmt.Errorf("get employees: %w", err)
+	}
+
+	snapshots, err := s.db.GetSnapshots(ctx, GetSnapshotsRequest{Month: &month, BranchID: branchID})
+	if err != nil {
+		return BulkCreateSnapshotsResult{}, fmt.Errorf("get snapshots from db: %w", err)
+	}
+
+	snapshotted := make(map[int64]bool, len(snapshots))
+	for _, snapshot := range snapshots {
+		snapshotted[snapshot.EmployeeID] = true
+	}
+
+	progress.SetTotal(len(employees))
+
+	var result BulkCreateSnapshotsResult
+	var errs []error
+	for _, employee := range employees {
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+
+		if snapshotted[employee.ID] {
+			result.Skipped++
+		} else if _, err := s.CreateSnapshot(ctx, CreateSnapshotRequest{EmployeeID: employee.ID, Month: month}); err != nil {
+			errs = append(errs, fmt.Errorf("create snapshot of employee %d: %w", employee.ID, err))
+			result.Failed++
+		} else {
+			result.Created++
+		}
+
+		progress.Add(1)
+	}
+
+	return result, errors.Join(errs...)
+}
@@ -0,0 +1,15 @@
+package salary
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/turfaa/apotek-hris/pkg/metrics"
+)
+
+var snapshotsCreated = promauto.NewCounter(prometheus.CounterOpts{
+	Namespace: metrics.Namespace,
+	Subsystem: "salary",
+	Name:      "snapshots_created_total",
+	Help:      "Number of salary snapshots created.",
+})
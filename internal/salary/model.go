@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/go-json-experiment/json"
+	"github.com/turfaa/apotek-hris/pkg/pagination"
 	"github.com/turfaa/apotek-hris/pkg/timex"
 
 	decimal "github.com/shopspring/decimal"
@@ -14,6 +15,10 @@ import (
 type Salary struct {
 	Components []Component `json:"components"`
 	ExtraInfos []ExtraInfo `json:"extraInfos"`
+
+	// BranchCosts splits the salary by the branch the costs were incurred at.
+	// Snapshots taken before branches existed don't have it.
+	BranchCosts []BranchCost `json:"branchCosts"`
 }
 
 func (s Salary) Total() decimal.Decimal {
@@ -42,14 +47,39 @@ func (s Salary) MarshalJSON() ([]byte, error) {
 		Total            decimal.Decimal `json:"total"`
 		TotalWithoutDebt decimal.Decimal `json:"totalWithoutDebt"`
 		ExtraInfos       []ExtraInfo     `json:"extraInfos"`
+		BranchCosts      []BranchCost    `json:"branchCosts"`
 	}{
 		Components:       s.Components,
 		Total:            s.Total(),
 		TotalWithoutDebt: s.TotalWithoutDebt(),
 		ExtraInfos:       s.ExtraInfos,
+		BranchCosts:      s.BranchCosts,
 	})
 }
 
+// BranchCost is the part of a salary incurred at a branch.
+// Attendance and work log costs go to the branch they were recorded at,
+// static and additional components go to the employee's home branch.
+type BranchCost struct {
+	BranchID   int64           `json:"branchID"`
+	BranchName string          `json:"branchName"`
+	Total      decimal.Decimal `json:"total"`
+}
+
+// BranchPayroll is the salary cost of a branch in a month.
+type BranchPayroll struct {
+	BranchID   int64                `json:"branchID"`
+	BranchName string               `json:"branchName"`
+	Total      decimal.Decimal      `json:"total"`
+	Employees  []EmployeeBranchCost `json:"employees"`
+}
+
+type EmployeeBranchCost struct {
+	EmployeeID   int64           `json:"employeeID"`
+	EmployeeName string          `json:"employeeName"`
+	Total        decimal.Decimal `json:"total"`
+}
+
 type Component struct {
 	Description string          `json:"description"`
 	Amount      decimal.Decimal `json:"amount"`
@@ -208,6 +238,23 @@ func (s SnapshotDB) ToSnapshot() (Snapshot, error) {
 type GetSnapshotsRequest struct {
 	EmployeeID *int64       `json:"employeeID"`
 	Month      *timex.Month `json:"month"`
+
+	// BranchID limits the snapshots to employees currently working at the branch.
+	BranchID *int64 `json:"branchID"`
+}
+
+// ListSnapshotsRequest filters and pages the snapshots.
+type ListSnapshotsRequest struct {
+	EmployeeID *int64
+
+	// BranchID limits the snapshots to employees currently working at the branch.
+	BranchID *int64
+
+	// FromMonth and ToMonth limit the snapshots to the months between them, inclusive.
+	FromMonth *timex.Month
+	ToMonth   *timex.Month
+
+	Page pagination.Request
 }
 
 type CreateSnapshotRequest struct {
@@ -215,6 +262,47 @@ type CreateSnapshotRequest struct {
 	Month      timex.Month `json:"month" validate:"required"`
 }
 
+type BulkCreateSnapshotsRequest struct {
+	Month timex.Month `json:"month" validate:"required"`
+
+	// BranchID limits the snapshots to employees currently working at the branch.
+	BranchID *int64 `json:"branchID"`
+}
+
+type BulkCreateSnapshotsResult struct {
+	Created int `json:"created"`
+
+	// Skipped is the number of employees who already had a snapshot of the month.
+	Skipped int `json:"skipped"`
+	Failed  int `json:"failed"`
+}
+
+type PayslipStatus string
+
+const (
+	PayslipStatusSent   PayslipStatus = "sent"
+	PayslipStatusFailed PayslipStatus = "failed"
+
+	// PayslipStatusSkipped means the employee has no email or doesn't want payslips by email.
+	PayslipStatusSkipped PayslipStatus = "skipped"
+)
+
+// PayslipDelivery is the latest attempt at emailing an employee their payslip for a month.
+type PayslipDelivery struct {
+	ID           int64         `db:"id" json:"id"`
+	EmployeeID   int64         `db:"employee_id" json:"employeeID"`
+	EmployeeName string        `db:"employee_name" json:"employeeName"`
+	Month        timex.Month   `db:"month" json:"month"`
+	SnapshotID   int64         `db:"snapshot_id" json:"snapshotID"`
+	Email        string        `db:"email" json:"email"`
+	Status       PayslipStatus `db:"status" json:"status"`
+	Error        string        `db:"error" json:"error"`
+	Attempts     int           `db:"attempts" json:"attempts"`
+	SentAt       *time.Time    `db:"sent_at" json:"sentAt"`
+	CreatedAt    time.Time     `db:"created_at" json:"createdAt"`
+	UpdatedAt    time.Time     `db:"updated_at" json:"updatedAt"`
+}
+
 type BulkCreateAdditionalComponentRequest struct {
 	Month       timex.Month                       `json:"-" validate:"required"`
 	EmployeeIDs []int64                           `json:"employeeIDs" validate:"required,min=1"`
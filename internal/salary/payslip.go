@@ -0,0 +1,210 @@
+package salary
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/turfaa/apotek-hris/internal/hris"
+	"github.com/turfaa/apotek-hris/internal/notification"
+	"github.com/turfaa/apotek-hris/internal/salary/templates"
+	"github.com/turfaa/apotek-hris/pkg/timex"
+	"github.com/turfaa/apotek-hris/pkg/tracing"
+)
+
+var ErrNoSnapshot = errors.New("employee has no salary snapshot for the month")
+
+func (s *Service) GetPayslipDeliveries(ctx context.Context, month timex.Month) ([]PayslipDelivery, error) {
+	ctx, span := tracing.Start(ctx, "salary.Service.GetPayslipDeliveries")
+	defer span.End()
+
+	deliveries, err := s.db.GetPayslipDeliveries(ctx, month)
+	if err != nil {
+		return []PayslipDelivery{}, fmt.Errorf("get payslip deliveries from db: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// SendPayslips emails every employee with a snapshot in the month the payslip of their latest snapshot.
+// Employees whose payslip was already sent are left out, so it is safe to call again after failures.
+func (s *Service) SendPayslips(ctx context.Context, month timex.Month) ([]PayslipDelivery, error) {
+	ctx, span := tracing.Start(ctx, "salary.Service.SendPayslips")
+	defer span.End()
+
+	snapshots, err := s.db.GetSnapshots(ctx, GetSnapshotsRequest{Month: &month})
+	if err != nil {
+		return []PayslipDelivery{}, fmt.Errorf("get snapshots from db: %w", err)
+	}
+
+	previousDeliveries, err := s.db.GetPayslipDeliveries(ctx, month)
+	if err != nil {
+		return []PayslipDelivery{}, fmt.Errorf("get payslip deliveries from db: %w", err)
+	}
+
+	sent := make(map[int64]bool, len(previousDeliveries))
+	for _, delivery := range previousDeliveries {
+		sent[delivery.EmployeeID] = delivery.Status == PayslipStatusSent
+	}
+
+	storeName, err := s.storeName(ctx)
+	if err != nil {
+		return []PayslipDelivery{}, err
+	}
+
+	deliveries := []PayslipDelivery{}
+
+	// Snapshots are ordered from the newest, so the first one of an employee is their latest.
+	seen := make(map[int64]bool, len(snapshots))
+	for _, snapshot := range snapshots {
+		if seen[snapshot.EmployeeID] {
+			continue
+		}
+		seen[snapshot.EmployeeID] = true
+
+		if sent[snapshot.EmployeeID] {
+			continue
+		}
+
+		delivery, err := s.sendPayslip(ctx, snapshot, storeName)
+		if err != nil {
+			return deliveries, fmt.Errorf("send payslip of employee %d: %w", snapshot.EmployeeID, err)
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, nil
+}
+
+// ResendPayslip emails the employee the payslip of their latest snapshot in the month, even if it was already sent.
+func (s *Service) ResendPayslip(ctx context.Context, employeeID int64, month timex.Month) (PayslipDelivery, error) {
+	ctx, span := tracing.Start(ctx, "salary.Service.ResendPayslip")
+	defer span.End()
+
+	snapshots, err := s.db.GetSnapshots(ctx, GetSnapshotsRequest{EmployeeID: &employeeID, Month: &month})
+	if err != nil {
+		return PayslipDelivery{}, fmt.Errorf("get snapshots from db: %w", err)
+	}
+
+	if len(snapshots) == 0 {
+		return PayslipDelivery{}, ErrNoSnapshot
+	}
+
+	storeName, err := s.storeName(ctx)
+	if err != nil {
+		return PayslipDelivery{}, err
+	}
+
+	return s.sendPayslip(ctx, snapshots[0], storeName)
+}
+
+// RenderPayslip returns the data of the payslip of a snapshot, to preview it before sending.
+func (s *Service) RenderPayslip(ctx context.Context, snapshotID int64) (templates.PayslipData, error) {
+	ctx, span := tracing.Start(ctx, "salary.Service.RenderPayslip")
+	defer span.End()
+
+	snapshot, err := s.db.GetSnapshot(ctx, snapshotID)
+	if err != nil {
+		return templates.PayslipData{}, fmt.Errorf("get snapshot from db: %w", err)
+	}
+
+	employee, err := s.hrisService.GetEmployee(ctx, snapshot.EmployeeID)
+	if err != nil {
+		return templates.PayslipData{}, fmt.Errorf("get employee: %w", err)
+	}
+
+	storeName, err := s.storeName(ctx)
+	if err != nil {
+		return templates.PayslipData{}, err
+	}
+
+	return payslipData(snapshot, employee, storeName), nil
+}
+
+// sendPayslip emails the payslip of a snapshot and records the outcome.
+// A failure to send is recorded in the delivery instead of being returned.
+func (s *Service) sendPayslip(ctx context.Context, snapshot Snapshot, storeName string) (PayslipDelivery, error) {
+	employee, err := s.hrisService.GetEmployee(ctx, snapshot.EmployeeID)
+	if err != nil {
+		return PayslipDelivery{}, fmt.Errorf("get employee: %w", err)
+	}
+
+	status, deliveryErr := PayslipStatusSkipped, ""
+	if employee.PayslipByEmail && employee.Email != "" {
+		status = PayslipStatusSent
+		if err := s.emailPayslip(ctx, employee.Email, payslipData(snapshot, employee, storeName)); err != nil {
+			status, deliveryErr = PayslipStatusFailed, err.Error()
+		}
+	}
+
+	delivery, err := s.db.UpsertPayslipDelivery(ctx, snapshot, employee.Email, status, deliveryErr)
+	if err != nil {
+		return PayslipDelivery{}, fmt.Errorf("upsert payslip delivery in db: %w", err)
+	}
+
+	return delivery, nil
+}
+
+func (s *Service) emailPayslip(ctx context.Context, to string, data templates.PayslipData) error {
+	var html, text bytes.Buffer
+	if err := templates.Payslip.Execute(&html, data); err != nil {
+		return fmt.Errorf("render html payslip: %w", err)
+	}
+
+	if err := templates.PayslipText.Execute(&text, data); err != nil {
+		return fmt.Errorf("render text payslip: %w", err)
+	}
+
+	message := notification.Message{
+		To:       to,
+		Subject:  "Slip Gaji " + data.Month,
+		TextBody: text.String(),
+		HTMLBody: html.String(),
+	}
+
+	if err := s.sender.Send(ctx, message); err != nil {
+		return fmt.Errorf("send email: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) storeName(ctx context.Context) (string, error) {
+	branding, err := s.hrisService.GetReceiptBranding(ctx)
+	if err != nil {
+		return "", fmt.Errorf("get receipt branding: %w", err)
+	}
+
+	return branding.StoreName, nil
+}
+
+func payslipData(snapshot Snapshot, employee hris.Employee, storeName string) templates.PayslipData {
+	components := make([]templates.PayslipComponent, len(snapshot.Salary.Components))
+	for i, component := range snapshot.Salary.Components {
+		components[i] = templates.PayslipComponent{
+			Description: component.Description,
+			Amount:      component.Amount,
+			Multiplier:  component.Multiplier,
+			Total:       component.Total(),
+		}
+	}
+
+	extraInfos := make([]templates.PayslipExtraInfo, len(snapshot.Salary.ExtraInfos))
+	for i, extraInfo := range snapshot.Salary.ExtraInfos {
+		extraInfos[i] = templates.PayslipExtraInfo{
+			Title:       extraInfo.Title,
+			Description: extraInfo.Description,
+		}
+	}
+
+	return templates.PayslipData{
+		StoreName:    storeName,
+		EmployeeName: employee.Name,
+		Month:        timex.FormatMonth(snapshot.Month),
+		Components:   components,
+		Total:        snapshot.Salary.Total(),
+		ExtraInfos:   extraInfos,
+	}
+}
@@ -20,10 +20,17 @@ func (h *Handler) registerSalaryRoutes(r chi.Router) {
 	r.Get(`/{month:20\d{2}-\d{2}}/{employeeID:^\d+}/extra-infos`, h.GetEmployeeExtraInfos)
 	r.Post(`/{month:20\d{2}-\d{2}}/{employeeID:^\d+}/extra-infos`, h.CreateExtraInfo)
 
+	r.Post(`/{month:20\d{2}-\d{2}}/{employeeID:^\d+}/payslip/resend`, h.ResendPayslip)
+	r.Get(`/{month:20\d{2}-\d{2}}/payslips`, h.GetPayslipDeliveries)
+	r.Post(`/{month:20\d{2}-\d{2}}/payslips/send`, h.SendPayslips)
+
 	r.Get(`/{month:20\d{2}-\d{2}}/{employeeID:^\d+}`, h.GetSalary)
+	r.Get(`/{month:20\d{2}-\d{2}}/branch-costs`, h.GetBranchPayrolls)
 
+	r.Get(`/snapshots/{id:^\d+}/payslip`, h.GetSnapshotPayslip)
 	r.Get(`/snapshots/{id:^\d+}`, h.GetSnapshot)
 	r.Get(`/snapshots`, h.GetSnapshots)
 	r.Post(`/snapshots`, h.CreateSnapshot)
+	r.Post(`/snapshots/bulk`, h.BulkCreateSnapshots)
 	r.Delete(`/snapshots/{id:^\d+}`, h.DeleteSnapshot)
 }
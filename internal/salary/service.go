@@ -2,15 +2,24 @@ package salary
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"maps"
 	"slices"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/shopspring/decimal"
 	"github.com/turfaa/apotek-hris/internal/attendance"
 	"github.com/turfaa/apotek-hris/internal/hris"
+	"github.com/turfaa/apotek-hris/internal/notification"
+	"github.com/turfaa/apotek-hris/internal/queue"
+	"github.com/turfaa/apotek-hris/pkg/etag"
+	"github.com/turfaa/apotek-hris/pkg/pagination"
+	"github.com/turfaa/apotek-hris/pkg/slicex"
 	"github.com/turfaa/apotek-hris/pkg/timex"
+	"github.com/turfaa/apotek-hris/pkg/tracing"
 	"github.com/turfaa/apotek-hris/pkg/validatorx"
 	"golang.org/x/sync/errgroup"
 )
@@ -20,21 +29,41 @@ var (
 	fixedBonus  = decimal.NewFromInt(200_000)
 )
 
+const (
+	auditEntityStaticComponent     = "salary_static_component"
+	auditEntityAdditionalComponent = "salary_additional_component"
+	auditEntityExtraInfo           = "salary_extra_info"
+	auditEntitySnapshot            = "salary_snapshot"
+)
+
 type Service struct {
 	db                *DB
 	hrisService       *hris.Service
 	attendanceService *attendance.Service
+	sender            notification.Sender
+	queue             *queue.Queue
 }
 
-func NewService(db *sqlx.DB, hrisService *hris.Service, attendanceService *attendance.Service) *Service {
+func NewService(
+	db *sqlx.DB,
+	hrisService *hris.Service,
+	attendanceService *attendance.Service,
+	sender notification.Sender,
+	queue *queue.Queue,
+) *Service {
 	return &Service{
 		db:                NewDB(db),
 		hrisService:       hrisService,
 		attendanceService: attendanceService,
+		sender:            sender,
+		queue:             queue,
 	}
 }
 
 func (s *Service) GetSalary(ctx context.Context, employeeID int64, month timex.Month) (Salary, error) {
+	ctx, span := tracing.Start(ctx, "salary.Service.GetSalary")
+	defer span.End()
+
 	monthDateFrom, monthDateTo, err := month.DateRange()
 	if err != nil {
 		return Salary{}, fmt.Errorf("get month date range: %w", err)
@@ -58,6 +87,7 @@ func (s *Service) GetSalary(ctx context.Context, employeeID int64, month timex.M
 		additionalComponents []AdditionalComponent
 		extraInfos           []ExtraInfo
 		quotas               []attendance.EmployeeAttendanceQuota
+		branches             []hris.Branch
 	)
 
 	eg, gCtx := errgroup.WithContext(ctx)
@@ -84,7 +114,8 @@ func (s *Service) GetSalary(ctx context.Context, employeeID int64, month timex.M
 
 	eg.Go(func() error {
 		var err error
-		workLogs, err = s.hrisService.GetEmployeeWorkLogsBetween(gCtx, employeeID, monthTimeFrom, monthTimeTo)
+		// Branches may be in other time zones, so the month is widened here and narrowed per branch below.
+		workLogs, err = s.hrisService.GetEmployeeWorkLogsBetween(gCtx, employeeID, monthTimeFrom.AddDate(0, 0, -1), monthTimeTo.AddDate(0, 0, 1))
 		if err != nil {
 			return fmt.Errorf("get employee work logs between dates from hris service: %w", err)
 		}
@@ -132,10 +163,22 @@ func (s *Service) GetSalary(ctx context.Context, employeeID int64, month timex.M
 		return nil
 	})
 
+	eg.Go(func() error {
+		var err error
+		branches, err = s.hrisService.GetBranches(gCtx)
+		if err != nil {
+			return fmt.Errorf("get branches from hris service: %w", err)
+		}
+
+		return nil
+	})
+
 	if err := eg.Wait(); err != nil {
 		return Salary{}, fmt.Errorf("wait for get salary: %w", err)
 	}
 
+	workLogs = workLogsInMonth(workLogs, month, branches)
+
 	monthStartTime, _ := timex.BeginningOfDate(monthDateFrom.String())
 
 	quotaExtraInfos := make([]ExtraInfo, 0, len(quotas))
@@ -152,14 +195,151 @@ func (s *Service) GetSalary(ctx context.Context, employeeID int64, month timex.M
 
 	attendanceSummary := attendance.CreateEmployeeSummary(attendances)
 
-	return s.calculateSalary(
+	salary := s.calculateSalary(
 		employee,
 		attendanceSummary,
 		workLogs,
 		staticComponents,
 		additionalComponents,
 		extraInfos,
-	), nil
+	)
+	salary.BranchCosts = s.calculateBranchCosts(
+		employee,
+		attendances,
+		workLogs,
+		staticComponents,
+		additionalComponents,
+		branches,
+	)
+
+	return salary, nil
+}
+
+// workLogsInMonth returns the work logs performed in the month in the time zone of their branch.
+func workLogsInMonth(workLogs []hris.WorkLog, month timex.Month, branches []hris.Branch) []hris.WorkLog {
+	locations := make(map[int64]*time.Location, len(branches))
+	for _, branch := range branches {
+		locations[branch.ID] = branch.Location()
+	}
+
+	inMonth := make([]hris.WorkLog, 0, len(workLogs))
+	for _, workLog := range workLogs {
+		loc, ok := locations[workLog.BranchID]
+		if !ok {
+			loc = time.Local
+		}
+
+		if month.Contains(workLog.PerformedAt.In(loc)) {
+			inMonth = append(inMonth, workLog)
+		}
+	}
+
+	return inMonth
+}
+
+// calculateBranchCosts splits the salary by calculating it again for the records of each branch.
+// The home branch is always included, other branches only if the employee worked there.
+func (s *Service) calculateBranchCosts(
+	employee hris.Employee,
+	attendances []attendance.Attendance,
+	workLogs []hris.WorkLog,
+	staticComponents []StaticComponent,
+	additionalComponents []AdditionalComponent,
+	branches []hris.Branch,
+) []BranchCost {
+	attendancesByBranch := slicex.GroupBy(attendances, func(a attendance.Attendance) int64 {
+		return a.BranchID
+	})
+	workLogsByBranch := slicex.GroupBy(workLogs, func(w hris.WorkLog) int64 {
+		return w.BranchID
+	})
+
+	var branchCosts []BranchCost
+	for _, branch := range branches {
+		isHome := branch.ID == employee.HomeBranchID
+		branchAttendances := attendancesByBranch[branch.ID]
+		branchWorkLogs := workLogsByBranch[branch.ID]
+		if !isHome && len(branchAttendances) == 0 && len(branchWorkLogs) == 0 {
+			continue
+		}
+
+		var (
+			branchStaticComponents     []StaticComponent
+			branchAdditionalComponents []AdditionalComponent
+		)
+		if isHome {
+			branchStaticComponents = staticComponents
+			branchAdditionalComponents = additionalComponents
+		}
+
+		branchSalary := s.calculateSalary(
+			employee,
+			attendance.CreateEmployeeSummary(branchAttendances),
+			branchWorkLogs,
+			branchStaticComponents,
+			branchAdditionalComponents,
+			nil,
+		)
+
+		branchCosts = append(branchCosts, BranchCost{
+			BranchID:   branch.ID,
+			BranchName: branch.Name,
+			Total:      branchSalary.Total(),
+		})
+	}
+
+	return branchCosts
+}
+
+// GetBranchPayrolls returns the salary cost of every branch in a month, limited to the branch if branchID is not nil.
+// Branches without any cost are left out.
+func (s *Service) GetBranchPayrolls(ctx context.Context, month timex.Month, branchID *int64) ([]BranchPayroll, error) {
+	ctx, span := tracing.Start(ctx, "salary.Service.GetBranchPayrolls")
+	defer span.End()
+
+	employees, err := s.hrisService.GetEmployees(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("get employees from hris service: %w", err)
+	}
+
+	var (
+		payrollByBranchID = make(map[int64]*BranchPayroll)
+		branchIDs         []int64
+	)
+	for _, employee := range employees {
+		salary, err := s.GetSalary(ctx, employee.ID, month)
+		if err != nil {
+			return nil, fmt.Errorf("get salary of employee %d: %w", employee.ID, err)
+		}
+
+		for _, branchCost := range salary.BranchCosts {
+			if branchCost.Total.IsZero() || (branchID != nil && branchCost.BranchID != *branchID) {
+				continue
+			}
+
+			payroll, ok := payrollByBranchID[branchCost.BranchID]
+			if !ok {
+				payroll = &BranchPayroll{BranchID: branchCost.BranchID, BranchName: branchCost.BranchName}
+				payrollByBranchID[branchCost.BranchID] = payroll
+				branchIDs = append(branchIDs, branchCost.BranchID)
+			}
+
+			payroll.Total = payroll.Total.Add(branchCost.Total)
+			payroll.Employees = append(payroll.Employees, EmployeeBranchCost{
+				EmployeeID:   employee.ID,
+				EmployeeName: employee.Name,
+				Total:        branchCost.Total,
+			})
+		}
+	}
+
+	slices.Sort(branchIDs)
+	payrolls := make([]BranchPayroll, 0, len(branchIDs))
+	for _, id := range branchIDs {
+		payrolls = append(payrolls, *payrollByBranchID[id])
+	}
+
+	return payrolls, nil
 }
 
 func (s *Service) calculateSalary(
@@ -226,26 +406,63 @@ func (*Service) calculateHourlyOvertimeFee(shiftFee decimal.Decimal) decimal.Dec
 }
 
 func (s *Service) GetEmployeeStaticComponents(ctx context.Context, employeeID int64) ([]StaticComponent, error) {
+	ctx, span := tracing.Start(ctx, "salary.Service.GetEmployeeStaticComponents")
+	defer span.End()
+
 	return s.db.GetEmployeeStaticComponents(ctx, employeeID)
 }
 
-func (s *Service) CreateStaticComponent(ctx context.Context, employeeID int64, component Component) (StaticComponent, error) {
-	return s.db.CreateStaticComponent(ctx, employeeID, component)
+// CreateStaticComponent adds a static component to an employee, if their static components were not changed since the
+// precondition was read. It returns the created component and the entity tag of the static components after the change.
+func (s *Service) CreateStaticComponent(ctx context.Context, employeeID int64, component Component, precondition etag.Precondition) (StaticComponent, string, error) {
+	ctx, span := tracing.Start(ctx, "salary.Service.CreateStaticComponent")
+	defer span.End()
+
+	created, staticComponentsETag, err := s.db.CreateStaticComponent(ctx, employeeID, component, precondition)
+	if err != nil {
+		return StaticComponent{}, "", fmt.Errorf("create static component in db: %w", err)
+	}
+
+	return created, staticComponentsETag, nil
 }
 
-func (s *Service) DeleteStaticComponent(ctx context.Context, employeeID int64, id int64) error {
-	return s.db.DeleteStaticComponent(ctx, employeeID, id)
+// DeleteStaticComponent deletes a static component of an employee, if their static components were not changed since the
+// precondition was read. It returns the entity tag of the static components after the change.
+func (s *Service) DeleteStaticComponent(ctx context.Context, employeeID int64, id int64, precondition etag.Precondition) (string, error) {
+	ctx, span := tracing.Start(ctx, "salary.Service.DeleteStaticComponent")
+	defer span.End()
+
+	_, staticComponentsETag, err := s.db.DeleteStaticComponent(ctx, employeeID, id, precondition)
+	if err != nil {
+		return "", fmt.Errorf("delete static component in db: %w", err)
+	}
+
+	return staticComponentsETag, nil
 }
 
 func (s *Service) GetEmployeeAdditionalComponents(ctx context.Context, employeeID int64, month timex.Month) ([]AdditionalComponent, error) {
+	ctx, span := tracing.Start(ctx, "salary.Service.GetEmployeeAdditionalComponents")
+	defer span.End()
+
 	return s.db.GetEmployeeAdditionalComponents(ctx, employeeID, month)
 }
 
 func (s *Service) CreateAdditionalComponent(ctx context.Context, employeeID int64, month timex.Month, component Component) (AdditionalComponent, error) {
-	return s.db.CreateAdditionalComponent(ctx, employeeID, month, component)
+	ctx, span := tracing.Start(ctx, "salary.Service.CreateAdditionalComponent")
+	defer span.End()
+
+	created, err := s.db.CreateAdditionalComponent(ctx, employeeID, month, component)
+	if err != nil {
+		return AdditionalComponent{}, fmt.Errorf("create additional component in db: %w", err)
+	}
+
+	return created, nil
 }
 
 func (s *Service) BulkCreateAdditionalComponents(ctx context.Context, request BulkCreateAdditionalComponentRequest) ([]AdditionalComponent, error) {
+	ctx, span := tracing.Start(ctx, "salary.Service.BulkCreateAdditionalComponents")
+	defer span.End()
+
 	if err := validatorx.Validate(request); err != nil {
 		return nil, fmt.Errorf("invalid request: %w", err)
 	}
@@ -296,34 +513,84 @@ func (s *Service) BulkCreateAdditionalComponents(ctx context.Context, request Bu
 }
 
 func (s *Service) DeleteAdditionalComponent(ctx context.Context, employeeID int64, month timex.Month, id int64) error {
-	return s.db.DeleteAdditionalComponent(ctx, employeeID, month, id)
+	ctx, span := tracing.Start(ctx, "salary.Service.DeleteAdditionalComponent")
+	defer span.End()
+
+	_, err := s.db.DeleteAdditionalComponent(ctx, employeeID, month, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Nothing to delete.
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("delete additional component in db: %w", err)
+	}
+
+	return nil
 }
 
 func (s *Service) GetEmployeeExtraInfos(ctx context.Context, employeeID int64, month timex.Month) ([]ExtraInfo, error) {
+	ctx, span := tracing.Start(ctx, "salary.Service.GetEmployeeExtraInfos")
+	defer span.End()
+
 	return s.db.GetEmployeeExtraInfos(ctx, employeeID, month)
 }
 
 func (s *Service) CreateExtraInfo(ctx context.Context, employeeID int64, month timex.Month, request CreateExtraInfoRequest) (ExtraInfo, error) {
+	ctx, span := tracing.Start(ctx, "salary.Service.CreateExtraInfo")
+	defer span.End()
+
 	if err := validatorx.Validate(request); err != nil {
 		return ExtraInfo{}, fmt.Errorf("invalid request: %w", err)
 	}
 
-	return s.db.CreateExtraInfo(ctx, employeeID, month, request.Title, request.Description)
+	created, err := s.db.CreateExtraInfo(ctx, employeeID, month, request.Title, request.Description)
+	if err != nil {
+		return ExtraInfo{}, fmt.Errorf("create extra info in db: %w", err)
+	}
+
+	return created, nil
 }
 
 func (s *Service) DeleteExtraInfo(ctx context.Context, employeeID int64, month timex.Month, id int64) error {
-	return s.db.DeleteExtraInfo(ctx, employeeID, month, id)
+	ctx, span := tracing.Start(ctx, "salary.Service.DeleteExtraInfo")
+	defer span.End()
+
+	_, err := s.db.DeleteExtraInfo(ctx, employeeID, month, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Nothing to delete.
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("delete extra info in db: %w", err)
+	}
+
+	return nil
 }
 
-func (s *Service) GetSnapshots(ctx context.Context, request GetSnapshotsRequest) ([]Snapshot, error) {
-	return s.db.GetSnapshots(ctx, request)
+// ListSnapshots returns a page of the snapshots matching the filters of the request.
+func (s *Service) ListSnapshots(ctx context.Context, request ListSnapshotsRequest) (pagination.Page[Snapshot], error) {
+	ctx, span := tracing.Start(ctx, "salary.Service.ListSnapshots")
+	defer span.End()
+
+	snapshots, err := s.db.ListSnapshots(ctx, request)
+	if err != nil {
+		return pagination.Page[Snapshot]{}, fmt.Errorf("list snapshots from db: %w", err)
+	}
+
+	return snapshots, nil
 }
 
 func (s *Service) GetSnapshot(ctx context.Context, id int64) (Snapshot, error) {
+	ctx, span := tracing.Start(ctx, "salary.Service.GetSnapshot")
+	defer span.End()
+
 	return s.db.GetSnapshot(ctx, id)
 }
 
 func (s *Service) CreateSnapshot(ctx context.Context, request CreateSnapshotRequest) (Snapshot, error) {
+	ctx, span := tracing.Start(ctx, "salary.Service.CreateSnapshot")
+	defer span.End()
+
 	if err := validatorx.Validate(request); err != nil {
 		return Snapshot{}, fmt.Errorf("invalid request: %w", err)
 	}
@@ -338,9 +605,40 @@ func (s *Service) CreateSnapshot(ctx context.Context, request CreateSnapshotRequ
 		return Snapshot{}, fmt.Errorf("create salary snapshot in db: %w", err)
 	}
 
+	snapshotsCreated.Inc()
+
 	return snapshot, nil
 }
 
+// EnqueueBulkCreateSnapshots queues a background job snapshotting the salary of the month of every employee without one.
+func (s *Service) EnqueueBulkCreateSnapshots(ctx context.Context, request BulkCreateSnapshotsRequest) (queue.Job, error) {
+	ctx, span := tracing.Start(ctx, "salary.Service.EnqueueBulkCreateSnapshots")
+	defer span.End()
+
+	if err := validatorx.Validate(request); err != nil {
+		return queue.Job{}, fmt.Errorf("invalid request: %w", err)
+	}
+
+	job, err := s.queue.Enqueue(ctx, TaskCreateSnapshots, request)
+	if err != nil {
+		return queue.Job{}, fmt.Errorf("enqueue job: %w", err)
+	}
+
+	return job, nil
+}
+
 func (s *Service) DeleteSnapshot(ctx context.Context, id int64) error {
-	return s.db.DeleteSnapshot(ctx, id)
+	ctx, span := tracing.Start(ctx, "salary.Service.DeleteSnapshot")
+	defer span.End()
+
+	_, err := s.db.DeleteSnapshot(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Nothing to delete.
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("delete salary snapshot in db: %w", err)
+	}
+
+	return nil
 }
@@ -0,0 +1,88 @@
+package templates
+
+import (
+	_ "embed"
+	"html/template"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	//go:embed payslip.html
+	payslipHTMLTemplate string
+
+	//go:embed payslip.txt
+	payslipTextTemplate string
+)
+
+var funcs = template.FuncMap{
+	"rupiah": FormatRupiah,
+	"number": FormatNumber,
+}
+
+// Payslip renders PayslipData as the HTML body of the payslip email.
+var Payslip = template.Must(template.New("payslip.html").Funcs(funcs).Parse(payslipHTMLTemplate))
+
+// PayslipText renders PayslipData as the plain text alternative of the payslip email.
+var PayslipText = texttemplate.Must(texttemplate.New("payslip.txt").Funcs(texttemplate.FuncMap(funcs)).Parse(payslipTextTemplate))
+
+type PayslipData struct {
+	StoreName    string
+	EmployeeName string
+
+	// Month is the month in words, e.g. "Oktober 2026".
+	Month string
+
+	Components []PayslipComponent
+	Total      decimal.Decimal
+	ExtraInfos []PayslipExtraInfo
+}
+
+type PayslipComponent struct {
+	Description string
+	Amount      decimal.Decimal
+	Multiplier  decimal.Decimal
+	Total       decimal.Decimal
+}
+
+type PayslipExtraInfo struct {
+	Title       string
+	Description string
+}
+
+// FormatRupiah formats an amount the Indonesian way, e.g. Rp1.250.000.
+func FormatRupiah(amount decimal.Decimal) string {
+	if amount.IsNegative() {
+		return "-Rp" + FormatNumber(amount.Neg())
+	}
+
+	return "Rp" + FormatNumber(amount)
+}
+
+// FormatNumber formats a number with dots between thousands and a decimal comma, e.g. 1.250,5.
+func FormatNumber(number decimal.Decimal) string {
+	sign := ""
+	if number.IsNegative() {
+		sign = "-"
+		number = number.Neg()
+	}
+
+	integer, fraction, _ := strings.Cut(number.String(), ".")
+
+	var b strings.Builder
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(digit)
+	}
+
+	if fraction != "" {
+		b.WriteByte(',')
+		b.WriteString(fraction)
+	}
+
+	return sign + b.String()
+}
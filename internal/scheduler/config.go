@@ -0,0 +1,34 @@
+package scheduler
+
+import "time"
+
+// DefaultElectionInterval is used when Config.ElectionInterval is not set.
+const DefaultElectionInterval = 15 * time.Second
+
+type Config struct {
+	// Disabled stops this instance from running jobs on their schedule. Jobs can still be run manually.
+	Disabled bool `mapstructure:"disabled"`
+
+	// ElectionInterval is how often a standby instance tries to become the leader,
+	// and how often the leader looks for due jobs.
+	ElectionInterval time.Duration `mapstructure:"election_interval" validate:"gte=0"`
+
+	// Jobs overrides the settings of the jobs by name.
+	Jobs map[string]JobConfig `mapstructure:"jobs"`
+}
+
+type JobConfig struct {
+	// Schedule replaces the default schedule of the job.
+	Schedule string `mapstructure:"schedule"`
+
+	// Disabled keeps the job from running on its schedule. It can still be run manually.
+	Disabled bool `mapstructure:"disabled"`
+}
+
+func (c Config) electionInterval() time.Duration {
+	if c.ElectionInterval == 0 {
+		return DefaultElectionInterval
+	}
+
+	return c.ElectionInterval
+}
@@ -0,0 +1,171 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const runColumns = `id, job_name, trigger, scheduled_at, triggered_by, status, error, started_at, finished_at`
+
+type DB struct {
+	db *sqlx.DB
+}
+
+func NewDB(db *sqlx.DB) *DB {
+	return &DB{db: db}
+}
+
+// Conn reserves a connection from the pool, to hold advisory locks on.
+func (d *DB) Conn(ctx context.Context) (*sqlx.Conn, error) {
+	conn, err := d.db.Connx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("connx from db: %w", err)
+	}
+
+	return conn, nil
+}
+
+// TryLock takes the session advisory lock named key on conn, without waiting for it.
+// The lock is held until it is unlocked or the connection is closed.
+func (d *DB) TryLock(ctx context.Context, conn *sqlx.Conn, key string) (bool, error) {
+	query := `SELECT pg_try_advisory_lock(hashtext(?))`
+	query = d.db.Rebind(query)
+	args := []any{key}
+
+	var acquired bool
+	if err := conn.GetContext(ctx, &acquired, query, args...); err != nil {
+		return false, fmt.Errorf("get context from db: %w", err)
+	}
+
+	return acquired, nil
+}
+
+func (d *DB) Unlock(ctx context.Context, conn *sqlx.Conn, key string) error {
+	query := `SELECT pg_advisory_unlock(hashtext(?))`
+	query = d.db.Rebind(query)
+	args := []any{key}
+
+	if _, err := conn.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("exec context from db: %w", err)
+	}
+
+	return nil
+}
+
+// StartRun records the start of a run. It returns sql.ErrNoRows if the schedule slot has already run.
+func (d *DB) StartRun(ctx context.Context, jobName string, trigger Trigger, scheduledAt *time.Time, triggeredBy *int64) (Run, error) {
+	query := `
+	INSERT INTO job_runs (job_name, trigger, scheduled_at, triggered_by)
+	VALUES (?, ?, ?, ?)
+	ON CONFLICT (job_name, scheduled_at) DO NOTHING
+	RETURNING ` + runColumns
+	query = d.db.Rebind(query)
+	args := []any{jobName, trigger, scheduledAt, triggeredBy}
+
+	var run Run
+	if err := d.db.GetContext(ctx, &run, query, args...); err != nil {
+		return Run{}, fmt.Errorf("get context from db: %w", err)
+	}
+
+	return run, nil
+}
+
+func (d *DB) FinishRun(ctx context.Context, id int64, status RunStatus, runErr string) error {
+	query := `
+	UPDATE job_runs
+	SET status = ?, error = ?, finished_at = CURRENT_TIMESTAMP
+	WHERE id = ?`
+	query = d.db.Rebind(query)
+	args := []any{status, runErr, id}
+
+	if _, err := d.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("exec context from db: %w", err)
+	}
+
+	return nil
+}
+
+// FailInterruptedRuns marks the runs of a job that never finished, because their instance stopped, as failed.
+// It must only be called while holding the lock of the job.
+func (d *DB) FailInterruptedRuns(ctx context.Context, jobName string) error {
+	query := `
+	UPDATE job_runs
+	SET status = 'failed', error = 'interrupted', finished_at = CURRENT_TIMESTAMP
+	WHERE job_name = ? AND status = 'running'`
+	query = d.db.Rebind(query)
+	args := []any{jobName}
+
+	if _, err := d.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("exec context from db: %w", err)
+	}
+
+	return nil
+}
+
+func (d *DB) GetRuns(ctx context.Context, jobName string, limit int) ([]Run, error) {
+	query := `
+	SELECT ` + runColumns + `
+	FROM job_runs
+	WHERE job_name = ?
+	ORDER BY id DESC
+	LIMIT ?`
+	query = d.db.Rebind(query)
+	args := []any{jobName, limit}
+
+	var runs []Run
+	if err := d.db.SelectContext(ctx, &runs, query, args...); err != nil {
+		return []Run{}, fmt.Errorf("select context from db: %w", err)
+	}
+
+	return runs, nil
+}
+
+// GetLastRuns returns the latest run of every job, or only the latest failed run if failedOnly is set.
+func (d *DB) GetLastRuns(ctx context.Context, failedOnly bool) (map[string]Run, error) {
+	query := `
+	SELECT DISTINCT ON (job_name) ` + runColumns + `
+	FROM job_runs
+	WHERE NOT ? OR status = 'failed'
+	ORDER BY job_name, id DESC`
+	query = d.db.Rebind(query)
+	args := []any{failedOnly}
+
+	var runs []Run
+	if err := d.db.SelectContext(ctx, &runs, query, args...); err != nil {
+		return nil, fmt.Errorf("select context from db: %w", err)
+	}
+
+	lastRuns := make(map[string]Run, len(runs))
+	for _, run := range runs {
+		lastRuns[run.JobName] = run
+	}
+
+	return lastRuns, nil
+}
+
+// GetLastScheduledAt returns the latest schedule slot that has run of every job.
+func (d *DB) GetLastScheduledAt(ctx context.Context) (map[string]time.Time, error) {
+	query := `
+	SELECT job_name, MAX(scheduled_at) AS scheduled_at
+	FROM job_runs
+	WHERE scheduled_at IS NOT NULL
+	GROUP BY job_name`
+
+	var rows []struct {
+		JobName     string    `db:"job_name"`
+		ScheduledAt time.Time `db:"scheduled_at"`
+	}
+	if err := d.db.SelectContext(ctx, &rows, query); err != nil {
+		return nil, fmt.Errorf("select context from db: %w", err)
+	}
+
+	lastScheduledAt := make(map[string]time.Time, len(rows))
+	for _, row := range rows {
+		lastScheduledAt[row.JobName] = row.ScheduledAt
+	}
+
+	return lastScheduledAt, nil
+}
@@ -0,0 +1,56 @@
+package scheduler
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/turfaa/apotek-hris/pkg/httpx"
+)
+
+type Handler struct {
+	scheduler *Scheduler
+}
+
+func NewHandler(scheduler *Scheduler) *Handler {
+	return &Handler{scheduler: scheduler}
+}
+
+func (h *Handler) GetJobs(w http.ResponseWriter, r *http.Request) {
+	jobs, err := h.scheduler.Jobs(r.Context())
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Ok(w, jobs)
+}
+
+func (h *Handler) GetRuns(w http.ResponseWriter, r *http.Request) {
+	runs, err := h.scheduler.Runs(r.Context(), chi.URLParam(r, "jobName"))
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Ok(w, runs)
+}
+
+func (h *Handler) TriggerJob(w http.ResponseWriter, r *http.Request) {
+	run, err := h.scheduler.Trigger(r.Context(), chi.URLParam(r, "jobName"))
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Status(w, run, http.StatusAccepted)
+}
+
+// errorCodes classify the errors of the scheduler for the clients.
+var errorCodes = []httpx.ErrorCode{
+	{Err: ErrJobNotFound, Code: httpx.CodeNotFound},
+	{Err: ErrJobRunning, Code: httpx.CodeConflict},
+}
+
+func httpServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	httpx.ServiceError(w, r, err, errorCodes)
+}
@@ -0,0 +1,76 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrJobNotFound = errors.New("job not found")
+	ErrJobRunning  = errors.New("job is already running")
+)
+
+// Job is a task run on a cron schedule.
+type Job struct {
+	// Name identifies the job in the config, the run history and the API, e.g. "check-license-expiry".
+	Name        string
+	Description string
+
+	// Schedule is the default cron expression of the job ("minute hour day-of-month month day-of-week"),
+	// in the deployment time zone.
+	Schedule string
+
+	Run func(ctx context.Context) error
+}
+
+type Trigger string
+
+const (
+	TriggerSchedule Trigger = "schedule"
+	TriggerManual   Trigger = "manual"
+)
+
+type RunStatus string
+
+const (
+	RunStatusRunning   RunStatus = "running"
+	RunStatusSucceeded RunStatus = "succeeded"
+	RunStatusFailed    RunStatus = "failed"
+)
+
+type Run struct {
+	ID      int64   `db:"id" json:"id"`
+	JobName string  `db:"job_name" json:"jobName"`
+	Trigger Trigger `db:"trigger" json:"trigger"`
+
+	// ScheduledAt is the schedule slot the run is for. Manual runs don't have one.
+	ScheduledAt *time.Time `db:"scheduled_at" json:"scheduledAt"`
+
+	// TriggeredBy is the employee who ran the job manually, if known.
+	TriggeredBy *int64 `db:"triggered_by" json:"triggeredBy"`
+
+	Status     RunStatus  `db:"status" json:"status"`
+	Error      string     `db:"error" json:"error"`
+	StartedAt  time.Time  `db:"started_at" json:"startedAt"`
+	FinishedAt *time.Time `db:"finished_at" json:"finishedAt"`
+}
+
+// JobStatus is a registered job with its schedule and latest runs.
+type JobStatus struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Schedule    string `json:"schedule"`
+
+	// Enabled is false when the job is disabled in the config.
+	Enabled bool `json:"enabled"`
+
+	// NextRunAt is when the job is next run on its schedule, if it is enabled.
+	NextRunAt *time.Time `json:"nextRunAt"`
+
+	LastRun *Run `json:"lastRun"`
+
+	// LastError is the error of the latest failed run.
+	LastError   string     `json:"lastError"`
+	LastErrorAt *time.Time `json:"lastErrorAt"`
+}
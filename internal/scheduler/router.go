@@ -0,0 +1,10 @@
+package scheduler
+
+import "github.com/go-chi/chi/v5"
+
+// RegisterRoutes registers the scheduled job routes. They share /jobs with the background jobs, which are addressed by ID.
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Get("/jobs", h.GetJobs)
+	r.Get("/jobs/{jobName}/runs", h.GetRuns)
+	r.Post("/jobs/{jobName}/run", h.TriggerJob)
+}
@@ -0,0 +1,393 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/robfig/cron/v3"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/turfaa/apotek-hris/pkg/actor"
+	"github.com/turfaa/apotek-hris/pkg/tracing"
+)
+
+const (
+	// leaderLockKey is the advisory lock held by the instance running the scheduled jobs.
+	leaderLockKey = "scheduler:leader"
+
+	// runsLimit is the number of latest runs returned for a job.
+	runsLimit = 50
+)
+
+// errSlotTaken means the schedule slot was already run, by this or another instance.
+var errSlotTaken = errors.New("schedule slot already run")
+
+// Scheduler runs the registered jobs on their schedules.
+// Of all the instances sharing a database, only the one holding the leader lock runs the scheduled jobs;
+// the others stand by to take over. A job never runs twice at the same time, wherever it is triggered.
+type Scheduler struct {
+	db     *DB
+	config Config
+
+	mu   sync.RWMutex
+	jobs []*registeredJob
+
+	// runs tracks the runs in progress of this instance.
+	runs sync.WaitGroup
+}
+
+type registeredJob struct {
+	Job
+	schedule cron.Schedule
+	enabled  bool
+}
+
+func New(db *sqlx.DB, config Config) *Scheduler {
+	return &Scheduler{db: NewDB(db), config: config}
+}
+
+// Register adds jobs to the scheduler, applying their settings from the config.
+func (s *Scheduler) Register(jobs ...Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, job := range jobs {
+		if _, ok := s.findJob(job.Name); ok {
+			return fmt.Errorf("job %s is already registered", job.Name)
+		}
+
+		jobConfig := s.config.Jobs[job.Name]
+		if jobConfig.Schedule != "" {
+			job.Schedule = jobConfig.Schedule
+		}
+
+		schedule, err := cron.ParseStandard(job.Schedule)
+		if err != nil {
+			return fmt.Errorf("parse schedule %q of job %s: %w", job.Schedule, job.Name, err)
+		}
+
+		s.jobs = append(s.jobs, &registeredJob{Job: job, schedule: schedule, enabled: !jobConfig.Disabled})
+	}
+
+	return nil
+}
+
+// Run runs the scheduled jobs whenever this instance is the leader, until ctx is cancelled.
+// It returns after the runs it started have finished.
+func (s *Scheduler) Run(ctx context.Context) {
+	defer s.runs.Wait()
+
+	if s.config.Disabled {
+		slog.InfoContext(ctx, "job scheduler is disabled")
+		return
+	}
+
+	ticker := time.NewTicker(s.config.electionInterval())
+	defer ticker.Stop()
+
+	for {
+		if err := s.lead(ctx, ticker.C); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "job scheduler stopped leading", slog.Any("error", err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// lead runs the due jobs on every tick for as long as this instance holds the leader lock.
+// It returns immediately if another instance holds it.
+func (s *Scheduler) lead(ctx context.Context, ticks <-chan time.Time) error {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("get connection: %w", err)
+	}
+	defer conn.Close()
+
+	acquired, err := s.db.TryLock(ctx, conn, leaderLockKey)
+	if err != nil {
+		return fmt.Errorf("try leader lock: %w", err)
+	}
+
+	if !acquired {
+		return nil
+	}
+
+	// Closing the connection would release the lock too, but it goes back to the pool instead.
+	defer func() {
+		if err := s.db.Unlock(context.WithoutCancel(ctx), conn, leaderLockKey); err != nil {
+			slog.ErrorContext(ctx, "failed to release the job scheduler leader lock", slog.Any("error", err))
+		}
+	}()
+
+	slog.InfoContext(ctx, "job scheduler is now the leader")
+
+	nextRuns, err := s.nextRuns(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("get next runs: %w", err)
+	}
+
+	for {
+		now := time.Now()
+		for job, next := range nextRuns {
+			if next.After(now) {
+				continue
+			}
+
+			// A missed slot, e.g. while no instance was running, is run once.
+			nextRuns[job] = job.schedule.Next(now)
+
+			if _, err := s.start(ctx, job, TriggerSchedule, &next, nil); err != nil && !errors.Is(err, errSlotTaken) {
+				slog.ErrorContext(ctx, "failed to start scheduled job", slog.String("job", job.Name), slog.Any("error", err))
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticks:
+		}
+
+		// The lock lives as long as the connection.
+		if err := conn.PingContext(ctx); err != nil {
+			return fmt.Errorf("lost the leader lock: %w", err)
+		}
+	}
+}
+
+// nextRuns returns when every enabled job is next due, following its last scheduled run.
+func (s *Scheduler) nextRuns(ctx context.Context, now time.Time) (map[*registeredJob]time.Time, error) {
+	lastScheduledAt, err := s.db.GetLastScheduledAt(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get last scheduled runs from db: %w", err)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	nextRuns := make(map[*registeredJob]time.Time, len(s.jobs))
+	for _, job := range s.jobs {
+		if !job.enabled {
+			continue
+		}
+
+		if last, ok := lastScheduledAt[job.Name]; ok {
+			nextRuns[job] = job.schedule.Next(last.In(now.Location()))
+		} else {
+			nextRuns[job] = job.schedule.Next(now)
+		}
+	}
+
+	return nextRuns, nil
+}
+
+// Trigger runs a job now, in the background. The run keeps going after ctx is cancelled.
+func (s *Scheduler) Trigger(ctx context.Context, name string) (Run, error) {
+	s.mu.RLock()
+	job, ok := s.findJob(name)
+	s.mu.RUnlock()
+
+	if !ok {
+		return Run{}, ErrJobNotFound
+	}
+
+	var triggeredBy *int64
+	if employeeID, ok := actor.EmployeeIDFromContext(ctx); ok {
+		triggeredBy = &employeeID
+	}
+
+	return s.start(context.WithoutCancel(ctx), job, TriggerManual, nil, triggeredBy)
+}
+
+// start records a run of the job and runs it in the background, holding the lock of the job until it finishes.
+func (s *Scheduler) start(ctx context.Context, job *registeredJob, trigger Trigger, scheduledAt *time.Time, triggeredBy *int64) (Run, error) {
+	lockKey := "scheduler:job:" + job.Name
+
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return Run{}, fmt.Errorf("get connection: %w", err)
+	}
+
+	run, err := s.startLocked(ctx, conn, lockKey, job, trigger, scheduledAt, triggeredBy)
+	if err != nil {
+		conn.Close()
+		return Run{}, err
+	}
+
+	s.runs.Add(1)
+	go func() {
+		defer s.runs.Done()
+		defer conn.Close()
+		defer func() {
+			if err := s.db.Unlock(context.WithoutCancel(ctx), conn, lockKey); err != nil {
+				slog.ErrorContext(ctx, "failed to release job lock", slog.String("job", job.Name), slog.Any("error", err))
+			}
+		}()
+
+		s.execute(ctx, job, run)
+	}()
+
+	return run, nil
+}
+
+func (s *Scheduler) startLocked(
+	ctx context.Context,
+	conn *sqlx.Conn,
+	lockKey string,
+	job *registeredJob,
+	trigger Trigger,
+	scheduledAt *time.Time,
+	triggeredBy *int64,
+) (Run, error) {
+	acquired, err := s.db.TryLock(ctx, conn, lockKey)
+	if err != nil {
+		return Run{}, fmt.Errorf("try job lock: %w", err)
+	}
+
+	if !acquired {
+		return Run{}, ErrJobRunning
+	}
+
+	run, returnedErr := func() (Run, error) {
+		if err := s.db.FailInterruptedRuns(ctx, job.Name); err != nil {
+			return Run{}, fmt.Errorf("fail interrupted runs in db: %w", err)
+		}
+
+		run, err := s.db.StartRun(ctx, job.Name, trigger, scheduledAt, triggeredBy)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return Run{}, errSlotTaken
+			}
+			return Run{}, fmt.Errorf("start run in db: %w", err)
+		}
+
+		return run, nil
+	}()
+
+	if returnedErr != nil {
+		if err := s.db.Unlock(context.WithoutCancel(ctx), conn, lockKey); err != nil {
+			return Run{}, errors.Join(returnedErr, fmt.Errorf("unlock job: %w", err))
+		}
+		return Run{}, returnedErr
+	}
+
+	return run, nil
+}
+
+// execute runs the job and records the outcome of the run.
+func (s *Scheduler) execute(ctx context.Context, job *registeredJob, run Run) {
+	ctx, span := tracing.Start(ctx, "job "+job.Name, trace.WithNewRoot())
+	defer span.End()
+
+	logger := slog.With(slog.String("job", job.Name), slog.Int64("run_id", run.ID), slog.String("trigger", string(run.Trigger)))
+	logger.InfoContext(ctx, "job started")
+
+	status, runErr := RunStatusSucceeded, ""
+	if err := runJob(ctx, job.Job); err != nil {
+		status, runErr = RunStatusFailed, err.Error()
+		span.SetStatus(codes.Error, runErr)
+		logger.ErrorContext(ctx, "job failed", slog.Any("error", err))
+	} else {
+		logger.InfoContext(ctx, "job succeeded")
+	}
+
+	if err := s.db.FinishRun(context.WithoutCancel(ctx), run.ID, status, runErr); err != nil {
+		logger.ErrorContext(ctx, "failed to record job run", slog.Any("error", err))
+	}
+}
+
+func runJob(ctx context.Context, job Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return job.Run(ctx)
+}
+
+// Jobs returns the registered jobs with their schedules and latest runs.
+func (s *Scheduler) Jobs(ctx context.Context) ([]JobStatus, error) {
+	lastRuns, err := s.db.GetLastRuns(ctx, false)
+	if err != nil {
+		return []JobStatus{}, fmt.Errorf("get last runs from db: %w", err)
+	}
+
+	lastFailedRuns, err := s.db.GetLastRuns(ctx, true)
+	if err != nil {
+		return []JobStatus{}, fmt.Errorf("get last failed runs from db: %w", err)
+	}
+
+	nextRuns, err := s.nextRuns(ctx, time.Now())
+	if err != nil {
+		return []JobStatus{}, fmt.Errorf("get next runs: %w", err)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	statuses := make([]JobStatus, len(s.jobs))
+	for i, job := range s.jobs {
+		status := JobStatus{
+			Name:        job.Name,
+			Description: job.Description,
+			Schedule:    job.Schedule,
+			Enabled:     job.enabled,
+		}
+
+		if next, ok := nextRuns[job]; ok && !s.config.Disabled {
+			status.NextRunAt = &next
+		}
+
+		if run, ok := lastRuns[job.Name]; ok {
+			status.LastRun = &run
+		}
+
+		if run, ok := lastFailedRuns[job.Name]; ok {
+			status.LastError = run.Error
+			status.LastErrorAt = run.FinishedAt
+		}
+
+		statuses[i] = status
+	}
+
+	return statuses, nil
+}
+
+// Runs returns the latest runs of a job, newest first.
+func (s *Scheduler) Runs(ctx context.Context, name string) ([]Run, error) {
+	s.mu.RLock()
+	_, ok := s.findJob(name)
+	s.mu.RUnlock()
+
+	if !ok {
+		return []Run{}, ErrJobNotFound
+	}
+
+	runs, err := s.db.GetRuns(ctx, name, runsLimit)
+	if err != nil {
+		return []Run{}, fmt.Errorf("get runs from db: %w", err)
+	}
+
+	return runs, nil
+}
+
+func (s *Scheduler) findJob(name string) (*registeredJob, bool) {
+	for _, job := range s.jobs {
+		if job.Name == name {
+			return job, true
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,49 @@
+package webhook
+
+import "time"
+
+const (
+	// DefaultPollInterval is used when Config.PollInterval is not set.
+	DefaultPollInterval = 5 * time.Second
+
+	// DefaultTimeout is used when Config.Timeout is not set.
+	DefaultTimeout = 10 * time.Second
+
+	// DefaultMaxAttempts is used when Config.MaxAttempts is not set.
+	DefaultMaxAttempts = 8
+)
+
+type Config struct {
+	// PollInterval is how often the dispatcher looks for new events and due deliveries.
+	PollInterval time.Duration `mapstructure:"poll_interval" validate:"gte=0"`
+
+	// Timeout is how long a webhook has to respond to a delivery.
+	Timeout time.Duration `mapstructure:"timeout" validate:"gte=0"`
+
+	// MaxAttempts is how many times a delivery is attempted before it is marked as failed.
+	MaxAttempts int `mapstructure:"max_attempts" validate:"gte=0"`
+}
+
+func (c Config) pollInterval() time.Duration {
+	if c.PollInterval == 0 {
+		return DefaultPollInterval
+	}
+
+	return c.PollInterval
+}
+
+func (c Config) timeout() time.Duration {
+	if c.Timeout == 0 {
+		return DefaultTimeout
+	}
+
+	return c.Timeout
+}
+
+func (c Config) maxAttempts() int {
+	if c.MaxAttempts == 0 {
+		return DefaultMaxAttempts
+	}
+
+	return c.MaxAttempts
+}
@@ -0,0 +1,341 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/turfaa/apotek-hris/internal/audit"
+)
+
+type DB struct {
+	db *sqlx.DB
+}
+
+func NewDB(db *sqlx.DB) *DB {
+	return &DB{db: db}
+}
+
+func (d *DB) GetWebhooks(ctx context.Context) ([]Webhook, error) {
+	query := `
+	SELECT id, url, secret, event_types, active, created_at, updated_at
+	FROM webhooks
+	ORDER BY id ASC`
+	query = d.db.Rebind(query)
+
+	var webhooks []Webhook
+	if err := d.db.SelectContext(ctx, &webhooks, query); err != nil {
+		return []Webhook{}, fmt.Errorf("select context from db: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+func (d *DB) GetWebhook(ctx context.Context, id int64) (Webhook, error) {
+	query := `
+	SELECT id, url, secret, event_types, active, created_at, updated_at
+	FROM webhooks
+	WHERE id = ?`
+	query = d.db.Rebind(query)
+	args := []any{id}
+
+	var webhook Webhook
+	if err := d.db.GetContext(ctx, &webhook, query, args...); err != nil {
+		return Webhook{}, fmt.Errorf("get context from db: %w", err)
+	}
+
+	return webhook, nil
+}
+
+func (d *DB) CreateWebhook(ctx context.Context, request CreateWebhookRequest) (webhook Webhook, returnedErr error) {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return Webhook{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		if returnedErr != nil {
+			_ = tx.Rollback()
+		} else if err := tx.Commit(); err != nil {
+			returnedErr = fmt.Errorf("commit tx: %w", err)
+		}
+	}()
+
+	query := tx.Rebind(`
+	INSERT INTO webhooks (url, secret, event_types)
+	VALUES (?, ?, ?)
+	RETURNING id, url, secret, event_types, active, created_at, updated_at`)
+	args := []any{request.URL, request.Secret, request.EventTypes}
+
+	if err := tx.GetContext(ctx, &webhook, query, args...); err != nil {
+		return Webhook{}, fmt.Errorf("get context from db: %w", err)
+	}
+
+	if err := audit.Record(ctx, tx, audit.ActionCreate, auditEntityWebhook, webhook.ID, nil, webhook); err != nil {
+		return Webhook{}, fmt.Errorf("record audit log: %w", err)
+	}
+
+	return webhook, nil
+}
+
+// UpdateWebhook updates a webhook, keeping its secret if request.Secret is empty.
+// Returns sql.ErrNoRows if the webhook does not exist.
+func (d *DB) UpdateWebhook(ctx context.Context, id int64, request UpdateWebhookRequest) (webhook Webhook, returnedErr error) {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return Webhook{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		if returnedErr != nil {
+			_ = tx.Rollback()
+		} else if err := tx.Commit(); err != nil {
+			returnedErr = fmt.Errorf("commit tx: %w", err)
+		}
+	}()
+
+	before, err := d.lockWebhook(ctx, tx, id)
+	if err != nil {
+		return Webhook{}, fmt.Errorf("lock webhook: %w", err)
+	}
+
+	query := tx.Rebind(`
+	UPDATE webhooks
+	SET url = ?, secret = COALESCE(NULLIF(?, ''), secret), event_types = ?, active = ?, updated_at = CURRENT_TIMESTAMP
+	WHERE id = ?
+	RETURNING id, url, secret, event_types, active, created_at, updated_at`)
+	args := []any{request.URL, request.Secret, request.EventTypes, request.Active, id}
+
+	if err := tx.GetContext(ctx, &webhook, query, args...); err != nil {
+		return Webhook{}, fmt.Errorf("get context from db: %w", err)
+	}
+
+	if err := audit.Record(ctx, tx, audit.ActionUpdate, auditEntityWebhook, webhook.ID, before, webhook); err != nil {
+		return Webhook{}, fmt.Errorf("record audit log: %w", err)
+	}
+
+	return webhook, nil
+}
+
+// DeleteWebhook deletes a webhook together with its deliveries.
+// Returns sql.ErrNoRows if the webhook does not exist.
+func (d *DB) DeleteWebhook(ctx context.Context, id int64) (returnedErr error) {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		if returnedErr != nil {
+			_ = tx.Rollback()
+		} else if err := tx.Commit(); err != nil {
+			returnedErr = fmt.Errorf("commit tx: %w", err)
+		}
+	}()
+
+	before, err := d.lockWebhook(ctx, tx, id)
+	if err != nil {
+		return fmt.Errorf("lock webhook: %w", err)
+	}
+
+	query := tx.Rebind(`DELETE FROM webhooks WHERE id = ?`)
+	if _, err := tx.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("exec context to db: %w", err)
+	}
+
+	if err := audit.Record(ctx, tx, audit.ActionDelete, auditEntityWebhook, id, before, nil); err != nil {
+		return fmt.Errorf("record audit log: %w", err)
+	}
+
+	return nil
+}
+
+// lockWebhook returns a webhook, locked until the end of the transaction.
+func (d *DB) lockWebhook(ctx context.Context, tx *sqlx.Tx, id int64) (Webhook, error) {
+	query := tx.Rebind(`
+	SELECT id, url, secret, event_types, active, created_at, updated_at
+	FROM webhooks
+	WHERE id = ?
+	FOR UPDATE`)
+
+	var webhook Webhook
+	if err := tx.GetContext(ctx, &webhook, query, id); err != nil {
+		return Webhook{}, fmt.Errorf("get context from db: %w", err)
+	}
+
+	return webhook, nil
+}
+
+// GetDeliveries returns the latest deliveries of a webhook, newest first.
+func (d *DB) GetDeliveries(ctx context.Context, webhookID int64, limit int) ([]Delivery, error) {
+	query := `
+	SELECT d.id, d.webhook_id, d.event_id, e.event_type, d.status, d.attempts, d.next_attempt_at,
+		d.last_attempt_at, d.response_status, d.last_error, d.created_at
+	FROM webhook_deliveries d
+	JOIN outbox_events e ON e.id = d.event_id
+	WHERE d.webhook_id = ?
+	ORDER BY d.id DESC
+	LIMIT ?`
+	query = d.db.Rebind(query)
+	args := []any{webhookID, limit}
+
+	var deliveries []Delivery
+	if err := d.db.SelectContext(ctx, &deliveries, query, args...); err != nil {
+		return []Delivery{}, fmt.Errorf("select context from db: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// Redeliver schedules a delivery of the webhook to be attempted again right away, with all its attempts.
+func (d *DB) Redeliver(ctx context.Context, webhookID int64, deliveryID int64) (Delivery, error) {
+	query := `
+	WITH updated AS (
+		UPDATE webhook_deliveries
+		SET status = 'pending', attempts = 0, next_attempt_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND webhook_id = ?
+		RETURNING id, webhook_id, event_id, status, attempts, next_attempt_at, last_attempt_at, response_status, last_error, created_at
+	)
+	SELECT u.id, u.webhook_id, u.event_id, e.event_type, u.status, u.attempts, u.next_attempt_at,
+		u.last_attempt_at, u.response_status, u.last_error, u.created_at
+	FROM updated u
+	JOIN outbox_events e ON e.id = u.event_id`
+	query = d.db.Rebind(query)
+	args := []any{deliveryID, webhookID}
+
+	var delivery Delivery
+	if err := d.db.GetContext(ctx, &delivery, query, args...); err != nil {
+		return Delivery{}, fmt.Errorf("get context from db: %w", err)
+	}
+
+	return delivery, nil
+}
+
+// CreatePing writes a ping event to the outbox and schedules its delivery to the webhook only.
+func (d *DB) CreatePing(ctx context.Context, webhookID int64) (returnedErr error) {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		if returnedErr != nil {
+			_ = tx.Rollback()
+		} else if err := tx.Commit(); err != nil {
+			returnedErr = fmt.Errorf("commit tx: %w", err)
+		}
+	}()
+
+	query := tx.Rebind(`
+	INSERT INTO outbox_events (event_type, entity_id, payload, dispatched_at)
+	VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+	RETURNING id`)
+	args := []any{EventPing, webhookID, `{}`}
+
+	var eventID int64
+	if err := tx.GetContext(ctx, &eventID, query, args...); err != nil {
+		return fmt.Errorf("insert ping event: %w", err)
+	}
+
+	query = tx.Rebind(`INSERT INTO webhook_deliveries (webhook_id, event_id) VALUES (?, ?)`)
+	if _, err := tx.ExecContext(ctx, query, webhookID, eventID); err != nil {
+		return fmt.Errorf("insert ping delivery: %w", err)
+	}
+
+	return nil
+}
+
+// FanOutEvents creates a delivery to every active subscribed webhook for up to limit undispatched events,
+// and marks the events as dispatched. Returns the number of events dispatched.
+func (d *DB) FanOutEvents(ctx context.Context, limit int) (int64, error) {
+	query := `
+	WITH events AS (
+		SELECT id, event_type
+		FROM outbox_events
+		WHERE dispatched_at IS NULL
+		ORDER BY id ASC
+		LIMIT ?
+		FOR UPDATE SKIP LOCKED
+	), deliveries AS (
+		INSERT INTO webhook_deliveries (webhook_id, event_id)
+		SELECT w.id, e.id
+		FROM events e
+		JOIN webhooks w ON w.active AND (w.event_types = '[]'::jsonb OR w.event_types @> jsonb_build_array(e.event_type))
+		ON CONFLICT (webhook_id, event_id) DO NOTHING
+	)
+	UPDATE outbox_events
+	SET dispatched_at = CURRENT_TIMESTAMP
+	WHERE id IN (SELECT id FROM events)`
+	query = d.db.Rebind(query)
+
+	result, err := d.db.ExecContext(ctx, query, limit)
+	if err != nil {
+		return 0, fmt.Errorf("exec context to db: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("rows affected: %w", err)
+	}
+
+	return rowsAffected, nil
+}
+
+// ClaimDueDeliveries claims up to limit pending deliveries that are due by pushing their next attempt
+// lease into the future, so other dispatchers skip them while they are being attempted.
+func (d *DB) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]dueDelivery, error) {
+	query := `
+	WITH claimed AS (
+		UPDATE webhook_deliveries
+		SET next_attempt_at = CURRENT_TIMESTAMP + make_interval(secs => ?)
+		WHERE id IN (
+			SELECT id
+			FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= CURRENT_TIMESTAMP
+			ORDER BY next_attempt_at ASC
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, webhook_id, event_id, attempts
+	)
+	SELECT
+		c.id AS "id",
+		c.attempts AS "attempts",
+		w.id AS "webhook.id",
+		w.url AS "webhook.url",
+		w.secret AS "webhook.secret",
+		e.id AS "event.id",
+		e.event_type AS "event.event_type",
+		e.entity_id AS "event.entity_id",
+		e.payload AS "event.payload",
+		e.request_id AS "event.request_id",
+		e.created_at AS "event.created_at"
+	FROM claimed c
+	JOIN webhooks w ON w.id = c.webhook_id
+	JOIN outbox_events e ON e.id = c.event_id
+	ORDER BY c.id ASC`
+	query = d.db.Rebind(query)
+	args := []any{lease.Seconds(), limit}
+
+	var deliveries []dueDelivery
+	if err := d.db.SelectContext(ctx, &deliveries, query, args...); err != nil {
+		return nil, fmt.Errorf("select context from db: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// RecordAttempt stores the outcome of a delivery attempt.
+func (d *DB) RecordAttempt(ctx context.Context, deliveryID int64, result attemptResult) error {
+	query := `
+	UPDATE webhook_deliveries
+	SET status = ?, attempts = attempts + 1, last_attempt_at = CURRENT_TIMESTAMP,
+		response_status = ?, last_error = ?, next_attempt_at = COALESCE(?, next_attempt_at)
+	WHERE id = ?`
+	query = d.db.Rebind(query)
+	args := []any{result.Status, result.ResponseStatus, result.Error, result.NextAttemptAt, deliveryID}
+
+	if _, err := d.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("exec context to db: %w", err)
+	}
+
+	return nil
+}
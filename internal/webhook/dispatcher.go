@@ -0,0 +1,194 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-json-experiment/json"
+	"github.com/jmoiron/sqlx"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// fanOutBatchSize is the number of outbox events dispatched at a time.
+	fanOutBatchSize = 100
+
+	// deliveryConcurrency is the number of deliveries attempted at the same time.
+	deliveryConcurrency = 8
+
+	// baseBackoff is the delay before the first retry. It doubles on every retry up to maxBackoff.
+	baseBackoff = 30 * time.Second
+	maxBackoff  = 6 * time.Hour
+
+	// maxErrorBodySize is how much of an error response is kept in the delivery log.
+	maxErrorBodySize = 1 << 10
+)
+
+// Dispatcher delivers the outbox events to the subscribed webhooks.
+// Several dispatchers can run against the same database; they skip each other's work.
+type Dispatcher struct {
+	db     *DB
+	config Config
+	client *http.Client
+}
+
+func NewDispatcher(db *sqlx.DB, config Config) *Dispatcher {
+	return &Dispatcher{
+		db:     NewDB(db),
+		config: config,
+		client: &http.Client{Timeout: config.timeout()},
+	}
+}
+
+// Run dispatches every poll interval until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.config.pollInterval())
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.DispatchOnce(ctx); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "failed to dispatch webhook deliveries", slog.Any("error", err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchOnce creates the deliveries of new outbox events and attempts every delivery that is due.
+func (d *Dispatcher) DispatchOnce(ctx context.Context) (DispatchResult, error) {
+	var result DispatchResult
+
+	for {
+		dispatched, err := d.db.FanOutEvents(ctx, fanOutBatchSize)
+		if err != nil {
+			return result, fmt.Errorf("fan out events: %w", err)
+		}
+
+		result.Events += int(dispatched)
+		if dispatched < fanOutBatchSize {
+			break
+		}
+	}
+
+	// A claimed batch is attempted concurrently, each attempt taking at most the timeout.
+	lease := 2 * d.config.timeout()
+
+	for {
+		deliveries, err := d.db.ClaimDueDeliveries(ctx, deliveryConcurrency, lease)
+		if err != nil {
+			return result, fmt.Errorf("claim due deliveries: %w", err)
+		}
+
+		var mu sync.Mutex
+		eg, gCtx := errgroup.WithContext(ctx)
+		for _, delivery := range deliveries {
+			eg.Go(func() error {
+				attempt := d.attempt(gCtx, delivery)
+				if err := d.db.RecordAttempt(gCtx, delivery.ID, attempt); err != nil {
+					return fmt.Errorf("record attempt of delivery %d: %w", delivery.ID, err)
+				}
+
+				mu.Lock()
+				defer mu.Unlock()
+				switch attempt.Status {
+				case DeliveryStatusSucceeded:
+					result.Succeeded++
+				case DeliveryStatusFailed:
+					result.Failed++
+				default:
+					result.Retrying++
+				}
+
+				return nil
+			})
+		}
+
+		if err := eg.Wait(); err != nil {
+			return result, err
+		}
+
+		if len(deliveries) < deliveryConcurrency {
+			return result, nil
+		}
+	}
+}
+
+// attempt POSTs the event to the webhook once and decides whether to retry.
+func (d *Dispatcher) attempt(ctx context.Context, delivery dueDelivery) attemptResult {
+	statusCode, err := d.post(ctx, delivery)
+	if err == nil {
+		return attemptResult{Status: DeliveryStatusSucceeded, ResponseStatus: statusCode}
+	}
+
+	result := attemptResult{
+		Status:         DeliveryStatusFailed,
+		ResponseStatus: statusCode,
+		Error:          err.Error(),
+	}
+
+	attempts := delivery.Attempts + 1
+	if attempts < d.config.maxAttempts() {
+		next := time.Now().Add(backoff(attempts))
+		result.Status = DeliveryStatusPending
+		result.NextAttemptAt = &next
+	}
+
+	return result
+}
+
+func (d *Dispatcher) post(ctx context.Context, delivery dueDelivery) (*int, error) {
+	body, err := json.Marshal(delivery.Event.Envelope())
+	if err != nil {
+		return nil, fmt.Errorf("marshal event: %w", err)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "apotek-hris-webhook")
+	request.Header.Set(HeaderEvent, string(delivery.Event.Type))
+	request.Header.Set(HeaderDelivery, strconv.FormatInt(delivery.ID, 10))
+	request.Header.Set(HeaderTimestamp, timestamp)
+	request.Header.Set(HeaderSignature, Sign(delivery.Webhook.Secret, timestamp, body))
+
+	response, err := d.client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("post: %w", err)
+	}
+	defer response.Body.Close()
+
+	statusCode := response.StatusCode
+	if statusCode < 200 || statusCode >= 300 {
+		responseBody, _ := io.ReadAll(io.LimitReader(response.Body, maxErrorBodySize))
+		return &statusCode, fmt.Errorf("unexpected status %d: %s", statusCode, responseBody)
+	}
+
+	_, _ = io.Copy(io.Discard, response.Body)
+
+	return &statusCode, nil
+}
+
+// backoff returns the delay before the retry following the given number of attempts.
+func backoff(attempts int) time.Duration {
+	delay := baseBackoff
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+
+	return min(delay, maxBackoff)
+}
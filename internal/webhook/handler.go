@@ -0,0 +1,154 @@
+package webhook
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-json-experiment/json"
+	"github.com/turfaa/apotek-hris/pkg/httpx"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	webhooks, err := h.service.GetWebhooks(r.Context())
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Ok(w, webhooks)
+}
+
+func (h *Handler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req CreateWebhookRequest
+	if err := json.UnmarshalRead(r.Body, &req); err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	webhook, err := h.service.CreateWebhook(r.Context(), req)
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Ok(w, webhook)
+}
+
+func (h *Handler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookID, err := int64FromURL(r, "webhookID")
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	var req UpdateWebhookRequest
+	if err := json.UnmarshalRead(r.Body, &req); err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	webhook, err := h.service.UpdateWebhook(r.Context(), webhookID, req)
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Ok(w, webhook)
+}
+
+func (h *Handler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookID, err := int64FromURL(r, "webhookID")
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeleteWebhook(r.Context(), webhookID); err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Ok(w, map[string]string{"message": "successfully deleted the webhook"})
+}
+
+func (h *Handler) PingWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookID, err := int64FromURL(r, "webhookID")
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Ping(r.Context(), webhookID); err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Status(w, map[string]string{"message": "ping scheduled"}, http.StatusAccepted)
+}
+
+func (h *Handler) GetDeliveries(w http.ResponseWriter, r *http.Request) {
+	webhookID, err := int64FromURL(r, "webhookID")
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	deliveries, err := h.service.GetDeliveries(r.Context(), webhookID)
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Ok(w, deliveries)
+}
+
+func (h *Handler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	webhookID, err := int64FromURL(r, "webhookID")
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	deliveryID, err := int64FromURL(r, "deliveryID")
+	if err != nil {
+		httpx.Error(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	delivery, err := h.service.Redeliver(r.Context(), webhookID, deliveryID)
+	if err != nil {
+		httpServiceError(w, r, err)
+		return
+	}
+
+	httpx.Ok(w, delivery)
+}
+
+// errorCodes classify the errors of the webhook service for the clients.
+var errorCodes = []httpx.ErrorCode{
+	{Err: ErrWebhookNotFound, Code: httpx.CodeNotFound},
+	{Err: ErrDeliveryNotFound, Code: httpx.CodeNotFound},
+}
+
+func httpServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	httpx.ServiceError(w, r, err, errorCodes)
+}
+
+func int64FromURL(r *http.Request, name string) (int64, error) {
+	valueStr := chi.URLParam(r, name)
+	if valueStr == "" {
+		return 0, fmt.Errorf("%s is required", name)
+	}
+
+	return strconv.ParseInt(valueStr, 10, 64)
+}
@@ -0,0 +1,172 @@
+package webhook
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-json-experiment/json"
+	"github.com/go-json-experiment/json/jsontext"
+)
+
+var (
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+type EventType string
+
+const (
+	EventEmployeeCreated       EventType = "employee.created"
+	EventWorkLogCreated        EventType = "work_log.created"
+	EventWorkLogUpdated        EventType = "work_log.updated"
+	EventWorkLogDeleted        EventType = "work_log.deleted"
+	EventAttendanceUpserted    EventType = "attendance.upserted"
+	EventSalarySnapshotCreated EventType = "salary_snapshot.created"
+	EventSalarySnapshotDeleted EventType = "salary_snapshot.deleted"
+
+	// EventPing is only sent to the webhook it was requested for, to test the endpoint.
+	EventPing EventType = "ping"
+)
+
+// Event is a domain event written to the outbox in the same transaction as the change.
+type Event struct {
+	ID        int64     `db:"id"`
+	Type      EventType `db:"event_type"`
+	EntityID  int64     `db:"entity_id"`
+	Payload   []byte    `db:"payload"`
+	RequestID string    `db:"request_id"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// Envelope is the body POSTed to webhooks.
+type Envelope struct {
+	ID        int64          `json:"id"`
+	Type      EventType      `json:"type"`
+	EntityID  int64          `json:"entityID"`
+	RequestID string         `json:"requestID"`
+	CreatedAt time.Time      `json:"createdAt"`
+	Data      jsontext.Value `json:"data"`
+}
+
+func (e Event) Envelope() Envelope {
+	return Envelope{
+		ID:        e.ID,
+		Type:      e.Type,
+		EntityID:  e.EntityID,
+		RequestID: e.RequestID,
+		CreatedAt: e.CreatedAt,
+		Data:      e.Payload,
+	}
+}
+
+// EventTypes are the event types a webhook subscribes to. Empty means all of them.
+type EventTypes []EventType
+
+// Value implements the driver.Valuer interface.
+func (t EventTypes) Value() (driver.Value, error) {
+	if t == nil {
+		t = EventTypes{}
+	}
+
+	b, err := json.Marshal(t)
+	if err != nil {
+		return nil, fmt.Errorf("marshal event types: %w", err)
+	}
+
+	return string(b), nil
+}
+
+// Scan implements the sql.Scanner interface.
+func (t *EventTypes) Scan(value any) error {
+	var b []byte
+	switch v := value.(type) {
+	case string:
+		b = []byte(v)
+	case []byte:
+		b = v
+	default:
+		return fmt.Errorf("cannot scan type %T into %T", value, t)
+	}
+
+	return json.Unmarshal(b, t)
+}
+
+type Webhook struct {
+	ID         int64      `db:"id" json:"id"`
+	URL        string     `db:"url" json:"url"`
+	Secret     string     `db:"secret" json:"-"`
+	EventTypes EventTypes `db:"event_types" json:"eventTypes"`
+	Active     bool       `db:"active" json:"active"`
+	CreatedAt  time.Time  `db:"created_at" json:"createdAt"`
+	UpdatedAt  time.Time  `db:"updated_at" json:"updatedAt"`
+}
+
+type CreateWebhookRequest struct {
+	URL string `json:"url" validate:"required,http_url"`
+
+	// Secret signs the deliveries. It is never returned by the API.
+	Secret string `json:"secret" validate:"required,min=16,max=255"`
+
+	EventTypes EventTypes `json:"eventTypes" validate:"dive,oneof=employee.created work_log.created work_log.updated work_log.deleted attendance.upserted salary_snapshot.created salary_snapshot.deleted"`
+}
+
+type UpdateWebhookRequest struct {
+	URL string `json:"url" validate:"required,http_url"`
+
+	// Secret replaces the current secret. Empty keeps it.
+	Secret string `json:"secret" validate:"omitempty,min=16,max=255"`
+
+	EventTypes EventTypes `json:"eventTypes" validate:"dive,oneof=employee.created work_log.created work_log.updated work_log.deleted attendance.upserted salary_snapshot.created salary_snapshot.deleted"`
+	Active     bool       `json:"active"`
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryStatusPending   DeliveryStatus = "pending"
+	DeliveryStatusSucceeded DeliveryStatus = "succeeded"
+	DeliveryStatusFailed    DeliveryStatus = "failed"
+)
+
+// Delivery is an event sent, or to be sent, to a webhook. It keeps the result of the last attempt.
+type Delivery struct {
+	ID             int64          `db:"id" json:"id"`
+	WebhookID      int64          `db:"webhook_id" json:"webhookID"`
+	EventID        int64          `db:"event_id" json:"eventID"`
+	EventType      EventType      `db:"event_type" json:"eventType"`
+	Status         DeliveryStatus `db:"status" json:"status"`
+	Attempts       int            `db:"attempts" json:"attempts"`
+	NextAttemptAt  time.Time      `db:"next_attempt_at" json:"nextAttemptAt"`
+	LastAttemptAt  *time.Time     `db:"last_attempt_at" json:"lastAttemptAt"`
+	ResponseStatus *int           `db:"response_status" json:"responseStatus"`
+	LastError      string         `db:"last_error" json:"lastError"`
+	CreatedAt      time.Time      `db:"created_at" json:"createdAt"`
+}
+
+// dueDelivery is a claimed delivery with everything needed to attempt it.
+type dueDelivery struct {
+	ID       int64   `db:"id"`
+	Attempts int     `db:"attempts"`
+	Webhook  Webhook `db:"webhook"`
+	Event    Event   `db:"event"`
+}
+
+// attemptResult is the outcome of one delivery attempt.
+type attemptResult struct {
+	Status         DeliveryStatus
+	ResponseStatus *int
+	Error          string
+
+	// NextAttemptAt is when to retry a pending delivery.
+	NextAttemptAt *time.Time
+}
+
+// DispatchResult counts what one dispatch round did.
+type DispatchResult struct {
+	Events    int
+	Succeeded int
+	Retrying  int
+	Failed    int
+}
@@ -0,0 +1,37 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-json-experiment/json"
+)
+
+// Execer is satisfied by *sqlx.Tx and *sqlx.DB.
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	Rebind(query string) string
+}
+
+// Publish writes an event to the outbox. Pass the transaction of the change,
+// so the event is delivered if and only if the change is committed.
+// payload is marshaled to JSON and sent as the data of the event.
+func Publish(ctx context.Context, execer Execer, eventType EventType, entityID int64, payload any) error {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal %s payload: %w", eventType, err)
+	}
+
+	query := execer.Rebind(`
+	INSERT INTO outbox_events (event_type, entity_id, payload, request_id)
+	VALUES (?, ?, ?, ?)`)
+	args := []any{eventType, entityID, string(b), middleware.GetReqID(ctx)}
+
+	if _, err := execer.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("insert %s event into outbox: %w", eventType, err)
+	}
+
+	return nil
+}
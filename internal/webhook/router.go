@@ -0,0 +1,17 @@
+package webhook
+
+import "github.com/go-chi/chi/v5"
+
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Route("/webhooks", h.registerWebhookRoutes)
+}
+
+func (h *Handler) registerWebhookRoutes(r chi.Router) {
+	r.Get("/", h.GetWebhooks)
+	r.Post("/", h.CreateWebhook)
+	r.Put("/{webhookID}", h.UpdateWebhook)
+	r.Delete("/{webhookID}", h.DeleteWebhook)
+	r.Post("/{webhookID}/ping", h.PingWebhook)
+	r.Get("/{webhookID}/deliveries", h.GetDeliveries)
+	r.Post("/{webhookID}/deliveries/{deliveryID}/redeliver", h.Redeliver)
+}
@@ -0,0 +1,151 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/turfaa/apotek-hris/pkg/tracing"
+	"github.com/turfaa/apotek-hris/pkg/validatorx"
+)
+
+const auditEntityWebhook = "webhook"
+
+// deliveriesLimit is the number of latest deliveries returned for a webhook.
+const deliveriesLimit = 100
+
+type Service struct {
+	db *DB
+}
+
+func NewService(db *sqlx.DB) *Service {
+	return &Service{db: NewDB(db)}
+}
+
+func (s *Service) GetWebhooks(ctx context.Context) ([]Webhook, error) {
+	ctx, span := tracing.Start(ctx, "webhook.Service.GetWebhooks")
+	defer span.End()
+
+	webhooks, err := s.db.GetWebhooks(ctx)
+	if err != nil {
+		return []Webhook{}, fmt.Errorf("get webhooks from db: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+func (s *Service) GetWebhook(ctx context.Context, id int64) (Webhook, error) {
+	ctx, span := tracing.Start(ctx, "webhook.Service.GetWebhook")
+	defer span.End()
+
+	webhook, err := s.db.GetWebhook(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Webhook{}, ErrWebhookNotFound
+		}
+		return Webhook{}, fmt.Errorf("get webhook from db: %w", err)
+	}
+
+	return webhook, nil
+}
+
+func (s *Service) CreateWebhook(ctx context.Context, request CreateWebhookRequest) (Webhook, error) {
+	ctx, span := tracing.Start(ctx, "webhook.Service.CreateWebhook")
+	defer span.End()
+
+	if err := validatorx.Validate(request); err != nil {
+		return Webhook{}, fmt.Errorf("invalid request: %w", err)
+	}
+
+	webhook, err := s.db.CreateWebhook(ctx, request)
+	if err != nil {
+		return Webhook{}, fmt.Errorf("create webhook in db: %w", err)
+	}
+
+	return webhook, nil
+}
+
+func (s *Service) UpdateWebhook(ctx context.Context, id int64, request UpdateWebhookRequest) (Webhook, error) {
+	ctx, span := tracing.Start(ctx, "webhook.Service.UpdateWebhook")
+	defer span.End()
+
+	if err := validatorx.Validate(request); err != nil {
+		return Webhook{}, fmt.Errorf("invalid request: %w", err)
+	}
+
+	webhook, err := s.db.UpdateWebhook(ctx, id, request)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Webhook{}, ErrWebhookNotFound
+		}
+		return Webhook{}, fmt.Errorf("update webhook in db: %w", err)
+	}
+
+	return webhook, nil
+}
+
+func (s *Service) DeleteWebhook(ctx context.Context, id int64) error {
+	ctx, span := tracing.Start(ctx, "webhook.Service.DeleteWebhook")
+	defer span.End()
+
+	if err := s.db.DeleteWebhook(ctx, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrWebhookNotFound
+		}
+		return fmt.Errorf("delete webhook from db: %w", err)
+	}
+
+	return nil
+}
+
+// GetDeliveries returns the latest deliveries of a webhook, newest first.
+func (s *Service) GetDeliveries(ctx context.Context, webhookID int64) ([]Delivery, error) {
+	ctx, span := tracing.Start(ctx, "webhook.Service.GetDeliveries")
+	defer span.End()
+
+	if _, err := s.GetWebhook(ctx, webhookID); err != nil {
+		return []Delivery{}, err
+	}
+
+	deliveries, err := s.db.GetDeliveries(ctx, webhookID, deliveriesLimit)
+	if err != nil {
+		return []Delivery{}, fmt.Errorf("get deliveries from db: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// Redeliver schedules a delivery to be attempted again by the dispatcher.
+func (s *Service) Redeliver(ctx context.Context, webhookID int64, deliveryID int64) (Delivery, error) {
+	ctx, span := tracing.Start(ctx, "webhook.Service.Redeliver")
+	defer span.End()
+
+	delivery, err := s.db.Redeliver(ctx, webhookID, deliveryID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Delivery{}, ErrDeliveryNotFound
+		}
+		return Delivery{}, fmt.Errorf("redeliver in db: %w", err)
+	}
+
+	return delivery, nil
+}
+
+// Ping schedules a ping event to the webhook, to test that it receives and verifies deliveries.
+func (s *Service) Ping(ctx context.Context, webhookID int64) error {
+	ctx, span := tracing.Start(ctx, "webhook.Service.Ping")
+	defer span.End()
+
+	if _, err := s.GetWebhook(ctx, webhookID); err != nil {
+		return err
+	}
+
+	if err := s.db.CreatePing(ctx, webhookID); err != nil {
+		return fmt.Errorf("create ping in db: %w", err)
+	}
+
+	return nil
+}
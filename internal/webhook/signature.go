@@ -0,0 +1,38 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+
+	signaturePrefix = "sha256="
+)
+
+// Sign returns the signature of a delivery, the hex HMAC-SHA256 of "<timestamp>.<body>"
+// keyed with the webhook secret, prefixed with "sha256=".
+// Receivers should reject deliveries with an old timestamp to prevent replays.
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature reports whether signature is the signature of the delivery.
+func VerifySignature(secret string, timestamp string, body []byte, signature string) bool {
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return false
+	}
+
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
@@ -1,10 +1,11 @@
 package main
 
 import (
-	"log"
+	"context"
 	"time"
 
 	"github.com/turfaa/apotek-hris/cmd/hris"
+	"github.com/turfaa/apotek-hris/pkg/logging"
 
 	"github.com/klauspost/lctime"
 )
@@ -13,14 +14,15 @@ func main() {
 	setupTime()
 
 	if err := hris.Execute(); err != nil {
-		log.Fatalf("Failed to execute command: %s", err)
+		logging.Fatal(context.Background(), "failed to execute command", err)
 	}
 }
 
+// setupTime sets the default time zone, which the timezone config can override.
 func setupTime() {
 	loc, err := time.LoadLocation("Asia/Jakarta")
 	if err != nil {
-		log.Fatalf("LoadLocation: %s", err)
+		logging.Fatal(context.Background(), "failed to load the default time zone", err)
 	}
 
 	time.Local = loc
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS attendance_attachments;
+ALTER TABLE attendance_types DROP COLUMN IF EXISTS attachment_required_after_days;
+ALTER TABLE attendances DROP COLUMN IF EXISTS notes;
@@ -0,0 +1,18 @@
+ALTER TABLE attendances ADD COLUMN notes TEXT NOT NULL DEFAULT '';
+
+-- Number of consecutive days of this type allowed before an attachment is required.
+-- NULL means attachments are never required.
+ALTER TABLE attendance_types ADD COLUMN attachment_required_after_days INT NULL;
+
+-- Attachments are uploaded first and linked to an attendance when it is upserted.
+CREATE TABLE attendance_attachments (
+    id BIGSERIAL PRIMARY KEY,
+    attendance_id BIGINT NULL REFERENCES attendances(id),
+    storage_key TEXT NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(255) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_attendance_attachments_attendance_id ON attendance_attachments(attendance_id);
@@ -0,0 +1,29 @@
+CREATE TABLE audit_logs (
+    id BIGSERIAL PRIMARY KEY,
+    actor_employee_id BIGINT NULL,
+    entity_type VARCHAR(100) NOT NULL,
+    entity_id BIGINT NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    -- JSON (not JSONB) keeps the exact bytes that were hashed.
+    before_data JSON NULL,
+    after_data JSON NULL,
+    request_id VARCHAR(255) NOT NULL DEFAULT '',
+    prev_hash CHAR(64) NOT NULL,
+    hash CHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_audit_logs_entity ON audit_logs(entity_type, entity_id);
+CREATE INDEX idx_audit_logs_actor_employee_id ON audit_logs(actor_employee_id);
+CREATE INDEX idx_audit_logs_created_at ON audit_logs(created_at);
+
+-- Audit logs are append-only. The hash chain still detects changes made by bypassing this trigger.
+CREATE FUNCTION prevent_audit_log_modification() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_audit_logs_append_only
+    BEFORE UPDATE OR DELETE ON audit_logs
+    FOR EACH ROW EXECUTE FUNCTION prevent_audit_log_modification();
@@ -0,0 +1,7 @@
+ALTER TABLE work_log_units
+    DROP COLUMN IF EXISTS removed_in_revision_id,
+    DROP COLUMN IF EXISTS added_in_revision_id,
+    DROP COLUMN IF EXISTS replaces_unit_id,
+    DROP COLUMN IF EXISTS created_at;
+
+DROP TABLE IF EXISTS work_log_revisions;
@@ -0,0 +1,25 @@
+CREATE TABLE work_log_revisions (
+    id BIGSERIAL PRIMARY KEY,
+    work_log_id BIGINT NOT NULL REFERENCES work_logs(id),
+    edited_by BIGINT NOT NULL REFERENCES employees(id),
+    patient_name_before VARCHAR(20) NOT NULL,
+    patient_name_after VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_work_log_revisions_work_log_id ON work_log_revisions(work_log_id);
+
+ALTER TABLE work_log_units
+    ADD COLUMN created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ADD COLUMN replaces_unit_id BIGINT NULL REFERENCES work_log_units(id),
+    ADD COLUMN added_in_revision_id BIGINT NULL REFERENCES work_log_revisions(id),
+    ADD COLUMN removed_in_revision_id BIGINT NULL REFERENCES work_log_revisions(id);
+
+-- Existing units were created together with their work log.
+UPDATE work_log_units wlu
+SET created_at = wl.created_at
+FROM work_logs wl
+WHERE wlu.work_log_id = wl.id;
+
+CREATE INDEX idx_work_log_units_added_in_revision_id ON work_log_units(added_in_revision_id);
+CREATE INDEX idx_work_log_units_removed_in_revision_id ON work_log_units(removed_in_revision_id);
@@ -0,0 +1,5 @@
+ALTER TABLE employees DROP COLUMN role;
+
+DROP INDEX IF EXISTS idx_work_logs_performed_at;
+
+ALTER TABLE work_logs DROP COLUMN performed_at;
@@ -0,0 +1,13 @@
+ALTER TABLE work_logs ADD COLUMN performed_at TIMESTAMP WITH TIME ZONE NULL;
+
+-- Existing work logs were entered when they were performed.
+UPDATE work_logs SET performed_at = created_at;
+
+ALTER TABLE work_logs
+    ALTER COLUMN performed_at SET NOT NULL,
+    ALTER COLUMN performed_at SET DEFAULT CURRENT_TIMESTAMP;
+
+CREATE INDEX idx_work_logs_performed_at ON work_logs(performed_at);
+
+ALTER TABLE employees
+    ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'staff' CHECK (role IN ('staff', 'manager'));
@@ -0,0 +1,7 @@
+-- patient_name columns stay VARCHAR(100): names longer than 20 characters may exist by now.
+
+DROP INDEX IF EXISTS idx_work_logs_patient_id;
+
+ALTER TABLE work_logs DROP COLUMN patient_id;
+
+DROP TABLE IF EXISTS patients;
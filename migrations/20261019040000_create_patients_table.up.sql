@@ -0,0 +1,23 @@
+CREATE TABLE patients (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    phone VARCHAR(20) NULL,
+    birth_year INT NULL CHECK (birth_year BETWEEN 1900 AND 2100),
+    gender VARCHAR(10) NULL CHECK (gender IN ('male', 'female')),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_patients_lower_name ON patients(LOWER(name) varchar_pattern_ops);
+CREATE INDEX idx_patients_phone ON patients(phone varchar_pattern_ops);
+
+-- Patient names from the registry can be longer than the old free-text column.
+ALTER TABLE work_logs
+    ALTER COLUMN patient_name TYPE VARCHAR(100),
+    ADD COLUMN patient_id BIGINT NULL REFERENCES patients(id);
+
+ALTER TABLE work_log_revisions
+    ALTER COLUMN patient_name_before TYPE VARCHAR(100),
+    ALTER COLUMN patient_name_after TYPE VARCHAR(100);
+
+CREATE INDEX idx_work_logs_patient_id ON work_logs(patient_id);
@@ -0,0 +1,3 @@
+ALTER TABLE work_types
+    DROP COLUMN reference_ranges,
+    DROP COLUMN outcome_schema;
@@ -0,0 +1,3 @@
+ALTER TABLE work_types
+    ADD COLUMN outcome_schema JSONB NOT NULL DEFAULT '{"type": "text"}',
+    ADD COLUMN reference_ranges JSONB NOT NULL DEFAULT '[]';
@@ -0,0 +1,5 @@
+ALTER TABLE work_log_units DROP COLUMN work_type_version_id;
+
+DROP TABLE IF EXISTS work_type_versions;
+
+ALTER TABLE work_types DROP COLUMN archived_at;
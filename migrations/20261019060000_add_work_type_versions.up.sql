@@ -0,0 +1,33 @@
+ALTER TABLE work_types ADD COLUMN archived_at TIMESTAMP WITH TIME ZONE NULL;
+
+CREATE TABLE work_type_versions (
+    id BIGSERIAL PRIMARY KEY,
+    work_type_id BIGINT NOT NULL REFERENCES work_types(id),
+    name VARCHAR(100) NOT NULL,
+    outcome_unit VARCHAR(20) NOT NULL,
+    multiplier NUMERIC NOT NULL,
+    notes TEXT NOT NULL,
+    outcome_schema JSONB NOT NULL,
+    reference_ranges JSONB NOT NULL,
+    -- Work logs performed at or after valid_from use this version until a newer one starts.
+    -- NULL means the version applies since the beginning.
+    valid_from TIMESTAMP WITH TIME ZONE NULL,
+    created_by BIGINT NULL REFERENCES employees(id),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_work_type_versions_work_type_id_valid_from ON work_type_versions(work_type_id, valid_from);
+
+-- The current definition of every work type applies to all existing work logs.
+INSERT INTO work_type_versions (work_type_id, name, outcome_unit, multiplier, notes, outcome_schema, reference_ranges, valid_from)
+SELECT id, name, outcome_unit, multiplier, notes, outcome_schema, reference_ranges, NULL
+FROM work_types;
+
+ALTER TABLE work_log_units ADD COLUMN work_type_version_id BIGINT NULL REFERENCES work_type_versions(id);
+
+UPDATE work_log_units wlu
+SET work_type_version_id = wtv.id
+FROM work_type_versions wtv
+WHERE wtv.work_type_id = wlu.work_type_id;
+
+ALTER TABLE work_log_units ALTER COLUMN work_type_version_id SET NOT NULL;
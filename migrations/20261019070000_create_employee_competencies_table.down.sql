@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS employee_competencies;
+
+ALTER TABLE work_types DROP COLUMN requires_competency;
@@ -0,0 +1,14 @@
+ALTER TABLE work_types ADD COLUMN requires_competency BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE employee_competencies (
+    id BIGSERIAL PRIMARY KEY,
+    employee_id BIGINT NOT NULL REFERENCES employees(id),
+    work_type_id BIGINT NOT NULL REFERENCES work_types(id),
+    certified_at DATE NOT NULL,
+    expires_at DATE NULL CHECK (expires_at >= certified_at),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (employee_id, work_type_id)
+);
+
+CREATE INDEX idx_employee_competencies_expires_at ON employee_competencies(expires_at);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS employee_document_alerts;
+DROP TABLE IF EXISTS employee_documents;
@@ -0,0 +1,28 @@
+CREATE TABLE employee_documents (
+    id BIGSERIAL PRIMARY KEY,
+    employee_id BIGINT NOT NULL REFERENCES employees(id),
+    document_type VARCHAR(20) NOT NULL CHECK (document_type IN ('stra', 'sipa', 'contract', 'id_card', 'other')),
+    number VARCHAR(100) NOT NULL DEFAULT '',
+    issued_at DATE NULL,
+    expires_at DATE NULL,
+    storage_key VARCHAR(255) NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(255) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE NULL,
+    CHECK (expires_at IS NULL OR issued_at IS NULL OR expires_at >= issued_at)
+);
+
+CREATE INDEX idx_employee_documents_employee_id ON employee_documents(employee_id);
+CREATE INDEX idx_employee_documents_expires_at ON employee_documents(expires_at) WHERE deleted_at IS NULL;
+
+CREATE TABLE employee_document_alerts (
+    id BIGSERIAL PRIMARY KEY,
+    document_id BIGINT NOT NULL REFERENCES employee_documents(id),
+    days_before INT NOT NULL,
+    -- The expiry date the alert was raised for, so a renewed document is alerted again.
+    expires_at DATE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (document_id, days_before, expires_at)
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS receipt_templates;
+DROP TABLE IF EXISTS receipt_branding;
@@ -0,0 +1,26 @@
+CREATE TABLE receipt_branding (
+    id INT PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+    store_name VARCHAR(100) NOT NULL,
+    address VARCHAR(255) NOT NULL DEFAULT '',
+    sia_number VARCHAR(100) NOT NULL DEFAULT '',
+    footer_disclaimer TEXT NOT NULL DEFAULT '',
+    logo_storage_key VARCHAR(255) NULL,
+    logo_content_type VARCHAR(255) NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_by BIGINT NULL REFERENCES employees(id)
+);
+
+-- Keep printing what was previously hard-coded until the branding is edited.
+INSERT INTO receipt_branding (store_name, footer_disclaimer)
+VALUES ('Apotek Aulia Farma', 'Untuk hasil yang lebih akurat, silakan lakukan tes kembali di laboratorium terdekat.');
+
+CREATE TABLE receipt_templates (
+    id BIGSERIAL PRIMARY KEY,
+    work_type_id BIGINT NOT NULL REFERENCES work_types(id),
+    content TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_by BIGINT NULL REFERENCES employees(id),
+    deleted_at TIMESTAMP WITH TIME ZONE NULL
+);
+
+CREATE UNIQUE INDEX idx_receipt_templates_work_type_id ON receipt_templates(work_type_id) WHERE deleted_at IS NULL;
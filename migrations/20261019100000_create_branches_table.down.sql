@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS idx_attendances_branch_id_date;
+DROP INDEX IF EXISTS idx_work_logs_branch_id_performed_at;
+
+ALTER TABLE work_logs DROP COLUMN IF EXISTS branch_id;
+ALTER TABLE attendances DROP COLUMN IF EXISTS branch_id;
+
+DROP TABLE IF EXISTS employee_branches;
+
+ALTER TABLE employees DROP COLUMN IF EXISTS home_branch_id;
+
+DROP TABLE IF EXISTS branches;
@@ -0,0 +1,37 @@
+CREATE TABLE branches (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    address VARCHAR(255) NOT NULL DEFAULT '',
+    sia_number VARCHAR(100) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Everything recorded so far happened at the existing store.
+INSERT INTO branches (name, address, sia_number)
+SELECT store_name, address, sia_number FROM receipt_branding;
+
+ALTER TABLE employees ADD COLUMN home_branch_id BIGINT NULL REFERENCES branches(id);
+UPDATE employees SET home_branch_id = (SELECT MIN(id) FROM branches);
+ALTER TABLE employees ALTER COLUMN home_branch_id SET NOT NULL;
+
+-- employee_branches are the branches an employee works at besides their home branch.
+CREATE TABLE employee_branches (
+    employee_id BIGINT NOT NULL REFERENCES employees(id),
+    branch_id BIGINT NOT NULL REFERENCES branches(id),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (employee_id, branch_id)
+);
+
+CREATE INDEX idx_employee_branches_branch_id ON employee_branches(branch_id);
+
+ALTER TABLE attendances ADD COLUMN branch_id BIGINT NULL REFERENCES branches(id);
+UPDATE attendances a SET branch_id = e.home_branch_id FROM employees e WHERE a.employee_id = e.id;
+ALTER TABLE attendances ALTER COLUMN branch_id SET NOT NULL;
+
+ALTER TABLE work_logs ADD COLUMN branch_id BIGINT NULL REFERENCES branches(id);
+UPDATE work_logs wl SET branch_id = e.home_branch_id FROM employees e WHERE wl.employee_id = e.id;
+ALTER TABLE work_logs ALTER COLUMN branch_id SET NOT NULL;
+
+CREATE INDEX idx_work_logs_branch_id_performed_at ON work_logs(branch_id, performed_at);
+CREATE INDEX idx_attendances_branch_id_date ON attendances(branch_id, date);
@@ -0,0 +1,2 @@
+-- An empty timezone means the branch uses the deployment's time zone.
+ALTER TABLE branches ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT '';
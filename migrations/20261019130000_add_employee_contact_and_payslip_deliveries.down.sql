@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS payslip_deliveries;
+
+ALTER TABLE employees DROP COLUMN IF EXISTS payslip_by_email;
+ALTER TABLE employees DROP COLUMN IF EXISTS email;
@@ -0,0 +1,18 @@
+ALTER TABLE employees ADD COLUMN email VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE employees ADD COLUMN payslip_by_email BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- The latest payslip delivery of an employee for a month.
+CREATE TABLE payslip_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    employee_id BIGINT NOT NULL REFERENCES employees(id),
+    month VARCHAR(7) NOT NULL,
+    snapshot_id BIGINT NOT NULL REFERENCES salary_snapshots(id),
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL CHECK (status IN ('sent', 'failed', 'skipped')),
+    error TEXT NOT NULL DEFAULT '',
+    attempts INT NOT NULL DEFAULT 0,
+    sent_at TIMESTAMP WITH TIME ZONE NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (employee_id, month)
+);
@@ -0,0 +1,16 @@
+CREATE TABLE job_runs (
+    id BIGSERIAL PRIMARY KEY,
+    job_name VARCHAR(100) NOT NULL,
+    trigger VARCHAR(16) NOT NULL CHECK (trigger IN ('schedule', 'manual')),
+    -- The schedule slot the run is for, NULL for manual runs. Unique per job so that
+    -- a slot runs once even if two instances consider themselves the leader.
+    scheduled_at TIMESTAMPTZ,
+    triggered_by BIGINT REFERENCES employees(id),
+    status VARCHAR(16) NOT NULL DEFAULT 'running' CHECK (status IN ('running', 'succeeded', 'failed')),
+    error TEXT NOT NULL DEFAULT '',
+    started_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMPTZ,
+    UNIQUE (job_name, scheduled_at)
+);
+
+CREATE INDEX job_runs_job_name_id_idx ON job_runs (job_name, id DESC);
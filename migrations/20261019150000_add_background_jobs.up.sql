@@ -0,0 +1,24 @@
+CREATE TABLE background_jobs (
+    id BIGSERIAL PRIMARY KEY,
+    kind VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+    status VARCHAR(16) NOT NULL DEFAULT 'queued' CHECK (status IN ('queued', 'running', 'succeeded', 'failed')),
+    progress_done INT NOT NULL DEFAULT 0,
+    progress_total INT NOT NULL DEFAULT 0,
+    result JSONB NOT NULL DEFAULT 'null',
+    result_location TEXT NOT NULL DEFAULT '',
+    -- A file produced by the job, served from the blob store.
+    result_file_key TEXT,
+    result_file_name TEXT NOT NULL DEFAULT '',
+    result_content_type TEXT NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT '',
+    attempts INT NOT NULL DEFAULT 0,
+    -- A running job whose lease expired belongs to a worker that stopped; it is picked up again.
+    lease_until TIMESTAMPTZ,
+    enqueued_by BIGINT REFERENCES employees(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX background_jobs_pending_idx ON background_jobs (id) WHERE status IN ('queued', 'running');
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_attendance_quota_audit_logs_created_at_id;
+DROP INDEX IF EXISTS idx_work_logs_performed_at_id;
@@ -0,0 +1,3 @@
+-- Keyset pages of the default sorts: the sort column with the ID breaking ties.
+CREATE INDEX idx_work_logs_performed_at_id ON work_logs(performed_at, id) WHERE deleted_at IS NULL;
+CREATE INDEX idx_attendance_quota_audit_logs_created_at_id ON attendance_quota_audit_logs(created_at, id);
@@ -0,0 +1,13 @@
+-- Requests made with an Idempotency-Key header and their responses, replayed on retries.
+CREATE TABLE idempotency_keys (
+    key TEXT PRIMARY KEY,
+    request_hash TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'processing',
+    response_status INTEGER,
+    response_headers JSONB,
+    response_body BYTEA,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_idempotency_keys_created_at ON idempotency_keys(created_at);
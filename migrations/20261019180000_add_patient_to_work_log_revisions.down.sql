@@ -0,0 +1,3 @@
+ALTER TABLE work_log_revisions
+    DROP COLUMN IF EXISTS patient_id_after,
+    DROP COLUMN IF EXISTS patient_id_before;
@@ -0,0 +1,9 @@
+ALTER TABLE work_log_revisions
+    ADD COLUMN patient_id_before BIGINT NULL REFERENCES patients(id),
+    ADD COLUMN patient_id_after BIGINT NULL REFERENCES patients(id);
+
+-- The patient of a work log could not be changed before, so existing revisions kept it.
+UPDATE work_log_revisions wlr
+SET patient_id_before = wl.patient_id, patient_id_after = wl.patient_id
+FROM work_logs wl
+WHERE wlr.work_log_id = wl.id;
@@ -0,0 +1,36 @@
+// Package actor carries the employee performing a request through the context.
+package actor
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+)
+
+// HeaderEmployeeID is the request header identifying the acting employee.
+const HeaderEmployeeID = "X-Employee-ID"
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying employeeID as the acting employee.
+func NewContext(ctx context.Context, employeeID int64) context.Context {
+	return context.WithValue(ctx, contextKey{}, employeeID)
+}
+
+// EmployeeIDFromContext returns the acting employee, if any.
+func EmployeeIDFromContext(ctx context.Context) (int64, bool) {
+	employeeID, ok := ctx.Value(contextKey{}).(int64)
+	return employeeID, ok
+}
+
+// Middleware stores the employee from the X-Employee-ID header in the request context.
+// Requests without a valid header pass through without an actor.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if employeeID, err := strconv.ParseInt(r.Header.Get(HeaderEmployeeID), 10, 64); err == nil && employeeID > 0 {
+			r = r.WithContext(NewContext(r.Context(), employeeID))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
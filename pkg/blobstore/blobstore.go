@@ -0,0 +1,42 @@
+package blobstore
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// ErrNotFound is returned when no blob exists under the requested key.
+var ErrNotFound = errors.New("blob not found")
+
+// Store persists opaque blobs addressed by slash-separated keys.
+type Store interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// New creates the store configured by the driver in config.
+func New(config Config) (Store, error) {
+	switch config.Driver {
+	case "local":
+		return NewLocalStore(config.Local.RootDir)
+	default:
+		return nil, fmt.Errorf("unknown blob store driver: %s", config.Driver)
+	}
+}
+
+// NewKey returns a random key under the given prefix, keeping the extension of fileName.
+func NewKey(prefix string, fileName string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("read random bytes: %w", err)
+	}
+
+	return path.Join(prefix, hex.EncodeToString(b)+strings.ToLower(path.Ext(fileName))), nil
+}
@@ -0,0 +1,10 @@
+package blobstore
+
+type Config struct {
+	Driver string      `mapstructure:"driver" validate:"required,oneof=local"`
+	Local  LocalConfig `mapstructure:"local"`
+}
+
+type LocalConfig struct {
+	RootDir string `mapstructure:"root_dir"`
+}
@@ -0,0 +1,105 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStore stores blobs as files under a root directory.
+type LocalStore struct {
+	rootDir string
+}
+
+func NewLocalStore(rootDir string) (*LocalStore, error) {
+	if rootDir == "" {
+		return nil, errors.New("local blob store root dir is required")
+	}
+
+	if err := os.MkdirAll(rootDir, 0o755); err != nil {
+		return nil, fmt.Errorf("create root dir: %w", err)
+	}
+
+	return &LocalStore{rootDir: rootDir}, nil
+}
+
+func (s *LocalStore) Put(ctx context.Context, key string, r io.Reader) (returnedErr error) {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return fmt.Errorf("create blob dir: %w", err)
+	}
+
+	// Write to a temporary file first so readers never see a partially written blob.
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	defer func() {
+		if returnedErr != nil {
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write blob: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close temp file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), p); err != nil {
+		return fmt.Errorf("rename temp file: %w", err)
+	}
+
+	return nil
+}
+
+func (s *LocalStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open blob: %w", err)
+	}
+
+	return f, nil
+}
+
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("remove blob: %w", err)
+	}
+
+	return nil
+}
+
+// path resolves key to a file path, rejecting keys that escape the root directory.
+func (s *LocalStore) path(key string) (string, error) {
+	cleaned := filepath.Clean(filepath.FromSlash(key))
+	if cleaned == "." || filepath.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid blob key: %s", key)
+	}
+
+	return filepath.Join(s.rootDir, cleaned), nil
+}
@@ -7,11 +7,13 @@ import (
 
 	pgxdecimal "github.com/jackc/pgx-shopspring-decimal"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/multitracer"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/jackc/pgx/v5/tracelog"
 	"github.com/jmoiron/sqlx"
 	pgxslog "github.com/mcosta74/pgx-slog"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 func NewPostgresConnection(ctx context.Context, config Config) (*sqlx.DB, error) {
@@ -28,11 +30,14 @@ func NewPostgresConnection(ctx context.Context, config Config) (*sqlx.DB, error)
 		return nil
 	}
 
-	poolConfig.ConnConfig.Tracer = &tracelog.TraceLog{
-		Logger:   pgxslog.NewLogger(slog.Default()),
-		LogLevel: tracelog.LogLevelInfo,
-		Config:   tracelog.DefaultTraceLogConfig(),
-	}
+	poolConfig.ConnConfig.Tracer = multitracer.New(
+		&tracelog.TraceLog{
+			Logger:   pgxslog.NewLogger(slog.Default()),
+			LogLevel: tracelog.LogLevelInfo,
+			Config:   tracelog.DefaultTraceLogConfig(),
+		},
+		newQueryTracer(config.DBName),
+	)
 
 	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
@@ -46,5 +51,9 @@ func NewPostgresConnection(ctx context.Context, config Config) (*sqlx.DB, error)
 		return nil, fmt.Errorf("ping database: %w", err)
 	}
 
+	if err := prometheus.Register(newPoolCollector(pool)); err != nil {
+		return nil, fmt.Errorf("register database pool metrics: %w", err)
+	}
+
 	return db, nil
 }
@@ -0,0 +1,79 @@
+package database
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/turfaa/apotek-hris/pkg/metrics"
+)
+
+// poolCollector exposes the statistics of a connection pool, read at every scrape.
+type poolCollector struct {
+	pool *pgxpool.Pool
+
+	acquiredConns           *prometheus.Desc
+	idleConns               *prometheus.Desc
+	constructingConns       *prometheus.Desc
+	totalConns              *prometheus.Desc
+	maxConns                *prometheus.Desc
+	acquireCount            *prometheus.Desc
+	acquireDuration         *prometheus.Desc
+	canceledAcquireCount    *prometheus.Desc
+	emptyAcquireCount       *prometheus.Desc
+	emptyAcquireWaitTime    *prometheus.Desc
+	newConnsCount           *prometheus.Desc
+	maxLifetimeDestroyCount *prometheus.Desc
+	maxIdleDestroyCount     *prometheus.Desc
+}
+
+func newPoolCollector(pool *pgxpool.Pool) *poolCollector {
+	desc := func(name string, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(metrics.Namespace, "db_pool", name), help, nil, nil)
+	}
+
+	return &poolCollector{
+		pool:                    pool,
+		acquiredConns:           desc("acquired_connections", "Number of connections currently in use."),
+		idleConns:               desc("idle_connections", "Number of idle connections."),
+		constructingConns:       desc("constructing_connections", "Number of connections being opened."),
+		totalConns:              desc("connections", "Number of open connections, in use, idle or being opened."),
+		maxConns:                desc("max_connections", "Maximum size of the pool."),
+		acquireCount:            desc("acquires_total", "Number of successful acquires of a connection."),
+		acquireDuration:         desc("acquire_duration_seconds_total", "Time spent on successful acquires of a connection."),
+		canceledAcquireCount:    desc("canceled_acquires_total", "Number of acquires cancelled by their context."),
+		emptyAcquireCount:       desc("empty_acquires_total", "Number of successful acquires that waited for a connection."),
+		emptyAcquireWaitTime:    desc("empty_acquire_wait_seconds_total", "Time spent waiting for a connection by the acquires that had to."),
+		newConnsCount:           desc("new_connections_total", "Number of connections opened."),
+		maxLifetimeDestroyCount: desc("max_lifetime_destroys_total", "Number of connections closed for exceeding their maximum lifetime."),
+		maxIdleDestroyCount:     desc("max_idle_destroys_total", "Number of connections closed for exceeding their maximum idle time."),
+	}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+
+	gauge := func(desc *prometheus.Desc, value float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value)
+	}
+	counter := func(desc *prometheus.Desc, value float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, value)
+	}
+
+	gauge(c.acquiredConns, float64(stat.AcquiredConns()))
+	gauge(c.idleConns, float64(stat.IdleConns()))
+	gauge(c.constructingConns, float64(stat.ConstructingConns()))
+	gauge(c.totalConns, float64(stat.TotalConns()))
+	gauge(c.maxConns, float64(stat.MaxConns()))
+	counter(c.acquireCount, float64(stat.AcquireCount()))
+	counter(c.acquireDuration, stat.AcquireDuration().Seconds())
+	counter(c.canceledAcquireCount, float64(stat.CanceledAcquireCount()))
+	counter(c.emptyAcquireCount, float64(stat.EmptyAcquireCount()))
+	counter(c.emptyAcquireWaitTime, stat.EmptyAcquireWaitTime().Seconds())
+	counter(c.newConnsCount, float64(stat.NewConnsCount()))
+	counter(c.maxLifetimeDestroyCount, float64(stat.MaxLifetimeDestroyCount()))
+	counter(c.maxIdleDestroyCount, float64(stat.MaxIdleDestroyCount()))
+}
@@ -0,0 +1,12 @@
+package database
+
+import "strings"
+
+// Where joins the filters of a query into its WHERE clause, which is empty without filters.
+func Where(filters []string) string {
+	if len(filters) == 0 {
+		return ""
+	}
+
+	return "WHERE " + strings.Join(filters, " AND ")
+}
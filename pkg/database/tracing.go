@@ -0,0 +1,78 @@
+package database
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/turfaa/apotek-hris/pkg/tracing"
+)
+
+// querySpanKey is the context key of the span of a query, ended when the query ends.
+type querySpanKey struct{}
+
+// queryTracer traces the queries made within a trace, e.g. of a request, each in a client span named
+// after its SQL operation, e.g. SELECT. Queries outside of any trace, like the polling of the
+// background workers, are not traced.
+type queryTracer struct {
+	attributes []attribute.KeyValue
+}
+
+func newQueryTracer(dbName string) *queryTracer {
+	return &queryTracer{
+		attributes: []attribute.KeyValue{
+			semconv.DBSystemNamePostgreSQL,
+			semconv.DBNamespace(dbName),
+		},
+	}
+}
+
+func (t *queryTracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx
+	}
+
+	operation := queryOperation(data.SQL)
+
+	ctx, span := tracing.Start(ctx, operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(t.attributes...),
+		trace.WithAttributes(
+			semconv.DBOperationName(operation),
+			semconv.DBQueryText(data.SQL),
+		),
+	)
+
+	return context.WithValue(ctx, querySpanKey{}, span)
+}
+
+func (t *queryTracer) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+	span, ok := ctx.Value(querySpanKey{}).(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+
+	if data.Err != nil {
+		span.RecordError(data.Err)
+		span.SetStatus(codes.Error, data.Err.Error())
+		return
+	}
+
+	span.SetAttributes(semconv.DBResponseReturnedRows(int(data.CommandTag.RowsAffected())))
+}
+
+// queryOperation returns the first keyword of the query, e.g. SELECT or WITH.
+func queryOperation(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "QUERY"
+	}
+
+	return strings.ToUpper(fields[0])
+}
@@ -0,0 +1,35 @@
+package escpos
+
+import "time"
+
+const (
+	// DefaultLineWidth is the number of characters per line of font A on 76mm paper.
+	DefaultLineWidth = 42
+
+	// DefaultTimeout bounds connecting and writing to a network printer.
+	DefaultTimeout = 10 * time.Second
+)
+
+type Config struct {
+	// Address is the host:port of a network printer, usually on port 9100.
+	// Printing is disabled when it is empty.
+	Address   string        `mapstructure:"address" validate:"omitempty,hostname_port"`
+	LineWidth int           `mapstructure:"line_width" validate:"gte=0"`
+	Timeout   time.Duration `mapstructure:"timeout" validate:"gte=0"`
+}
+
+func (c Config) lineWidth() int {
+	if c.LineWidth == 0 {
+		return DefaultLineWidth
+	}
+
+	return c.LineWidth
+}
+
+func (c Config) timeout() time.Duration {
+	if c.Timeout == 0 {
+		return DefaultTimeout
+	}
+
+	return c.Timeout
+}
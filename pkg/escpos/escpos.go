@@ -0,0 +1,180 @@
+// Package escpos builds ESC/POS command streams for thermal receipt printers.
+package escpos
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	esc = 0x1b
+	gs  = 0x1d
+)
+
+type Align byte
+
+const (
+	AlignLeft   Align = 0
+	AlignCenter Align = 1
+	AlignRight  Align = 2
+)
+
+// Builder accumulates printer commands. Text is wrapped to the line width
+// and non-ASCII characters are replaced, since printers default to a single-byte code page.
+type Builder struct {
+	buf       bytes.Buffer
+	lineWidth int
+}
+
+// NewBuilder returns a builder for lines of the given width, starting with a printer reset.
+func NewBuilder(lineWidth int) *Builder {
+	if lineWidth <= 0 {
+		lineWidth = DefaultLineWidth
+	}
+
+	b := &Builder{lineWidth: lineWidth}
+	b.buf.Write([]byte{esc, '@'})
+	return b
+}
+
+// LineWidth returns the number of characters per line.
+func (b *Builder) LineWidth() int {
+	return b.lineWidth
+}
+
+func (b *Builder) Bold(on bool) *Builder {
+	b.buf.Write([]byte{esc, 'E', boolByte(on)})
+	return b
+}
+
+func (b *Builder) Align(align Align) *Builder {
+	b.buf.Write([]byte{esc, 'a', byte(align)})
+	return b
+}
+
+// Line prints the text, wrapping it at word boundaries.
+func (b *Builder) Line(text string) *Builder {
+	for _, line := range wrap(sanitize(text), b.lineWidth) {
+		b.buf.WriteString(line)
+		b.buf.WriteByte('\n')
+	}
+	return b
+}
+
+// Row prints the label on the left and the value on the right of the same line.
+// It falls back to two lines when they do not fit.
+func (b *Builder) Row(label string, value string) *Builder {
+	label, value = sanitize(label), sanitize(value)
+
+	gap := b.lineWidth - len(label) - len(value)
+	if gap < 1 {
+		b.Line(label)
+		return b.Align(AlignRight).Line(value).Align(AlignLeft)
+	}
+
+	b.buf.WriteString(label + strings.Repeat(" ", gap) + value + "\n")
+	return b
+}
+
+// Divider prints a full-width line of the given character, e.g. '-' or '='.
+func (b *Builder) Divider(char byte) *Builder {
+	b.buf.Write(bytes.Repeat([]byte{char}, b.lineWidth))
+	b.buf.WriteByte('\n')
+	return b
+}
+
+// QRCode prints the data as a centered QR code using the printer's native QR support.
+func (b *Builder) QRCode(data string) *Builder {
+	store := len(data) + 3
+
+	b.Align(AlignCenter)
+	// Select model 2, module size 6 and error correction level M.
+	b.buf.Write([]byte{gs, '(', 'k', 4, 0, 49, 65, 50, 0})
+	b.buf.Write([]byte{gs, '(', 'k', 3, 0, 49, 67, 6})
+	b.buf.Write([]byte{gs, '(', 'k', 3, 0, 49, 69, 49})
+	// Store the data, then print it.
+	b.buf.Write([]byte{gs, '(', 'k', byte(store), byte(store >> 8), 49, 80, 48})
+	b.buf.WriteString(data)
+	b.buf.Write([]byte{gs, '(', 'k', 3, 0, 49, 81, 48})
+	b.buf.WriteByte('\n')
+	return b.Align(AlignLeft)
+}
+
+func (b *Builder) Feed(lines int) *Builder {
+	b.buf.Write([]byte{esc, 'd', byte(lines)})
+	return b
+}
+
+// Cut feeds the paper past the cutter and performs a partial cut.
+func (b *Builder) Cut() *Builder {
+	b.buf.Write([]byte{gs, 'V', 'B', 0})
+	return b
+}
+
+func (b *Builder) Bytes() []byte {
+	return bytes.Clone(b.buf.Bytes())
+}
+
+func boolByte(v bool) byte {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+// sanitize replaces characters the printer's default code page cannot print.
+func sanitize(text string) string {
+	var sb strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '\n' || r == '\t':
+			sb.WriteByte(' ')
+		case r < 0x20 || r == utf8.RuneError:
+			continue
+		case r > 0x7e:
+			sb.WriteByte('?')
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+func wrap(text string, width int) []string {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return []string{""}
+	}
+
+	var (
+		lines   []string
+		current string
+	)
+	for _, word := range words {
+		for len(word) > width {
+			if current != "" {
+				lines = append(lines, current)
+				current = ""
+			}
+			lines = append(lines, word[:width])
+			word = word[width:]
+		}
+
+		switch {
+		case current == "":
+			current = word
+		case len(current)+1+len(word) <= width:
+			current += " " + word
+		default:
+			lines = append(lines, current)
+			current = word
+		}
+	}
+
+	if current != "" {
+		lines = append(lines, current)
+	}
+
+	return lines
+}
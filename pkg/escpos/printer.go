@@ -0,0 +1,57 @@
+package escpos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+var (
+	// ErrPrinterNotConfigured is returned when printing without a printer address.
+	ErrPrinterNotConfigured = errors.New("printer is not configured")
+
+	// ErrPrinterUnavailable is returned when the printer cannot be reached or written to.
+	ErrPrinterUnavailable = errors.New("printer is unavailable")
+)
+
+// Printer sends raw ESC/POS streams to a network printer.
+type Printer struct {
+	config Config
+}
+
+func NewPrinter(config Config) *Printer {
+	return &Printer{config: config}
+}
+
+// NewBuilder returns a builder sized for the printer's paper.
+func (p *Printer) NewBuilder() *Builder {
+	return NewBuilder(p.config.lineWidth())
+}
+
+// Print writes data to the printer over a raw TCP connection.
+func (p *Printer) Print(ctx context.Context, data []byte) error {
+	if p.config.Address == "" {
+		return ErrPrinterNotConfigured
+	}
+
+	timeout := p.config.timeout()
+	dialer := net.Dialer{Timeout: timeout}
+
+	conn, err := dialer.DialContext(ctx, "tcp", p.config.Address)
+	if err != nil {
+		return fmt.Errorf("%w: dial %s: %w", ErrPrinterUnavailable, p.config.Address, err)
+	}
+	defer conn.Close()
+
+	if err := conn.SetWriteDeadline(time.Now().Add(timeout)); err != nil {
+		return fmt.Errorf("set write deadline: %w", err)
+	}
+
+	if _, err := conn.Write(data); err != nil {
+		return fmt.Errorf("%w: write to %s: %w", ErrPrinterUnavailable, p.config.Address, err)
+	}
+
+	return nil
+}
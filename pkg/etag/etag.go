@@ -0,0 +1,91 @@
+package etag
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-json-experiment/json"
+)
+
+// ErrStale is the error of a write whose If-Match precondition does not match the current state of the resource.
+var ErrStale = errors.New("the resource was changed since it was read")
+
+// StaleError is returned by a write whose precondition failed, with the current state of the resource,
+// so clients can show the conflict without reading it again.
+type StaleError struct {
+	// Current is the resource as it is now, nil if it does not exist.
+	Current any
+
+	// ETag is the entity tag of Current, empty if it does not exist.
+	ETag string
+}
+
+func (e *StaleError) Error() string {
+	return ErrStale.Error()
+}
+
+func (e *StaleError) Unwrap() error {
+	return ErrStale
+}
+
+// FromTime returns the entity tag of a resource last updated at t: its updatedAt in UTC, quoted,
+// so clients can make the If-Match of a write from a resource they listed.
+func FromTime(t time.Time) string {
+	return `"` + t.UTC().Format(time.RFC3339Nano) + `"`
+}
+
+// FromContent returns the entity tag of the JSON of v, for resources without an update time such as lists.
+func FromContent(v any) string {
+	b, _ := json.Marshal(v)
+	sum := sha256.Sum256(b)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// Precondition is the If-Match header of a write. The zero value matches any state.
+type Precondition string
+
+// Check returns a StaleError with the current state of the resource if it does not satisfy the precondition.
+// currentETag is empty if the resource does not exist, which only satisfies the zero precondition.
+func (p Precondition) Check(current any, currentETag string) error {
+	if p.matches(currentETag) {
+		return nil
+	}
+
+	if currentETag == "" {
+		current = nil
+	}
+
+	return &StaleError{Current: current, ETag: currentETag}
+}
+
+func (p Precondition) matches(currentETag string) bool {
+	if p == "" {
+		return true
+	}
+
+	if currentETag == "" {
+		return false
+	}
+
+	for tag := range strings.SplitSeq(string(p), ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || equal(tag, currentETag) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// equal compares entity tags strongly, as If-Match requires: weak tags never match,
+// since a weakly equal resource may still differ from the one the client read.
+func equal(a string, b string) bool {
+	if strings.HasPrefix(a, "W/") || strings.HasPrefix(b, "W/") {
+		return false
+	}
+
+	return a == b
+}
@@ -0,0 +1,22 @@
+package httpx
+
+import (
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+)
+
+// File streams r to w as a downloadable attachment named fileName.
+func File(w http.ResponseWriter, r io.Reader, fileName string, contentType string) {
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": fileName}))
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, r); err != nil {
+		slog.Error("failed to write file response", slog.Any("error", err))
+	}
+}
@@ -2,24 +2,16 @@ package httpx
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 
 	"github.com/go-json-experiment/json"
 )
 
-type ErrorResponse struct {
-	Message string `json:"message"`
-}
-
 func Ok(w http.ResponseWriter, v any) {
 	Status(w, v, http.StatusOK)
 }
 
-func Error(w http.ResponseWriter, err error, status int) {
-	Status(w, ErrorResponse{Message: err.Error()}, status)
-}
-
 func Status(w http.ResponseWriter, v any, status int) {
 	if v == nil {
 		w.WriteHeader(status)
@@ -27,7 +19,7 @@ func Status(w http.ResponseWriter, v any, status int) {
 	}
 
 	if err := writeJSON(w, status, v); err != nil {
-		log.Println("error writing json response:", err)
+		slog.Error("failed to write json response", slog.Any("error", err))
 	}
 }
 
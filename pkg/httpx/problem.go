@@ -0,0 +1,263 @@
+package httpx
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-json-experiment/json"
+	"github.com/go-playground/validator/v10"
+
+	"github.com/turfaa/apotek-hris/pkg/blobstore"
+	"github.com/turfaa/apotek-hris/pkg/etag"
+	"github.com/turfaa/apotek-hris/pkg/pagination"
+	"github.com/turfaa/apotek-hris/pkg/validatorx"
+)
+
+// ContentTypeProblem is the media type of the error responses, see RFC 7807.
+const ContentTypeProblem = "application/problem+json"
+
+// Code classifies an error response for clients, which should branch on it rather than on the detail.
+type Code string
+
+const (
+	CodeValidation     Code = "validation"
+	CodeNotFound       Code = "not_found"
+	CodeQuotaExhausted Code = "quota_exhausted"
+	CodeConflict       Code = "conflict"
+	CodeStale          Code = "stale"
+	CodeForbidden      Code = "forbidden"
+	CodeUnavailable    Code = "unavailable"
+	CodeInternal       Code = "internal"
+)
+
+// status is the HTTP status of the responses of the code, unless an ErrorCode says otherwise.
+func (c Code) status() int {
+	switch c {
+	case CodeValidation, CodeQuotaExhausted:
+		return http.StatusBadRequest
+	case CodeNotFound:
+		return http.StatusNotFound
+	case CodeConflict:
+		return http.StatusConflict
+	case CodeStale:
+		return http.StatusPreconditionFailed
+	case CodeForbidden:
+		return http.StatusForbidden
+	case CodeUnavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func codeOfStatus(status int) Code {
+	switch {
+	case status == http.StatusNotFound:
+		return CodeNotFound
+	case status == http.StatusConflict:
+		return CodeConflict
+	case status == http.StatusPreconditionFailed:
+		return CodeStale
+	case status == http.StatusForbidden:
+		return CodeForbidden
+	case status == http.StatusBadGateway, status == http.StatusServiceUnavailable:
+		return CodeUnavailable
+	case status >= http.StatusInternalServerError:
+		return CodeInternal
+	default:
+		return CodeValidation
+	}
+}
+
+// Problem is the body of the error responses, following RFC 7807.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+
+	Code Code `json:"code"`
+
+	// RequestID identifies the request in the logs.
+	RequestID string `json:"requestID,omitempty"`
+
+	// Errors lists the invalid fields of a request failing validation.
+	Errors []FieldError `json:"errors,omitempty"`
+
+	// Current is the current state of the resource a stale write was rejected for, absent if it does not exist.
+	Current any `json:"current,omitzero"`
+}
+
+// FieldError is a field of the request that failed a validation rule.
+type FieldError struct {
+	// Field is the path of the field in the request, e.g. units[0].workTypeID.
+	Field string `json:"field"`
+
+	// Rule is the validation rule that failed, e.g. required or gte, with its parameter, if any.
+	Rule  string `json:"rule"`
+	Param string `json:"param,omitempty"`
+
+	Message string `json:"message"`
+}
+
+// ErrorCode classifies the errors matching Err, as per errors.Is, with Code.
+type ErrorCode struct {
+	Err  error
+	Code Code
+
+	// Status overrides the status of Code.
+	Status int
+
+	// Detail replaces the message of Err, for errors whose message makes no sense to clients.
+	Detail string
+}
+
+// sharedErrorCodes classify the errors any service may return.
+var sharedErrorCodes = []ErrorCode{
+	{Err: sql.ErrNoRows, Code: CodeNotFound, Detail: "the resource does not exist"},
+	{Err: blobstore.ErrNotFound, Code: CodeNotFound, Detail: "the file does not exist"},
+	{Err: pagination.ErrInvalidCursor, Code: CodeValidation},
+	{Err: pagination.ErrInvalidSort, Code: CodeValidation},
+	{Err: pagination.ErrInvalidLimit, Code: CodeValidation},
+}
+
+// internalDetail replaces the detail of server errors, which is only logged.
+const internalDetail = "an unexpected error occurred"
+
+// Error responds with a problem of the given status, detailed by the message of err.
+// The message of server errors is logged instead.
+func Error(w http.ResponseWriter, r *http.Request, err error, status int) {
+	writeProblem(w, r, err, Problem{Status: status, Code: codeOfStatus(status), Detail: err.Error()})
+}
+
+// ServiceError responds with the problem err is classified as by codes, or by the errors shared by all services.
+// Unclassified errors are server errors.
+func ServiceError(w http.ResponseWriter, r *http.Request, err error, codes []ErrorCode) {
+	var validationErrors validatorx.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		writeProblem(w, r, err, Problem{
+			Status: http.StatusBadRequest,
+			Code:   CodeValidation,
+			Detail: "the request has invalid fields",
+			Errors: fieldErrors(validationErrors),
+		})
+		return
+	}
+
+	var staleError *etag.StaleError
+	if errors.As(err, &staleError) {
+		if staleError.ETag != "" {
+			w.Header().Set("ETag", staleError.ETag)
+		}
+
+		writeProblem(w, r, err, Problem{
+			Status:  http.StatusPreconditionFailed,
+			Code:    CodeStale,
+			Detail:  etag.ErrStale.Error(),
+			Current: staleError.Current,
+		})
+		return
+	}
+
+	for _, errorCode := range slices.Concat(codes, sharedErrorCodes) {
+		if !errors.Is(err, errorCode.Err) {
+			continue
+		}
+
+		status := errorCode.Status
+		if status == 0 {
+			status = errorCode.Code.status()
+		}
+
+		problemDetail := errorCode.Detail
+		if problemDetail == "" {
+			problemDetail = detail(err, errorCode.Err)
+		}
+
+		writeProblem(w, r, err, Problem{Status: status, Code: errorCode.Code, Detail: problemDetail})
+		return
+	}
+
+	writeProblem(w, r, err, Problem{Status: http.StatusInternalServerError, Code: CodeInternal})
+}
+
+func writeProblem(w http.ResponseWriter, r *http.Request, err error, problem Problem) {
+	if problem.Status >= http.StatusInternalServerError {
+		slog.ErrorContext(r.Context(), "request failed", slog.Int("status", problem.Status), slog.Any("error", err))
+		problem.Detail = internalDetail
+	}
+
+	problem.Type = "about:blank"
+	problem.Title = http.StatusText(problem.Status)
+	problem.Instance = r.URL.Path
+	problem.RequestID = middleware.GetReqID(r.Context())
+
+	w.Header().Set("Content-Type", ContentTypeProblem)
+	w.WriteHeader(problem.Status)
+	if err := json.MarshalWrite(w, problem); err != nil {
+		slog.ErrorContext(r.Context(), "failed to write problem response", slog.Any("error", err))
+	}
+}
+
+// detail describes err to clients without the context added by wrapping it: it is the message of the
+// outermost error wrapping target that starts with the message of target, e.g. the message of
+// fmt.Errorf("%w: details", target), or the message of target itself.
+func detail(err error, target error) string {
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if strings.HasPrefix(e.Error(), target.Error()) {
+			return e.Error()
+		}
+	}
+
+	return target.Error()
+}
+
+func fieldErrors(validationErrors validatorx.ValidationErrors) []FieldError {
+	fields := make([]FieldError, len(validationErrors))
+	for i, fieldError := range validationErrors {
+		// The namespace starts with the name of the request type.
+		_, field, _ := strings.Cut(fieldError.Namespace(), ".")
+
+		fields[i] = FieldError{
+			Field:   field,
+			Rule:    fieldError.Tag(),
+			Param:   fieldError.Param(),
+			Message: fieldMessage(fieldError),
+		}
+	}
+
+	return fields
+}
+
+func fieldMessage(fieldError validator.FieldError) string {
+	switch fieldError.Tag() {
+	case "required", "required_with", "required_without", "required_if":
+		return "is required"
+	case "min", "gte":
+		return fmt.Sprintf("must be at least %s", fieldError.Param())
+	case "max", "lte":
+		return fmt.Sprintf("must be at most %s", fieldError.Param())
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", fieldError.Param())
+	case "lt":
+		return fmt.Sprintf("must be less than %s", fieldError.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of %s", strings.ReplaceAll(fieldError.Param(), " ", ", "))
+	case "email":
+		return "must be an email address"
+	case "url":
+		return "must be a URL"
+	default:
+		if fieldError.Param() != "" {
+			return fmt.Sprintf("must satisfy %s=%s", fieldError.Tag(), fieldError.Param())
+		}
+		return fmt.Sprintf("must satisfy %s", fieldError.Tag())
+	}
+}
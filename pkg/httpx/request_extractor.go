@@ -0,0 +1,52 @@
+package httpx
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/turfaa/apotek-hris/pkg/etag"
+	"github.com/turfaa/apotek-hris/pkg/pagination"
+)
+
+// GetOptionalInt64FromQuery returns the query parameter as an int64, or nil if it is not provided.
+func GetOptionalInt64FromQuery(request *http.Request, name string) (*int64, error) {
+	valueStr := request.URL.Query().Get(name)
+	if valueStr == "" {
+		return nil, nil
+	}
+
+	value, err := strconv.ParseInt(valueStr, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %s", name, valueStr)
+	}
+
+	return &value, nil
+}
+
+// GetPageFromQuery returns the page requested by the limit, sort and cursor query parameters.
+// The sort and cursor are checked against the list by the service.
+func GetPageFromQuery(request *http.Request) (pagination.Request, error) {
+	query := request.URL.Query()
+
+	page := pagination.Request{
+		Sort:   query.Get("sort"),
+		Cursor: query.Get("cursor"),
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > pagination.MaxLimit {
+			return pagination.Request{}, fmt.Errorf("invalid limit: %s, must be between 1 and %d", limitStr, pagination.MaxLimit)
+		}
+
+		page.Limit = limit
+	}
+
+	return page, nil
+}
+
+// GetPrecondition returns the If-Match header of a write, which only applies it to the state the client read.
+func GetPrecondition(request *http.Request) etag.Precondition {
+	return etag.Precondition(request.Header.Get("If-Match"))
+}
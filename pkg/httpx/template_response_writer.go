@@ -2,14 +2,18 @@ package httpx
 
 import (
 	"html/template"
-	"log"
+	"log/slog"
 	"net/http"
 )
 
 func Template(w http.ResponseWriter, tmpl *template.Template, data any) {
+	TemplateStatus(w, tmpl, data, http.StatusOK)
+}
+
+func TemplateStatus(w http.ResponseWriter, tmpl *template.Template, data any, status int) {
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	w.WriteHeader(http.StatusOK)
+	w.WriteHeader(status)
 	if err := tmpl.Execute(w, data); err != nil {
-		log.Println("error writing template:", err)
+		slog.Error("failed to write template response", slog.Any("error", err))
 	}
 }
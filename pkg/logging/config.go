@@ -0,0 +1,30 @@
+package logging
+
+import "log/slog"
+
+// DefaultLevel is used when Config.Level is not set.
+const DefaultLevel = slog.LevelInfo
+
+type Format string
+
+const (
+	FormatText Format = "text"
+	FormatJSON Format = "json"
+)
+
+type Config struct {
+	// Level is the lowest level logged: debug, info, warn or error.
+	Level string `mapstructure:"level" validate:"omitempty,oneof=debug info warn error"`
+
+	// Format is how the log lines are written: text, the default, or json.
+	Format Format `mapstructure:"format" validate:"omitempty,oneof=text json"`
+}
+
+func (c Config) level() slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Level)); err != nil {
+		return DefaultLevel
+	}
+
+	return level
+}
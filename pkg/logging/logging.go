@@ -0,0 +1,106 @@
+// Package logging sets up the application logger, which tags every log line with the request it was made for.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/turfaa/apotek-hris/pkg/actor"
+)
+
+// redactedKeys are the attributes whose values never reach the logs: patient names, salary amounts,
+// and the arguments of database queries, which carry both. Keys match regardless of case and underscores.
+var redactedKeys = map[string]bool{
+	"args":        true,
+	"patient":     true,
+	"patientname": true,
+	"salary":      true,
+	"amount":      true,
+	"total":       true,
+	"snapshot":    true,
+}
+
+const redacted = "[REDACTED]"
+
+// Setup makes the configured logger the default of slog and of the log package.
+func Setup(config Config) {
+	slog.SetDefault(New(os.Stderr, config))
+}
+
+// New returns a logger writing to w that redacts the sensitive attributes and adds the request,
+// route, acting employee and trace of the context to every line.
+func New(w io.Writer, config Config) *slog.Logger {
+	options := &slog.HandlerOptions{
+		Level:       config.level(),
+		ReplaceAttr: redact,
+	}
+
+	var handler slog.Handler
+	if config.Format == FormatJSON {
+		handler = slog.NewJSONHandler(w, options)
+	} else {
+		handler = slog.NewTextHandler(w, options)
+	}
+
+	return slog.New(contextHandler{Handler: handler})
+}
+
+func redact(groups []string, attr slog.Attr) slog.Attr {
+	key := strings.ToLower(strings.ReplaceAll(attr.Key, "_", ""))
+	if redactedKeys[key] {
+		return slog.String(attr.Key, redacted)
+	}
+
+	return attr
+}
+
+// contextHandler adds the attributes of the request in the context to the records.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := middleware.GetReqID(ctx); requestID != "" {
+		record.AddAttrs(slog.String("request_id", requestID))
+	}
+
+	if rctx := chi.RouteContext(ctx); rctx != nil {
+		if pattern := rctx.RoutePattern(); pattern != "" {
+			record.AddAttrs(slog.String("route", pattern))
+		}
+	}
+
+	if employeeID, ok := actor.EmployeeIDFromContext(ctx); ok {
+		record.AddAttrs(slog.Int64("actor_employee_id", employeeID))
+	}
+
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		record.AddAttrs(
+			slog.String("trace_id", spanContext.TraceID().String()),
+			slog.String("span_id", spanContext.SpanID().String()),
+		)
+	}
+
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+// Fatal logs the error that stops a command and exits.
+func Fatal(ctx context.Context, msg string, err error) {
+	slog.ErrorContext(ctx, msg, slog.Any("error", err))
+	os.Exit(1)
+}
@@ -0,0 +1,7 @@
+package metrics
+
+type Config struct {
+	// Token is the bearer token scrapers must send to read the metrics.
+	// The metrics endpoint is not served without one.
+	Token string `mapstructure:"token"`
+}
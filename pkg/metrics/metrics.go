@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Namespace prefixes the names of all the metrics of the application.
+const Namespace = "hris"
+
+var httpRequestDuration = promauto.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of the HTTP requests, by route pattern.",
+		Buckets:   prometheus.DefBuckets,
+	},
+	[]string{"method", "route", "status"},
+)
+
+// Middleware records the latency of the requests under the chi route pattern they matched,
+// so that requests to e.g. /employees/1 and /employees/2 share a series.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		defer func() {
+			// The route is only known once the router has matched the request.
+			route := "unmatched"
+			if rctx := chi.RouteContext(r.Context()); rctx != nil {
+				if pattern := rctx.RoutePattern(); pattern != "" {
+					route = pattern
+				}
+			}
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			httpRequestDuration.
+				WithLabelValues(r.Method, route, strconv.Itoa(status)).
+				Observe(time.Since(start).Seconds())
+		}()
+
+		next.ServeHTTP(ww, r)
+	})
+}
+
+// Handler serves the metrics in the Prometheus format to the requests bearing the configured token.
+func Handler(config Config) http.Handler {
+	metricsHandler := promhttp.Handler()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(config.Token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		metricsHandler.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,282 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-json-experiment/json"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 200
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidSort   = errors.New("invalid sort")
+	ErrInvalidLimit  = errors.New("invalid limit")
+)
+
+// Request asks for a page of a list.
+type Request struct {
+	// Limit is the maximum number of items in the page. Zero means DefaultLimit.
+	Limit int `json:"limit"`
+
+	// Sort is the field the list is sorted by, prefixed with "-" for descending, e.g. "-createdAt".
+	// Empty means the default sort of the list.
+	Sort string `json:"sort"`
+
+	// Cursor is the nextCursor or prevCursor of another page. Empty means the first page.
+	Cursor string `json:"cursor"`
+}
+
+// Page is a slice of a list, with the cursors of its neighbouring pages.
+// A cursor is nil when there is no page in that direction.
+type Page[T any] struct {
+	Items      []T     `json:"items"`
+	NextCursor *string `json:"nextCursor"`
+	PrevCursor *string `json:"prevCursor"`
+
+	// Total is the number of items matching the filters, across all pages.
+	Total int `json:"total"`
+}
+
+// Field is a field a list can be sorted by.
+type Field[T any] struct {
+	// Column is the SQL expression of the field, e.g. "wl.performed_at".
+	Column string
+
+	// Type is the SQL type of the column, used to cast the value stored in cursors.
+	// It is one of bigint, timestamptz, date and text.
+	Type string
+
+	// Value is the value of the field of an item, as stored in cursors.
+	Value func(T) string
+}
+
+// Sorting lists the fields a list can be sorted by. Ties are broken by the ID, in the same direction,
+// so every item has a unique position to resume from.
+type Sorting[T any] struct {
+	Fields map[string]Field[T]
+
+	// Default is the sort used when the request has none, e.g. "-createdAt".
+	Default string
+
+	IDColumn string
+	ID       func(T) int64
+}
+
+// Names returns the sorts the list accepts, for documenting errors.
+func (s Sorting[T]) Names() []string {
+	names := make([]string, 0, 2*len(s.Fields))
+	for name := range s.Fields {
+		names = append(names, name, "-"+name)
+	}
+
+	slices.Sort(names)
+	return names
+}
+
+// Query validates a page request against the sorting and returns the query fetching the page.
+func (s Sorting[T]) Query(request Request) (Query[T], error) {
+	sort := request.Sort
+	if sort == "" {
+		sort = s.Default
+	}
+
+	field, ok := s.Fields[strings.TrimPrefix(sort, "-")]
+	if !ok {
+		return Query[T]{}, fmt.Errorf("%w: %q, must be one of %s", ErrInvalidSort, sort, strings.Join(s.Names(), ", "))
+	}
+
+	limit := request.Limit
+	if limit == 0 {
+		limit = DefaultLimit
+	}
+
+	if limit < 0 || limit > MaxLimit {
+		return Query[T]{}, fmt.Errorf("%w: %d, must be between 1 and %d", ErrInvalidLimit, limit, MaxLimit)
+	}
+
+	q := Query[T]{
+		sorting: s,
+		field:   field,
+		sort:    sort,
+		desc:    strings.HasPrefix(sort, "-"),
+		limit:   limit,
+	}
+
+	if request.Cursor != "" {
+		c, err := decodeCursor(request.Cursor)
+		if err != nil {
+			return Query[T]{}, err
+		}
+
+		// A cursor only points to a position in the order it was made for.
+		if c.Sort != sort {
+			return Query[T]{}, fmt.Errorf("%w: made for sort %q, not %q", ErrInvalidCursor, c.Sort, sort)
+		}
+
+		// The value is cast in SQL, so a malformed one must be caught before it fails the query.
+		if err := checkValue(field.Type, c.Value); err != nil {
+			return Query[T]{}, err
+		}
+
+		q.cursor = &c
+	}
+
+	return q, nil
+}
+
+// Query is the keyset part of the SQL query fetching a page: the condition selecting the items after
+// (or before) the cursor, the order and the limit. The filters of the list are up to the caller.
+type Query[T any] struct {
+	sorting Sorting[T]
+	field   Field[T]
+	sort    string
+	desc    bool
+	limit   int
+	cursor  *cursor
+}
+
+// Where returns the condition selecting the items past the cursor with its args, or an empty condition on the first page.
+func (q Query[T]) Where() (string, []any) {
+	if q.cursor == nil {
+		return "", nil
+	}
+
+	op := ">"
+	if q.backwards() {
+		op = "<"
+	}
+
+	condition := fmt.Sprintf("(%s, %s) %s (CAST(? AS %s), ?)", q.field.Column, q.sorting.IDColumn, op, q.field.Type)
+	return condition, []any{q.cursor.Value, q.cursor.ID}
+}
+
+// OrderByLimit returns the ORDER BY and LIMIT clauses. One more item than the limit is fetched
+// to know whether there is another page.
+func (q Query[T]) OrderByLimit() string {
+	direction := "ASC"
+	if q.backwards() {
+		direction = "DESC"
+	}
+
+	return fmt.Sprintf("ORDER BY %s %s, %s %s LIMIT %d", q.field.Column, direction, q.sorting.IDColumn, direction, q.limit+1)
+}
+
+// Page makes the page out of the items fetched with the query and the total of the list.
+func (q Query[T]) Page(items []T, total int) Page[T] {
+	more := len(items) > q.limit
+	if more {
+		items = items[:q.limit]
+	}
+
+	before := q.cursor != nil && q.cursor.Before
+	if before {
+		// Items before the cursor are fetched nearest first.
+		slices.Reverse(items)
+	}
+
+	page := Page[T]{Items: items, Total: total}
+	if page.Items == nil {
+		page.Items = []T{}
+	}
+
+	if len(items) == 0 {
+		return page
+	}
+
+	// Coming from a page means there is one in that direction.
+	hasNext, hasPrev := more, q.cursor != nil
+	if before {
+		hasNext, hasPrev = true, more
+	}
+
+	if hasNext {
+		next := q.cursorOf(items[len(items)-1], false)
+		page.NextCursor = &next
+	}
+
+	if hasPrev {
+		prev := q.cursorOf(items[0], true)
+		page.PrevCursor = &prev
+	}
+
+	return page
+}
+
+// backwards tells whether the items are fetched against the order of the sort,
+// which is the case for descending sorts and for pages before a cursor, but not both.
+func (q Query[T]) backwards() bool {
+	return q.desc != (q.cursor != nil && q.cursor.Before)
+}
+
+func (q Query[T]) cursorOf(item T, before bool) string {
+	return cursor{
+		Sort:   q.sort,
+		Value:  q.field.Value(item),
+		ID:     q.sorting.ID(item),
+		Before: before,
+	}.encode()
+}
+
+// cursor is a position in a sorted list, encoded opaquely for clients.
+type cursor struct {
+	Sort   string `json:"s"`
+	Value  string `json:"v"`
+	ID     int64  `json:"i"`
+	Before bool   `json:"b,omitempty"`
+}
+
+func (c cursor) encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cursor{}, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+
+	var c cursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return cursor{}, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+
+	return c, nil
+}
+
+// checkValue checks that a cursor value can be cast to the SQL type of its field.
+func checkValue(sqlType, value string) error {
+	var err error
+	switch sqlType {
+	case "bigint":
+		_, err = strconv.ParseInt(value, 10, 64)
+	case "timestamptz":
+		_, err = time.Parse(time.RFC3339Nano, value)
+	case "date":
+		_, err = time.Parse(time.DateOnly, value)
+	case "text":
+	default:
+		return fmt.Errorf("unsupported cursor field type %q", sqlType)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%w: value %q is not a %s", ErrInvalidCursor, value, sqlType)
+	}
+
+	return nil
+}
+
+// FormatInt is the cursor value of integer fields.
+func FormatInt(v int64) string {
+	return strconv.FormatInt(v, 10)
+}
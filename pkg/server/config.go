@@ -1,6 +1,9 @@
 package server
 
+import "github.com/turfaa/apotek-hris/pkg/metrics"
+
 type Config struct {
-	Port int    `mapstructure:"port" validate:"required"`
-	Host string `mapstructure:"host" validate:"required"`
+	Port    int            `mapstructure:"port" validate:"required"`
+	Host    string         `mapstructure:"host" validate:"required"`
+	Metrics metrics.Config `mapstructure:"metrics"`
 }
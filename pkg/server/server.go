@@ -2,14 +2,28 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"time"
 
 	"github.com/turfaa/apotek-hris/internal/attendance"
+	"github.com/turfaa/apotek-hris/internal/audit"
+	"github.com/turfaa/apotek-hris/internal/document"
 	"github.com/turfaa/apotek-hris/internal/hris"
+	"github.com/turfaa/apotek-hris/internal/idempotency"
+	"github.com/turfaa/apotek-hris/internal/notification"
+	"github.com/turfaa/apotek-hris/internal/queue"
 	"github.com/turfaa/apotek-hris/internal/salary"
+	"github.com/turfaa/apotek-hris/internal/scheduler"
+	"github.com/turfaa/apotek-hris/internal/webhook"
+	"github.com/turfaa/apotek-hris/pkg/actor"
+	"github.com/turfaa/apotek-hris/pkg/blobstore"
 	"github.com/turfaa/apotek-hris/pkg/httpx"
+	"github.com/turfaa/apotek-hris/pkg/logging"
+	"github.com/turfaa/apotek-hris/pkg/metrics"
+	"github.com/turfaa/apotek-hris/pkg/tracing"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -18,26 +32,58 @@ import (
 )
 
 type Server struct {
-	config Config
-	db     *sqlx.DB
-	server *http.Server
+	config           Config
+	hrisConfig       hris.Config
+	attendanceConfig attendance.Config
+	db               *sqlx.DB
+	blobStore        blobstore.Store
+	sender           notification.Sender
+	scheduler        *scheduler.Scheduler
+	queue            *queue.Queue
+	idempotency      *idempotency.Service
+	handler          http.Handler
+	server           *http.Server
 }
 
-func New(config Config, db *sqlx.DB) *Server {
-	return &Server{
-		config: config,
-		db:     db,
+// New sets up the services and routes of the server, registering their jobs with a scheduler.
+func New(
+	config Config,
+	hrisConfig hris.Config,
+	attendanceConfig attendance.Config,
+	schedulerConfig scheduler.Config,
+	queueConfig queue.Config,
+	idempotencyConfig idempotency.Config,
+	db *sqlx.DB,
+	blobStore blobstore.Store,
+	sender notification.Sender,
+) (*Server, error) {
+	s := &Server{
+		config:           config,
+		hrisConfig:       hrisConfig,
+		attendanceConfig: attendanceConfig,
+		db:               db,
+		blobStore:        blobStore,
+		sender:           sender,
+		scheduler:        scheduler.New(db, schedulerConfig),
+		queue:            queue.New(db, queueConfig, blobStore),
+		idempotency:      idempotency.NewService(db, idempotencyConfig),
 	}
-}
 
-func (s *Server) Start() error {
 	r := chi.NewRouter()
 	s.setupMiddleware(r)
-	s.setupRoutes(r)
+	if err := s.setupRoutes(r); err != nil {
+		return nil, err
+	}
+
+	s.handler = r
+
+	return s, nil
+}
 
+func (s *Server) Start() error {
 	s.server = &http.Server{
 		Addr:         fmt.Sprintf("%s:%d", s.config.Host, s.config.Port),
-		Handler:      r,
+		Handler:      s.handler,
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 	}
@@ -45,6 +91,16 @@ func (s *Server) Start() error {
 	return s.server.ListenAndServe()
 }
 
+// RunScheduler runs the scheduled jobs until ctx is cancelled. See scheduler.Scheduler.Run.
+func (s *Server) RunScheduler(ctx context.Context) {
+	s.scheduler.Run(ctx)
+}
+
+// RunQueue works on the background jobs until ctx is cancelled. See queue.Queue.Run.
+func (s *Server) RunQueue(ctx context.Context) {
+	s.queue.Run(ctx)
+}
+
 func (s *Server) Shutdown(ctx context.Context) error {
 	return s.server.Shutdown(ctx)
 }
@@ -53,47 +109,86 @@ func (s *Server) setupMiddleware(r *chi.Mux) {
 	// Basic CORS setup to allow all origins
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"*"},
-		ExposedHeaders:   []string{"Link"},
+		ExposedHeaders:   []string{"Link", "ETag", idempotency.ReplayedHeader},
 		AllowCredentials: true,
 		MaxAge:           300, // Maximum value not ignored by any of major browsers
 	}))
 
 	r.Use(middleware.RequestID)
+	r.Use(tracing.Middleware)
+	r.Use(actor.Middleware)
 	r.Use(middleware.RealIP)
-	r.Use(middleware.Logger)
+	r.Use(logging.Middleware)
+	r.Use(metrics.Middleware)
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.Compress(5))
 	r.Use(middleware.Timeout(60 * time.Second))
 }
 
-func (s *Server) setupRoutes(r *chi.Mux) {
+func (s *Server) setupRoutes(r *chi.Mux) error {
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		httpx.Error(w, r, errors.New("no endpoint matches the path"), http.StatusNotFound)
+	})
+
 	r.Get("/health", s.handleHealth())
 	r.Get("/docs/openapi.yaml", s.handleOpenAPISpec())
 	r.Get("/docs", s.handleAPIDocs())
 
-	hrisService := hris.NewService(s.db)
-	attendanceService := attendance.NewService(s.db)
-	salaryService := salary.NewService(s.db, hrisService, attendanceService)
+	if s.config.Metrics.Token != "" {
+		r.Method(http.MethodGet, "/metrics", metrics.Handler(s.config.Metrics))
+	}
+
+	auditService := audit.NewService(s.db)
+	documentService := document.NewService(s.db, s.blobStore)
+	hrisService := hris.NewService(s.db, s.hrisConfig, s.blobStore, documentService, s.queue)
+	attendanceService := attendance.NewService(s.db, s.blobStore)
+	salaryService := salary.NewService(s.db, hrisService, attendanceService, s.sender, s.queue)
+	webhookService := webhook.NewService(s.db)
 
+	auditHandler := audit.NewHandler(auditService)
 	hrisHandler := hris.NewHandler(hrisService)
+	documentHandler := document.NewHandler(documentService)
 	attendanceHandler := attendance.NewHandler(attendanceService, hrisService)
 	salaryHandler := salary.NewHandler(salaryService)
+	webhookHandler := webhook.NewHandler(webhookService)
+	schedulerHandler := scheduler.NewHandler(s.scheduler)
+	queueHandler := queue.NewHandler(s.queue)
+
+	jobs := slices.Concat(hrisService.Jobs(), attendanceService.Jobs(s.attendanceConfig), salaryService.Jobs(), s.idempotency.Jobs())
+	if err := s.scheduler.Register(jobs...); err != nil {
+		return fmt.Errorf("register jobs: %w", err)
+	}
+
+	if err := s.queue.Register(slices.Concat(hrisService.Tasks(), salaryService.Tasks())...); err != nil {
+		return fmt.Errorf("register tasks: %w", err)
+	}
+
+	hrisHandler.RegisterPublicRoutes(r)
 
 	r.Group(func(r chi.Router) {
 		r.Route("/api/v1", func(r chi.Router) {
+			r.Use(s.idempotency.Middleware)
+
 			hrisHandler.RegisterRoutes(r)
+			documentHandler.RegisterRoutes(r)
 			attendanceHandler.RegisterRoutes(r)
 			salaryHandler.RegisterRoutes(r)
+			auditHandler.RegisterRoutes(r)
+			webhookHandler.RegisterRoutes(r)
+			schedulerHandler.RegisterRoutes(r)
+			queueHandler.RegisterRoutes(r)
 		})
 	})
+
+	return nil
 }
 
 func (s *Server) handleHealth() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if err := s.db.Ping(); err != nil {
-			httpx.Error(w, err, http.StatusServiceUnavailable)
+			httpx.Error(w, r, err, http.StatusServiceUnavailable)
 			return
 		}
 
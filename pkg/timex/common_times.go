@@ -7,17 +7,28 @@ import (
 	"github.com/turfaa/go-date"
 )
 
+// The functions without a location use time.Local, the deployment's time zone.
+// Their In variants compute the same boundaries in the given location, e.g. a branch's time zone.
+
 func Today() (from time.Time, until time.Time) {
-	return BeginningOfToday(), EndOfToday()
+	return TodayIn(time.Local)
+}
+
+func TodayIn(loc *time.Location) (from time.Time, until time.Time) {
+	return BeginningOfTodayIn(loc), EndOfTodayIn(loc)
 }
 
 func Day(date string) (from time.Time, until time.Time, err error) {
-	from, err = BeginningOfDate(date)
+	return DayIn(date, time.Local)
+}
+
+func DayIn(date string, loc *time.Location) (from time.Time, until time.Time, err error) {
+	from, err = BeginningOfDateIn(date, loc)
 	if err != nil {
 		return time.Time{}, time.Time{}, fmt.Errorf("beginning of date: %w", err)
 	}
 
-	until, err = EndOfDate(date)
+	until, err = EndOfDateIn(date, loc)
 	if err != nil {
 		return time.Time{}, time.Time{}, fmt.Errorf("end of date: %w", err)
 	}
@@ -26,31 +37,47 @@ func Day(date string) (from time.Time, until time.Time, err error) {
 }
 
 func BeginningOfToday() time.Time {
-	year, month, day := time.Now().Date()
-	return time.Date(year, month, day, 0, 0, 0, 0, time.Local)
+	return BeginningOfTodayIn(time.Local)
+}
+
+func BeginningOfTodayIn(loc *time.Location) time.Time {
+	year, month, day := time.Now().In(loc).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
 }
 
 func EndOfToday() time.Time {
-	year, month, day := time.Now().Date()
-	return time.Date(year, month, day, 23, 59, 59, 999999999, time.Local)
+	return EndOfTodayIn(time.Local)
+}
+
+func EndOfTodayIn(loc *time.Location) time.Time {
+	year, month, day := time.Now().In(loc).Date()
+	return time.Date(year, month, day, 23, 59, 59, 999999999, loc)
 }
 
 func BeginningOfDate(date string) (time.Time, error) {
+	return BeginningOfDateIn(date, time.Local)
+}
+
+func BeginningOfDateIn(date string, loc *time.Location) (time.Time, error) {
 	t, err := time.Parse("2006-01-02", date)
 	if err != nil {
 		return time.Time{}, fmt.Errorf("parse date: %w", err)
 	}
 
-	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local), nil
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc), nil
 }
 
 func EndOfDate(date string) (time.Time, error) {
+	return EndOfDateIn(date, time.Local)
+}
+
+func EndOfDateIn(date string, loc *time.Location) (time.Time, error) {
 	t, err := time.Parse("2006-01-02", date)
 	if err != nil {
 		return time.Time{}, fmt.Errorf("parse date: %w", err)
 	}
 
-	return time.Date(t.Year(), t.Month(), t.Day(), 23, 59, 59, 999999999, time.Local), nil
+	return time.Date(t.Year(), t.Month(), t.Day(), 23, 59, 59, 999999999, loc), nil
 }
 
 func BeginningOfLastMonth() time.Time {
@@ -13,3 +13,7 @@ func FormatDateTime(t time.Time) string {
 func FormatDate(t time.Time) string {
 	return lctime.Strftime("%d %B %Y", t)
 }
+
+func FormatMonth(m Month) string {
+	return lctime.Strftime("%B %Y", time.Date(m.Year, time.Month(m.Month), 1, 0, 0, 0, 0, time.Local))
+}
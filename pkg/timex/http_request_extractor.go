@@ -13,15 +13,47 @@ func GetOneDayFromQuery(request *http.Request) (from time.Time, to time.Time, er
 }
 
 func GetTimeRangeFromQuery(request *http.Request) (from time.Time, to time.Time, err error) {
+	return GetTimeRangeFromQueryIn(request, time.Local)
+}
+
+// GetTimeRangeFromQueryIn is GetTimeRangeFromQuery with the day boundaries in loc.
+func GetTimeRangeFromQueryIn(request *http.Request, loc *time.Location) (from time.Time, to time.Time, err error) {
 	query := request.URL.Query()
 
-	return ParseTimeRange(
+	return ParseTimeRangeIn(
 		query.Get("date"),
 		query.Get("from"),
 		query.Get("to"),
+		loc,
 	)
 }
 
+// GetOptionalTimeRangeFromQuery returns the beginning of the `from` day and the end of the `to` day,
+// each nil if its query parameter is not provided.
+func GetOptionalTimeRangeFromQuery(request *http.Request) (from *time.Time, to *time.Time, err error) {
+	query := request.URL.Query()
+
+	if fromStr := query.Get("from"); fromStr != "" {
+		t, err := BeginningOfDate(fromStr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parse from: %w", err)
+		}
+
+		from = &t
+	}
+
+	if toStr := query.Get("to"); toStr != "" {
+		t, err := EndOfDate(toStr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parse to: %w", err)
+		}
+
+		to = &t
+	}
+
+	return from, to, nil
+}
+
 func GetMonthDateRangeFromQuery(request *http.Request) (from date.Date, to date.Date, err error) {
 	monthStr := request.URL.Query().Get("month")
 	if monthStr == "" {
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/turfaa/go-date"
 )
@@ -35,6 +36,19 @@ func (m Month) DateRange() (from date.Date, to date.Date, err error) {
 	return MonthDateRange(m.Year, m.Month)
 }
 
+// TimeRangeIn returns the first and the last instant of the month in loc.
+func (m Month) TimeRangeIn(loc *time.Location) (from time.Time, to time.Time) {
+	from = time.Date(m.Year, time.Month(m.Month), 1, 0, 0, 0, 0, loc)
+	to = from.AddDate(0, 1, 0).Add(-time.Nanosecond)
+	return from, to
+}
+
+// Contains reports whether t falls in the month in t's location.
+func (m Month) Contains(t time.Time) bool {
+	year, month, _ := t.Date()
+	return year == m.Year && int(month) == m.Month
+}
+
 func (m Month) MarshalJSON() ([]byte, error) {
 	return json.Marshal(m.String())
 }
@@ -7,26 +7,31 @@ import (
 )
 
 func ParseTimeRange(dateQuery, fromQuery string, toQuery string) (from time.Time, to time.Time, err error) {
+	return ParseTimeRangeIn(dateQuery, fromQuery, toQuery, time.Local)
+}
+
+// ParseTimeRangeIn is ParseTimeRange with the day boundaries in loc.
+func ParseTimeRangeIn(dateQuery, fromQuery string, toQuery string, loc *time.Location) (from time.Time, to time.Time, err error) {
 	if fromQuery != "" {
-		from, _, err = Day(fromQuery)
+		from, _, err = DayIn(fromQuery, loc)
 		if err != nil {
 			err = fmt.Errorf("parse time range from `from` query [%s]: %w", fromQuery, err)
 			return
 		}
 
 		if toQuery != "" {
-			_, to, err = Day(toQuery)
+			_, to, err = DayIn(toQuery, loc)
 			if err != nil {
 				err = fmt.Errorf("parse time range from `to` query [%s]: %w", toQuery, err)
 				return
 			}
 		} else {
-			to = EndOfToday()
+			to = EndOfTodayIn(loc)
 		}
 	} else if dateQuery == "" {
-		from, to = Today()
+		from, to = TodayIn(loc)
 	} else {
-		from, to, err = Day(dateQuery)
+		from, to, err = DayIn(dateQuery, loc)
 		if err != nil {
 			err = fmt.Errorf("parse time range from `date` query [%s]: %w", dateQuery, err)
 			return
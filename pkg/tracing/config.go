@@ -0,0 +1,74 @@
+package tracing
+
+const (
+	// DefaultServiceName is used when Config.ServiceName is not set.
+	DefaultServiceName = "apotek-hris"
+
+	// DefaultSampleRatio is used when Config.SampleRatio is not set.
+	DefaultSampleRatio = 1.0
+
+	// DefaultFilePath is used when Config.FilePath is not set.
+	DefaultFilePath = "traces.jsonl"
+)
+
+type Exporter string
+
+const (
+	// ExporterOTLP sends the spans to an OpenTelemetry collector over OTLP/HTTP.
+	ExporterOTLP Exporter = "otlp"
+
+	// ExporterStdout prints the spans to the standard output, for local debugging.
+	ExporterStdout Exporter = "stdout"
+
+	// ExporterFile appends the spans to a file as JSON, for local debugging.
+	ExporterFile Exporter = "file"
+)
+
+type Config struct {
+	// Exporter is where the spans go. Tracing is disabled when it is not set.
+	Exporter Exporter `mapstructure:"exporter" validate:"omitempty,oneof=otlp stdout file"`
+
+	// ServiceName identifies this application in the traces.
+	ServiceName string `mapstructure:"service_name"`
+
+	// SampleRatio is the fraction of the traces started here that are recorded, from 0 to 1.
+	// Traces started by a caller follow the caller's decision.
+	SampleRatio float64 `mapstructure:"sample_ratio" validate:"gte=0,lte=1"`
+
+	OTLP OTLPConfig `mapstructure:"otlp"`
+
+	// FilePath is the file the file exporter appends to.
+	FilePath string `mapstructure:"file_path"`
+}
+
+type OTLPConfig struct {
+	// Endpoint is the URL of the collector, e.g. http://localhost:4318. Plain http disables TLS.
+	Endpoint string `mapstructure:"endpoint" validate:"omitempty,url"`
+
+	// Headers are sent with every export, e.g. for authentication.
+	Headers map[string]string `mapstructure:"headers"`
+}
+
+func (c Config) serviceName() string {
+	if c.ServiceName == "" {
+		return DefaultServiceName
+	}
+
+	return c.ServiceName
+}
+
+func (c Config) sampleRatio() float64 {
+	if c.SampleRatio == 0 {
+		return DefaultSampleRatio
+	}
+
+	return c.SampleRatio
+}
+
+func (c Config) filePath() string {
+	if c.FilePath == "" {
+		return DefaultFilePath
+	}
+
+	return c.FilePath
+}
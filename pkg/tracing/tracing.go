@@ -0,0 +1,107 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName names the tracer of the spans created by the application.
+const instrumentationName = "github.com/turfaa/apotek-hris"
+
+// Setup installs the global tracer provider exporting to the configured exporter.
+// The returned function flushes the remaining spans and must be called before exiting.
+// When no exporter is configured, spans are not recorded and the returned function does nothing.
+func Setup(ctx context.Context, config Config) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	if config.Exporter == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, closeExporter, err := newExporter(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("create %s exporter: %w", config.Exporter, err)
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(semconv.ServiceName(config.serviceName())),
+	)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("create resource: %w", err), closeExporter())
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.sampleRatio()))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		return errors.Join(provider.Shutdown(ctx), closeExporter())
+	}, nil
+}
+
+func newExporter(ctx context.Context, config Config) (sdktrace.SpanExporter, func() error, error) {
+	noClose := func() error { return nil }
+
+	switch config.Exporter {
+	case ExporterOTLP:
+		var options []otlptracehttp.Option
+		if config.OTLP.Endpoint != "" {
+			options = append(options, otlptracehttp.WithEndpointURL(config.OTLP.Endpoint))
+		}
+		if len(config.OTLP.Headers) > 0 {
+			options = append(options, otlptracehttp.WithHeaders(config.OTLP.Headers))
+		}
+
+		exporter, err := otlptracehttp.New(ctx, options...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("create otlp exporter: %w", err)
+		}
+
+		return exporter, noClose, nil
+
+	case ExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return nil, nil, fmt.Errorf("create stdout exporter: %w", err)
+		}
+
+		return exporter, noClose, nil
+
+	case ExporterFile:
+		f, err := os.OpenFile(config.filePath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("open %s: %w", config.filePath(), err)
+		}
+
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			return nil, nil, errors.Join(fmt.Errorf("create file exporter: %w", err), f.Close())
+		}
+
+		return exporter, f.Close, nil
+
+	default:
+		return nil, nil, fmt.Errorf("unknown exporter %q", config.Exporter)
+	}
+}
+
+// Start starts a span as a child of the span in ctx, if any. The caller must end it.
+func Start(ctx context.Context, name string, options ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, options...)
+}
@@ -1,6 +1,9 @@
 package validatorx
 
 import (
+	"reflect"
+	"strings"
+
 	"github.com/go-playground/validator/v10"
 	dv "github.com/sblackstone/shopspring-decimal-validators"
 )
@@ -11,8 +14,23 @@ var v = validator.New(validator.WithRequiredStructEnabled())
 
 func init() {
 	dv.RegisterDecimalValidators(v)
+
+	// Name the fields in the errors as the clients know them.
+	v.RegisterTagNameFunc(jsonFieldName)
 }
 
 func Validate(i any) error {
 	return v.Struct(i)
 }
+
+func jsonFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return field.Name
+	default:
+		return name
+	}
+}